@@ -0,0 +1,195 @@
+/*
+ * Copyright 2018 The OpenWallet Authors
+ * This file is part of the OpenWallet library.
+ *
+ * The OpenWallet library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The OpenWallet library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ */
+
+package bitshares
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/blocktree/bitshares-adapter/types"
+)
+
+const (
+	defaultAccountCacheSize = 10000
+	defaultAccountCacheTTL  = 10 * time.Minute
+)
+
+// AccountResolver resolves accounts by name or id through a bounded LRU cache,
+// so the hot accounts of a block are asked to the node once per TTL. An account
+// is dropped from the cache when an account_update operation of it is scanned.
+type AccountResolver struct {
+	api  *WalletClient
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mutex  sync.Mutex               // protects the following
+	lru    *list.List               // *accountEntry, most recently used first
+	byID   map[string]*list.Element // account id -> entry
+	byName map[string]*list.Element // account name -> entry
+}
+
+type accountEntry struct {
+	account *types.Account
+	expires time.Time
+}
+
+// NewAccountResolver returns a resolver caching at most size accounts for ttl,
+// zero values use the defaults
+func NewAccountResolver(api *WalletClient, size int, ttl time.Duration) *AccountResolver {
+	if size <= 0 {
+		size = defaultAccountCacheSize
+	}
+	if ttl <= 0 {
+		ttl = defaultAccountCacheTTL
+	}
+	return &AccountResolver{
+		api:    api,
+		size:   size,
+		ttl:    ttl,
+		now:    time.Now,
+		lru:    list.New(),
+		byID:   make(map[string]*list.Element),
+		byName: make(map[string]*list.Element),
+	}
+}
+
+// Resolve returns the account of the name or id
+func (r *AccountResolver) Resolve(ctx context.Context, nameOrID string) (*types.Account, error) {
+	accounts, err := r.ResolveBatch(ctx, nameOrID)
+	if err != nil {
+		return nil, err
+	}
+	account, ok := accounts[nameOrID]
+	if !ok {
+		return nil, fmt.Errorf("account %s does not exist", nameOrID)
+	}
+	return account, nil
+}
+
+// ResolveBatch returns the accounts of the names or ids keyed by both name and
+// id, like GetAccountsBatch. Only the accounts missing from the cache are asked
+// to the node, in a single batch; accounts that do not exist are left out.
+func (r *AccountResolver) ResolveBatch(ctx context.Context, namesOrIDs ...string) (map[string]*types.Account, error) {
+	accounts := make(map[string]*types.Account, 2*len(namesOrIDs))
+	missing := make([]string, 0)
+
+	r.mutex.Lock()
+	for _, key := range namesOrIDs {
+		if account := r.get(key); account != nil {
+			accounts[account.ID.String()] = account
+			accounts[account.Name] = account
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	r.mutex.Unlock()
+
+	if len(missing) == 0 {
+		return accounts, nil
+	}
+
+	fetched, err := r.api.GetAccountsBatchContext(ctx, missing...)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for key, account := range fetched {
+		accounts[key] = account
+		if key == account.Name {
+			r.add(account)
+		}
+	}
+	return accounts, nil
+}
+
+// Invalidate drops the account of the name or id from the cache
+func (r *AccountResolver) Invalidate(nameOrID string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if e, ok := r.lookup(nameOrID); ok {
+		r.remove(e)
+	}
+}
+
+// InvalidateUpdated drops the accounts updated by account_update operations of the transactions
+func (r *AccountResolver) InvalidateUpdated(transactions []*types.Transaction) {
+	for _, tx := range transactions {
+		for _, operation := range tx.Operations {
+			if update, ok := operation.(*types.AccountUpdateOperation); ok {
+				r.Invalidate(update.Account.String())
+			}
+		}
+	}
+}
+
+// Len returns the number of cached accounts
+func (r *AccountResolver) Len() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.lru.Len()
+}
+
+// lookup returns the entry of the name or id, the caller holds the mutex
+func (r *AccountResolver) lookup(nameOrID string) (*list.Element, bool) {
+	if e, ok := r.byID[nameOrID]; ok {
+		return e, true
+	}
+	e, ok := r.byName[nameOrID]
+	return e, ok
+}
+
+// get returns the cached account of the name or id, nil when missing or expired
+func (r *AccountResolver) get(nameOrID string) *types.Account {
+	e, ok := r.lookup(nameOrID)
+	if !ok {
+		return nil
+	}
+	entry := e.Value.(*accountEntry)
+	if r.now().After(entry.expires) {
+		r.remove(e)
+		return nil
+	}
+	r.lru.MoveToFront(e)
+	return entry.account
+}
+
+// add caches the account, evicting the least recently used ones beyond the size
+func (r *AccountResolver) add(account *types.Account) {
+	if e, ok := r.byID[account.ID.String()]; ok {
+		r.remove(e)
+	}
+	e := r.lru.PushFront(&accountEntry{account: account, expires: r.now().Add(r.ttl)})
+	r.byID[account.ID.String()] = e
+	r.byName[account.Name] = e
+
+	for r.lru.Len() > r.size {
+		r.remove(r.lru.Back())
+	}
+}
+
+// remove drops the entry from the list and both indexes
+func (r *AccountResolver) remove(e *list.Element) {
+	account := e.Value.(*accountEntry).account
+	r.lru.Remove(e)
+	delete(r.byID, account.ID.String())
+	delete(r.byName, account.Name)
+}
@@ -0,0 +1,148 @@
+package bitshares
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/blocktree/bitshares-adapter/bitsharestest"
+	"github.com/blocktree/bitshares-adapter/types"
+)
+
+func TestAccountResolver_Resolve(t *testing.T) {
+	node := bitsharestest.NewNode()
+	defer node.Close()
+	aliceID := node.CreateAccount("alice", "")
+	node.CreateAccount("bob", "")
+
+	ctx := context.Background()
+	r := NewAccountResolver(NewWalletClient(node.URL, "", false), 0, 0)
+
+	// by name, then by id from the cache
+	alice, err := r.Resolve(ctx, "alice")
+	if err != nil || alice.ID.String() != aliceID {
+		t.Fatalf("Resolve(alice) = %v, %v", alice, err)
+	}
+	if account, err := r.Resolve(ctx, aliceID); err != nil || account != alice {
+		t.Errorf("Resolve(%s) = %v, %v, want the cached alice", aliceID, account, err)
+	}
+	if calls := node.Calls("get_accounts"); calls != 1 {
+		t.Errorf("get_accounts called %d times, want 1", calls)
+	}
+
+	// only the missing accounts are asked
+	accounts, err := r.ResolveBatch(ctx, "alice", "bob", "nobody")
+	if err != nil {
+		t.Fatalf("ResolveBatch failed unexpected error: %v", err)
+	}
+	if accounts["alice"] != alice || accounts["bob"] == nil || accounts[accounts["bob"].ID.String()] == nil {
+		t.Errorf("ResolveBatch = %v", accounts)
+	}
+	if _, ok := accounts["nobody"]; ok {
+		t.Errorf("unknown account resolved")
+	}
+	if calls := node.Calls("get_accounts"); calls != 2 {
+		t.Errorf("get_accounts called %d times, want 2", calls)
+	}
+	if _, err := r.Resolve(ctx, "nobody"); err == nil {
+		t.Errorf("Resolve of an unknown account should fail")
+	}
+	if r.Len() != 2 {
+		t.Errorf("%d accounts cached, want 2", r.Len())
+	}
+}
+
+func TestAccountResolver_Expire(t *testing.T) {
+	node := bitsharestest.NewNode()
+	defer node.Close()
+	for _, name := range []string{"alice", "bob", "carol"} {
+		node.CreateAccount(name, "")
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	r := NewAccountResolver(NewWalletClient(node.URL, "", false), 2, time.Minute)
+	r.now = func() time.Time { return now }
+
+	// the least recently used account is evicted
+	r.Resolve(ctx, "alice")
+	r.Resolve(ctx, "bob")
+	r.Resolve(ctx, "alice")
+	r.Resolve(ctx, "carol")
+	if r.Len() != 2 {
+		t.Errorf("%d accounts cached, want 2", r.Len())
+	}
+	calls := node.Calls("get_accounts")
+	r.Resolve(ctx, "alice")
+	if node.Calls("get_accounts") != calls {
+		t.Errorf("alice should still be cached")
+	}
+	r.Resolve(ctx, "bob")
+	if node.Calls("get_accounts") != calls+1 {
+		t.Errorf("bob should have been evicted")
+	}
+
+	// an expired account is asked again
+	calls = node.Calls("get_accounts")
+	now = now.Add(time.Minute + time.Second)
+	r.Resolve(ctx, "bob")
+	if node.Calls("get_accounts") != calls+1 {
+		t.Errorf("expired bob should be asked again")
+	}
+}
+
+func TestAccountResolver_InvalidateUpdated(t *testing.T) {
+	node := bitsharestest.NewNode()
+	defer node.Close()
+	oldKey, newKey := bitsharestest.WitnessSigningKey("1.6.1"), bitsharestest.WitnessSigningKey("1.6.2")
+	aliceID := node.CreateAccount("alice", oldKey)
+
+	ctx := context.Background()
+	r := NewAccountResolver(NewWalletClient(node.URL, "", false), 0, 0)
+	if _, err := r.Resolve(ctx, "alice"); err != nil {
+		t.Fatalf("Resolve failed unexpected error: %v", err)
+	}
+	node.SetMemoKey("alice", newKey)
+
+	var tx types.Transaction
+	data := `{"operations":[[6,{"fee":{"amount":100,"asset_id":"1.3.0"},"account":"` + aliceID + `","new_options":{"memo_key":"` + newKey + `"},"extensions":{}}]]}`
+	if err := json.Unmarshal([]byte(data), &tx); err != nil {
+		t.Fatalf("unmarshal account_update failed unexpected error: %v", err)
+	}
+	if _, ok := tx.Operations[0].(*types.AccountUpdateOperation); !ok {
+		t.Fatalf("operation %T is not an account_update", tx.Operations[0])
+	}
+
+	if alice, _ := r.Resolve(ctx, "alice"); alice.Options.MemoKey != oldKey {
+		t.Errorf("memo key %s, want the cached %s", alice.Options.MemoKey, oldKey)
+	}
+	r.InvalidateUpdated([]*types.Transaction{&tx})
+	if alice, _ := r.Resolve(ctx, "alice"); alice.Options.MemoKey != newKey {
+		t.Errorf("memo key %s after account_update, want %s", alice.Options.MemoKey, newKey)
+	}
+}
+
+func TestBtsBlockScanner_AccountCache(t *testing.T) {
+	node := bitsharestest.NewNode()
+	defer node.Close()
+	node.CreateAccount("alice", "")
+	node.CreateAccount("bob", "")
+	node.SetBalance("alice", bitsharestest.CoreAssetID, 100000)
+
+	bs, observer := testFakeNodeScanner(node, "bob")
+	for i := 0; i < 3; i++ {
+		node.Transfer("alice", "bob", bitsharestest.CoreAssetID, int64(1000+i))
+		block := node.MintBlock()
+		if err := bs.ScanBlock(uint64(block.Height)); err != nil {
+			t.Fatalf("ScanBlock(%d) failed unexpected error: %v", block.Height, err)
+		}
+	}
+
+	if len(observer.extract["bob"]) != 3 {
+		t.Errorf("extracted %d transfers to bob, want 3", len(observer.extract["bob"]))
+	}
+	if calls := node.Calls("get_accounts"); calls != 1 {
+		t.Errorf("get_accounts called %d times, want 1", calls)
+	}
+}
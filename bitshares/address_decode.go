@@ -61,7 +61,7 @@ func (decoder *addressDecoder) WIFToPrivateKey(wif string, isTestnet bool) ([]by
 
 // AddressVerify 地址校验
 func (decoder *addressDecoder) AddressVerify(address string, opts ...interface{}) bool {
-	_, err := decoder.wm.Api.GetAccounts(address)
+	_, err := decoder.wm.Accounts.Resolve(decoder.wm.Context(), address)
 	if err != nil {
 		return false
 	}
@@ -0,0 +1,162 @@
+/*
+ * Copyright 2018 The OpenWallet Authors
+ * This file is part of the OpenWallet library.
+ *
+ * The OpenWallet library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The OpenWallet library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ */
+
+package bitshares
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/blocktree/bitshares-adapter/types"
+	"github.com/blocktree/openwallet/v2/openwallet"
+)
+
+// CoreAssetID is the id of the core asset, the one fees are paid in
+const CoreAssetID = "1.3.0"
+
+// AssetRegistry resolves assets by symbol or id and caches them. The symbol and
+// the precision of an asset never change, so assets are cached until they are
+// invalidated: the scanner drops the assets whose options, issuer or core
+// exchange rate are changed by the operations of a block.
+type AssetRegistry struct {
+	api *WalletClient
+
+	mutex    sync.RWMutex      // protects the following
+	byID     map[string]*Asset // asset id -> asset
+	bySymbol map[string]*Asset // asset symbol -> asset
+}
+
+// NewAssetRegistry returns an empty registry asking the assets to the api
+func NewAssetRegistry(api *WalletClient) *AssetRegistry {
+	return &AssetRegistry{
+		api:      api,
+		byID:     make(map[string]*Asset),
+		bySymbol: make(map[string]*Asset),
+	}
+}
+
+// Resolve returns the asset of the symbol or id
+func (r *AssetRegistry) Resolve(ctx context.Context, symbolOrID string) (*Asset, error) {
+	assets, err := r.ResolveBatch(ctx, symbolOrID)
+	if err != nil {
+		return nil, err
+	}
+	asset, ok := assets[symbolOrID]
+	if !ok {
+		return nil, fmt.Errorf("asset %s does not exist", symbolOrID)
+	}
+	return asset, nil
+}
+
+// ResolveBatch returns the assets of the symbols or ids keyed by both symbol
+// and id. The missing ids are asked by get_assets and the missing symbols by
+// lookup_asset_symbols; assets that do not exist are left out.
+func (r *AssetRegistry) ResolveBatch(ctx context.Context, symbolsOrIDs ...string) (map[string]*Asset, error) {
+	var (
+		assets  = make(map[string]*Asset, 2*len(symbolsOrIDs))
+		ids     = make([]string, 0)
+		symbols = make([]string, 0)
+	)
+
+	r.mutex.RLock()
+	for _, key := range symbolsOrIDs {
+		if asset := r.get(key); asset != nil {
+			assets[asset.ID.String()] = asset
+			assets[asset.Symbol] = asset
+		} else if _, err := types.ParseObjectID(key); err == nil {
+			ids = append(ids, key)
+		} else {
+			symbols = append(symbols, key)
+		}
+	}
+	r.mutex.RUnlock()
+
+	fetched := make([]*Asset, 0, len(ids)+len(symbols))
+	if len(ids) > 0 {
+		list, err := r.api.GetAssetsContext(ctx, ids...)
+		if err != nil {
+			return nil, err
+		}
+		fetched = append(fetched, list...)
+	}
+	if len(symbols) > 0 {
+		list, err := r.api.LookupAssetSymbolsContext(ctx, symbols...)
+		if err != nil {
+			return nil, err
+		}
+		fetched = append(fetched, list...)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, asset := range fetched {
+		if asset == nil {
+			continue
+		}
+		r.byID[asset.ID.String()] = asset
+		r.bySymbol[asset.Symbol] = asset
+		assets[asset.ID.String()] = asset
+		assets[asset.Symbol] = asset
+	}
+	return assets, nil
+}
+
+// Invalidate drops the asset of the symbol or id, its flags are asked again on the next lookup
+func (r *AssetRegistry) Invalidate(symbolOrID string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if asset := r.get(symbolOrID); asset != nil {
+		delete(r.byID, asset.ID.String())
+		delete(r.bySymbol, asset.Symbol)
+	}
+}
+
+// InvalidateUpdated drops the assets changed by asset_update, asset_update_issuer
+// and asset_publish_feed operations of the transactions
+func (r *AssetRegistry) InvalidateUpdated(transactions []*types.Transaction) {
+	for _, tx := range transactions {
+		for _, operation := range tx.Operations {
+			switch op := operation.(type) {
+			case *types.AssetUpdateOperation:
+				r.Invalidate(op.AssetToUpdate.String())
+			case *types.AssetUpdateIssuerOperation:
+				r.Invalidate(op.AssetToUpdate.String())
+			case *types.AssetPublishFeedOperation:
+				r.Invalidate(op.AssetID.String())
+			}
+		}
+	}
+}
+
+// Contract returns the asset as a smart contract of the chain symbol, its address is the asset id
+func (a *Asset) Contract(symbol string) openwallet.SmartContract {
+	return openwallet.SmartContract{
+		Symbol:     symbol,
+		ContractID: openwallet.GenContractID(symbol, a.ID.String()),
+		Address:    a.ID.String(),
+		Token:      a.Symbol,
+		Name:       a.Symbol,
+		Decimals:   uint64(a.Precision),
+	}
+}
+
+// get returns the cached asset of the symbol or id, the caller holds the mutex
+func (r *AssetRegistry) get(symbolOrID string) *Asset {
+	if asset, ok := r.byID[symbolOrID]; ok {
+		return asset
+	}
+	return r.bySymbol[symbolOrID]
+}
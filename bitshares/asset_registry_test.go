@@ -0,0 +1,156 @@
+package bitshares
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/blocktree/bitshares-adapter/bitsharestest"
+	"github.com/blocktree/bitshares-adapter/types"
+)
+
+func TestAssetRegistry_Resolve(t *testing.T) {
+	node := bitsharestest.NewNode()
+	defer node.Close()
+	usdID := node.CreateAsset("USD", 4, AssetChargeMarketFee|AssetWitnessFedAsset)
+
+	ctx := context.Background()
+	r := NewAssetRegistry(NewWalletClient(node.URL, "", false))
+
+	// by symbol, then by id from the cache
+	usd, err := r.Resolve(ctx, "USD")
+	if err != nil {
+		t.Fatalf("Resolve(USD) failed unexpected error: %v", err)
+	}
+	if usd.ID.String() != usdID || usd.Precision != 4 || usd.DynamicAssetDataID != "2.3.1" {
+		t.Errorf("Resolve(USD) = %+v", usd)
+	}
+	if !usd.HasFlag(AssetWitnessFedAsset) || usd.HasFlag(AssetWhiteList) {
+		t.Errorf("flags %x", usd.Options.Flags)
+	}
+	if asset, err := r.Resolve(ctx, usdID); err != nil || asset != usd {
+		t.Errorf("Resolve(%s) = %v, %v, want the cached USD", usdID, asset, err)
+	}
+	if node.Calls("lookup_asset_symbols") != 1 || node.Calls("get_assets") != 0 {
+		t.Errorf("lookup_asset_symbols called %d times, get_assets %d times", node.Calls("lookup_asset_symbols"), node.Calls("get_assets"))
+	}
+
+	// ids are asked by get_assets
+	assets, err := r.ResolveBatch(ctx, bitsharestest.CoreAssetID, "USD", "1.3.99", "NOPE")
+	if err != nil {
+		t.Fatalf("ResolveBatch failed unexpected error: %v", err)
+	}
+	if core := assets[bitsharestest.CoreAssetSymbol]; core == nil || core.Precision != bitsharestest.CoreAssetPrecision {
+		t.Errorf("core asset %+v", core)
+	}
+	if assets["1.3.99"] != nil || assets["NOPE"] != nil {
+		t.Errorf("unknown assets resolved")
+	}
+	if node.Calls("get_assets") != 1 {
+		t.Errorf("get_assets called %d times, want 1", node.Calls("get_assets"))
+	}
+	if _, err := r.Resolve(ctx, "NOPE"); err == nil {
+		t.Errorf("Resolve of an unknown asset should fail")
+	}
+
+	r.Invalidate("USD")
+	if _, err := r.Resolve(ctx, usdID); err != nil || node.Calls("get_assets") != 2 {
+		t.Errorf("invalidated USD not asked again: %v, %d", err, node.Calls("get_assets"))
+	}
+}
+
+func TestAsset_Amount(t *testing.T) {
+	tests := []struct {
+		precision uint8
+		amount    uint64
+		want      string
+	}{
+		{5, 1000, "0.01"},
+		{5, 123456789, "1234.56789"},
+		{0, 42, "42"},
+		{8, 18446744073709551615, "184467440737.09551615"},
+	}
+	for _, test := range tests {
+		asset := Asset{Precision: test.precision}
+		if got := asset.Amount(test.amount); got != test.want {
+			t.Errorf("Amount(%d) with precision %d = %s, want %s", test.amount, test.precision, got, test.want)
+		}
+	}
+}
+
+func TestBtsBlockScanner_ExtractAsset(t *testing.T) {
+	node := bitsharestest.NewNode()
+	defer node.Close()
+	node.CreateAccount("alice", "")
+	node.CreateAccount("bob", "")
+	usdID := node.CreateAsset("USD", 4, 0)
+	node.SetBalance("alice", usdID, 1000000)
+	node.SetBalance("alice", bitsharestest.CoreAssetID, 100000)
+
+	bs, observer := testFakeNodeScanner(node, "alice")
+	if _, err := node.Transfer("alice", "bob", usdID, 12345); err != nil {
+		t.Fatalf("Transfer failed unexpected error: %v", err)
+	}
+	block := node.MintBlock()
+	if err := bs.ScanBlock(uint64(block.Height)); err != nil {
+		t.Fatalf("ScanBlock failed unexpected error: %v", err)
+	}
+
+	// the transfer and its fee paid in the core asset
+	list := observer.extract["alice"]
+	if len(list) != 2 {
+		t.Fatalf("extracted %d records of alice, want 2", len(list))
+	}
+	transfer, fee := list[0].Transaction, list[1].Transaction
+	if transfer.Amount != "1.2345" || transfer.Decimal != 4 || transfer.Coin.Contract.Token != "USD" || transfer.Coin.Contract.Address != usdID {
+		t.Errorf("wrong transfer extracted: %s %d %+v", transfer.Amount, transfer.Decimal, transfer.Coin.Contract)
+	}
+	if len(list[0].TxInputs) != 1 || list[0].TxInputs[0].Amount != "1.2345" {
+		t.Errorf("wrong inputs extracted: %+v", list[0].TxInputs)
+	}
+	if fee.Amount != "0.2" || fee.Decimal != bitsharestest.CoreAssetPrecision || fee.Coin.Contract.Token != bitsharestest.CoreAssetSymbol || fee.TxType != 1 {
+		t.Errorf("wrong fee extracted: %s %d %+v", fee.Amount, fee.Decimal, fee.Coin.Contract)
+	}
+}
+
+func TestAssetRegistry_InvalidateUpdated(t *testing.T) {
+	node := bitsharestest.NewNode()
+	defer node.Close()
+	usdID := node.CreateAsset("USD", 4, 0)
+	node.SetCoreExchangeRate(usdID, 10, 1)
+
+	ctx := context.Background()
+	api := NewWalletClient(node.URL, "", false)
+	r := NewAssetRegistry(api)
+	cached, err := r.Resolve(ctx, "USD")
+	if err != nil {
+		t.Fatalf("Resolve failed unexpected error: %v", err)
+	}
+	node.SetCoreExchangeRate(usdID, 20, 1)
+
+	// a transfer leaves the asset cached, an asset_update drops it
+	for _, test := range []struct {
+		operation string
+		cached    bool
+	}{
+		{`[0,{"fee":{"amount":100,"asset_id":"1.3.0"},"from":"1.2.1","to":"1.2.2","amount":{"amount":1,"asset_id":"` + usdID + `"},"extensions":[]}]`, true},
+		{`[11,{"fee":{"amount":100,"asset_id":"1.3.0"},"issuer":"1.2.0","asset_to_update":"` + usdID + `","new_options":{},"extensions":[]}]`, false},
+	} {
+		var tx types.Transaction
+		if err := json.Unmarshal([]byte(`{"operations":[`+test.operation+`]}`), &tx); err != nil {
+			t.Fatalf("unmarshal %s failed unexpected error: %v", test.operation, err)
+		}
+		r.InvalidateUpdated([]*types.Transaction{&tx})
+
+		usd, err := r.Resolve(ctx, "USD")
+		if err != nil {
+			t.Fatalf("Resolve failed unexpected error: %v", err)
+		}
+		if (usd == cached) != test.cached {
+			t.Errorf("operation %d: cached = %v, want %v", tx.Operations[0].Type(), usd == cached, test.cached)
+		}
+		if !test.cached && usd.Options.CoreExchangeRate.Base.Amount == cached.Options.CoreExchangeRate.Base.Amount {
+			t.Errorf("core exchange rate %+v after asset_update, want the new one", usd.Options.CoreExchangeRate)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+/*
+ * Copyright 2018 The OpenWallet Authors
+ * This file is part of the OpenWallet library.
+ *
+ * The OpenWallet library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The OpenWallet library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ */
+
+package bitshares
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/blocktree/bitshares-adapter/addrdec"
+	"github.com/blocktree/bitshares-adapter/types"
+	"github.com/pkg/errors"
+)
+
+// ErrCannotVerify is the cause of the error verifying a block with an operation
+// the adapter cannot serialize: its transactions cannot be hashed, so they are
+// neither verified nor proven forged. The header, its id and its witness
+// signature are verified all the same.
+var ErrCannotVerify = errors.New("cannot verify the block")
+
+// BlockVerifier checks the blocks answered by a node against their signed header,
+// so a lying node cannot make up transactions: the block id is recomputed, the
+// transactions must hash to the transaction_merkle_root and the header must be
+// signed by the signing key of its witness.
+type BlockVerifier struct {
+	api *WalletClient
+
+	mutex       sync.Mutex        // protects the following
+	signingKeys map[string][]byte // witness id -> compressed signing key
+}
+
+// NewBlockVerifier returns a verifier asking the witnesses' signing keys to the api
+func NewBlockVerifier(api *WalletClient) *BlockVerifier {
+	return &BlockVerifier{
+		api:         api,
+		signingKeys: make(map[string][]byte),
+	}
+}
+
+// Verify checks the block and sets its BlockID. Chaining the block to the previous
+// one is left to the caller, which knows the block it expects.
+func (v *BlockVerifier) Verify(ctx context.Context, block *Block) error {
+	header := BlockHeader{
+		TransactionMerkleRoot: block.TransactionMerkleRoot,
+		Previous:              block.Previous,
+		Timestamp:             block.Timestamp,
+		Witness:               block.Witness,
+		Extensions:            block.Extensions,
+		WitnessSignature:      block.WitnessSignature,
+	}
+	signed, err := header.header()
+	if err != nil {
+		return fmt.Errorf("block %d: %v", block.Height, err)
+	}
+
+	// id
+	if num := signed.BlockNum(); uint64(num) != block.Height {
+		return fmt.Errorf("block %d: previous %s is not block %d", block.Height, block.Previous, block.Height-1)
+	}
+	id, err := signed.ID()
+	if err != nil {
+		return fmt.Errorf("block %d: %v", block.Height, err)
+	}
+	if len(block.BlockID) > 0 && block.BlockID != id {
+		return fmt.Errorf("block %d: id %s does not match the header id %s", block.Height, block.BlockID, id)
+	}
+	block.BlockID = id
+
+	// transactions
+	var unverified error
+	root, err := types.MerkleRoot(block.Transactions)
+	if errors.Cause(err) == types.ErrNotSerializable {
+		unverified = errors.Wrapf(ErrCannotVerify, "block %d: %v", block.Height, err)
+	} else if err != nil {
+		return fmt.Errorf("block %d: %v", block.Height, err)
+	} else if root != block.TransactionMerkleRoot {
+		return fmt.Errorf("block %d: transactions do not match the merkle root %s", block.Height, block.TransactionMerkleRoot)
+	}
+	for i, tx := range block.Transactions {
+		txID, err := tx.ID()
+		if err == nil && i < len(block.TransactionIDs) && block.TransactionIDs[i] != txID {
+			return fmt.Errorf("block %d: transaction id %s does not match the transaction %s", block.Height, block.TransactionIDs[i], txID)
+		}
+	}
+
+	// witness signature
+	signee, err := signed.Signee()
+	if err != nil {
+		return fmt.Errorf("block %d: %v", block.Height, err)
+	}
+	key, err := v.signingKey(ctx, block.Witness, false)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(signee, key) {
+		// the witness may have changed its signing key since it was cached
+		if key, err = v.signingKey(ctx, block.Witness, true); err != nil {
+			return err
+		}
+		if !bytes.Equal(signee, key) {
+			return fmt.Errorf("block %d: not signed by the signing key of witness %s", block.Height, block.Witness)
+		}
+	}
+	return unverified
+}
+
+// signingKey returns the signing key of the witness, asked again to the node when refresh is set
+func (v *BlockVerifier) signingKey(ctx context.Context, witness string, refresh bool) ([]byte, error) {
+	v.mutex.Lock()
+	key, ok := v.signingKeys[witness]
+	v.mutex.Unlock()
+	if ok && !refresh {
+		return key, nil
+	}
+
+	id, err := types.ParseObjectID(witness)
+	if err != nil {
+		return nil, err
+	}
+	r, err := v.api.GetObjectsContext(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	signingKey := r.Get("0.signing_key").String()
+	if len(signingKey) == 0 {
+		return nil, fmt.Errorf("witness %s does not exist", witness)
+	}
+	key, err = addrdec.Default.AddressDecode(signingKey)
+	if err != nil {
+		return nil, fmt.Errorf("witness %s signing key %s: %v", witness, signingKey, err)
+	}
+
+	v.mutex.Lock()
+	v.signingKeys[witness] = key
+	v.mutex.Unlock()
+	return key, nil
+}
@@ -0,0 +1,227 @@
+package bitshares
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blocktree/bitshares-adapter/bitsharestest"
+	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/blocktree/bitshares-adapter/types"
+	"github.com/pkg/errors"
+)
+
+// testVerifiedNode returns a node with a transfer in its head block
+func testVerifiedNode(t *testing.T) (*bitsharestest.Node, *bitsharestest.Block) {
+	node := bitsharestest.NewNode()
+	node.CreateAccount("alice", "")
+	node.CreateAccount("bob", "")
+	node.SetBalance("alice", bitsharestest.CoreAssetID, 100000000)
+	node.MintBlocks(2)
+	for i := 0; i < 3; i++ {
+		if _, err := node.Transfer("alice", "bob", bitsharestest.CoreAssetID, int64(1000+i)); err != nil {
+			t.Fatalf("Transfer failed unexpected error: %v", err)
+		}
+	}
+	return node, node.MintBlock()
+}
+
+func TestBlockVerifier_Verify(t *testing.T) {
+	node, head := testVerifiedNode(t)
+	defer node.Close()
+
+	c := NewWalletClient(node.URL, "", false)
+	v := NewBlockVerifier(c)
+
+	for height := uint32(1); height <= head.Height; height++ {
+		block, err := c.GetBlockByHeight(height)
+		if err != nil {
+			t.Fatalf("GetBlockByHeight failed unexpected error: %v", err)
+		}
+		if err := v.Verify(context.Background(), block); err != nil {
+			t.Errorf("Verify block %d failed unexpected error: %v", height, err)
+		}
+		if block.BlockID != node.Block(height).ID {
+			t.Errorf("block %d id = %s, want %s", height, block.BlockID, node.Block(height).ID)
+		}
+	}
+
+	// the signing key of the witness is asked once
+	if calls := node.Calls("get_objects"); calls != 1 {
+		t.Errorf("get_objects called %d times, want 1", calls)
+	}
+}
+
+func TestBlockVerifier_Forged(t *testing.T) {
+	tests := []struct {
+		name   string
+		forge  func(b *bitsharestest.Block)
+		reason string
+	}{
+		{
+			name: "transaction",
+			forge: func(b *bitsharestest.Block) {
+				b.Transactions[1] = json.RawMessage(bytes.Replace(b.Transactions[1], []byte(`"amount":1001,`), []byte(`"amount":9001,`), 1))
+			},
+			reason: "merkle root",
+		},
+		{
+			name: "transaction order",
+			forge: func(b *bitsharestest.Block) {
+				b.Transactions[0], b.Transactions[1] = b.Transactions[1], b.Transactions[0]
+			},
+			reason: "merkle root",
+		},
+		{
+			name: "signature",
+			forge: func(b *bitsharestest.Block) {
+				b.WitnessSignature = strings.Repeat("0", 2) + b.WitnessSignature[2:66] + strings.Repeat("1", 64)
+			},
+			reason: "witness",
+		},
+		{
+			name: "witness",
+			forge: func(b *bitsharestest.Block) {
+				b.Witness = "1.6.2"
+			},
+			reason: "witness",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node, head := testVerifiedNode(t)
+			defer node.Close()
+			// a second witness the forged block may claim to be signed by
+			node.Fork(0)
+
+			test.forge(head)
+
+			c := NewWalletClient(node.URL, "", false)
+			block, err := c.GetBlockByHeight(head.Height)
+			if err != nil {
+				t.Fatalf("GetBlockByHeight failed unexpected error: %v", err)
+			}
+			err = NewBlockVerifier(c).Verify(context.Background(), block)
+			if err == nil {
+				t.Fatalf("forged %s has been verified", test.name)
+			}
+			if !strings.Contains(err.Error(), test.reason) {
+				t.Errorf("error %v, want %s", err, test.reason)
+			}
+		})
+	}
+}
+
+// testOperations are operations other than transfers, as found on the mainnet
+const testOperations = `[
+	[19,{"fee":{"amount":121,"asset_id":"1.3.0"},"publisher":"1.2.0","asset_id":"1.3.1362","feed":{"settlement_price":{"base":{"amount":"6145100000","asset_id":"1.3.1362"},"quote":{"amount":"10847812100000","asset_id":"1.3.0"}},"maintenance_collateral_ratio":2000,"maximum_short_squeeze_ratio":1100,"core_exchange_rate":{"base":{"amount":"7636000000","asset_id":"1.3.1362"},"quote":{"amount":"14153648900000","asset_id":"1.3.0"}}},"extensions":[]}],
+	[1,{"fee":{"amount":48260,"asset_id":"1.3.0"},"seller":"1.2.0","amount_to_sell":{"amount":100000,"asset_id":"1.3.0"},"min_to_receive":{"amount":1000,"asset_id":"1.3.113"},"expiration":"2030-01-01T00:00:00","fill_or_kill":false,"extensions":[]}]
+]`
+
+// testBroadcastOperations broadcasts the testOperations of a new account, signed
+// by its key, and returns the block they are in
+func testBroadcastOperations(t *testing.T, node *bitsharestest.Node, c *WalletClient) *BroadcastResponse {
+	head := node.HeadBlock()
+	tx, err := types.NewTransaction(head.ID, head.Timestamp.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("NewTransaction failed unexpected error: %v", err)
+	}
+	if err := json.Unmarshal([]byte(strings.Replace(testOperations, "1.2.0", node.CreateAccount("carol", testAliceKey), -1)), &tx.Operations); err != nil {
+		t.Fatalf("Operations unmarshal failed unexpected error: %v", err)
+	}
+	key, err := encoding.DecodeWIF(testAliceWIF)
+	if err != nil {
+		t.Fatalf("DecodeWIF failed unexpected error: %v", err)
+	}
+	if err := tx.Sign(types.ChainIDBTS, key); err != nil {
+		t.Fatalf("Sign failed unexpected error: %v", err)
+	}
+	r, err := c.BroadcastTransactionSynchronous(tx)
+	if err != nil {
+		t.Fatalf("BroadcastTransactionSynchronous failed unexpected error: %v", err)
+	}
+	return r
+}
+
+func TestBlockVerifier_Operations(t *testing.T) {
+	node, _ := testVerifiedNode(t)
+	defer node.Close()
+
+	c := NewWalletClient(node.URL, "", false)
+	v := NewBlockVerifier(c)
+	r := testBroadcastOperations(t, node, c)
+
+	block, err := c.GetBlockByHeight(r.BlockNum)
+	if err != nil {
+		t.Fatalf("GetBlockByHeight failed unexpected error: %v", err)
+	}
+	if err := v.Verify(context.Background(), block); err != nil {
+		t.Errorf("Verify failed unexpected error: %v", err)
+	}
+
+	// an operation the adapter does not know cannot be hashed
+	unknown := &types.Transaction{}
+	if err := json.Unmarshal(bytes.Replace(node.Block(r.BlockNum).Transactions[0], []byte(`[19,{`), []byte(`[99,{`), 1), unknown); err != nil {
+		t.Fatalf("Transaction unmarshal failed unexpected error: %v", err)
+	}
+	block.Transactions[0] = unknown
+	if err := v.Verify(context.Background(), block); errors.Cause(err) != ErrCannotVerify {
+		t.Errorf("Verify error %v, want %v", err, ErrCannotVerify)
+	}
+}
+
+func TestBtsBlockScanner_VerifyBlock(t *testing.T) {
+	node, head := testVerifiedNode(t)
+	defer node.Close()
+
+	bs, observer := testFakeNodeScanner(node, "bob")
+	bs.wm.Verifier = NewBlockVerifier(bs.wm.Api)
+
+	head.Transactions[0] = json.RawMessage(bytes.Replace(head.Transactions[0], []byte(`"amount":1000,`), []byte(`"amount":9000000,`), 1))
+
+	if err := bs.ScanBlock(uint64(head.Height)); err == nil {
+		t.Errorf("forged block has been scanned")
+	}
+	if len(observer.extract["bob"]) != 0 {
+		t.Errorf("transactions of a forged block have been extracted: %v", observer.extract["bob"])
+	}
+}
+
+func TestBtsBlockScanner_UnverifiedBlock(t *testing.T) {
+	node, head := testVerifiedNode(t)
+	defer node.Close()
+	node.BlockInfo = true
+	node.MintBlocks(2)
+
+	bs, observer := testFakeNodeScanner(node, "bob")
+	bs.wm.Verifier = NewBlockVerifier(bs.wm.Api)
+	bs.Scanning = true
+	previous := node.Block(head.Height - 1)
+	bs.SaveLocalBlockHead(previous.Height, previous.ID)
+
+	// an operation the adapter does not know, its transactions cannot be hashed
+	head.Transactions[0] = json.RawMessage(bytes.Replace(head.Transactions[0], []byte(`"operations":[[0,{`), []byte(`"operations":[[99,{`), 1))
+
+	// the scanner goes on past the block without extracting it
+	bs.ScanBlockTask()
+	height, hash, _ := bs.GetLocalBlockHead()
+	if want := node.Block(node.HeadBlock().Height - 1); height != want.Height || hash != want.ID {
+		t.Errorf("local head = %d %s, want %d %s", height, hash, want.Height, want.ID)
+	}
+	if len(observer.extract["bob"]) != 0 {
+		t.Errorf("transactions of an unverified block have been extracted: %v", observer.extract["bob"])
+	}
+
+	// and records it for rescan
+	records, err := bs.GetUnscanRecords()
+	if err != nil {
+		t.Fatalf("GetUnscanRecords failed unexpected error: %v", err)
+	}
+	if len(records) != 1 || records[0].BlockHeight != uint64(head.Height) {
+		t.Errorf("unscan records = %+v, want block %d", records, head.Height)
+	}
+}
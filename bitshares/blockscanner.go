@@ -16,24 +16,28 @@
 package bitshares
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"math/big"
-	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/blocktree/bitshares-adapter/encoding"
 	"github.com/blocktree/bitshares-adapter/types"
 
-	"github.com/blocktree/openwallet/v2/common"
 	"github.com/blocktree/openwallet/v2/log"
 	"github.com/blocktree/openwallet/v2/openwallet"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 )
 
 const (
 	//blockchainBucket = "blockchain" // blockchain dataset
 	//periodOfTask      = 5 * time.Second // task interval
 	maxExtractingSize = 10 // thread count
+	// pushed head is trusted for this long, after that the chain info is polled again
+	pushedHeadTimeout = 30 * time.Second
 )
 
 //BtsBlockScanner BTS block scanner
@@ -45,6 +49,16 @@ type BtsBlockScanner struct {
 	wm                   *WalletManager //钱包管理者
 	IsScanMemPool        bool           //是否扫描交易池
 	RescanLastBlockCount uint64         //重扫上N个区块数量
+
+	scanMutex        sync.Mutex    //扫描任务互斥锁
+	scanSignal       chan struct{} //新区块推送信号
+	subscribed       bool          //是否已订阅新区块推送
+	pushedHeadHeight uint64        //推送的最新区块高度
+	pushedHeadTime   int64         //最近一次推送的时间
+
+	ctxMutex sync.Mutex         //扫描上下文互斥锁
+	ctx      context.Context    //扫描上下文，停止扫描时取消进行中的请求
+	cancel   context.CancelFunc //取消扫描上下文
 }
 
 //ExtractResult extract result
@@ -71,6 +85,7 @@ func NewBlockScanner(wm *WalletManager) *BtsBlockScanner {
 	}
 
 	bs.extractingCH = make(chan struct{}, maxExtractingSize)
+	bs.scanSignal = make(chan struct{}, 1)
 	bs.wm = wm
 	bs.IsScanMemPool = true
 	bs.RescanLastBlockCount = 0
@@ -81,6 +96,119 @@ func NewBlockScanner(wm *WalletManager) *BtsBlockScanner {
 	return &bs
 }
 
+//Run 运行扫描器，节点支持websocket时订阅新区块推送
+func (bs *BtsBlockScanner) Run() error {
+	bs.startContext()
+	if err := bs.BlockScannerBase.Run(); err != nil {
+		return err
+	}
+	bs.subscribeNewBlock()
+	return nil
+}
+
+//Stop 停止扫描，取消进行中的请求
+func (bs *BtsBlockScanner) Stop() error {
+	defer bs.cancelContext()
+	return bs.BlockScannerBase.Stop()
+}
+
+//Pause 暂停扫描，取消进行中的请求
+func (bs *BtsBlockScanner) Pause() error {
+	defer bs.cancelContext()
+	return bs.BlockScannerBase.Pause()
+}
+
+//Restart 继续扫描
+func (bs *BtsBlockScanner) Restart() error {
+	bs.startContext()
+	return bs.BlockScannerBase.Restart()
+}
+
+//CloseBlockScanner 关闭扫描器
+func (bs *BtsBlockScanner) CloseBlockScanner() error {
+	bs.cancelContext()
+	return bs.BlockScannerBase.CloseBlockScanner()
+}
+
+//startContext 创建新的扫描上下文，并处理该上下文内的新区块推送信号
+func (bs *BtsBlockScanner) startContext() {
+	bs.ctxMutex.Lock()
+	defer bs.ctxMutex.Unlock()
+	if bs.ctx != nil && bs.ctx.Err() == nil {
+		return
+	}
+	bs.ctx, bs.cancel = context.WithCancel(bs.wm.Context())
+	go bs.handleScanSignal(bs.ctx)
+}
+
+//cancelContext 取消扫描上下文
+func (bs *BtsBlockScanner) cancelContext() {
+	bs.ctxMutex.Lock()
+	defer bs.ctxMutex.Unlock()
+	if bs.cancel != nil {
+		bs.cancel()
+	}
+}
+
+//scanContext 当前的扫描上下文，停止后为已取消的上下文，从未运行时为适配器的上下文
+func (bs *BtsBlockScanner) scanContext() context.Context {
+	bs.ctxMutex.Lock()
+	defer bs.ctxMutex.Unlock()
+	if bs.ctx == nil {
+		return bs.wm.Context()
+	}
+	return bs.ctx
+}
+
+//subscribeNewBlock 订阅节点的新区块推送，推送到达即触发扫描，定时任务作为兜底
+func (bs *BtsBlockScanner) subscribeNewBlock() {
+	if bs.subscribed || bs.wm.Api.Websocket() == nil {
+		return
+	}
+
+	err := bs.wm.Api.SubscribeBlockApplied(func(blockID string) {
+		atomic.StoreUint64(&bs.pushedHeadHeight, uint64(BlockNumFromID(blockID)))
+		atomic.StoreInt64(&bs.pushedHeadTime, time.Now().Unix())
+		select {
+		case bs.scanSignal <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		bs.wm.Log.Std.Error("subscribe block applied failed, fall back to polling, err: %v", err)
+		return
+	}
+	bs.subscribed = true
+}
+
+//handleScanSignal 推送到达即触发扫描，扫描上下文取消时退出
+func (bs *BtsBlockScanner) handleScanSignal(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-bs.scanSignal:
+			if bs.Scanning {
+				bs.ScanBlockTask()
+			}
+		}
+	}
+}
+
+//getHeadBlockHeight 获取最新区块高度，推送的高度未过期时无需轮询节点
+func (bs *BtsBlockScanner) getHeadBlockHeight() (uint64, error) {
+	pushedTime := atomic.LoadInt64(&bs.pushedHeadTime)
+	if bs.subscribed && time.Since(time.Unix(pushedTime, 0)) < pushedHeadTimeout {
+		return atomic.LoadUint64(&bs.pushedHeadHeight), nil
+	}
+
+	infoResp, err := bs.GetChainInfo()
+	if err != nil {
+		return 0, err
+	}
+	return infoResp.HeadBlockNum, nil
+}
+
 // ScanBlockTask scan block task
 func (bs *BtsBlockScanner) ScanBlockTask() {
 
@@ -89,6 +217,10 @@ func (bs *BtsBlockScanner) ScanBlockTask() {
 		currentHash   string
 	)
 
+	//定时任务与推送可能同时触发
+	bs.scanMutex.Lock()
+	defer bs.scanMutex.Unlock()
+
 	// get local block header
 	currentHeight, currentHash, err := bs.GetLocalBlockHead()
 
@@ -102,6 +234,7 @@ func (bs *BtsBlockScanner) ScanBlockTask() {
 		headBlock, err := bs.GetGlobalHeadBlock()
 		if err != nil {
 			bs.wm.Log.Std.Info("get head block error, err=%v", err)
+			return
 		}
 
 		currentHash = headBlock.Previous
@@ -114,14 +247,12 @@ func (bs *BtsBlockScanner) ScanBlockTask() {
 			return
 		}
 
-		infoResp, err := bs.GetChainInfo()
+		maxBlockHeight, err := bs.getHeadBlockHeight()
 		if err != nil {
 			bs.wm.Log.Errorf("get chain info failed, err=%v", err)
 			break
 		}
 
-		maxBlockHeight := infoResp.HeadBlockNum
-
 		bs.wm.Log.Info("current block height:", currentHeight, " maxBlockHeight:", maxBlockHeight)
 		if uint64(currentHeight) == maxBlockHeight-1 {
 			bs.wm.Log.Std.Info("block scanner has scanned full chain data. Current height %d", maxBlockHeight)
@@ -132,9 +263,13 @@ func (bs *BtsBlockScanner) ScanBlockTask() {
 		currentHeight = currentHeight + 1
 
 		bs.wm.Log.Std.Info("block scanner scanning height: %d ...", currentHeight)
-		block, err := bs.wm.Api.GetBlockByHeight(currentHeight)
+		block, err := bs.getBlock(currentHeight)
+		unverified := err
+		if errors.Cause(err) != ErrCannotVerify {
+			unverified = nil
+		}
 
-		if err != nil {
+		if err != nil && unverified == nil {
 			bs.wm.Log.Std.Info("block scanner can not get new block data by rpc; unexpected error: %v", err)
 			break
 		}
@@ -158,8 +293,8 @@ func (bs *BtsBlockScanner) ScanBlockTask() {
 				bs.wm.Log.Std.Error("block scanner can not get local block; unexpected error: %v", err)
 				//get block from rpc
 				bs.wm.Log.Info("block scanner prev block height:", currentHeight)
-				curBlock, err := bs.wm.Api.GetBlockByHeight(currentHeight)
-				if err != nil {
+				curBlock, err := bs.getBlock(currentHeight)
+				if err != nil && errors.Cause(err) != ErrCannotVerify {
 					bs.wm.Log.Std.Error("block scanner can not get prev block by rpc; unexpected error: %v", err)
 					break
 				}
@@ -180,9 +315,15 @@ func (bs *BtsBlockScanner) ScanBlockTask() {
 
 		} else {
 			currentHash = block.BlockID
-			err := bs.BatchExtractTransactions(uint64(currentHeight), currentHash, block.Timestamp.Unix(), block.Transactions, block.TransactionIDs)
-			if err != nil {
-				bs.wm.Log.Std.Error("block scanner ran BatchExtractTransactions occured unexpected error: %v", err)
+			if unverified != nil {
+				//交易无法校验，不提取，记录未扫区块待升级后重扫
+				unscanRecord := openwallet.NewUnscanRecord(uint64(currentHeight), "", unverified.Error(), bs.wm.Symbol())
+				bs.SaveUnscanRecord(unscanRecord)
+			} else {
+				err := bs.BatchExtractTransactions(uint64(currentHeight), currentHash, block.Timestamp.Unix(), block.Transactions, block.TransactionIDs)
+				if err != nil {
+					bs.wm.Log.Std.Error("block scanner ran BatchExtractTransactions occured unexpected error: %v", err)
+				}
 			}
 
 			//保存本地新高度
@@ -225,8 +366,15 @@ func (bs *BtsBlockScanner) BatchExtractTransactions(blockHeight uint64, blockHas
 		return nil
 	}
 
+	//区块内更新的账户和资产从缓存中移除
+	bs.wm.Accounts.InvalidateUpdated(transactions)
+	bs.wm.Assets.InvalidateUpdated(transactions)
+
 	bs.wm.Log.Std.Info("block scanner ready extract transactions total: %d ", len(transactions))
 
+	//批量查询区块内转账涉及的账户
+	accounts := bs.prefetchAccounts(transactions)
+
 	//生产通道
 	producer := make(chan ExtractResult)
 	defer close(producer)
@@ -271,7 +419,7 @@ func (bs *BtsBlockScanner) BatchExtractTransactions(blockHeight uint64, blockHas
 
 			go func(mBlockHeight uint64, mTx *types.Transaction, end chan struct{}, mProducer chan<- ExtractResult) {
 				//导出提出的交易
-				mProducer <- bs.ExtractTransaction(mBlockHeight, eBlockHash, eBlockTime, mTx, bs.ScanTargetFunc)
+				mProducer <- bs.extractTransaction(mBlockHeight, eBlockHash, eBlockTime, mTx, bs.ScanTargetFunc, accounts)
 				//释放
 				<-end
 
@@ -325,8 +473,62 @@ func (bs *BtsBlockScanner) extractRuntime(producer chan ExtractResult, worker ch
 	//return
 }
 
+//prefetchAccounts 一次批量请求查询交易单中所有转账的发送者和接收者，已缓存的账户不再请求
+func (bs *BtsBlockScanner) prefetchAccounts(transactions []*types.Transaction) map[string]*types.Account {
+	ids := make([]string, 0)
+	for _, tx := range transactions {
+		for _, operation := range tx.Operations {
+			if transferOperation, ok := operation.(*types.TransferOperation); ok {
+				ids = append(ids, transferOperation.From.String(), transferOperation.To.String())
+			}
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	accounts, err := bs.wm.Accounts.ResolveBatch(bs.scanContext(), ids...)
+	if err != nil {
+		//失败时逐笔查询
+		bs.wm.Log.Std.Error("batch get accounts failed, unexpected error: %v", err)
+		return nil
+	}
+	return accounts
+}
+
+//transferAccounts 获取转账的发送者和接收者，优先使用已查询的账户
+func (bs *BtsBlockScanner) transferAccounts(operation *types.TransferOperation, accounts map[string]*types.Account) (*types.Account, *types.Account, error) {
+	from, ok1 := accounts[operation.From.String()]
+	to, ok2 := accounts[operation.To.String()]
+	if ok1 && ok2 {
+		return from, to, nil
+	}
+
+	if !ok1 {
+		account, err := bs.wm.Accounts.Resolve(bs.scanContext(), operation.From.String())
+		if err != nil {
+			return nil, nil, err
+		}
+		from = account
+	}
+	if !ok2 {
+		account, err := bs.wm.Accounts.Resolve(bs.scanContext(), operation.To.String())
+		if err != nil {
+			return nil, nil, err
+		}
+		to = account
+	}
+	return from, to, nil
+}
+
 // ExtractTransaction 提取交易单
 func (bs *BtsBlockScanner) ExtractTransaction(blockHeight uint64, blockHash string, blockTime int64, transaction *types.Transaction, scanTargetFunc openwallet.BlockScanTargetFunc) ExtractResult {
+	return bs.extractTransaction(blockHeight, blockHash, blockTime, transaction, scanTargetFunc, nil)
+}
+
+//extractTransaction 提取交易单，accounts为预先查询的账户
+func (bs *BtsBlockScanner) extractTransaction(blockHeight uint64, blockHash string, blockTime int64, transaction *types.Transaction, scanTargetFunc openwallet.BlockScanTargetFunc, accounts map[string]*types.Account) ExtractResult {
 	var (
 		success = true
 		result  = ExtractResult{
@@ -349,13 +551,12 @@ func (bs *BtsBlockScanner) ExtractTransaction(blockHeight uint64, blockHash stri
 
 			txID := transaction.TransactionID
 			if len(txID) == 0 {
-				txID, err := bs.wm.Api.GetTransactionID(transaction)
-				bs.wm.Log.Std.Debug("tx: %v", txID)
-
-				if err != nil || len(txID) == 0 {
+				id, err := bs.wm.Api.GetTransactionIDContext(bs.scanContext(), transaction)
+				if err != nil || len(id) == 0 {
 					bs.wm.Log.Std.Error("cannot get txid, block: %v %s \n%v", blockHeight, transaction.Signatures, err)
 					return ExtractResult{Success: false}
 				}
+				txID = id
 			}
 			result.TxID = txID
 
@@ -364,29 +565,31 @@ func (bs *BtsBlockScanner) ExtractTransaction(blockHeight uint64, blockHash stri
 				return ExtractResult{Success: false}
 			}
 
-			accounts, err := bs.wm.Api.GetAccounts(transferOperation.From.String(), transferOperation.To.String())
-			if len(accounts) != 2 {
+			from, to, err := bs.transferAccounts(transferOperation, accounts)
+			if err != nil {
 				bs.wm.Log.Std.Error("cannot get accounts, block: %v %s \n%v", blockHeight, txID, err)
 				return ExtractResult{Success: false}
 			}
-			from := accounts[0]
-			to := accounts[1]
 
 			//订阅地址为交易单中的发送者
 			accountID1, ok1 := scanTargetFunc(openwallet.ScanTarget{Alias: from.Name, Symbol: bs.wm.Symbol(), BalanceModelType: openwallet.BalanceModelTypeAccount})
 			//订阅地址为交易单中的接收者
 			accountID2, ok2 := scanTargetFunc(openwallet.ScanTarget{Alias: to.Name, Symbol: bs.wm.Symbol(), BalanceModelType: openwallet.BalanceModelTypeAccount})
 			if accountID1 == accountID2 && len(accountID1) > 0 && len(accountID2) > 0 {
-				bs.InitExtractResult(accountID1, transferOperation, &result, 0)
+				err = bs.initExtractResult(accountID1, transferOperation, &result, 0, from, to)
 			} else {
 				if ok1 {
-					bs.InitExtractResult(accountID1, transferOperation, &result, 1)
+					err = bs.initExtractResult(accountID1, transferOperation, &result, 1, from, to)
 				}
 
-				if ok2 {
-					bs.InitExtractResult(accountID2, transferOperation, &result, 2)
+				if ok2 && err == nil {
+					err = bs.initExtractResult(accountID2, transferOperation, &result, 2, from, to)
 				}
 			}
+			if err != nil {
+				bs.wm.Log.Std.Error("cannot get assets, block: %v %s \n%v", blockHeight, txID, err)
+				return ExtractResult{Success: false}
+			}
 
 		}
 	}
@@ -397,6 +600,27 @@ func (bs *BtsBlockScanner) ExtractTransaction(blockHeight uint64, blockHash stri
 
 //InitExtractResult optType = 0: 输入输出提取，1: 输入提取，2：输出提取
 func (bs *BtsBlockScanner) InitExtractResult(sourceKey string, operation *types.TransferOperation, result *ExtractResult, optType int64) {
+	from, to, err := bs.transferAccounts(operation, nil)
+	if err != nil {
+		bs.wm.Log.Std.Error("cannot get accounts, %s %s \n %v", operation.From.String(), operation.To.String(), err)
+		return
+	}
+	if err := bs.initExtractResult(sourceKey, operation, result, optType, from, to); err != nil {
+		bs.wm.Log.Std.Error("cannot get assets, %s %s \n %v", operation.Amount.AssetID.String(), operation.Fee.AssetID.String(), err)
+	}
+}
+
+//initExtractResult 使用已查询的发送者和接收者提取，金额按资产精度换算
+func (bs *BtsBlockScanner) initExtractResult(sourceKey string, operation *types.TransferOperation, result *ExtractResult, optType int64, from, to *types.Account) error {
+
+	assets, err := bs.wm.Assets.ResolveBatch(bs.scanContext(), operation.Amount.AssetID.String(), operation.Fee.AssetID.String())
+	if err != nil {
+		return err
+	}
+	asset, feeAsset := assets[operation.Amount.AssetID.String()], assets[operation.Fee.AssetID.String()]
+	if asset == nil || feeAsset == nil {
+		return fmt.Errorf("asset %s or %s does not exist", operation.Amount.AssetID.String(), operation.Fee.AssetID.String())
+	}
 
 	txExtractDataArray := result.extractData[sourceKey]
 	if txExtractDataArray == nil {
@@ -408,38 +632,15 @@ func (bs *BtsBlockScanner) InitExtractResult(sourceKey string, operation *types.
 	status := "1"
 	reason := ""
 
-	token := operation.Amount.AssetID.String()
-	amount := common.NewString(operation.Amount.Amount).String()
-
-	contractID := openwallet.GenContractID(bs.wm.Symbol(), token)
-	coin := openwallet.Coin{
-		Symbol:     bs.wm.Symbol(),
-		IsContract: true,
-		ContractID: contractID,
-	}
-
-	coin.Contract = openwallet.SmartContract{
-		Symbol:     bs.wm.Symbol(),
-		ContractID: contractID,
-		Address:    token,
-		Token:      token,
-	}
-
-	accounts, err := bs.wm.Api.GetAccounts(operation.From.String(), operation.To.String())
-	if len(accounts) != 2 {
-		bs.wm.Log.Std.Error("cannot get accounts, %s %s \n %v", operation.From.String(), operation.To.String(), err)
-		return
-	}
-	from := accounts[0]
-	to := accounts[1]
+	amount := asset.Amount(operation.Amount.Amount)
 
 	transx := &openwallet.Transaction{
 		Fees:        "0",
-		Coin:        coin,
+		Coin:        bs.assetCoin(asset),
 		BlockHash:   result.BlockHash,
 		BlockHeight: result.BlockHeight,
 		TxID:        result.TxID,
-		// Decimal:     0,
+		Decimal:     int32(asset.Precision),
 		Amount:      amount,
 		ConfirmTime: result.BlockTime,
 		From:        []string{from.Name + ":" + amount},
@@ -450,7 +651,7 @@ func (bs *BtsBlockScanner) InitExtractResult(sourceKey string, operation *types.
 		TxType:      0,
 	}
 
-	if len(operation.Memo.Message) > 0 {
+	if operation.Memo != nil && len(operation.Memo.Message) > 0 {
 		// Config
 		memoPrivateKey := bs.wm.Config.MemoPrivateKey
 
@@ -458,15 +659,7 @@ func (bs *BtsBlockScanner) InitExtractResult(sourceKey string, operation *types.
 			bs.wm.Log.Std.Error("Config MemoPrivateKey is empty!")
 		} else {
 			// Decrypt Memo with MemoPrivateKey
-			var nonce uint64
-
-			if len(operation.Memo.Nonce) != 0 {
-				nonce, err = strconv.ParseUint(operation.Memo.Nonce, 10, 64)
-				if err != nil {
-					bs.wm.Log.Std.Error("ParseUint: %v, %v", err, operation.Memo)
-				}
-			}
-			memo, err := encoding.Decrypt(operation.Memo.Message, operation.Memo.From, operation.Memo.To, nonce, memoPrivateKey)
+			memo, err := encoding.Decrypt(operation.Memo.Message, operation.Memo.From.String(), operation.Memo.To.String(), uint64(operation.Memo.Nonce), memoPrivateKey)
 			if err != nil {
 				bs.wm.Log.Std.Error("Decrypt: %v, %v", err, operation.Memo)
 			}
@@ -490,30 +683,15 @@ func (bs *BtsBlockScanner) InitExtractResult(sourceKey string, operation *types.
 	txExtractDataArray = append(txExtractDataArray, txExtractData)
 
 	if operation.Fee.AssetID != operation.Amount.AssetID && optType != 2 {
-		fee := common.NewString(operation.Fee.Amount).String()
-		feeToken := operation.Fee.AssetID.String()
-
-		contractID := openwallet.GenContractID(bs.wm.Symbol(), feeToken)
-		feeCoin := openwallet.Coin{
-			Symbol:     bs.wm.Symbol(),
-			IsContract: true,
-			ContractID: contractID,
-		}
-
-		feeCoin.Contract = openwallet.SmartContract{
-			Symbol:     bs.wm.Symbol(),
-			ContractID: contractID,
-			Address:    feeToken,
-			Token:      feeToken,
-		}
+		fee := feeAsset.Amount(operation.Fee.Amount)
 
 		feeTransx := &openwallet.Transaction{
 			Fees:        "0",
-			Coin:        feeCoin,
+			Coin:        bs.assetCoin(feeAsset),
 			BlockHash:   result.BlockHash,
 			BlockHeight: result.BlockHeight,
 			TxID:        result.TxID,
-			// Decimal:     0,
+			Decimal:     int32(feeAsset.Precision),
 			Amount:      fee,
 			ConfirmTime: result.BlockTime,
 			From:        []string{from.Name + ":" + fee},
@@ -534,6 +712,18 @@ func (bs *BtsBlockScanner) InitExtractResult(sourceKey string, operation *types.
 	}
 
 	result.extractData[sourceKey] = txExtractDataArray
+	return nil
+}
+
+//assetCoin 资产对应的币种信息，合约地址为资产ID
+func (bs *BtsBlockScanner) assetCoin(asset *Asset) openwallet.Coin {
+	contract := asset.Contract(bs.wm.Symbol())
+	return openwallet.Coin{
+		Symbol:     bs.wm.Symbol(),
+		IsContract: true,
+		ContractID: contract.ContractID,
+		Contract:   contract,
+	}
 }
 
 //extractTxInput 提取交易单输入部分,无需手续费，所以只包含1个TxInput
@@ -561,9 +751,8 @@ func (bs *BtsBlockScanner) extractTxInput(from string, operation *types.Transfer
 	bs.wm.Log.Std.Info("extract input: %v", txInput)
 
 	if tx.TxType == 0 && operation.Fee.Amount > 0 && operation.Fee.AssetID == operation.Amount.AssetID {
-		//手续费也作为一个输出s
-		fee := new(big.Int)
-		fee.SetUint64(operation.Fee.Amount)
+		//手续费也作为一个输出s，与转账同一资产
+		fee := decimal.NewFromBigInt(new(big.Int).SetUint64(operation.Fee.Amount), -int32(coin.Contract.Decimals))
 		tmp := *txInput
 		feeCharge := &tmp
 		feeCharge.Amount = fee.String()
@@ -636,9 +825,30 @@ func (bs *BtsBlockScanner) ScanBlock(height uint64) error {
 	return nil
 }
 
+//getBlock 获取区块，开启区块校验时校验区块头、交易和见证人签名。
+//交易无法校验的区块连同ErrCannotVerify一起返回：区块头已校验，可以接续链，但交易不可提取
+func (bs *BtsBlockScanner) getBlock(height uint32) (*Block, error) {
+	block, err := bs.wm.Api.GetBlockByHeightContext(bs.scanContext(), height)
+	if err != nil {
+		return nil, err
+	}
+	if bs.wm.Verifier != nil {
+		err := bs.wm.Verifier.Verify(bs.scanContext(), block)
+		if errors.Cause(err) == ErrCannotVerify {
+			bs.wm.Log.Std.Warning("block %d transactions cannot be verified, skipped and recorded for rescan: %v", height, err)
+			return block, err
+		}
+		if err != nil {
+			bs.wm.Log.Std.Error("block verification failed: %v", err)
+			return nil, err
+		}
+	}
+	return block, nil
+}
+
 func (bs *BtsBlockScanner) scanBlock(height uint64) (*Block, error) {
 
-	block, err := bs.wm.Api.GetBlockByHeight(uint32(height))
+	block, err := bs.getBlock(uint32(height))
 	if err != nil {
 		bs.wm.Log.Std.Info("block scanner can not get new block data; unexpected error: %v", err)
 
@@ -665,7 +875,7 @@ func (bs *BtsBlockScanner) SetRescanBlockHeight(height uint64) error {
 		return errors.New("block height to rescan must greater than 0. ")
 	}
 
-	block, err := bs.wm.Api.GetBlockByHeight(uint32(height - 1))
+	block, err := bs.getBlock(uint32(height - 1))
 	if err != nil {
 		return err
 	}
@@ -693,7 +903,7 @@ func (bs *BtsBlockScanner) GetGlobalHeadBlock() (block *Block, err error) {
 		return
 	}
 
-	block, err = bs.wm.Api.GetBlockByHeight(uint32(infoResp.HeadBlockNum) - 1)
+	block, err = bs.getBlock(uint32(infoResp.HeadBlockNum) - 1)
 	if err != nil {
 		bs.wm.Log.Std.Info("block scanner can not get block by height; unexpected error:%v", err)
 		return
@@ -704,7 +914,7 @@ func (bs *BtsBlockScanner) GetGlobalHeadBlock() (block *Block, err error) {
 
 //GetChainInfo GetChainInfo
 func (bs *BtsBlockScanner) GetChainInfo() (infoResp *BlockchainInfo, err error) {
-	infoResp, err = bs.wm.Api.GetBlockchainInfo()
+	infoResp, err = bs.wm.Api.GetBlockchainInfoContext(bs.scanContext())
 	if err != nil {
 		bs.wm.Log.Std.Info("block scanner can not get info; unexpected error:%v", err)
 	}
@@ -720,7 +930,37 @@ func (bs *BtsBlockScanner) GetScannedBlockHeight() uint64 {
 //GetBalanceByAddress 查询地址余额
 func (bs *BtsBlockScanner) GetBalanceByAddress(address ...string) ([]*openwallet.Balance, error) {
 
-	addrBalanceArr := make([]*openwallet.Balance, 0)
+	addrBalanceArr := make([]*openwallet.Balance, 0, len(address))
+	if len(address) == 0 {
+		return addrBalanceArr, nil
+	}
+
+	core, err := bs.wm.Assets.Resolve(bs.wm.Context(), CoreAssetID)
+	if err != nil {
+		return nil, err
+	}
+
+	//一次批量请求查询所有账户的核心资产余额
+	balances, err := bs.wm.Api.GetAssetsBalanceBatchContext(bs.wm.Context(), core.ID, address...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, addr := range address {
+		if balances[i] == nil {
+			return nil, fmt.Errorf("get account[%v] balance failed", addr)
+		}
+		balance, _ := decimal.NewFromString(balances[i].Amount)
+		balance = balance.Shift(-int32(core.Precision))
+
+		addrBalanceArr = append(addrBalanceArr, &openwallet.Balance{
+			Symbol:           bs.wm.Symbol(),
+			Address:          addr,
+			Balance:          balance.String(),
+			ConfirmBalance:   balance.String(),
+			UnconfirmBalance: "0",
+		})
+	}
 
 	return addrBalanceArr, nil
 }
@@ -769,7 +1009,7 @@ func (bs *BtsBlockScanner) RescanFailedRecord() {
 
 		bs.wm.Log.Std.Info("block scanner rescanning height: %d ...", height)
 
-		block, err := bs.wm.Api.GetBlockByHeight(uint32(height))
+		block, err := bs.getBlock(uint32(height))
 		if err != nil {
 			bs.wm.Log.Std.Info("block scanner can not get new block data; unexpected error: %v", err)
 			continue
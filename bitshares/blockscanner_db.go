@@ -17,6 +17,9 @@ package bitshares
 
 import (
 	"fmt"
+	"time"
+
+	"github.com/blocktree/bitshares-adapter/types"
 	"github.com/blocktree/openwallet/v2/openwallet"
 )
 
@@ -84,9 +87,10 @@ func (bs *BtsBlockScanner) GetLocalBlock(height uint32) (*Block, error) {
 	}
 
 	block := &Block{
-		BlockID:  header.Hash,
-		Previous: header.Previousblockhash,
-		Height:   header.Height,
+		BlockID:   header.Hash,
+		Previous:  header.Previousblockhash,
+		Height:    header.Height,
+		Timestamp: types.NewTime(time.Unix(int64(header.Time), 0).UTC()),
 	}
 
 	return block, nil
@@ -16,8 +16,12 @@
 package bitshares
 
 import (
+	"context"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/blocktree/bitshares-adapter/bitsharestest"
 	"github.com/blocktree/openwallet/v2/openwallet"
 )
 
@@ -57,3 +61,151 @@ func TestBtsBlockScanner_ScanBlock(t *testing.T) {
 		})
 	}
 }
+
+// testObserver collects the data extracted by the scanner
+type testObserver struct {
+	mutex   sync.Mutex
+	extract map[string][]*openwallet.TxExtractData
+}
+
+func (o *testObserver) BlockScanNotify(header *openwallet.BlockHeader) error {
+	return nil
+}
+
+func (o *testObserver) BlockExtractDataNotify(sourceKey string, data *openwallet.TxExtractData) error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.extract[sourceKey] = append(o.extract[sourceKey], data)
+	return nil
+}
+
+func (o *testObserver) BlockExtractSmartContractDataNotify(sourceKey string, data *openwallet.SmartContractReceipt) error {
+	return nil
+}
+
+// testFakeNodeScanner returns a scanner of a fake node watching the given accounts
+func testFakeNodeScanner(node *bitsharestest.Node, watched ...string) (*BtsBlockScanner, *testObserver) {
+	wm := NewWalletManager(nil)
+	wm.Api = NewWalletClient(node.URL, node.URL, false)
+	wm.Accounts = NewAccountResolver(wm.Api, 0, 0)
+	wm.Assets = NewAssetRegistry(wm.Api)
+	wm.Fees = NewFeeCache(wm.Api)
+
+	bs := wm.Blockscanner
+	bs.SetBlockchainDAI(bitsharestest.NewBlockchainDAI())
+	bs.SetBlockScanTargetFunc(func(target openwallet.ScanTarget) (string, bool) {
+		for _, name := range watched {
+			if target.Alias == name {
+				return name, true
+			}
+		}
+		return "", false
+	})
+
+	observer := &testObserver{extract: make(map[string][]*openwallet.TxExtractData)}
+	bs.AddObserver(observer)
+	return bs, observer
+}
+
+func TestBtsBlockScanner_FakeNode(t *testing.T) {
+	node := bitsharestest.NewNode()
+	defer node.Close()
+
+	node.CreateAccount("alice", "")
+	node.CreateAccount("bob", "")
+	node.SetBalance("alice", bitsharestest.CoreAssetID, 100000000)
+	node.MintBlocks(3)
+
+	bs, observer := testFakeNodeScanner(node, "bob")
+
+	txID, err := node.Transfer("alice", "bob", bitsharestest.CoreAssetID, 1000)
+	if err != nil {
+		t.Fatalf("Transfer failed unexpected error: %v", err)
+	}
+	block := node.MintBlock()
+
+	if err := bs.ScanBlock(uint64(block.Height)); err != nil {
+		t.Fatalf("ScanBlock failed unexpected error: %v", err)
+	}
+
+	list := observer.extract["bob"]
+	if len(list) != 1 {
+		t.Fatalf("extracted %d transactions of bob, want 1", len(list))
+	}
+	tx := list[0].Transaction
+	if tx.TxID != txID || tx.Amount != "0.01" || tx.BlockHash != block.ID {
+		t.Errorf("wrong transaction extracted: %+v", tx)
+	}
+	if tx.Decimal != bitsharestest.CoreAssetPrecision || tx.Coin.Contract.Token != bitsharestest.CoreAssetSymbol || tx.Coin.Contract.Address != bitsharestest.CoreAssetID {
+		t.Errorf("wrong asset extracted: %d %+v", tx.Decimal, tx.Coin.Contract)
+	}
+	if len(list[0].TxOutputs) != 1 || list[0].TxOutputs[0].Address != "bob" {
+		t.Errorf("wrong outputs extracted: %+v", list[0].TxOutputs)
+	}
+}
+
+func TestBtsBlockScanner_FakeNodeFork(t *testing.T) {
+	node := bitsharestest.NewNode()
+	defer node.Close()
+	node.MintBlocks(5)
+
+	bs, _ := testFakeNodeScanner(node)
+	bs.Scanning = true
+
+	// the scanner stays one block behind the head
+	bs.ScanBlockTask()
+	height, hash, _ := bs.GetLocalBlockHead()
+	if want := node.Block(node.HeadBlock().Height - 1); height != want.Height || hash != want.ID {
+		t.Fatalf("local head = %d %s, want %d %s", height, hash, want.Height, want.ID)
+	}
+
+	node.Fork(2)
+
+	// the scanner steps back from the fork and follows the new branch
+	bs.ScanBlockTask()
+	height, hash, _ = bs.GetLocalBlockHead()
+	if want := node.Block(node.HeadBlock().Height - 1); height != want.Height || hash != want.ID {
+		t.Errorf("local head after fork = %d %s, want %d %s", height, hash, want.Height, want.ID)
+	}
+}
+
+func TestBtsBlockScanner_FakeNodeStop(t *testing.T) {
+	node := bitsharestest.NewNode()
+	defer node.Close()
+	node.MintBlocks(3)
+
+	bs, _ := testFakeNodeScanner(node)
+	if err := bs.Run(); err != nil {
+		t.Fatalf("Run failed unexpected error: %v", err)
+	}
+	ctx := bs.scanContext()
+	if ctx.Err() != nil {
+		t.Fatalf("scan context of a running scanner: %v", ctx.Err())
+	}
+
+	if err := bs.Stop(); err != nil {
+		t.Fatalf("Stop failed unexpected error: %v", err)
+	}
+	// the requests of the stopped scan are cancelled
+	if err := bs.scanContext().Err(); err != context.Canceled {
+		t.Errorf("scan context error after Stop = %v, want %v", err, context.Canceled)
+	}
+
+	// nothing handles the new block signals anymore
+	time.Sleep(50 * time.Millisecond)
+	bs.scanSignal <- struct{}{}
+	time.Sleep(50 * time.Millisecond)
+	if len(bs.scanSignal) != 1 {
+		t.Errorf("new block signal handled after Stop")
+	}
+
+	// a new scan context handles them again
+	bs.startContext()
+	defer bs.cancelContext()
+	for i := 0; len(bs.scanSignal) != 0; i++ {
+		if i == 100 {
+			t.Fatalf("new block signal not handled in a new scan context")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -16,7 +16,12 @@
 package bitshares
 
 import (
+	"fmt"
+	"os"
+	"time"
+
 	"github.com/astaxie/beego/config"
+	"github.com/blocktree/bitshares-adapter/types"
 	"github.com/blocktree/openwallet/v2/log"
 	"github.com/blocktree/openwallet/v2/openwallet"
 )
@@ -75,7 +80,60 @@ func (wm *WalletManager) LoadAssetsConfig(c config.Configer) error {
 	wm.Config.ServerWS = c.String("serverWS")
 	wm.Config.WalletAPI = c.String("walletAPI")
 	wm.Config.MemoPrivateKey = c.String("memoPrivateKey")
+	wm.Config.ChainID = c.DefaultString("chainID", types.ChainIDBTS)
+	wm.Config.MaxHeadLag = uint64(c.DefaultInt64("maxHeadLag", int64(defaultMaxHeadLag)))
+	wm.Config.NodeCheckInterval = time.Duration(c.DefaultInt64("nodeCheckInterval", 10)) * time.Second
+	wm.Config.ReadTimeout = time.Duration(c.DefaultInt64("readTimeout", int64(DefaultCallTimeouts.Read/time.Second))) * time.Second
+	wm.Config.FeeTimeout = time.Duration(c.DefaultInt64("feeTimeout", int64(DefaultCallTimeouts.Fee/time.Second))) * time.Second
+	wm.Config.BroadcastTimeout = time.Duration(c.DefaultInt64("broadcastTimeout", int64(DefaultCallTimeouts.Broadcast/time.Second))) * time.Second
+	wm.Config.RetryPolicy = RetryPolicy{
+		MaxAttempts:    c.DefaultInt("retryMaxAttempts", DefaultRetryPolicy.MaxAttempts),
+		InitialBackoff: time.Duration(c.DefaultInt64("retryInitialBackoff", int64(DefaultRetryPolicy.InitialBackoff/time.Millisecond))) * time.Millisecond,
+		MaxBackoff:     time.Duration(c.DefaultInt64("retryMaxBackoff", int64(DefaultRetryPolicy.MaxBackoff/time.Millisecond))) * time.Millisecond,
+		Multiplier:     c.DefaultFloat("retryMultiplier", DefaultRetryPolicy.Multiplier),
+	}
+	if wm.Api != nil {
+		wm.Api.Close()
+	}
 	wm.Api = NewWalletClient(wm.Config.ServerAPI, wm.Config.WalletAPI, false)
+	wm.Api.Pool().MaxHeadLag = wm.Config.MaxHeadLag
+	wm.Api.Timeouts = CallTimeouts{
+		Read:      wm.Config.ReadTimeout,
+		Fee:       wm.Config.FeeTimeout,
+		Broadcast: wm.Config.BroadcastTimeout,
+	}
+	wm.Api.RetryPolicy = wm.Config.RetryPolicy
+	if len(wm.Config.ServerWS) > 0 {
+		//serverWS同时在serverAPI中时复用节点池的连接
+		if ws := wm.Api.Pool().Websocket(wm.Config.ServerWS); ws != nil && ws.IsConnected() {
+			wm.Api.SetWebsocket(ws)
+		} else {
+			ws = NewWSClient(wm.Config.ServerWS, false)
+			if err := ws.Connect(); err != nil {
+				wm.Log.Errorf("connect websocket api %s failed, use http api only, err: %v", wm.Config.ServerWS, err)
+			} else {
+				wm.Api.SetWebsocket(ws)
+			}
+		}
+	}
+	if wm.Api.Pool().Len() > 1 {
+		wm.Api.StartHealthCheck(wm.Config.NodeCheckInterval)
+	}
+	wm.Config.AccountCacheSize = c.DefaultInt("accountCacheSize", defaultAccountCacheSize)
+	wm.Config.AccountCacheTTL = time.Duration(c.DefaultInt64("accountCacheTTL", int64(defaultAccountCacheTTL/time.Second))) * time.Second
+	wm.Accounts = NewAccountResolver(wm.Api, wm.Config.AccountCacheSize, wm.Config.AccountCacheTTL)
+	wm.Assets = NewAssetRegistry(wm.Api)
+	wm.Fees = NewFeeCache(wm.Api)
+	wm.Config.VerifyBlock = c.DefaultBool("verifyBlock", false)
+	wm.Verifier = nil
+	if wm.Config.VerifyBlock {
+		wm.Verifier = NewBlockVerifier(wm.Api)
+	}
+	wm.Config.CassetteMode = c.String("cassetteMode")
+	wm.Config.CassetteFile = c.String("cassetteFile")
+	if err := wm.loadCassette(); err != nil {
+		return err
+	}
 	wm.Config.DataDir = c.String("dataDir")
 
 	//数据文件夹
@@ -83,6 +141,34 @@ func (wm *WalletManager) LoadAssetsConfig(c config.Configer) error {
 	return nil
 }
 
+//loadCassette 按配置录制或回放节点请求，环境变量BTS_CASSETTE_MODE、BTS_CASSETTE_FILE优先
+func (wm *WalletManager) loadCassette() error {
+	if mode := os.Getenv("BTS_CASSETTE_MODE"); len(mode) > 0 {
+		wm.Config.CassetteMode = mode
+	}
+	if file := os.Getenv("BTS_CASSETTE_FILE"); len(file) > 0 {
+		wm.Config.CassetteFile = file
+	}
+
+	mode, err := ParseCassetteMode(wm.Config.CassetteMode)
+	if err != nil {
+		return err
+	}
+	if mode == CassetteOff {
+		return nil
+	}
+	if len(wm.Config.CassetteFile) == 0 {
+		return fmt.Errorf("cassetteFile is not set")
+	}
+
+	cassette, err := NewCassette(wm.Config.CassetteFile, mode)
+	if err != nil {
+		return err
+	}
+	wm.Api.SetCassette(cassette)
+	return nil
+}
+
 //InitAssetsConfig 初始化默认配置
 func (wm *WalletManager) InitAssetsConfig() (config.Configer, error) {
 	return config.NewConfigData("ini", []byte(wm.Config.DefaultConfig))
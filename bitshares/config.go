@@ -18,7 +18,9 @@ package bitshares
 import (
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/blocktree/bitshares-adapter/types"
 	"github.com/blocktree/go-owcrypt"
 	"github.com/blocktree/openwallet/v2/common/file"
 )
@@ -29,8 +31,35 @@ const (
 	//默认配置内容
 	defaultConfig = `
 
-# RPC api url
+# RPC api url of witness_node, separate multiple nodes with commas, e.g. "https://node1/rpc,wss://node2/ws"
+# no cli_wallet is needed, blocks are fetched from the database api and transactions broadcast by the network_broadcast api
 serverAPI = ""
+# a node whose head block falls behind its peers by more than maxHeadLag blocks is skipped for a while
+maxHeadLag = 10
+# interval in seconds of the node health check, only used with multiple nodes
+nodeCheckInterval = 10
+# websocket api url, new blocks are pushed to the scanner when it is set
+serverWS = ""
+# timeouts in seconds of one attempt of a call: chain queries, fee queries and broadcasts
+readTimeout = 30
+feeTimeout = 15
+broadcastTimeout = 60
+# retries of the chain and fee queries failing on the network, broadcasts are never retried:
+# total attempts, milliseconds before the first retry, the most milliseconds between two retries and the growth of the wait
+retryMaxAttempts = 3
+retryInitialBackoff = 500
+retryMaxBackoff = 5000
+retryMultiplier = 2
+# size of the account cache and seconds an account is cached, an account_update drops it earlier
+accountCacheSize = 10000
+accountCacheTTL = 600
+# verify every scanned block: its id, its transactions against the merkle root and the witness signature
+verifyBlock = false
+# record the node calls to cassetteFile, or replay them from it without network: off, record or replay
+cassetteMode = "off"
+cassetteFile = ""
+# chain id signed by transactions, the bitshares mainnet when empty
+chainID = ""
 
 `
 )
@@ -52,10 +81,29 @@ type WalletConfig struct {
 	//BlockchainFile string
 	//本地数据库文件路径
 	dbPath string
-	//钱包服务API
+	//钱包服务API，多个节点用逗号分隔
 	ServerAPI string
 	ServerWS  string
+	//cli_wallet API，可选，节点API已足够
 	WalletAPI string
+	//节点落后最高区块的最大数量
+	MaxHeadLag uint64
+	//节点健康检查间隔
+	NodeCheckInterval time.Duration
+	//单次请求超时：查询、手续费查询、广播
+	ReadTimeout      time.Duration
+	FeeTimeout       time.Duration
+	BroadcastTimeout time.Duration
+	//网络失败的查询重试：总次数、首次重试等待、最长等待、等待增长倍数，广播不重试
+	RetryPolicy RetryPolicy
+	//账户缓存数量及有效期，账户更新时提前失效
+	AccountCacheSize int
+	AccountCacheTTL  time.Duration
+	//校验扫描的区块：区块ID、交易默克尔根、见证人签名
+	VerifyBlock bool
+	//节点请求录制回放：off、record、replay，及录制文件
+	CassetteMode string
+	CassetteFile string
 	//默认配置内容
 	DefaultConfig string
 	//曲线类型
@@ -63,6 +111,8 @@ type WalletConfig struct {
 	//数据目录
 	DataDir        string
 	MemoPrivateKey string
+	//链ID，交易签名摘要使用
+	ChainID string
 }
 
 func NewConfig(symbol string) *WalletConfig {
@@ -89,7 +139,16 @@ func NewConfig(symbol string) *WalletConfig {
 	c.ServerAPI = ""
 	c.ServerWS = ""
 	c.WalletAPI = ""
+	c.MaxHeadLag = defaultMaxHeadLag
+	c.NodeCheckInterval = 10 * time.Second
+	c.ReadTimeout = DefaultCallTimeouts.Read
+	c.FeeTimeout = DefaultCallTimeouts.Fee
+	c.BroadcastTimeout = DefaultCallTimeouts.Broadcast
+	c.RetryPolicy = DefaultRetryPolicy
+	c.AccountCacheSize = defaultAccountCacheSize
+	c.AccountCacheTTL = defaultAccountCacheTTL
 	c.MemoPrivateKey = ""
+	c.ChainID = types.ChainIDBTS
 
 	//创建目录
 	//file.MkdirAll(c.dbPath)
@@ -16,9 +16,9 @@
 package bitshares
 
 import (
-	"github.com/blocktree/bitshares-adapter/types"
+	"fmt"
+
 	"github.com/blocktree/openwallet/v2/openwallet"
-	"github.com/shopspring/decimal"
 )
 
 type ContractDecoder struct {
@@ -33,51 +33,107 @@ func NewContractDecoder(wm *WalletManager) *ContractDecoder {
 	return &decoder
 }
 
-// GetTokenBalanceByAddress return the balance by account alias, queried by rpc
+// GetTokenBalanceByAddress return the balance by account alias, queried by rpc. ConfirmBalance is the
+// available balance, UnconfirmBalance the balance locked in orders, collateral and vesting balances.
 func (decoder *ContractDecoder) GetTokenBalanceByAddress(contract openwallet.SmartContract, address ...string) ([]*openwallet.TokenBalance, error) {
 
-	var (
-		asset = types.MustParseObjectID(contract.Address)
-	)
 	tokenBalanceList := make([]*openwallet.TokenBalance, 0)
 
-	for _, addr := range address {
+	if len(address) == 0 {
+		return tokenBalanceList, nil
+	}
 
-		account, err := decoder.wm.Api.GetAccountID(addr)
-		if err != nil {
-			decoder.wm.Log.Errorf("get account[%v] id failed, err: %v", addr, err)
-		}
+	//合约地址可以是资产ID或资产符号
+	assets, err := decoder.wm.Assets.ResolveBatch(decoder.wm.Context(), contract.Address)
+	if err != nil {
+		decoder.wm.Log.Errorf("get asset %s failed, err: %v", contract.Address, err)
+		return nil, err
+	}
+	asset := assets[contract.Address]
+	if asset == nil {
+		return nil, openwallet.Errorf(openwallet.ErrContractNotFound, "asset [%s] does not exist", contract.Address)
+	}
 
-		if account == nil {
-			return nil, err
-		}
+	//一次批量请求查询所有账户余额，包括挂单、抵押及冻结部分
+	balances, err := decoder.wm.Api.GetFullBalancesContext(decoder.wm.Context(), address...)
+	if err != nil {
+		decoder.wm.Log.Errorf("get accounts %v token balance failed, err: %v", address, err)
+		return nil, ConvertRPCError(err, openwallet.ErrCallFullNodeAPIFailed, "")
+	}
 
-		balance, err := decoder.wm.Api.GetAssetsBalance(*account, asset)
-		if err != nil {
-			decoder.wm.Log.Errorf("get account[%v] token balance failed, err: %v", addr, err)
+	for _, addr := range address {
+		balance := &FullBalance{AssetID: asset.ID}
+		for _, b := range balances[addr] {
+			if b.AssetID == asset.ID {
+				balance = b
+			}
 		}
+		tokenBalanceList = append(tokenBalanceList, newTokenBalance(&contract, addr, asset, balance))
+	}
 
-		if balance == nil {
-			return nil, err
-		}
+	return tokenBalanceList, nil
+
+}
+
+// GetAllTokenBalanceByAddress returns every asset balance of the accounts, by alias, keyed by
+// alias. The balances are queried in a single batch and shifted by the precision of their asset.
+func (decoder *ContractDecoder) GetAllTokenBalanceByAddress(address ...string) (map[string][]*openwallet.TokenBalance, error) {
+
+	tokenBalances := make(map[string][]*openwallet.TokenBalance, len(address))
+	if len(address) == 0 {
+		return tokenBalances, nil
+	}
 
-		balanceDec, _ := decimal.NewFromString(balance.Amount)
-		balanceDec = balanceDec.Shift(0 - int32(contract.Decimals))
-
-		tokenBalance := &openwallet.TokenBalance{
-			Contract: &contract,
-			Balance: &openwallet.Balance{
-				Address:          addr,
-				Symbol:           contract.Symbol,
-				Balance:          balanceDec.String(),
-				ConfirmBalance:   balanceDec.String(),
-				UnconfirmBalance: "0",
-			},
+	//一次批量请求查询所有账户的全部资产余额，包括挂单、抵押及冻结部分
+	balances, err := decoder.wm.Api.GetFullBalancesContext(decoder.wm.Context(), address...)
+	if err != nil {
+		decoder.wm.Log.Errorf("get accounts %v balances failed, err: %v", address, err)
+		return nil, ConvertRPCError(err, openwallet.ErrCallFullNodeAPIFailed, "")
+	}
+
+	//余额涉及的资产一次查询，已缓存的资产不再请求
+	ids := make([]string, 0)
+	for _, list := range balances {
+		for _, balance := range list {
+			ids = append(ids, balance.AssetID.String())
 		}
+	}
+	assets, err := decoder.wm.Assets.ResolveBatch(decoder.wm.Context(), ids...)
+	if err != nil {
+		decoder.wm.Log.Errorf("get assets %v failed, err: %v", ids, err)
+		return nil, err
+	}
 
-		tokenBalanceList = append(tokenBalanceList, tokenBalance)
+	for _, addr := range address {
+		tokenBalanceList := make([]*openwallet.TokenBalance, 0, len(balances[addr]))
+		for _, balance := range balances[addr] {
+			asset := assets[balance.AssetID.String()]
+			if asset == nil {
+				return nil, fmt.Errorf("asset %s does not exist", balance.AssetID.String())
+			}
+			if balance.Total() == 0 {
+				continue
+			}
+
+			contract := asset.Contract(decoder.wm.Symbol())
+			tokenBalanceList = append(tokenBalanceList, newTokenBalance(&contract, addr, asset, balance))
+		}
+		tokenBalances[addr] = tokenBalanceList
 	}
 
-	return tokenBalanceList, nil
+	return tokenBalances, nil
+}
 
+//newTokenBalance 可用余额为确认余额，挂单、抵押及冻结部分为未确认余额
+func newTokenBalance(contract *openwallet.SmartContract, address string, asset *Asset, balance *FullBalance) *openwallet.TokenBalance {
+	return &openwallet.TokenBalance{
+		Contract: contract,
+		Balance: &openwallet.Balance{
+			Address:          address,
+			Symbol:           contract.Symbol,
+			Balance:          asset.Amount(balance.Total()),
+			ConfirmBalance:   asset.Amount(balance.Available),
+			UnconfirmBalance: asset.Amount(balance.Locked()),
+		},
+	}
 }
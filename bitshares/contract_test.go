@@ -0,0 +1,186 @@
+package bitshares
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/blocktree/bitshares-adapter/bitsharestest"
+	"github.com/blocktree/openwallet/v2/openwallet"
+)
+
+// testBalanceNode returns a fake node where alice holds core and USD, and bob holds nothing
+func testBalanceNode() (*bitsharestest.Node, string) {
+	node := bitsharestest.NewNode()
+	node.CreateAccount("alice", "")
+	node.CreateAccount("bob", "")
+	usdID := node.CreateAsset("USD", 4, 0)
+	node.SetBalance("alice", bitsharestest.CoreAssetID, 123456789)
+	node.SetBalance("alice", usdID, 50000)
+	return node, usdID
+}
+
+func TestWalletClient_GetAccountsBalancesBatch(t *testing.T) {
+	node, usdID := testBalanceNode()
+	defer node.Close()
+
+	c := NewWalletClient(node.URL, "", false)
+	balances, err := c.GetAccountsBalancesBatch("alice", "bob")
+	if err != nil {
+		t.Fatalf("GetAccountsBalancesBatch failed unexpected error: %v", err)
+	}
+	if len(balances) != 2 || len(balances[0]) != 2 || len(balances[1]) != 0 {
+		t.Fatalf("balances = %v", balances)
+	}
+	if balances[0][0].Amount != "123456789" || balances[0][1].AssetID.String() != usdID || balances[0][1].Amount != "50000" {
+		t.Errorf("alice balances = %+v %+v", balances[0][0], balances[0][1])
+	}
+
+	if _, err := c.GetAccountsBalancesBatch("alice", "nobody"); err == nil {
+		t.Errorf("balances of unknown account should fail")
+	}
+}
+
+func TestBtsBlockScanner_GetBalanceByAddress(t *testing.T) {
+	node, _ := testBalanceNode()
+	defer node.Close()
+
+	bs, _ := testFakeNodeScanner(node)
+	balances, err := bs.GetBalanceByAddress("alice", "bob")
+	if err != nil {
+		t.Fatalf("GetBalanceByAddress failed unexpected error: %v", err)
+	}
+	if len(balances) != 2 {
+		t.Fatalf("got %d balances, want 2", len(balances))
+	}
+	if b := balances[0]; b.Address != "alice" || b.Balance != "1234.56789" || b.ConfirmBalance != "1234.56789" || b.Symbol != bs.wm.Symbol() {
+		t.Errorf("alice balance = %+v", b)
+	}
+	if b := balances[1]; b.Address != "bob" || b.Balance != "0" {
+		t.Errorf("bob balance = %+v", b)
+	}
+}
+
+func TestContractDecoder_GetAllTokenBalanceByAddress(t *testing.T) {
+	node, usdID := testBalanceNode()
+	defer node.Close()
+
+	bs, _ := testFakeNodeScanner(node)
+	decoder := NewContractDecoder(bs.wm)
+	balances, err := decoder.GetAllTokenBalanceByAddress("alice", "bob")
+	if err != nil {
+		t.Fatalf("GetAllTokenBalanceByAddress failed unexpected error: %v", err)
+	}
+
+	alice := balances["alice"]
+	if len(alice) != 2 || len(balances["bob"]) != 0 {
+		t.Fatalf("balances = %v", balances)
+	}
+	if b := alice[0]; b.Contract.Token != bitsharestest.CoreAssetSymbol || b.Balance.Balance != "1234.56789" {
+		t.Errorf("alice core balance = %+v %+v", b.Contract, b.Balance)
+	}
+	if b := alice[1]; b.Contract.Address != usdID || b.Contract.Token != "USD" || b.Contract.Decimals != 4 || b.Balance.Balance != "5" {
+		t.Errorf("alice USD balance = %+v %+v", b.Contract, b.Balance)
+	}
+	if calls := node.Calls("get_full_accounts"); calls != 1 {
+		t.Errorf("get_full_accounts called %d times, want 1", calls)
+	}
+}
+
+func TestContractDecoder_GetAllTokenBalanceByAddressBadAmount(t *testing.T) {
+	// get_full_accounts of alice with a balance that is not an amount
+	full := `[["alice",{"balances":[{"id":"2.5.1","asset_type":"1.3.0","balance":"12a"}]}]]`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if strings.HasPrefix(string(body), "[") {
+			var reqs []map[string]interface{}
+			json.Unmarshal(body, &reqs)
+			fmt.Fprintf(w, `[{"id":%v,"jsonrpc":"2.0","result":%s}]`, reqs[0]["id"], full)
+			return
+		}
+		var req map[string]interface{}
+		json.Unmarshal(body, &req)
+		fmt.Fprintf(w, `{"id":%v,"jsonrpc":"2.0","result":%s}`, req["id"], full)
+	}))
+	defer server.Close()
+
+	wm := NewWalletManager(nil)
+	wm.Api = NewWalletClient(server.URL, "", false)
+	wm.Assets = NewAssetRegistry(wm.Api)
+	balances, err := NewContractDecoder(wm).GetAllTokenBalanceByAddress("alice")
+	if err == nil || !strings.Contains(err.Error(), `invalid amount "\"12a\""`) {
+		t.Errorf("GetAllTokenBalanceByAddress = %v, %v, want an invalid amount error", balances, err)
+	}
+}
+
+func TestWalletClient_GetFullBalances(t *testing.T) {
+	node, usdID := testBalanceNode()
+	defer node.Close()
+	node.AddLimitOrder("alice", usdID, 20000)
+	node.AddLimitOrder("alice", usdID, 5000)
+	node.AddCallOrder("alice", bitsharestest.CoreAssetID, 300000, usdID, 1000)
+	node.AddVestingBalance("alice", bitsharestest.CoreAssetID, 7000)
+	node.AddVestingBalance("bob", bitsharestest.CoreAssetID, 100)
+
+	c := NewWalletClient(node.URL, "", false)
+	balances, err := c.GetFullBalances("alice", "bob")
+	if err != nil {
+		t.Fatalf("GetFullBalances failed unexpected error: %v", err)
+	}
+
+	alice := balances["alice"]
+	if len(alice) != 2 {
+		t.Fatalf("alice balances = %v", alice)
+	}
+	if b := alice[0]; b.AssetID.String() != bitsharestest.CoreAssetID || b.Available != 123456789 || b.InOrders != 0 || b.Collateral != 300000 || b.Vesting != 7000 {
+		t.Errorf("alice core balance = %+v", b)
+	}
+	if b := alice[1]; b.AssetID.String() != usdID || b.Available != 50000 || b.InOrders != 25000 || b.Collateral != 0 || b.Total() != 75000 {
+		t.Errorf("alice USD balance = %+v", b)
+	}
+	if bob := balances["bob"]; len(bob) != 1 || bob[0].Available != 0 || bob[0].Vesting != 100 {
+		t.Errorf("bob balances = %v", bob)
+	}
+
+	vesting, err := c.GetVestingBalances("alice")
+	if err != nil || len(vesting) != 1 || vesting[0].Balance.Amount != 7000 {
+		t.Errorf("GetVestingBalances = %v, %v", vesting, err)
+	}
+
+	_, err = c.GetFullBalances("alice", "nobody")
+	if ErrorKind(err) != RPCErrorAccountNotFound {
+		t.Errorf("balances of unknown account failed with %v, want account not found", err)
+	}
+}
+
+func TestContractDecoder_GetTokenBalanceByAddress(t *testing.T) {
+	node, usdID := testBalanceNode()
+	defer node.Close()
+	node.AddLimitOrder("alice", usdID, 25000)
+
+	bs, _ := testFakeNodeScanner(node)
+	decoder := NewContractDecoder(bs.wm)
+
+	// by symbol
+	balances, err := decoder.GetTokenBalanceByAddress(openwallet.SmartContract{Address: "USD"}, "alice", "bob")
+	if err != nil {
+		t.Fatalf("GetTokenBalanceByAddress failed unexpected error: %v", err)
+	}
+	if len(balances) != 2 {
+		t.Fatalf("got %d balances, want 2", len(balances))
+	}
+	if b := balances[0].Balance; b.Balance != "7.5" || b.ConfirmBalance != "5" || b.UnconfirmBalance != "2.5" {
+		t.Errorf("alice balance = %+v", b)
+	}
+	if b := balances[1].Balance; b.Balance != "0" || b.ConfirmBalance != "0" {
+		t.Errorf("bob balance = %+v", b)
+	}
+
+	if _, err := decoder.GetTokenBalanceByAddress(openwallet.SmartContract{Address: "NOPE"}, "alice"); err == nil {
+		t.Errorf("balance of unknown asset should fail")
+	}
+}
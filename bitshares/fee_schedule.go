@@ -0,0 +1,227 @@
+/*
+ * Copyright 2018 The OpenWallet Authors
+ * This file is part of the OpenWallet library.
+ *
+ * The OpenWallet library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The OpenWallet library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ */
+
+package bitshares
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/blocktree/bitshares-adapter/types"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// feeScaleBase is the fee scale of 100%, GRAPHENE_100_PERCENT
+	feeScaleBase = 10000
+	// maxShareSupply is the max amount of any asset, GRAPHENE_MAX_SHARE_SUPPLY
+	maxShareSupply = 1000000000000000
+)
+
+// FeeParameters are the fee parameters of an operation type. Only the flat fee
+// and the price per kilobyte of data are used by the operations calculated locally.
+type FeeParameters struct {
+	Fee           types.Suint64 `json:"fee"`
+	PricePerKbyte types.Suint64 `json:"price_per_kbyte"`
+}
+
+// FeeSchedule is the current_fees of the chain parameters
+type FeeSchedule struct {
+	Parameters map[types.OpType]*FeeParameters
+	Scale      uint64
+}
+
+// NewFeeSchedule parses the current_fees of the chain parameters
+func NewFeeSchedule(result gjson.Result) (*FeeSchedule, error) {
+	if !result.IsObject() {
+		return nil, fmt.Errorf("invalid fee schedule: %s", result.Raw)
+	}
+	schedule := &FeeSchedule{
+		Parameters: make(map[types.OpType]*FeeParameters),
+		Scale:      result.Get("scale").Uint(),
+	}
+	for _, item := range result.Get("parameters").Array() {
+		var parameters FeeParameters
+		if err := json.Unmarshal([]byte(item.Get("1").Raw), &parameters); err != nil {
+			return nil, fmt.Errorf("invalid fee parameters %s: %v", item.Raw, err)
+		}
+		schedule.Parameters[types.OpType(item.Get("0").Uint())] = &parameters
+	}
+	return schedule, nil
+}
+
+// CoreFee returns the fee of the operation in the core asset, scaled. Only
+// transfers, orders and proposals are calculated, other operations return an error.
+func (s *FeeSchedule) CoreFee(op types.Operation) (uint64, error) {
+	parameters, ok := s.Parameters[op.Type()]
+	if !ok {
+		return 0, fmt.Errorf("no fee parameters of operation %d", op.Type())
+	}
+
+	fee := new(big.Int).SetUint64(uint64(parameters.Fee))
+	switch op := op.(type) {
+	case *types.TransferOperation:
+		if op.Memo != nil {
+			var b bytes.Buffer
+			if err := encoding.NewEncoder(&b).Encode(op.Memo); err != nil {
+				return 0, err
+			}
+			fee.Add(fee, dataFee(uint64(b.Len()), uint64(parameters.PricePerKbyte)))
+		}
+	case *types.ProposalCreateOperation, *types.ProposalUpdateOperation:
+		// the data fee is on the whole operation, the proposed operations included
+		size, err := operationSize(op)
+		if err != nil {
+			return 0, err
+		}
+		fee.Add(fee, dataFee(size, uint64(parameters.PricePerKbyte)))
+	case *types.LimitOrderCreateOperation, *types.LimitOrderCancelOperation, *types.ProposalDeleteOperation:
+	default:
+		return 0, fmt.Errorf("fee of operation %d is not calculated locally", op.Type())
+	}
+
+	fee.Mul(fee, new(big.Int).SetUint64(s.Scale))
+	fee.Div(fee, big.NewInt(feeScaleBase))
+	if !fee.IsUint64() || fee.Uint64() > maxShareSupply {
+		return 0, fmt.Errorf("fee of operation %d exceeds the max supply", op.Type())
+	}
+	return fee.Uint64(), nil
+}
+
+// operationSize returns the size of the serialized operation without its type,
+// as fc::raw::pack_size counts it
+func operationSize(op types.Operation) (uint64, error) {
+	var b bytes.Buffer
+	if err := encoding.NewEncoder(&b).Encode(op); err != nil {
+		return 0, err
+	}
+	var tag [binary.MaxVarintLen64]byte
+	return uint64(b.Len() - binary.PutUvarint(tag[:], uint64(op.Type()))), nil
+}
+
+// dataFee returns the price of size bytes of data
+func dataFee(size, pricePerKbyte uint64) *big.Int {
+	fee := new(big.Int).SetUint64(size)
+	fee.Mul(fee, new(big.Int).SetUint64(pricePerKbyte))
+	return fee.Div(fee, big.NewInt(1024))
+}
+
+// ConvertFee returns the core fee in the other asset of the core exchange rate,
+// the least amount worth at least the core fee, as the chain requires.
+func ConvertFee(coreFee uint64, rate types.Price) (types.AssetAmount, error) {
+	core := types.MustParseObjectID(CoreAssetID)
+	var from, to types.AssetAmount
+	switch core {
+	case rate.Base.AssetID:
+		from, to = rate.Base, rate.Quote
+	case rate.Quote.AssetID:
+		from, to = rate.Quote, rate.Base
+	default:
+		return types.AssetAmount{}, fmt.Errorf("core asset is not in the price")
+	}
+	if from.Amount == 0 || to.Amount == 0 {
+		return types.AssetAmount{}, fmt.Errorf("invalid core exchange rate of asset %s", to.AssetID.String())
+	}
+
+	// ceil(coreFee * to / from)
+	fee := new(big.Int).SetUint64(coreFee)
+	fee.Mul(fee, new(big.Int).SetUint64(to.Amount))
+	fee.Add(fee, new(big.Int).SetUint64(from.Amount-1))
+	fee.Div(fee, new(big.Int).SetUint64(from.Amount))
+	if !fee.IsUint64() || fee.Uint64() > maxShareSupply {
+		return types.AssetAmount{}, fmt.Errorf("fee in asset %s exceeds the max supply", to.AssetID.String())
+	}
+	return types.AssetAmount{Amount: fee.Uint64(), AssetID: to.AssetID}, nil
+}
+
+// FeeCache keeps the fee schedule of the chain until the next maintenance,
+// the only time the chain parameters change.
+type FeeCache struct {
+	api *WalletClient
+	now func() time.Time
+
+	mutex    sync.Mutex   // protects the following
+	schedule *FeeSchedule // nil until fetched
+	expires  time.Time    // next maintenance time of the schedule
+}
+
+// NewFeeCache returns an empty cache asking the fee schedule to the api
+func NewFeeCache(api *WalletClient) *FeeCache {
+	return &FeeCache{api: api, now: time.Now}
+}
+
+// Schedule returns the fee schedule, fetched again after a maintenance
+func (c *FeeCache) Schedule(ctx context.Context) (*FeeSchedule, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.schedule != nil && c.now().Before(c.expires) {
+		return c.schedule, nil
+	}
+
+	info, err := c.api.GetBlockchainInfoContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := c.api.GetFeeScheduleContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.schedule, c.expires = schedule, info.NextMaintenanceTime
+	return schedule, nil
+}
+
+// RequiredFees returns the fees of the operations paid in the asset. Fees in an
+// asset other than core are converted by its current core exchange rate.
+func (c *FeeCache) RequiredFees(ctx context.Context, ops []types.Operation, assetID string) ([]types.AssetAmount, error) {
+	schedule, err := c.Schedule(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var rate *types.Price
+	if assetID != CoreAssetID {
+		// the core exchange rate changes at any time, it is not cached
+		assets, err := c.api.GetAssetsContext(ctx, assetID)
+		if err != nil {
+			return nil, err
+		}
+		if len(assets) != 1 || assets[0] == nil {
+			return nil, fmt.Errorf("asset %s does not exist", assetID)
+		}
+		rate = &assets[0].Options.CoreExchangeRate
+	}
+
+	fees := make([]types.AssetAmount, len(ops))
+	for i, op := range ops {
+		coreFee, err := schedule.CoreFee(op)
+		if err != nil {
+			return nil, err
+		}
+		if rate == nil {
+			fees[i] = types.AssetAmount{Amount: coreFee, AssetID: types.MustParseObjectID(CoreAssetID)}
+		} else if fees[i], err = ConvertFee(coreFee, *rate); err != nil {
+			return nil, err
+		}
+	}
+	return fees, nil
+}
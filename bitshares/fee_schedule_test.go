@@ -0,0 +1,144 @@
+package bitshares
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blocktree/bitshares-adapter/bitsharestest"
+	"github.com/blocktree/bitshares-adapter/types"
+	"github.com/tidwall/gjson"
+)
+
+func TestFeeSchedule_CoreFee(t *testing.T) {
+	// part of the current_fees of the mainnet
+	schedule, err := NewFeeSchedule(gjson.Parse(`{"parameters":[[0,{"fee":86869,"price_per_kbyte":48260}],[1,{"fee":482}],[2,{"fee":"0"}]],"scale":10000}`))
+	if err != nil {
+		t.Fatalf("NewFeeSchedule failed unexpected error: %v", err)
+	}
+
+	key := types.PublicKey(bitsharestest.WitnessSigningKey("1.6.1"))
+	memo := &types.TransferOperation{Memo: &types.Memo{From: key, To: key, Nonce: 1, Message: make(types.Buffer, 32)}}
+	tests := []struct {
+		name  string
+		op    types.Operation
+		scale uint64
+		want  uint64
+	}{
+		{"transfer", &types.TransferOperation{}, 10000, 86869},
+		// 107 bytes of memo: 107 * 48260 / 1024 = 5042
+		{"transfer with memo", memo, 10000, 86869 + 5042},
+		{"scaled transfer with memo", memo, 20000, 2 * (86869 + 5042)},
+		{"limit order create", &types.LimitOrderCreateOperation{}, 10000, 482},
+		{"limit order cancel", &types.LimitOrderCancelOperation{}, 10000, 0},
+	}
+	for _, test := range tests {
+		schedule.Scale = test.scale
+		fee, err := schedule.CoreFee(test.op)
+		if err != nil || fee != test.want {
+			t.Errorf("%s: fee = %d, %v, want %d", test.name, fee, err, test.want)
+		}
+	}
+
+	if _, err := schedule.CoreFee(&types.AccountUpdateOperation{}); err == nil {
+		t.Errorf("fee of an operation without parameters should fail")
+	}
+}
+
+func TestConvertFee(t *testing.T) {
+	core, usd := types.MustParseObjectID(CoreAssetID), types.MustParseObjectID("1.3.1")
+	rates := []types.Price{
+		{Base: types.AssetAmount{Amount: 7, AssetID: core}, Quote: types.AssetAmount{Amount: 3, AssetID: usd}},
+		{Base: types.AssetAmount{Amount: 3, AssetID: usd}, Quote: types.AssetAmount{Amount: 7, AssetID: core}},
+	}
+	for _, rate := range rates {
+		// 20000 * 3 / 7 = 8571.4, rounded up so that it is worth the core fee
+		fee, err := ConvertFee(20000, rate)
+		if err != nil || fee.Amount != 8572 || fee.AssetID != usd {
+			t.Errorf("ConvertFee(20000, %v) = %v, %v, want 8572 of %s", rate, fee, err, usd.String())
+		}
+	}
+
+	other := types.Price{Base: types.AssetAmount{Amount: 1, AssetID: usd}, Quote: types.AssetAmount{Amount: 1, AssetID: types.MustParseObjectID("1.3.2")}}
+	if _, err := ConvertFee(20000, other); err == nil {
+		t.Errorf("conversion by a price without the core asset should fail")
+	}
+}
+
+func TestFeeCache_RequiredFees(t *testing.T) {
+	node := bitsharestest.NewNode()
+	defer node.Close()
+	usdID := node.CreateAsset("USD", 4, 0)
+	node.SetCoreExchangeRate(usdID, 7, 3)
+
+	ctx := context.Background()
+	c := NewFeeCache(NewWalletClient(node.URL, "", false))
+	c.now = func() time.Time { return node.HeadBlock().Timestamp }
+
+	ops := []types.Operation{&types.TransferOperation{}, &types.LimitOrderCancelOperation{}}
+	node.SetFee(types.LimitOrderCancelOpType, 0)
+	fees, err := c.RequiredFees(ctx, ops, CoreAssetID)
+	if err != nil {
+		t.Fatalf("RequiredFees failed unexpected error: %v", err)
+	}
+	if len(fees) != 2 || fees[0].Amount != bitsharestest.DefaultTransferFee || fees[0].AssetID.String() != CoreAssetID || fees[1].Amount != 0 {
+		t.Errorf("fees = %v", fees)
+	}
+
+	// in USD by its core exchange rate
+	fees, err = c.RequiredFees(ctx, ops[:1], usdID)
+	if err != nil || fees[0].Amount != 8572 || fees[0].AssetID.String() != usdID {
+		t.Errorf("fees in USD = %v, %v", fees, err)
+	}
+
+	// the schedule is kept until the next maintenance
+	node.SetFee(types.TransferOpType, 30000)
+	fees, _ = c.RequiredFees(ctx, ops[:1], CoreAssetID)
+	if fees[0].Amount != bitsharestest.DefaultTransferFee {
+		t.Errorf("fee %d changed before the maintenance", fees[0].Amount)
+	}
+	if calls := node.Calls("get_global_properties"); calls != 1 {
+		t.Errorf("get_global_properties called %d times, want 1", calls)
+	}
+
+	node.MintBlocks(int(bitsharestest.MaintenanceInterval / bitsharestest.BlockInterval))
+	fees, _ = c.RequiredFees(ctx, ops[:1], CoreAssetID)
+	if fees[0].Amount != 30000 {
+		t.Errorf("fee %d after the maintenance, want 30000", fees[0].Amount)
+	}
+	if calls := node.Calls("get_global_properties"); calls != 2 {
+		t.Errorf("get_global_properties called %d times, want 2", calls)
+	}
+}
+
+func TestTransactionDecoder_GetRawTransactionFeeRate(t *testing.T) {
+	node := bitsharestest.NewNode()
+	defer node.Close()
+
+	bs, _ := testFakeNodeScanner(node)
+	feeRate, unit, err := bs.wm.TxDecoder.GetRawTransactionFeeRate()
+	if err != nil || feeRate != "0.2" || unit != "TX" {
+		t.Errorf("GetRawTransactionFeeRate = %s %s, %v, want 0.2 TX", feeRate, unit, err)
+	}
+}
+
+func TestTransactionDecoder_RequiredFeesLocally(t *testing.T) {
+	node := bitsharestest.NewNode()
+	defer node.Close()
+	aliceID := node.CreateAccount("alice", "")
+	bobID := node.CreateAccount("bob", "")
+
+	bs, _ := testFakeNodeScanner(node)
+	amount := types.AssetAmount{Amount: 1000, AssetID: types.MustParseObjectID(CoreAssetID)}
+	ops := types.Operations{types.NewTransferOperation(types.MustParseObjectID(aliceID), types.MustParseObjectID(bobID), amount, types.AssetAmount{})}
+	fees, err := bs.wm.TxDecoder.(*TransactionDecoder).requiredFees(ops, CoreAssetID)
+	if err != nil {
+		t.Fatalf("requiredFees failed unexpected error: %v", err)
+	}
+	if len(fees) != 1 || uint64(fees[0].Amount) != bitsharestest.DefaultTransferFee {
+		t.Errorf("fees = %v, want %d", fees, bitsharestest.DefaultTransferFee)
+	}
+	if calls := node.Calls("get_required_fees"); calls != 0 {
+		t.Errorf("get_required_fees called %d times, want the fees calculated locally", calls)
+	}
+}
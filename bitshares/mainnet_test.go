@@ -0,0 +1,314 @@
+package bitshares
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/blocktree/bitshares-adapter/addrdec"
+	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/blocktree/bitshares-adapter/types"
+	"github.com/tidwall/gjson"
+)
+
+// mainnetCassette holds blocks recorded from a mainnet node: the get_block answers,
+// with the block_id, signing_key and transaction_ids the node reports, and the
+// get_transaction_hex of each of their transactions. It is written by
+// TestMainnet_Record and the tests replaying it skip while it is missing.
+const mainnetCassette = "testdata/mainnet.jsonl"
+
+// mainnetScanLimit is how many blocks TestMainnet_Record goes back looking for
+// the blocks covering mainnetFeatures
+const mainnetScanLimit = 200000
+
+// mainnetFeatures are what the recorded transactions must cover
+var mainnetFeatures = []string{
+	"transfer with memo",
+	"limit_order_create",
+	"limit_order_cancel",
+	"account_update",
+	"proposal_create",
+	"proposal_update",
+	"extensions",
+}
+
+// transactionFeatures returns the mainnetFeatures of a transaction as the node answers it
+func transactionFeatures(tx gjson.Result) []string {
+	features := make([]string, 0)
+	notEmpty := func(r gjson.Result) bool {
+		return (r.IsArray() && len(r.Array()) > 0) || (r.IsObject() && len(r.Map()) > 0)
+	}
+	if notEmpty(tx.Get("extensions")) {
+		features = append(features, "extensions")
+	}
+	for _, op := range tx.Get("operations").Array() {
+		switch types.OpType(op.Get("0").Int()) {
+		case types.TransferOpType:
+			if op.Get("1.memo").Exists() {
+				features = append(features, "transfer with memo")
+			}
+		case types.LimitOrderCreateOpType:
+			features = append(features, "limit_order_create")
+		case types.LimitOrderCancelOpType:
+			features = append(features, "limit_order_cancel")
+		case types.AccountUpdateOpType:
+			features = append(features, "account_update")
+		case types.ProposalCreateOpType:
+			features = append(features, "proposal_create")
+		case types.ProposalUpdateOpType:
+			features = append(features, "proposal_update")
+		}
+		if notEmpty(op.Get("1.extensions")) {
+			features = append(features, "extensions")
+		}
+	}
+	return features
+}
+
+// mainnetBlock is a recorded block
+type mainnetBlock struct {
+	Height uint32
+	Block  *Block        // as the adapter reads it
+	Raw    *gjson.Result // as the node answered it
+	Hexes  []string      // get_transaction_hex of the transactions
+}
+
+// loadMainnetBlocks replays the blocks of mainnetCassette
+func loadMainnetBlocks(t *testing.T) (*WalletClient, []*mainnetBlock) {
+	file, err := os.Open(mainnetCassette)
+	if os.IsNotExist(err) {
+		t.Skipf("%s is not recorded, run TestMainnet_Record with BTS_MAINNET_API set to a mainnet node", mainnetCassette)
+	}
+	if err != nil {
+		t.Fatalf("Open failed unexpected error: %v", err)
+	}
+	heights := make([]uint32, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var it Interaction
+		if err := json.Unmarshal(scanner.Bytes(), &it); err != nil || it.Method != "get_block" {
+			continue
+		}
+		var params []uint32
+		if err := json.Unmarshal(it.Params, &params); err == nil && len(params) == 1 {
+			heights = append(heights, params[0])
+		}
+	}
+	file.Close()
+	if len(heights) == 0 {
+		t.Fatalf("%s has no block", mainnetCassette)
+	}
+
+	player, err := NewCassette(mainnetCassette, CassetteReplay)
+	if err != nil {
+		t.Fatalf("NewCassette failed unexpected error: %v", err)
+	}
+	c := NewWalletClient("http://127.0.0.1:0", "", false)
+	c.SetCassette(player)
+
+	blocks := make([]*mainnetBlock, 0, len(heights))
+	for _, height := range heights {
+		block, err := c.GetBlockByHeight(height)
+		if err != nil {
+			t.Fatalf("block %d: GetBlockByHeight failed unexpected error: %v", height, err)
+		}
+		raw, err := c.callContext(context.Background(), "get_block", []interface{}{height})
+		if err != nil {
+			t.Fatalf("block %d: get_block failed unexpected error: %v", height, err)
+		}
+		recorded := &mainnetBlock{Height: height, Block: block, Raw: raw}
+		for i, tx := range raw.Get("transactions").Array() {
+			r, err := c.callContext(context.Background(), "get_transaction_hex", []interface{}{json.RawMessage(tx.Raw)})
+			if err != nil {
+				t.Fatalf("block %d transaction %d: get_transaction_hex failed unexpected error: %v", height, i, err)
+			}
+			recorded.Hexes = append(recorded.Hexes, r.String())
+		}
+		blocks = append(blocks, recorded)
+	}
+	return c, blocks
+}
+
+// TestMainnet_Record records mainnetCassette from the node of BTS_MAINNET_API. The
+// blocks are those of BTS_MAINNET_BLOCKS, comma separated heights, or else the
+// ones found going back from the head block until mainnetFeatures are covered.
+func TestMainnet_Record(t *testing.T) {
+	api := os.Getenv("BTS_MAINNET_API")
+	if len(api) == 0 {
+		t.Skip("BTS_MAINNET_API is not set")
+	}
+	ctx := context.Background()
+	c := NewWalletClient(api, "", false)
+	defer c.Close()
+
+	heights := make([]uint32, 0)
+	for _, s := range strings.Split(os.Getenv("BTS_MAINNET_BLOCKS"), ",") {
+		if s = strings.TrimSpace(s); len(s) == 0 {
+			continue
+		}
+		height, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			t.Fatalf("BTS_MAINNET_BLOCKS: %v", err)
+		}
+		heights = append(heights, uint32(height))
+	}
+
+	if len(heights) == 0 {
+		info, err := c.GetBlockchainInfo()
+		if err != nil {
+			t.Fatalf("GetBlockchainInfo failed unexpected error: %v", err)
+		}
+		missing := make(map[string]bool)
+		for _, feature := range mainnetFeatures {
+			missing[feature] = true
+		}
+		for height := uint32(info.LastIrreversibleBlockNum); height > 0 && len(missing) > 0; height-- {
+			if uint64(height)+mainnetScanLimit < info.LastIrreversibleBlockNum {
+				t.Fatalf("no block of %v in the last %d blocks", missing, mainnetScanLimit)
+			}
+			r, err := c.callContext(ctx, "get_block", []interface{}{height})
+			if err != nil {
+				t.Fatalf("block %d: get_block failed unexpected error: %v", height, err)
+			}
+			found := false
+			for _, tx := range r.Get("transactions").Array() {
+				for _, feature := range transactionFeatures(tx) {
+					found = found || missing[feature]
+					delete(missing, feature)
+				}
+			}
+			if found {
+				heights = append(heights, height)
+			}
+		}
+	}
+
+	if err := os.MkdirAll("testdata", 0755); err != nil {
+		t.Fatalf("MkdirAll failed unexpected error: %v", err)
+	}
+	recorder, err := NewCassette(mainnetCassette, CassetteRecord)
+	if err != nil {
+		t.Fatalf("NewCassette failed unexpected error: %v", err)
+	}
+	defer recorder.Close()
+	c.SetCassette(recorder)
+
+	for _, height := range heights {
+		r, err := c.callContext(ctx, "get_block", []interface{}{height})
+		if err != nil {
+			t.Fatalf("block %d: get_block failed unexpected error: %v", height, err)
+		}
+		if !r.Get("block_id").Exists() || !r.Get("signing_key").Exists() || !r.Get("transaction_ids").Exists() {
+			t.Fatalf("block %d: the node does not report the block_id, signing_key and transaction_ids", height)
+		}
+		for i, tx := range r.Get("transactions").Array() {
+			if _, err := c.callContext(ctx, "get_transaction_hex", []interface{}{json.RawMessage(tx.Raw)}); err != nil {
+				t.Fatalf("block %d transaction %d: get_transaction_hex failed unexpected error: %v", height, i, err)
+			}
+		}
+		t.Logf("recorded block %d", height)
+	}
+}
+
+func TestMainnet_Coverage(t *testing.T) {
+	_, blocks := loadMainnetBlocks(t)
+	covered := make(map[string]bool)
+	for _, block := range blocks {
+		for _, tx := range block.Raw.Get("transactions").Array() {
+			for _, feature := range transactionFeatures(tx) {
+				covered[feature] = true
+			}
+		}
+	}
+	for _, feature := range mainnetFeatures {
+		if !covered[feature] {
+			t.Errorf("no recorded transaction with %s", feature)
+		}
+	}
+}
+
+func TestMainnet_Transactions(t *testing.T) {
+	_, blocks := loadMainnetBlocks(t)
+	for _, block := range blocks {
+		for i, tx := range block.Block.Transactions {
+			// the bytes of the node
+			var b bytes.Buffer
+			if err := tx.MarshalSigned(encoding.NewEncoder(&b)); err != nil {
+				t.Errorf("block %d transaction %d: MarshalSigned failed unexpected error: %v", block.Height, i, err)
+				continue
+			}
+			if got := hex.EncodeToString(b.Bytes()); got != block.Hexes[i] {
+				t.Errorf("block %d transaction %d: MarshalSigned = %s, want %s", block.Height, i, got, block.Hexes[i])
+				continue
+			}
+
+			// which decode to the same transaction
+			raw, _ := hex.DecodeString(block.Hexes[i])
+			decoded := types.Transaction{}
+			decoder := encoding.NewDecoder(bytes.NewReader(raw))
+			if err := decoded.UnmarshalSigned(decoder); err != nil || !decoder.EOF() {
+				t.Errorf("block %d transaction %d: UnmarshalSigned failed unexpected error: %v", block.Height, i, err)
+				continue
+			}
+			b.Reset()
+			if err := decoded.MarshalSigned(encoding.NewEncoder(&b)); err != nil || hex.EncodeToString(b.Bytes()) != block.Hexes[i] {
+				t.Errorf("block %d transaction %d: decoded transaction serializes to %x, %v", block.Height, i, b.Bytes(), err)
+			}
+		}
+	}
+}
+
+func TestMainnet_TransactionIDs(t *testing.T) {
+	_, blocks := loadMainnetBlocks(t)
+	for _, block := range blocks {
+		ids := block.Raw.Get("transaction_ids").Array()
+		if len(ids) != len(block.Block.Transactions) {
+			t.Fatalf("block %d: %d transaction ids for %d transactions", block.Height, len(ids), len(block.Block.Transactions))
+		}
+		for i, tx := range block.Block.Transactions {
+			id, err := tx.ID()
+			if err != nil {
+				t.Errorf("block %d transaction %d: ID failed unexpected error: %v", block.Height, i, err)
+			} else if id != ids[i].String() {
+				t.Errorf("block %d transaction %d: ID = %s, want %s", block.Height, i, id, ids[i].String())
+			}
+		}
+	}
+}
+
+func TestMainnet_Verify(t *testing.T) {
+	c, blocks := loadMainnetBlocks(t)
+	v := NewBlockVerifier(c)
+	for _, block := range blocks {
+		// the signing key of the witness at the height of the block
+		key, err := addrdec.Default.AddressDecode(block.Raw.Get("signing_key").String())
+		if err != nil {
+			t.Fatalf("block %d: signing key: %v", block.Height, err)
+		}
+		v.signingKeys[block.Block.Witness] = key
+
+		if err := v.Verify(context.Background(), block.Block); err != nil {
+			t.Errorf("block %d: Verify failed unexpected error: %v", block.Height, err)
+		}
+		if id := block.Raw.Get("block_id").String(); block.Block.BlockID != id {
+			t.Errorf("block %d: id = %s, want %s", block.Height, block.Block.BlockID, id)
+		}
+
+		// a copy with a transaction changed is rejected
+		if len(block.Block.Transactions) == 0 {
+			continue
+		}
+		tampered := NewBlock(block.Height, block.Raw)
+		tampered.Transactions[0].RefBlockPrefix++
+		if err := v.Verify(context.Background(), tampered); err == nil || !strings.Contains(err.Error(), "merkle root") {
+			t.Errorf("block %d: tampered copy verified, error %v", block.Height, err)
+		}
+	}
+}
@@ -16,10 +16,11 @@
 package bitshares
 
 import (
+	"context"
+
+	"github.com/blocktree/bitshares-adapter/types"
 	"github.com/blocktree/openwallet/v2/log"
 	"github.com/blocktree/openwallet/v2/openwallet"
-	"github.com/denkhaus/bitshares"
-	"github.com/denkhaus/bitshares/config"
 )
 
 type WalletManager struct {
@@ -33,15 +34,24 @@ type WalletManager struct {
 	Log             *log.OWLogger                   //日志工具
 	ContractDecoder openwallet.SmartContractDecoder //智能合约解析器
 	Blockscanner    *BtsBlockScanner                //区块扫描器
+	Verifier        *BlockVerifier                  //区块校验器，未开启校验时为nil
+	Accounts        *AccountResolver                //账户解析缓存
+	Assets          *AssetRegistry                  //资产注册表
+	Fees            *FeeCache                       //手续费表缓存
 	CacheManager    openwallet.ICacheManager        //缓存管理器
-	WebsocketAPI    bitshares.WebsocketAPI          //bitshares WebsocketAPI
+
+	ctx    context.Context    //适配器上下文
+	cancel context.CancelFunc //关闭适配器时取消进行中的请求
 }
 
 func NewWalletManager(cacheManager openwallet.ICacheManager) *WalletManager {
-	config.SetCurrent(config.ChainIDBTS)
 	wm := WalletManager{}
+	wm.ctx, wm.cancel = context.WithCancel(context.Background())
 	wm.Config = NewConfig(Symbol)
 	wm.Api = NewWalletClient(wm.Config.ServerAPI, wm.Config.WalletAPI, false)
+	wm.Accounts = NewAccountResolver(wm.Api, wm.Config.AccountCacheSize, wm.Config.AccountCacheTTL)
+	wm.Assets = NewAssetRegistry(wm.Api)
+	wm.Fees = NewFeeCache(wm.Api)
 	wm.Blockscanner = NewBlockScanner(&wm)
 	wm.Decoder = NewAddressDecoder(&wm)
 	wm.DecoderV2 = NewAddressDecoder(&wm)
@@ -49,11 +59,43 @@ func NewWalletManager(cacheManager openwallet.ICacheManager) *WalletManager {
 	wm.Log = log.NewOWLogger(wm.Symbol())
 	wm.CacheManager = cacheManager
 	wm.ContractDecoder = NewContractDecoder(&wm)
-	wm.WebsocketAPI = NewWebsocketAPI(wm.Config.ServerWS)
 	return &wm
 }
 
-func NewWebsocketAPI(api string) bitshares.WebsocketAPI {
-	config.SetCurrent(config.ChainIDBTS)
-	return bitshares.NewWebsocketAPI(api)
+//Context 适配器上下文，适配器关闭后所有请求立即返回
+func (wm *WalletManager) Context() context.Context {
+	if wm.ctx == nil {
+		return context.Background()
+	}
+	return wm.ctx
+}
+
+//Close 关闭适配器，取消进行中的请求并断开节点连接
+func (wm *WalletManager) Close() {
+	if wm.cancel != nil {
+		wm.cancel()
+	}
+	if wm.Api != nil {
+		wm.Api.Close()
+	}
+}
+
+//GetPendingProposals 获取关注账户待处理的提案：账户提交、需要其批准或已批准的提案，按提案ID去重
+func (wm *WalletManager) GetPendingProposals(accounts ...string) ([]*Proposal, error) {
+	proposals := make([]*Proposal, 0)
+	seen := make(map[types.ObjectID]bool)
+	for _, account := range accounts {
+		list, err := wm.Api.GetProposedTransactionsContext(wm.Context(), account)
+		if err != nil {
+			return nil, ConvertRPCError(err, openwallet.ErrCallFullNodeAPIFailed, "get proposed transactions")
+		}
+		for _, p := range list {
+			if p == nil || seen[p.ID] {
+				continue
+			}
+			seen[p.ID] = true
+			proposals = append(proposals, p)
+		}
+	}
+	return proposals, nil
 }
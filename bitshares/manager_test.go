@@ -27,5 +27,12 @@ func testNewWalletManager() *WalletManager {
 	wm := NewWalletManager(nil)
 	wm.Config.ServerAPI = "http://api.bts.ai/rpc"
 	wm.Api = NewWalletClient(wm.Config.ServerAPI, wm.Config.WalletAPI, false)
+	wm.Accounts = NewAccountResolver(wm.Api, 0, 0)
+	wm.Assets = NewAssetRegistry(wm.Api)
+	wm.Fees = NewFeeCache(wm.Api)
+	// BTS_CASSETTE_MODE=record|replay BTS_CASSETTE_FILE=... runs the tests against a cassette
+	if err := wm.loadCassette(); err != nil {
+		panic(err)
+	}
 	return wm
 }
@@ -17,9 +17,10 @@ package bitshares
 
 import (
 	"bytes"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
+	"math/big"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/pkg/errors"
@@ -27,15 +28,55 @@ import (
 	"github.com/blocktree/bitshares-adapter/encoding"
 	"github.com/blocktree/bitshares-adapter/types"
 	"github.com/blocktree/openwallet/v2/openwallet"
+	"github.com/shopspring/decimal"
 	"github.com/tidwall/gjson"
 )
 
+// Asset flags and issuer permissions
+const (
+	AssetChargeMarketFee     uint16 = 0x01
+	AssetWhiteList           uint16 = 0x02
+	AssetOverrideAuthority   uint16 = 0x04
+	AssetTransferRestricted  uint16 = 0x08
+	AssetDisableForceSettle  uint16 = 0x10
+	AssetGlobalSettle        uint16 = 0x20
+	AssetDisableConfidential uint16 = 0x40
+	AssetWitnessFedAsset     uint16 = 0x80
+	AssetCommitteeFedAsset   uint16 = 0x100
+)
+
 type Asset struct {
 	ID                 types.ObjectID `json:"id"`
 	Symbol             string         `json:"symbol"`
 	Precision          uint8          `json:"precision"`
 	Issuer             string         `json:"issuer"`
 	DynamicAssetDataID string         `json:"dynamic_asset_data_id"`
+	Options            AssetOptions   `json:"options"`
+}
+
+type AssetOptions struct {
+	MaxSupply         types.Suint64 `json:"max_supply"`
+	IssuerPermissions uint16        `json:"issuer_permissions"`
+	Flags             uint16        `json:"flags"`
+	CoreExchangeRate  types.Price   `json:"core_exchange_rate"`
+}
+
+// HasFlag reports whether the flag is set on the asset
+func (a *Asset) HasFlag(flag uint16) bool {
+	return a.Options.Flags&flag != 0
+}
+
+// AssetDynamicData is the changing state of an asset, the object of its dynamic_asset_data_id
+type AssetDynamicData struct {
+	ID              types.ObjectID `json:"id"`
+	CurrentSupply   types.Suint64  `json:"current_supply"`
+	AccumulatedFees types.Suint64  `json:"accumulated_fees"`
+	FeePool         types.Suint64  `json:"fee_pool"` // core asset paying the fees paid in the asset
+}
+
+// Amount returns the amount in satoshis as a decimal string in units of the asset
+func (a *Asset) Amount(amount uint64) string {
+	return decimal.NewFromBigInt(new(big.Int).SetUint64(amount), -int32(a.Precision)).String()
 }
 
 type BlockHeader struct {
@@ -53,6 +94,22 @@ func NewBlockHeader(result *gjson.Result) *BlockHeader {
 	return &obj
 }
 
+// header returns the header in the form it is serialized in
+func (block *BlockHeader) header() (*types.BlockHeader, error) {
+	witness, err := types.ParseObjectID(block.Witness)
+	if err != nil {
+		return nil, errors.Wrap(err, "witness")
+	}
+	return &types.BlockHeader{
+		Previous:              block.Previous,
+		Timestamp:             block.Timestamp,
+		Witness:               witness,
+		TransactionMerkleRoot: block.TransactionMerkleRoot,
+		Extensions:            block.Extensions,
+		WitnessSignature:      block.WitnessSignature,
+	}, nil
+}
+
 func (block *BlockHeader) Serialize() ([]byte, error) {
 	var b bytes.Buffer
 	encoder := encoding.NewEncoder(&b)
@@ -63,46 +120,22 @@ func (block *BlockHeader) Serialize() ([]byte, error) {
 	return b.Bytes(), nil
 }
 
+// CalculateID returns the block id calculated from the header, as the node does
 func (block *BlockHeader) CalculateID() (string, error) {
-	var msgBuffer bytes.Buffer
-
-	// Write the serialized transaction.
-	rawTx, err := block.Serialize()
+	header, err := block.header()
 	if err != nil {
 		return "", err
 	}
-
-	if _, err := msgBuffer.Write(rawTx); err != nil {
-		return "", errors.Wrap(err, "failed to write serialized block header")
-	}
-
-	msgBytes := msgBuffer.Bytes()
-
-	// Compute the digest.
-	digest := sha256.Sum224(msgBytes)
-
-	id := hex.EncodeToString(digest[:])
-	length := 40
-	if len(id) < 40 {
-		length = len(id)
-	}
-	return id[:length], nil
+	return header.ID()
 }
 
 // MarshalBlockHeader implements encoding.Marshaller interface.
 func (block *BlockHeader) Marshal(encoder *encoding.Encoder) error {
-
-	enc := encoding.NewRollingEncoder(encoder)
-
-	enc.Encode(block.TransactionMerkleRoot)
-	enc.Encode(block.Previous)
-	enc.Encode(block.Timestamp)
-	enc.Encode(block.Witness)
-	enc.Encode(block.WitnessSignature)
-
-	// Extensions are not supported yet.
-	enc.EncodeUVarint(0)
-	return enc.Err()
+	header, err := block.header()
+	if err != nil {
+		return err
+	}
+	return header.Marshal(encoder)
 }
 
 type Block struct {
@@ -168,6 +201,7 @@ type BlockchainInfo struct {
 	HeadBlockID              string    `json:"head_block_id"`
 	LastIrreversibleBlockNum uint64    `json:"last_irreversible_block_num"`
 	Timestamp                time.Time `json:"time"`
+	NextMaintenanceTime      time.Time `json:"next_maintenance_time"`
 
 	/*
 		{
@@ -197,6 +231,7 @@ func NewBlockchainInfo(result *gjson.Result) *BlockchainInfo {
 	obj.HeadBlockID = result.Get("head_block_id").String()
 	obj.LastIrreversibleBlockNum = result.Get("last_irreversible_block_num").Uint()
 	obj.Timestamp, _ = time.ParseInLocation(TimeLayout, result.Get("time").String(), time.UTC)
+	obj.NextMaintenanceTime, _ = time.ParseInLocation(TimeLayout, result.Get("next_maintenance_time").String(), time.UTC)
 	return &obj
 }
 
@@ -205,6 +240,7 @@ type Balance struct {
 	Amount  string         `json:"amount"`
 }
 
+// NewBalance returns the first balance of an answer to get_account_balances
 func NewBalance(result *gjson.Result) *Balance {
 	arr := result.Array()
 	for _, item := range arr {
@@ -216,6 +252,150 @@ func NewBalance(result *gjson.Result) *Balance {
 	return nil
 }
 
+// NewBalances returns every balance of an answer to get_account_balances
+func NewBalances(result *gjson.Result) []*Balance {
+	arr := result.Array()
+	balances := make([]*Balance, 0, len(arr))
+	for _, item := range arr {
+		balances = append(balances, &Balance{
+			Amount:  item.Get("amount").String(),
+			AssetID: types.MustParseObjectID(item.Get("asset_id").String()),
+		})
+	}
+	return balances
+}
+
+// FullBalance is the balance of an asset of an account, split by where the funds are
+type FullBalance struct {
+	AssetID    types.ObjectID
+	Available  uint64 // in the account balance, spendable
+	InOrders   uint64 // for sale in open limit orders
+	Collateral uint64 // collateral of call orders
+	Vesting    uint64 // in vesting balances, withdrawable or not
+}
+
+// Locked returns the amount that is not spendable
+func (b *FullBalance) Locked() uint64 {
+	return b.InOrders + b.Collateral + b.Vesting
+}
+
+// Total returns the amount owned by the account
+func (b *FullBalance) Total() uint64 {
+	return b.Available + b.Locked()
+}
+
+// VestingBalance is a vesting balance object
+type VestingBalance struct {
+	ID      types.ObjectID    `json:"id"`
+	Owner   types.ObjectID    `json:"owner"`
+	Balance types.AssetAmount `json:"balance"`
+	Policy  json.RawMessage   `json:"policy"`
+}
+
+// NewFullBalances returns the balances of an account answered by get_full_accounts, sorted by asset id.
+// An amount that is not an unsigned integer fails the call.
+func NewFullBalances(result *gjson.Result) ([]*FullBalance, error) {
+	byAsset := make(map[types.ObjectID]*FullBalance)
+	balance := func(assetID string) *FullBalance {
+		id := types.MustParseObjectID(assetID)
+		b, ok := byAsset[id]
+		if !ok {
+			b = &FullBalance{AssetID: id}
+			byAsset[id] = b
+		}
+		return b
+	}
+
+	add := func(items []gjson.Result, assetPath, amountPath string, field func(b *FullBalance) *uint64) error {
+		for _, item := range items {
+			amount, err := parseAmount(item.Get(amountPath))
+			if err != nil {
+				return errors.Wrapf(err, "%s of %s", amountPath, item.Get("id").String())
+			}
+			*field(balance(item.Get(assetPath).String())) += amount
+		}
+		return nil
+	}
+	if err := add(result.Get("balances").Array(), "asset_type", "balance", func(b *FullBalance) *uint64 { return &b.Available }); err != nil {
+		return nil, err
+	}
+	if err := add(result.Get("limit_orders").Array(), "sell_price.base.asset_id", "for_sale", func(b *FullBalance) *uint64 { return &b.InOrders }); err != nil {
+		return nil, err
+	}
+	if err := add(result.Get("call_orders").Array(), "call_price.base.asset_id", "collateral", func(b *FullBalance) *uint64 { return &b.Collateral }); err != nil {
+		return nil, err
+	}
+	if err := add(result.Get("vesting_balances").Array(), "balance.asset_id", "balance.amount", func(b *FullBalance) *uint64 { return &b.Vesting }); err != nil {
+		return nil, err
+	}
+
+	balances := make([]*FullBalance, 0, len(byAsset))
+	for _, b := range byAsset {
+		balances = append(balances, b)
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		return balances[i].AssetID.ID < balances[j].AssetID.ID
+	})
+	return balances, nil
+}
+
+// parseAmount reads a share amount, which the node answers as a number or, when large, a string
+func parseAmount(r gjson.Result) (uint64, error) {
+	raw := r.Raw
+	if r.Type == gjson.String {
+		raw = r.Str
+	} else if r.Type != gjson.Number {
+		return 0, errors.Errorf("invalid amount %q", r.Raw)
+	}
+	amount, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, errors.Errorf("invalid amount %q", r.Raw)
+	}
+	return amount, nil
+}
+
+// Proposal is a proposed transaction waiting for the approvals of the authorities
+// it requires. It is executed once approved, or at its expiration when it has
+// a review period, and removed from the chain then.
+type Proposal struct {
+	ID                       types.ObjectID    `json:"id"`
+	ExpirationTime           types.Time        `json:"expiration_time"`
+	ReviewPeriodTime         *types.Time       `json:"review_period_time,omitempty"`
+	ProposedTransaction      types.Transaction `json:"proposed_transaction"`
+	RequiredActiveApprovals  []types.ObjectID  `json:"required_active_approvals"`
+	AvailableActiveApprovals []types.ObjectID  `json:"available_active_approvals"`
+	RequiredOwnerApprovals   []types.ObjectID  `json:"required_owner_approvals"`
+	AvailableOwnerApprovals  []types.ObjectID  `json:"available_owner_approvals"`
+	AvailableKeyApprovals    []types.PublicKey `json:"available_key_approvals"`
+	Proposer                 types.ObjectID    `json:"proposer"`
+	FailReason               string            `json:"fail_reason"`
+}
+
+// Requires reports whether the active or the owner authority of the account is
+// required by the proposal
+func (p *Proposal) Requires(account types.ObjectID) bool {
+	return containsID(p.RequiredActiveApprovals, account) || containsID(p.RequiredOwnerApprovals, account)
+}
+
+// Approved reports whether the account has added its active approval
+func (p *Proposal) Approved(account types.ObjectID) bool {
+	return containsID(p.AvailableActiveApprovals, account)
+}
+
+func containsID(ids []types.ObjectID, id types.ObjectID) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// BroadcastResponse is the answer to a broadcast. BlockNum, TrxNum and Expired
+// are only known when the broadcast waited for the transaction to be included.
 type BroadcastResponse struct {
-	ID string `json:"id"`
+	ID       string `json:"id"`
+	BlockNum uint32 `json:"block_num"`
+	TrxNum   uint32 `json:"trx_num"`
+	Expired  bool   `json:"expired"`
 }
@@ -0,0 +1,74 @@
+package bitshares
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestBlockHeader_Vectors(t *testing.T) {
+	raw, err := ioutil.ReadFile("../types/testdata/vectors.json")
+	if err != nil {
+		t.Fatalf("ReadFile failed unexpected error: %v", err)
+	}
+	var corpus struct {
+		BlockHeaders []struct {
+			Name   string          `json:"name"`
+			Header json.RawMessage `json:"header"`
+			Hex    string          `json:"hex"`
+			ID     string          `json:"id"`
+		} `json:"block_headers"`
+	}
+	if err := json.Unmarshal(raw, &corpus); err != nil {
+		t.Fatalf("Unmarshal failed unexpected error: %v", err)
+	}
+	if len(corpus.BlockHeaders) == 0 {
+		t.Fatal("no block header in the corpus")
+	}
+
+	for _, test := range corpus.BlockHeaders {
+		header := BlockHeader{}
+		if err := json.Unmarshal(test.Header, &header); err != nil {
+			t.Fatalf("%s: Unmarshal failed unexpected error: %v", test.Name, err)
+		}
+
+		serialized, err := header.Serialize()
+		if err != nil {
+			t.Errorf("%s: Serialize failed unexpected error: %v", test.Name, err)
+		} else if got := hex.EncodeToString(serialized); got != test.Hex {
+			t.Errorf("%s: Serialize = %s, want %s", test.Name, got, test.Hex)
+		}
+
+		id, err := header.CalculateID()
+		if err != nil {
+			t.Errorf("%s: CalculateID failed unexpected error: %v", test.Name, err)
+		} else if id != test.ID {
+			t.Errorf("%s: CalculateID = %s, want %s", test.Name, id, test.ID)
+		}
+	}
+}
+
+func TestNewFullBalances_Amounts(t *testing.T) {
+	// amounts are numbers, or strings when large
+	result := gjson.Parse(`{
+		"balances":[{"id":"2.5.1","asset_type":"1.3.0","balance":100},{"id":"2.5.2","asset_type":"1.3.1","balance":"18446744073709551615"}],
+		"vesting_balances":[{"id":"1.13.1","balance":{"amount":"7","asset_id":"1.3.0"}}]
+	}`)
+	balances, err := NewFullBalances(&result)
+	if err != nil {
+		t.Fatalf("NewFullBalances failed unexpected error: %v", err)
+	}
+	if len(balances) != 2 || balances[0].Available != 100 || balances[0].Vesting != 7 || balances[1].Available != 18446744073709551615 {
+		t.Errorf("balances = %+v %+v", balances[0], balances[1])
+	}
+
+	for _, bad := range []string{`"12a"`, `-1`, `1.5`, `null`, `"18446744073709551616"`} {
+		result := gjson.Parse(`{"balances":[{"id":"2.5.1","asset_type":"1.3.0","balance":` + bad + `}]}`)
+		if _, err := NewFullBalances(&result); err == nil {
+			t.Errorf("balance %s: NewFullBalances should fail", bad)
+		}
+	}
+}
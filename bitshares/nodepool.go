@@ -0,0 +1,360 @@
+/*
+ * Copyright 2018 The OpenWallet Authors
+ * This file is part of the OpenWallet library.
+ *
+ * The OpenWallet library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The OpenWallet library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ */
+
+package bitshares
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blocktree/openwallet/v2/log"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	defaultMaxHeadLag       = 10               // blocks a node may fall behind its peers
+	defaultDisableDuration  = 30 * time.Second // how long an unhealthy node is skipped
+	defaultMaxNodeFailures  = 3                // consecutive failures before a node is skipped
+	nodeStatSmoothingFactor = 0.3              // weight of the latest sample in the moving averages
+)
+
+// rpcNode is an API endpoint of the node pool together with its health statistics
+type rpcNode struct {
+	URL     string
+	ws      *WSClient // set for websocket endpoints
+	noBatch int32     // set to 1 when the node rejects json-rpc batch arrays
+
+	latency       time.Duration // moving average of the call latency
+	errorRate     float64       // moving average of the failed calls
+	failures      int           // consecutive failures
+	headBlock     uint64        // last head_block_number reported by the node
+	disabledUntil time.Time     // the node is skipped until then
+}
+
+// NodeStatus is a snapshot of the health of a node
+type NodeStatus struct {
+	URL       string
+	Latency   time.Duration
+	ErrorRate float64
+	HeadBlock uint64
+	Disabled  bool
+}
+
+// score is lower for healthier nodes, nodes without samples come first
+func (n *rpcNode) score() float64 {
+	return float64(n.latency.Milliseconds()) * (1 + 10*n.errorRate)
+}
+
+// NodePool spreads the calls over several API nodes. Every call goes to the
+// healthiest node; a node that fails repeatedly or whose head block falls
+// behind its peers is skipped for a while.
+type NodePool struct {
+	MaxHeadLag      uint64
+	DisableDuration time.Duration
+	MaxFailures     int
+
+	mutex sync.Mutex // protects the following
+	nodes []*rpcNode
+	stop  chan struct{}
+}
+
+// NewNodePool creates a pool from a comma-separated list of endpoints.
+// ws:// and wss:// endpoints are served over a persistent websocket. An
+// endpoint listed twice is added once.
+func NewNodePool(endpoints string, debug bool) *NodePool {
+	p := NodePool{
+		MaxHeadLag:      defaultMaxHeadLag,
+		DisableDuration: defaultDisableDuration,
+		MaxFailures:     defaultMaxNodeFailures,
+	}
+
+	for _, url := range strings.Split(endpoints, ",") {
+		url = endpointURL(url)
+		if len(url) == 0 || p.node(url) != nil {
+			continue
+		}
+		node := &rpcNode{URL: url}
+		if strings.HasPrefix(url, "ws://") || strings.HasPrefix(url, "wss://") {
+			node.ws = NewWSClient(url, debug)
+			if err := node.ws.Connect(); err != nil {
+				log.Std.Warning("connect websocket node %s failed: %v", url, err)
+			}
+		}
+		p.nodes = append(p.nodes, node)
+	}
+
+	return &p
+}
+
+// endpointURL returns the url of an endpoint without spaces and trailing slash
+func endpointURL(url string) string {
+	return strings.TrimSuffix(strings.TrimSpace(url), "/")
+}
+
+// node returns the node of the endpoint, nil if not in the pool
+func (p *NodePool) node(url string) *rpcNode {
+	for _, n := range p.nodes {
+		if n.URL == url {
+			return n
+		}
+	}
+	return nil
+}
+
+// AddWebsocket adds an already connected websocket to the pool. When its
+// endpoint is already in the pool, the node uses it instead of its own connection.
+func (p *NodePool) AddWebsocket(ws *WSClient) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	url := endpointURL(ws.URL)
+	if n := p.node(url); n != nil {
+		if n.ws != nil && n.ws != ws {
+			n.ws.Close()
+		}
+		n.ws = ws
+		return
+	}
+	p.nodes = append(p.nodes, &rpcNode{URL: url, ws: ws})
+}
+
+// Websocket returns the websocket connection of an endpoint of the pool, nil
+// if the endpoint is not in the pool or is not a websocket
+func (p *NodePool) Websocket(url string) *WSClient {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if n := p.node(endpointURL(url)); n != nil {
+		return n.ws
+	}
+	return nil
+}
+
+// Len returns the number of nodes in the pool
+func (p *NodePool) Len() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return len(p.nodes)
+}
+
+// Status returns the health of every node
+func (p *NodePool) Status() []NodeStatus {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := time.Now()
+	status := make([]NodeStatus, 0, len(p.nodes))
+	for _, n := range p.nodes {
+		status = append(status, NodeStatus{
+			URL:       n.URL,
+			Latency:   n.latency,
+			ErrorRate: n.errorRate,
+			HeadBlock: n.headBlock,
+			Disabled:  now.Before(n.disabledUntil),
+		})
+	}
+	return status
+}
+
+// pick returns the healthiest node not tried yet. Disabled nodes are only
+// used when no enabled node is left.
+func (p *NodePool) pick(tried map[*rpcNode]bool) *rpcNode {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	var best, fallback *rpcNode
+	now := time.Now()
+	for _, n := range p.nodes {
+		if tried[n] {
+			continue
+		}
+		if now.Before(n.disabledUntil) {
+			if fallback == nil || n.disabledUntil.Before(fallback.disabledUntil) {
+				fallback = n
+			}
+			continue
+		}
+		if best == nil || n.score() < best.score() {
+			best = n
+		}
+	}
+	if best == nil {
+		return fallback
+	}
+	return best
+}
+
+// report records the outcome of a call made to the node
+func (p *NodePool) report(node *rpcNode, elapsed time.Duration, success bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if node.latency == 0 {
+		node.latency = elapsed
+	} else {
+		node.latency = time.Duration((1-nodeStatSmoothingFactor)*float64(node.latency) + nodeStatSmoothingFactor*float64(elapsed))
+	}
+
+	node.errorRate *= 1 - nodeStatSmoothingFactor
+	if success {
+		node.failures = 0
+		return
+	}
+
+	node.errorRate += nodeStatSmoothingFactor
+	node.failures++
+	if node.failures >= p.MaxFailures {
+		node.disabledUntil = time.Now().Add(p.DisableDuration)
+		log.Std.Warning("node %s failed %d times, disabled until %v", node.URL, node.failures, node.disabledUntil)
+	}
+}
+
+// observeHead records the head block of the node and disables every node
+// lagging too far behind the highest one.
+func (p *NodePool) observeHead(node *rpcNode, head uint64) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	node.headBlock = head
+
+	var maxHead uint64
+	for _, n := range p.nodes {
+		if n.headBlock > maxHead {
+			maxHead = n.headBlock
+		}
+	}
+
+	for _, n := range p.nodes {
+		if n.headBlock > 0 && n.headBlock+p.MaxHeadLag < maxHead {
+			n.disabledUntil = time.Now().Add(p.DisableDuration)
+			log.Std.Warning("node %s head block %d is behind %d, disabled until %v", n.URL, n.headBlock, maxHead, n.disabledUntil)
+		}
+	}
+}
+
+// call runs fn on the healthiest node, failing over to the next one when the
+// node itself fails, until ctx is done. Errors returned by the node's API are not retried.
+// A non idempotent method is sent to one node only: the failed node may have
+// accepted it, so its error is returned as is.
+func (p *NodePool) call(ctx context.Context, method string, fn func(node *rpcNode) (*gjson.Result, error)) (*gjson.Result, error) {
+	var (
+		tried   = make(map[*rpcNode]bool)
+		lastErr error
+	)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		node := p.pick(tried)
+		if node == nil {
+			break
+		}
+		tried[node] = true
+
+		start := time.Now()
+		r, err := fn(node)
+		if err != nil && ctx.Err() != nil {
+			// cancelled by the caller, not a failure of the node
+			return nil, err
+		}
+		if err != nil && isNodeFailure(err) {
+			p.report(node, time.Since(start), false)
+			if nonIdempotentMethods[method] {
+				return nil, err
+			}
+			lastErr = err
+			continue
+		}
+		p.report(node, time.Since(start), true)
+
+		if err == nil && method == "get_dynamic_global_properties" {
+			p.observeHead(node, r.Get("head_block_number").Uint())
+		}
+		return r, err
+	}
+
+	if lastErr == nil {
+		return nil, fmt.Errorf("API url is not setup. ")
+	}
+	return nil, lastErr
+}
+
+// CheckHealth probes every node, including the disabled ones so they can recover
+func (p *NodePool) CheckHealth(fn func(node *rpcNode) (*gjson.Result, error)) {
+	p.mutex.Lock()
+	nodes := append([]*rpcNode{}, p.nodes...)
+	p.mutex.Unlock()
+
+	for _, node := range nodes {
+		start := time.Now()
+		r, err := fn(node)
+		if err != nil {
+			p.report(node, time.Since(start), false)
+			continue
+		}
+		p.report(node, time.Since(start), true)
+
+		p.mutex.Lock()
+		node.disabledUntil = time.Time{}
+		p.mutex.Unlock()
+		p.observeHead(node, r.Get("head_block_number").Uint())
+	}
+}
+
+// StartHealthCheck probes the nodes periodically until Close is called
+func (p *NodePool) StartHealthCheck(interval time.Duration, fn func(node *rpcNode) (*gjson.Result, error)) {
+	p.mutex.Lock()
+	if p.stop != nil {
+		p.mutex.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	p.stop = stop
+	p.mutex.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.CheckHealth(fn)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the health check and the websocket connections
+func (p *NodePool) Close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
+	for _, n := range p.nodes {
+		if n.ws != nil {
+			n.ws.Close()
+		}
+	}
+}
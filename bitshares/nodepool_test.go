@@ -0,0 +1,168 @@
+package bitshares
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+func testHTTPNode(head uint64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"id":1,"jsonrpc":"2.0","result":{"head_block_number":%d}}`, head)
+	}))
+}
+
+func TestNodePool_Failover(t *testing.T) {
+	alive := testHTTPNode(100)
+	defer alive.Close()
+	dead := testHTTPNode(100)
+	dead.Close()
+
+	c := NewWalletClient(dead.URL+","+alive.URL, "", false)
+	for i := 0; i < 5; i++ {
+		info, err := c.GetBlockchainInfo()
+		if err != nil {
+			t.Fatalf("GetBlockchainInfo failed unexpected error: %v", err)
+		}
+		if info.HeadBlockNum != 100 {
+			t.Errorf("head block = %d, want 100", info.HeadBlockNum)
+		}
+	}
+
+	for _, s := range c.Pool().Status() {
+		if s.URL == dead.URL && (!s.Disabled || s.ErrorRate == 0) {
+			t.Errorf("dead node should be disabled: %+v", s)
+		}
+	}
+}
+
+func TestNodePool_HeadLag(t *testing.T) {
+	ahead := testHTTPNode(1000)
+	defer ahead.Close()
+	behind := testHTTPNode(900)
+	defer behind.Close()
+
+	c := NewWalletClient(behind.URL+","+ahead.URL, "", false)
+	c.Pool().CheckHealth(func(node *rpcNode) (*gjson.Result, error) {
+		return c.callNode(context.Background(), node, "database", "get_dynamic_global_properties", []interface{}{})
+	})
+
+	for i := 0; i < 3; i++ {
+		info, err := c.GetBlockchainInfo()
+		if err != nil {
+			t.Fatalf("GetBlockchainInfo failed unexpected error: %v", err)
+		}
+		if info.HeadBlockNum != 1000 {
+			t.Errorf("lagging node was used, head block = %d", info.HeadBlockNum)
+		}
+	}
+}
+
+func TestNodePool_CallContext(t *testing.T) {
+	release := make(chan struct{})
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer hanging.Close()
+	defer close(release)
+	alive := testHTTPNode(100)
+	defer alive.Close()
+
+	// the read timeout of an attempt fails over to the next node
+	c := NewWalletClient(hanging.URL+","+alive.URL, "", false)
+	c.Timeouts.Read = 100 * time.Millisecond
+	info, err := c.GetBlockchainInfo()
+	if err != nil {
+		t.Fatalf("GetBlockchainInfo failed unexpected error: %v", err)
+	}
+	if info.HeadBlockNum != 100 {
+		t.Errorf("head block = %d, want 100", info.HeadBlockNum)
+	}
+
+	// a cancelled context returns at once without failing over or retrying
+	c = NewWalletClient(hanging.URL+","+alive.URL, "", false)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	if _, err := c.GetBlockchainInfoContext(ctx); err == nil {
+		t.Errorf("cancelled call should fail")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("cancelled call returned after %v", elapsed)
+	}
+}
+
+func TestNodePool_Broadcast(t *testing.T) {
+	var hangingCalls, aliveCalls int32
+	release := make(chan struct{})
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hangingCalls, 1)
+		<-release
+	}))
+	defer hanging.Close()
+	defer close(release)
+	alive := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&aliveCalls, 1)
+		fmt.Fprint(w, `{"id":1,"jsonrpc":"2.0","result":null}`)
+	}))
+	defer alive.Close()
+
+	// the node timing out may have accepted the transaction: it is neither
+	// sent to the next node nor retried
+	for _, method := range []string{"broadcast_transaction", "broadcast_transaction_synchronous"} {
+		atomic.StoreInt32(&hangingCalls, 0)
+		atomic.StoreInt32(&aliveCalls, 0)
+		c := NewWalletClient(hanging.URL+","+alive.URL, "", false)
+		c.Timeouts.Broadcast = 100 * time.Millisecond
+
+		_, err := c.callAPI(context.Background(), "network_broadcast", method, []interface{}{})
+		if err == nil {
+			t.Fatalf("%s to a node timing out should fail", method)
+		}
+		if !strings.Contains(err.Error(), hanging.URL) || ErrorKind(err) != RPCErrorNetwork {
+			t.Errorf("%s error = %v, want the timeout of %s", method, err, hanging.URL)
+		}
+		if calls := atomic.LoadInt32(&hangingCalls); calls != 1 {
+			t.Errorf("%s sent %d times to the node timing out, want 1", method, calls)
+		}
+		if calls := atomic.LoadInt32(&aliveCalls); calls != 0 {
+			t.Errorf("%s sent %d times to the next node, want 0", method, calls)
+		}
+	}
+}
+
+func TestNodePool_Dedupe(t *testing.T) {
+	alive := testHTTPNode(100)
+	defer alive.Close()
+	closed := testHTTPNode(100)
+	closed.Close()
+	wsURL := "ws" + strings.TrimPrefix(closed.URL, "http")
+
+	// the websocket endpoint of the README example is also the serverWS
+	c := NewWalletClient(alive.URL+", "+alive.URL+"/,"+wsURL, "", false)
+	defer c.Close()
+	if n := c.Pool().Len(); n != 2 {
+		t.Fatalf("pool has %d nodes, want 2", n)
+	}
+
+	ws := NewWSClient(wsURL+"/", false)
+	c.SetWebsocket(ws)
+	if n := c.Pool().Len(); n != 2 {
+		t.Errorf("pool has %d nodes after SetWebsocket, want 2", n)
+	}
+	if got := c.Pool().Websocket(wsURL); got != ws {
+		t.Errorf("websocket of %s = %v, want the one set", wsURL, got)
+	}
+
+	info, err := c.GetBlockchainInfo()
+	if err != nil || info.HeadBlockNum != 100 {
+		t.Errorf("GetBlockchainInfo = %v, %v", info, err)
+	}
+}
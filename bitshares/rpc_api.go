@@ -2,87 +2,241 @@ package bitshares
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/blocktree/bitshares-adapter/types"
 	"github.com/blocktree/openwallet/v2/log"
-	bt "github.com/denkhaus/bitshares/types"
 	"github.com/imroc/req"
+	"github.com/pkg/errors"
 	"github.com/tidwall/gjson"
 )
 
 // WalletClient is a Bitshares RPC client. It performs RPCs over HTTP using JSON
 // request and responses. A Client must be configured with a secret token
 // to authenticate with other Cores on the network.
+//
+// Every call goes to the database_api and network_broadcast_api of the nodes
+// in ServerAPI, so a bare witness_node is enough. WalletAPI is optional and
+// kept for configurations that still set it.
 type WalletClient struct {
 	WalletAPI, ServerAPI string
 	Debug                bool
 	client               *req.Req
+	ws                   *WSClient
+	pool                 *NodePool
+	RetryPolicy          RetryPolicy
+	Timeouts             CallTimeouts
+	cassette             *Cassette
 }
 
-// NewWalletClient init a rpc client
+// CallTimeouts bound every attempt of a call, by class of method. A zero
+// timeout leaves the attempt bounded by the caller's context only.
+type CallTimeouts struct {
+	Read      time.Duration // queries of chain data
+	Fee       time.Duration // fee queries
+	Broadcast time.Duration // transaction broadcasts
+}
+
+// DefaultCallTimeouts is used by new clients
+var DefaultCallTimeouts = CallTimeouts{
+	Read:      30 * time.Second,
+	Fee:       15 * time.Second,
+	Broadcast: 60 * time.Second,
+}
+
+// timeout returns the timeout of the method's class
+func (t CallTimeouts) timeout(method string) time.Duration {
+	switch {
+	case strings.HasPrefix(method, "broadcast_"):
+		return t.Broadcast
+	case method == "get_required_fees", method == "get_global_properties":
+		return t.Fee
+	default:
+		return t.Read
+	}
+}
+
+// withTimeout derives a context bounded by the timeout of the method's class
+func (t CallTimeouts) withTimeout(ctx context.Context, method string) (context.Context, context.CancelFunc) {
+	if d := t.timeout(method); d > 0 {
+		return context.WithTimeout(ctx, d)
+	}
+	return context.WithCancel(ctx)
+}
+
+// NewWalletClient init a rpc client, serverAPI may be a comma-separated list of nodes
 func NewWalletClient(serverAPI, walletAPI string, debug bool) *WalletClient {
 
 	walletAPI = strings.TrimSuffix(walletAPI, "/")
 	serverAPI = strings.TrimSuffix(serverAPI, "/")
 	c := WalletClient{
-		WalletAPI: walletAPI,
-		ServerAPI: serverAPI,
-		Debug:     debug,
+		WalletAPI:   walletAPI,
+		ServerAPI:   serverAPI,
+		Debug:       debug,
+		RetryPolicy: DefaultRetryPolicy,
+		Timeouts:    DefaultCallTimeouts,
 	}
 
 	api := req.New()
 	c.client = api
+	c.pool = NewNodePool(serverAPI, debug)
 
 	return &c
 }
 
-// Call calls a remote procedure on another node, specified by the path.
-func (c *WalletClient) call(method string, request interface{}, queryWalletAPI bool) (*gjson.Result, error) {
+// SetWebsocket adds a persistent websocket connection to the node pool and
+// uses it for subscriptions.
+func (c *WalletClient) SetWebsocket(ws *WSClient) {
+	c.ws = ws
+	c.pool.AddWebsocket(ws)
+}
 
-	var (
-		body = make(map[string]interface{}, 0)
-	)
+// Websocket returns the websocket connection of the client, nil if not set
+func (c *WalletClient) Websocket() *WSClient {
+	return c.ws
+}
 
-	if c.client == nil {
-		return nil, fmt.Errorf("API url is not setup. ")
+// Pool returns the node pool of the client
+func (c *WalletClient) Pool() *NodePool {
+	return c.pool
+}
+
+// StartHealthCheck probes the head block of every node periodically
+func (c *WalletClient) StartHealthCheck(interval time.Duration) {
+	c.pool.StartHealthCheck(interval, func(node *rpcNode) (*gjson.Result, error) {
+		return c.callNode(context.Background(), node, "database", "get_dynamic_global_properties", []interface{}{})
+	})
+}
+
+// SetCassette records the calls of the client to the cassette or answers
+// them from it, according to its mode. nil turns the cassette off.
+func (c *WalletClient) SetCassette(cassette *Cassette) {
+	if cassette != nil && cassette.Mode == CassetteOff {
+		cassette = nil
 	}
+	c.cassette = cassette
+}
 
-	authHeader := req.Header{
-		"Accept":       "application/json",
-		"Content-Type": "application/json",
-		"Connection":   "close",
+// Cassette returns the cassette of the client, nil if not set
+func (c *WalletClient) Cassette() *Cassette {
+	return c.cassette
+}
+
+// Close stops the health check and closes the websocket connections and the cassette
+func (c *WalletClient) Close() {
+	c.pool.Close()
+	if c.cassette != nil {
+		c.cassette.Close()
 	}
+}
 
-	//json-rpc
-	body["jsonrpc"] = "2.0"
-	body["id"] = 1
-	body["method"] = method
-	body["params"] = request
+// SubscribeBlockApplied calls back with the id of every new block applied by the node.
+// The subscription is installed again automatically when the websocket reconnects.
+func (c *WalletClient) SubscribeBlockApplied(callback func(blockID string)) error {
+	if c.ws == nil {
+		return fmt.Errorf("websocket API is not setup. ")
+	}
+	return c.ws.SetBlockAppliedCallback(callback)
+}
 
-	if c.Debug {
-		log.Std.Info("Start Request API...")
+// Call calls a remote procedure of the database api on another node.
+func (c *WalletClient) call(method string, request interface{}) (*gjson.Result, error) {
+	return c.callContext(context.Background(), method, request)
+}
+
+// callContext calls a remote procedure of the database api until ctx is done
+func (c *WalletClient) callContext(ctx context.Context, method string, request interface{}) (*gjson.Result, error) {
+	return c.callAPI(ctx, "database", method, request)
+}
+
+// callAPI calls a remote procedure of the api until ctx is done. Every attempt is bounded by
+// the timeout of the method class and transient failures of idempotent calls are
+// retried according to the RetryPolicy.
+func (c *WalletClient) callAPI(ctx context.Context, api, method string, request interface{}) (*gjson.Result, error) {
+	if c.cassette != nil && c.cassette.Mode == CassetteReplay {
+		return c.cassette.replay(method, request)
 	}
 
-	host := c.ServerAPI
-	if queryWalletAPI {
-		host = c.WalletAPI
+	r, err := c.withRetry(ctx, method, func() (*gjson.Result, error) {
+		return c.pool.call(ctx, method, func(node *rpcNode) (*gjson.Result, error) {
+			return c.callNode(ctx, node, api, method, request)
+		})
+	})
+
+	if c.cassette != nil {
+		c.cassette.record(method, request, r, err)
 	}
+	return r, err
+}
 
-	r, err := c.client.Post(host, req.BodyJSON(&body), authHeader)
+// withRetry runs fn until it succeeds, ctx is done or it fails in a way the RetryPolicy does not retry
+func (c *WalletClient) withRetry(ctx context.Context, method string, fn func() (*gjson.Result, error)) (*gjson.Result, error) {
 
-	if c.Debug {
-		log.Std.Info("Request API Completed")
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		r, err := fn()
+		if err == nil {
+			return r, nil
+		}
+
+		if e, ok := err.(*RPCError); ok && len(e.Method) == 0 {
+			e.Method = method
+		}
+
+		if ctx.Err() != nil || !c.RetryPolicy.shouldRetry(method, attempt, err) {
+			return nil, err
+		}
+
+		backoff := c.RetryPolicy.backoff(attempt)
+		log.Std.Warning("call %s failed: %v, retry in %v", method, err, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, err
+		}
 	}
+}
 
-	if c.Debug {
-		log.Std.Info("%+v", r)
+// callNode calls a method of one of the node's apis, bounded by the timeout of the method class.
+// Over HTTP the database api is called directly and the other apis through "call".
+func (c *WalletClient) callNode(ctx context.Context, node *rpcNode, api, method string, request interface{}) (*gjson.Result, error) {
+	ctx, cancel := c.Timeouts.withTimeout(ctx, method)
+	defer cancel()
+
+	if node.ws != nil {
+		return node.ws.CallContext(ctx, api, method, request)
+	}
+	if api != "database" {
+		return c.post(ctx, node.URL, "call", []interface{}{api, method, request})
 	}
+	return c.post(ctx, node.URL, method, request)
+}
+
+// post sends a json-rpc request over HTTP
+func (c *WalletClient) post(ctx context.Context, host, method string, request interface{}) (*gjson.Result, error) {
+
+	var (
+		body = make(map[string]interface{}, 0)
+	)
 
+	//json-rpc
+	body["jsonrpc"] = "2.0"
+	body["id"] = 1
+	body["method"] = method
+	body["params"] = request
+
+	r, err := c.postBody(ctx, host, body)
 	if err != nil {
 		return nil, err
 	}
@@ -98,32 +252,78 @@ func (c *WalletClient) call(method string, request interface{}, queryWalletAPI b
 	return &result, nil
 }
 
+// postBody posts a json body to the host
+func (c *WalletClient) postBody(ctx context.Context, host string, body interface{}) (*req.Resp, error) {
+
+	if c.client == nil || len(host) == 0 {
+		return nil, fmt.Errorf("API url is not setup. ")
+	}
+
+	authHeader := req.Header{
+		"Accept":       "application/json",
+		"Content-Type": "application/json",
+		"Connection":   "close",
+	}
+
+	if c.Debug {
+		log.Std.Info("Start Request API...")
+	}
+
+	r, err := c.client.Post(host, req.BodyJSON(body), authHeader, ctx)
+
+	if c.Debug {
+		log.Std.Info("Request API Completed")
+	}
+
+	if c.Debug {
+		log.Std.Info("%+v", r)
+	}
+
+	return r, err
+}
+
 // isError 是否报错
 func (c *WalletClient) isError(r *req.Resp) error {
 
+	// graphene answers failed calls with an error object and a non-200 status
+	if err := rpcError(gjson.ParseBytes(r.Bytes())); err != nil {
+		return err
+	}
+
 	if r.Response().StatusCode != http.StatusOK {
 		message := r.Response().Status
 		status := r.Response().StatusCode
 		return fmt.Errorf("[%d]%s", status, message)
 	}
 
-	result := gjson.ParseBytes(r.Bytes())
+	return nil
 
-	if result.Get("error").IsObject() {
+}
 
-		return fmt.Errorf("[%d]%s",
-			result.Get("error.code").Int(),
-			result.Get("error.message").String())
+// rpcError returns the error object of a json-rpc response, if any
+func rpcError(result gjson.Result) error {
 
+	if result.Get("error").IsObject() {
+		return NewRPCError(result.Get("error"))
 	}
 
 	return nil
+}
 
+// isNodeFailure returns false for the errors answered by the node's API, which
+// would be the same on any other node.
+func isNodeFailure(err error) bool {
+	return ErrorKind(err) == RPCErrorNetwork
 }
 
 // GetObjects return a block by the given block number
 func (c *WalletClient) GetObjects(assets ...types.ObjectID) (*gjson.Result, error) {
-	resp, err := c.call("get_objects", []interface{}{objectsToParams(assets)}, false)
+	return c.GetObjectsContext(context.Background(), assets...)
+}
+
+// GetObjectsContext is GetObjects bounded by ctx
+func (c *WalletClient) GetObjectsContext(ctx context.Context, assets ...types.ObjectID) (*gjson.Result, error) {
+	resp, err := c.callContext(ctx, "get_objects", []interface{}{objectsToParams(assets)})
 	return resp, err
 }
 
@@ -137,7 +337,26 @@ func objectsToParams(objs []types.ObjectID) []string {
 
 // GetBlockchainInfo returns current blockchain data
 func (c *WalletClient) GetBlockchainInfo() (*BlockchainInfo, error) {
-	r, err := c.call("get_dynamic_global_properties", []interface{}{}, false)
+	return c.GetBlockchainInfoContext(context.Background())
+}
+
+// GetFeeSchedule returns the current fee schedule of the chain
+func (c *WalletClient) GetFeeSchedule() (*FeeSchedule, error) {
+	return c.GetFeeScheduleContext(context.Background())
+}
+
+// GetFeeScheduleContext is GetFeeSchedule bounded by ctx
+func (c *WalletClient) GetFeeScheduleContext(ctx context.Context) (*FeeSchedule, error) {
+	r, err := c.callContext(ctx, "get_global_properties", []interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	return NewFeeSchedule(r.Get("parameters.current_fees"))
+}
+
+// GetBlockchainInfoContext is GetBlockchainInfo bounded by ctx
+func (c *WalletClient) GetBlockchainInfoContext(ctx context.Context) (*BlockchainInfo, error) {
+	r, err := c.callContext(ctx, "get_dynamic_global_properties", []interface{}{})
 	if err != nil {
 		return nil, err
 	}
@@ -147,36 +366,122 @@ func (c *WalletClient) GetBlockchainInfo() (*BlockchainInfo, error) {
 
 // GetBlockByHeight returns a certain block
 func (c *WalletClient) GetBlockByHeight(height uint32) (*Block, error) {
-	r, err := c.call("get_block", []interface{}{height}, true)
+	return c.GetBlockByHeightContext(context.Background(), height)
+}
+
+// GetBlockByHeightContext is GetBlockByHeight bounded by ctx
+func (c *WalletClient) GetBlockByHeightContext(ctx context.Context, height uint32) (*Block, error) {
+	r, err := c.callContext(ctx, "get_block", []interface{}{height})
 	if err != nil {
 		return nil, err
 	}
+	if !r.IsObject() {
+		return nil, fmt.Errorf("block %d does not exist", height)
+	}
 	block := NewBlock(height, r)
+
+	// a witness_node answers without the ids the cli_wallet adds
+	if len(block.BlockID) == 0 {
+		if err := block.CalculateID(); err != nil {
+			return nil, err
+		}
+	}
+	if len(block.TransactionIDs) != len(block.Transactions) {
+		block.TransactionIDs, err = c.transactionIDs(ctx, block.Transactions, r.Get("transactions").Array())
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i, tx := range block.Transactions {
+		tx.TransactionID = block.TransactionIDs[i]
+	}
 	return block, nil
 }
 
+// transactionIDs returns the ids of the transactions, computed locally. Only a
+// transaction with an operation unknown to the adapter is serialized by the node,
+// in one batch, and it is logged: the node is trusted with its id.
+func (c *WalletClient) transactionIDs(ctx context.Context, txs []*types.Transaction, raws []gjson.Result) ([]string, error) {
+	ids := make([]string, len(txs))
+	requests := make([]RPCRequest, 0)
+	missing := make([]int, 0)
+
+	for i, tx := range txs {
+		id, err := tx.ID()
+		if err == nil {
+			ids[i] = id
+			continue
+		}
+		if errors.Cause(err) != types.ErrNotSerializable {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		log.Std.Warning("transaction %d id cannot be computed locally, asking the node: %v", i, err)
+		requests = append(requests, RPCRequest{Method: "get_transaction_hex_without_sig", Params: []interface{}{json.RawMessage(raws[i].Raw)}})
+		missing = append(missing, i)
+	}
+	if len(requests) == 0 {
+		return ids, nil
+	}
+
+	results, err := c.BatchCallContext(ctx, requests...)
+	if err != nil {
+		return nil, err
+	}
+	for i, r := range results {
+		if ids[missing[i]], err = transactionIDFromHex(r.String()); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// transactionIDFromHex returns the id of a transaction serialized without signatures
+func transactionIDFromHex(raw string) (string, error) {
+	data, err := hex.DecodeString(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid transaction hex: %v", err)
+	}
+	digest := sha256.Sum256(data)
+	return hex.EncodeToString(digest[:20]), nil
+}
+
 // GetTransaction returns the TX
 func (c *WalletClient) GetTransaction(height uint32, trxInBlock int) (*types.Transaction, error) {
-	r, err := c.call("get_transaction", []interface{}{height, trxInBlock}, false)
+	return c.GetTransactionContext(context.Background(), height, trxInBlock)
+}
+
+// GetTransactionContext is GetTransaction bounded by ctx
+func (c *WalletClient) GetTransactionContext(ctx context.Context, height uint32, trxInBlock int) (*types.Transaction, error) {
+	r, err := c.callContext(ctx, "get_transaction", []interface{}{height, trxInBlock})
 	if err != nil {
 		return nil, err
 	}
 	if r.Raw == "null" {
 		return nil, fmt.Errorf("cannot find this transaction: %v, %v", height, trxInBlock)
 	}
-	block, err := c.GetBlockByHeight(height)
+	tx, err := NewTransaction(r, "")
 	if err != nil {
 		return nil, err
 	}
-	if len(block.TransactionIDs) <= trxInBlock {
-		return nil, fmt.Errorf("cannot find this transaction on the block: %v, %v", height, trxInBlock)
+	tx.TransactionID, err = tx.ID()
+	if errors.Cause(err) == types.ErrNotSerializable {
+		log.Std.Warning("transaction %d of block %d id cannot be computed locally, asking the node: %v", trxInBlock, height, err)
+		tx.TransactionID, err = c.transactionID(ctx, json.RawMessage(r.Raw))
+	}
+	if err != nil {
+		return nil, err
 	}
-	return NewTransaction(r, block.TransactionIDs[trxInBlock])
+	return tx, nil
 }
 
 // GetAssetsBalance Returns information about the given account.
 func (c *WalletClient) GetAssetsBalance(account types.ObjectID, asset types.ObjectID) (*Balance, error) {
-	r, err := c.call("get_account_balances", []interface{}{account.String(), []interface{}{asset.String()}}, false)
+	return c.GetAssetsBalanceContext(context.Background(), account, asset)
+}
+
+// GetAssetsBalanceContext is GetAssetsBalance bounded by ctx
+func (c *WalletClient) GetAssetsBalanceContext(ctx context.Context, account types.ObjectID, asset types.ObjectID) (*Balance, error) {
+	r, err := c.callContext(ctx, "get_account_balances", []interface{}{account.String(), []interface{}{asset.String()}})
 	if err != nil {
 		return nil, err
 	}
@@ -185,7 +490,12 @@ func (c *WalletClient) GetAssetsBalance(account types.ObjectID, asset types.Obje
 
 // GetAssetsBalance Returns information about the given account.
 func (c *WalletClient) GetAccountID(name string) (*types.ObjectID, error) {
-	r, err := c.call("lookup_accounts", []interface{}{name, 1}, false)
+	return c.GetAccountIDContext(context.Background(), name)
+}
+
+// GetAccountIDContext is GetAccountID bounded by ctx
+func (c *WalletClient) GetAccountIDContext(ctx context.Context, name string) (*types.ObjectID, error) {
+	r, err := c.callContext(ctx, "lookup_accounts", []interface{}{name, 1})
 	if err != nil {
 		return nil, err
 	}
@@ -200,10 +510,29 @@ func (c *WalletClient) GetAccountID(name string) (*types.ObjectID, error) {
 	return nil, fmt.Errorf("[%s] have not registered", name)
 }
 
-// GetAssetsBalance Returns information about the given account.
-func (c *WalletClient) GetAccounts(names_or_ids ...string) ([]*types.Account, error) {
-	var resp []*types.Account
-	r, err := c.call("get_accounts", []interface{}{names_or_ids}, false)
+// GetAssets returns the assets of the ids, nil for the unknown ones
+func (c *WalletClient) GetAssets(ids ...string) ([]*Asset, error) {
+	return c.GetAssetsContext(context.Background(), ids...)
+}
+
+// GetAssetsContext is GetAssets bounded by ctx
+func (c *WalletClient) GetAssetsContext(ctx context.Context, ids ...string) ([]*Asset, error) {
+	return c.assets(ctx, "get_assets", ids)
+}
+
+// LookupAssetSymbols returns the assets of the symbols or ids, nil for the unknown ones
+func (c *WalletClient) LookupAssetSymbols(symbolsOrIDs ...string) ([]*Asset, error) {
+	return c.LookupAssetSymbolsContext(context.Background(), symbolsOrIDs...)
+}
+
+// LookupAssetSymbolsContext is LookupAssetSymbols bounded by ctx
+func (c *WalletClient) LookupAssetSymbolsContext(ctx context.Context, symbolsOrIDs ...string) ([]*Asset, error) {
+	return c.assets(ctx, "lookup_asset_symbols", symbolsOrIDs)
+}
+
+func (c *WalletClient) assets(ctx context.Context, method string, keys []string) ([]*Asset, error) {
+	var resp []*Asset
+	r, err := c.callContext(ctx, method, []interface{}{keys})
 	if err != nil {
 		return nil, err
 	}
@@ -213,56 +542,188 @@ func (c *WalletClient) GetAccounts(names_or_ids ...string) ([]*types.Account, er
 	return resp, nil
 }
 
-func (c *WalletClient) GetRequiredFee(ops []bt.Operation, assetID string) ([]bt.AssetAmount, error) {
-	var resp []bt.AssetAmount
+// GetAssetDynamicData returns the dynamic data of the asset, with its fee pool
+func (c *WalletClient) GetAssetDynamicData(asset *Asset) (*AssetDynamicData, error) {
+	return c.GetAssetDynamicDataContext(context.Background(), asset)
+}
 
-	opsJSON := []interface{}{}
-	for _, o := range ops {
-		_, err := json.Marshal(o)
-		if err != nil {
-			return []bt.AssetAmount{}, err
-		}
+// GetAssetDynamicDataContext is GetAssetDynamicData bounded by ctx
+func (c *WalletClient) GetAssetDynamicDataContext(ctx context.Context, asset *Asset) (*AssetDynamicData, error) {
+	r, err := c.callContext(ctx, "get_objects", []interface{}{[]string{asset.DynamicAssetDataID}})
+	if err != nil {
+		return nil, err
+	}
+	var resp []*AssetDynamicData
+	if err := json.Unmarshal([]byte(r.Raw), &resp); err != nil {
+		return nil, err
+	}
+	if len(resp) != 1 || resp[0] == nil {
+		return nil, fmt.Errorf("dynamic data %s of asset %s does not exist", asset.DynamicAssetDataID, asset.Symbol)
+	}
+	return resp[0], nil
+}
+
+// GetVestingBalances returns the vesting balances of the account, by name or id
+func (c *WalletClient) GetVestingBalances(account string) ([]*VestingBalance, error) {
+	return c.GetVestingBalancesContext(context.Background(), account)
+}
+
+// GetVestingBalancesContext is GetVestingBalances bounded by ctx
+func (c *WalletClient) GetVestingBalancesContext(ctx context.Context, account string) ([]*VestingBalance, error) {
+	var resp []*VestingBalance
+	r, err := c.callContext(ctx, "get_vesting_balances", []interface{}{account})
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(r.Raw), &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
 
-		opArr := []interface{}{o.Type(), o}
+// GetProposedTransactions returns the pending proposals the account, by name or
+// id, proposed, is required by or has approved
+func (c *WalletClient) GetProposedTransactions(account string) ([]*Proposal, error) {
+	return c.GetProposedTransactionsContext(context.Background(), account)
+}
 
-		opsJSON = append(opsJSON, opArr)
+// GetProposedTransactionsContext is GetProposedTransactions bounded by ctx
+func (c *WalletClient) GetProposedTransactionsContext(ctx context.Context, account string) ([]*Proposal, error) {
+	var resp []*Proposal
+	r, err := c.callContext(ctx, "get_proposed_transactions", []interface{}{account})
+	if err != nil {
+		return nil, err
 	}
-	r, err := c.call("get_required_fees", []interface{}{opsJSON, assetID}, false)
+	if err := json.Unmarshal([]byte(r.Raw), &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetProposal returns the pending proposal with the id
+func (c *WalletClient) GetProposal(id types.ObjectID) (*Proposal, error) {
+	return c.GetProposalContext(context.Background(), id)
+}
+
+// GetProposalContext is GetProposal bounded by ctx
+func (c *WalletClient) GetProposalContext(ctx context.Context, id types.ObjectID) (*Proposal, error) {
+	r, err := c.GetObjectsContext(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	var resp []*Proposal
 	if err := json.Unmarshal([]byte(r.Raw), &resp); err != nil {
 		return nil, err
 	}
+	if len(resp) != 1 || resp[0] == nil {
+		return nil, fmt.Errorf("proposal %s does not exist", id.String())
+	}
+	return resp[0], nil
+}
+
+// GetAssetsBalance Returns information about the given account.
+func (c *WalletClient) GetAccounts(names_or_ids ...string) ([]*types.Account, error) {
+	return c.GetAccountsContext(context.Background(), names_or_ids...)
+}
 
+// GetAccountsContext is GetAccounts bounded by ctx
+func (c *WalletClient) GetAccountsContext(ctx context.Context, names_or_ids ...string) ([]*types.Account, error) {
+	var resp []*types.Account
+	r, err := c.callContext(ctx, "get_accounts", []interface{}{names_or_ids})
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(r.Raw), &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetRequiredFee returns the fees of the operations paid in the asset
+func (c *WalletClient) GetRequiredFee(ops types.Operations, assetID string) ([]types.AssetAmount, error) {
+	return c.GetRequiredFeeContext(context.Background(), ops, assetID)
+}
+
+// GetRequiredFeeContext is GetRequiredFee bounded by ctx. The fee of a proposal_create
+// comes with the fees of its proposed operations, [fee, [fees]], only the first is returned.
+func (c *WalletClient) GetRequiredFeeContext(ctx context.Context, ops types.Operations, assetID string) ([]types.AssetAmount, error) {
+	r, err := c.callContext(ctx, "get_required_fees", []interface{}{ops, assetID})
+	if err != nil {
+		return nil, err
+	}
+
+	items := r.Array()
+	resp := make([]types.AssetAmount, len(items))
+	for i, item := range items {
+		if item.IsArray() {
+			item = item.Get("0")
+		}
+		if err := json.Unmarshal([]byte(item.Raw), &resp[i]); err != nil {
+			return nil, err
+		}
+	}
 	return resp, nil
 }
 
 // BroadcastTransaction broadcast a transaction
-func (c *WalletClient) BroadcastTransaction(tx *bt.SignedTransaction) (*BroadcastResponse, error) {
-	resp := BroadcastResponse{}
+func (c *WalletClient) BroadcastTransaction(tx *types.Transaction) (*BroadcastResponse, error) {
+	return c.BroadcastTransactionContext(context.Background(), tx)
+}
 
-	r, err := c.call("broadcast_transaction", []interface{}{tx}, true)
+// BroadcastTransactionContext is BroadcastTransaction bounded by ctx. When ctx is
+// done before the node answers, the transaction may still have been broadcast.
+// The node answers as soon as the transaction is accepted into its pending pool.
+func (c *WalletClient) BroadcastTransactionContext(ctx context.Context, tx *types.Transaction) (*BroadcastResponse, error) {
+	id, err := c.GetTransactionIDContext(ctx, tx)
 	if err != nil {
 		return nil, err
 	}
-	data := []interface{}{}
-	if err := json.Unmarshal([]byte(r.Raw), &data); err != nil {
+
+	if _, err := c.callAPI(ctx, "network_broadcast", "broadcast_transaction", []interface{}{tx}); err != nil {
 		return nil, err
 	}
-	if len(data) == 2 {
-		resp.ID = data[0].(string)
+	return &BroadcastResponse{ID: id}, nil
+}
+
+// BroadcastTransactionSynchronous broadcast a transaction and waits until it is included in a block
+func (c *WalletClient) BroadcastTransactionSynchronous(tx *types.Transaction) (*BroadcastResponse, error) {
+	return c.BroadcastTransactionSynchronousContext(context.Background(), tx)
+}
+
+// BroadcastTransactionSynchronousContext is BroadcastTransactionSynchronous bounded by ctx
+func (c *WalletClient) BroadcastTransactionSynchronousContext(ctx context.Context, tx *types.Transaction) (*BroadcastResponse, error) {
+	r, err := c.callAPI(ctx, "network_broadcast", "broadcast_transaction_synchronous", []interface{}{tx})
+	if err != nil {
+		return nil, err
+	}
+	resp := BroadcastResponse{}
+	if err := json.Unmarshal([]byte(r.Raw), &resp); err != nil {
+		return nil, err
 	}
-	return &resp, err
+	return &resp, nil
 }
 
 // GetTransactionID return the TX ID
 func (c *WalletClient) GetTransactionID(tx *types.Transaction) (string, error) {
-	r, err := c.call("get_transaction_id", []interface{}{tx}, true)
+	return c.GetTransactionIDContext(context.Background(), tx)
+}
+
+// GetTransactionIDContext is GetTransactionID bounded by ctx. The id is computed
+// locally, the node is only called for operations unknown to the adapter.
+func (c *WalletClient) GetTransactionIDContext(ctx context.Context, tx *types.Transaction) (string, error) {
+	if id, err := tx.ID(); err == nil {
+		return id, nil
+	}
+	return c.transactionID(ctx, tx)
+}
+
+// transactionID returns the id of a transaction serialized by the node
+func (c *WalletClient) transactionID(ctx context.Context, tx interface{}) (string, error) {
+	r, err := c.callContext(ctx, "get_transaction_hex_without_sig", []interface{}{tx})
 	if err != nil {
 		return "", err
 	}
-	return r.String(), err
+	return transactionIDFromHex(r.String())
 }
 
 func post(url, method string, request interface{}) (*gjson.Result, error) {
@@ -3,6 +3,7 @@ package bitshares
 import (
 	"testing"
 
+	"github.com/blocktree/bitshares-adapter/bitsharestest"
 	"github.com/blocktree/bitshares-adapter/types"
 	"github.com/blocktree/openwallet/v2/log"
 )
@@ -25,6 +26,58 @@ func TestWalletClient_GetBlockByHeight(t *testing.T) {
 	}
 }
 
+func TestWalletClient_FakeNodeTransactionIDs(t *testing.T) {
+	node, head := testVerifiedNode(t)
+	defer node.Close()
+
+	c := NewWalletClient(node.URL, "", false)
+	r := testBroadcastOperations(t, node, c)
+
+	for _, height := range []uint32{head.Height, r.BlockNum} {
+		block, err := c.GetBlockByHeight(height)
+		if err != nil {
+			t.Fatalf("GetBlockByHeight failed unexpected error: %v", err)
+		}
+		want := node.Block(height).TransactionIDs
+		if len(block.TransactionIDs) != len(want) {
+			t.Fatalf("block %d transaction ids = %v, want %v", height, block.TransactionIDs, want)
+		}
+		for i, id := range want {
+			if block.TransactionIDs[i] != id || block.Transactions[i].TransactionID != id {
+				t.Errorf("block %d transaction %d id = %s, want %s", height, i, block.TransactionIDs[i], id)
+			}
+		}
+	}
+
+	// the ids are computed locally, without asking the node
+	if calls := node.Calls("get_transaction_hex_without_sig"); calls != 0 {
+		t.Errorf("get_transaction_hex_without_sig called %d times, want 0", calls)
+	}
+}
+
+func TestWalletClient_FakeNodeGetTransaction(t *testing.T) {
+	node, _ := testVerifiedNode(t)
+	defer node.Close()
+
+	id, err := node.Transfer("alice", "bob", bitsharestest.CoreAssetID, 1000)
+	if err != nil {
+		t.Fatalf("Transfer failed unexpected error: %v", err)
+	}
+	block := node.MintBlock()
+
+	c := NewWalletClient(node.URL, "", false)
+	tx, err := c.GetTransaction(block.Height, 0)
+	if err != nil {
+		t.Fatalf("GetTransaction failed unexpected error: %v", err)
+	}
+	if tx.TransactionID != id {
+		t.Errorf("transaction id = %s, want %s", tx.TransactionID, id)
+	}
+	if calls := node.Calls("get_transaction_hex_without_sig"); calls != 0 {
+		t.Errorf("get_transaction_hex_without_sig called %d times, want 0", calls)
+	}
+}
+
 func TestWalletClient_GetTransaction(t *testing.T) {
 	tx, err := tw.Api.GetTransaction(1545399, 0)
 	if err != nil {
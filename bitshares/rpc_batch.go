@@ -0,0 +1,404 @@
+/*
+ * Copyright 2018 The OpenWallet Authors
+ * This file is part of the OpenWallet library.
+ *
+ * The OpenWallet library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The OpenWallet library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ */
+
+package bitshares
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/blocktree/bitshares-adapter/types"
+	"github.com/blocktree/openwallet/v2/log"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// maxBatchSize is the max number of requests sent in one batch
+	maxBatchSize = 100
+	// maxFullAccounts is the max number of accounts asked in one get_full_accounts,
+	// the default api_limit_get_full_accounts of the nodes
+	maxFullAccounts = 10
+	// maxBatchConcurrency is the max number of requests of a batch posted at once
+	// to a node without batch support
+	maxBatchConcurrency = 8
+)
+
+// RPCRequest is one call of a json-rpc batch
+type RPCRequest struct {
+	Method string
+	Params interface{}
+}
+
+// BatchCall sends the requests to one node in a single json-rpc 2.0 batch and
+// returns their results in order. Only websocket connections and http nodes
+// answering json arrays get a real batch: a witness_node over http rejects them,
+// so it is sent the requests as single posts, maxBatchConcurrency at once. The
+// first failed request fails the whole batch.
+func (c *WalletClient) BatchCall(requests ...RPCRequest) ([]*gjson.Result, error) {
+	return c.BatchCallContext(context.Background(), requests...)
+}
+
+// BatchCallContext is BatchCall bounded by ctx
+func (c *WalletClient) BatchCallContext(ctx context.Context, requests ...RPCRequest) ([]*gjson.Result, error) {
+	results := make([]*gjson.Result, 0, len(requests))
+
+	// cassettes keep every request of a batch on its own
+	if c.cassette != nil && c.cassette.Mode == CassetteReplay {
+		for _, req := range requests {
+			r, err := c.cassette.replay(req.Method, req.Params)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, r)
+		}
+		return results, nil
+	}
+
+	for start := 0; start < len(requests); start += maxBatchSize {
+		end := start + maxBatchSize
+		if end > len(requests) {
+			end = len(requests)
+		}
+		chunk := requests[start:end]
+
+		r, err := c.withRetry(ctx, "batch", func() (*gjson.Result, error) {
+			return c.pool.call(ctx, "batch", func(node *rpcNode) (*gjson.Result, error) {
+				return c.batchNode(ctx, node, chunk)
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for i, resp := range r.Array() {
+			if err := rpcError(resp); err != nil {
+				err.(*RPCError).Method = chunk[i].Method
+				if c.cassette != nil {
+					c.cassette.record(chunk[i].Method, chunk[i].Params, nil, err)
+				}
+				return nil, err
+			}
+			result := resp.Get("result")
+			if c.cassette != nil {
+				c.cassette.record(chunk[i].Method, chunk[i].Params, &result, nil)
+			}
+			results = append(results, &result)
+		}
+	}
+
+	return results, nil
+}
+
+// batchNode returns the whole responses of the requests as a json array, in order
+func (c *WalletClient) batchNode(ctx context.Context, node *rpcNode, requests []RPCRequest) (*gjson.Result, error) {
+	var responses []gjson.Result
+
+	ctx, cancel := c.Timeouts.withTimeout(ctx, "batch")
+	defer cancel()
+
+	if node.ws != nil {
+		resps, err := node.ws.Batch(ctx, "database", requests)
+		if err != nil {
+			return nil, err
+		}
+		responses = resps
+	} else {
+		resps, err := c.postBatch(ctx, node, requests)
+		if err != nil {
+			return nil, err
+		}
+		responses = resps
+	}
+
+	raw := make([]string, len(responses))
+	for i, resp := range responses {
+		raw[i] = resp.Raw
+	}
+	arr := gjson.Parse("[" + strings.Join(raw, ",") + "]")
+	return &arr, nil
+}
+
+// postBatch posts the requests as a json array. A node answering the array with
+// a json-rpc error does not support batches: it is remembered and sent the
+// requests one by one from then on.
+func (c *WalletClient) postBatch(ctx context.Context, node *rpcNode, requests []RPCRequest) ([]gjson.Result, error) {
+	if atomic.LoadInt32(&node.noBatch) == 0 {
+		body := make([]map[string]interface{}, len(requests))
+		for i, r := range requests {
+			body[i] = map[string]interface{}{
+				"jsonrpc": "2.0",
+				"id":      i,
+				"method":  r.Method,
+				"params":  r.Params,
+			}
+		}
+
+		r, err := c.postBody(ctx, node.URL, body)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := gjson.ParseBytes(r.Bytes())
+		switch {
+		case resp.IsArray():
+			responses := make([]gjson.Result, len(requests))
+			found := 0
+			for _, item := range resp.Array() {
+				id := item.Get("id").Int()
+				if id >= 0 && int(id) < len(responses) {
+					responses[id] = item
+					found++
+				}
+			}
+			if found != len(requests) {
+				return nil, fmt.Errorf("batch response has %d of %d results", found, len(requests))
+			}
+			return responses, nil
+		case resp.Get("error").IsObject():
+			if c.Debug {
+				log.Std.Info("node %s rejects batches: %v", node.URL, rpcError(resp))
+			}
+			atomic.StoreInt32(&node.noBatch, 1)
+		default:
+			// a page of a proxy or a node in trouble says nothing of batch support
+			if err := c.isError(r); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("invalid batch response: %s", r.Response().Status)
+		}
+	}
+
+	return c.postEach(ctx, node, requests)
+}
+
+// postEach posts the requests one by one, maxBatchConcurrency at once. The first
+// request failing to be answered cancels the others.
+func (c *WalletClient) postEach(ctx context.Context, node *rpcNode, requests []RPCRequest) ([]gjson.Result, error) {
+	var (
+		responses = make([]gjson.Result, len(requests))
+		slots     = make(chan struct{}, maxBatchConcurrency)
+		wg        sync.WaitGroup
+		mutex     sync.Mutex // protects failure
+		failure   error
+	)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for i, req := range requests {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int, req RPCRequest) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			resp, err := c.postOne(ctx, node, i, req)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				if failure == nil {
+					failure = err
+					cancel()
+				}
+				return
+			}
+			responses[i] = resp
+		}(i, req)
+	}
+	wg.Wait()
+
+	if failure != nil {
+		return nil, failure
+	}
+	return responses, nil
+}
+
+// postOne posts a request of a batch on its own and returns its whole response
+func (c *WalletClient) postOne(ctx context.Context, node *rpcNode, id int, req RPCRequest) (gjson.Result, error) {
+	body := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  req.Method,
+		"params":  req.Params,
+	}
+	r, err := c.postBody(ctx, node.URL, body)
+	if err != nil {
+		return gjson.Result{}, err
+	}
+	resp := gjson.ParseBytes(r.Bytes())
+	if !resp.Get("result").Exists() && !resp.Get("error").IsObject() {
+		if err := c.isError(r); err != nil {
+			return gjson.Result{}, err
+		}
+		return gjson.Result{}, fmt.Errorf("invalid response: %s", r.Response().Status)
+	}
+	return resp, nil
+}
+
+// GetAccountsBatch returns the accounts of the given names or ids, keyed by
+// both name and id, fetching at most maxBatchSize accounts per request.
+func (c *WalletClient) GetAccountsBatch(namesOrIDs ...string) (map[string]*types.Account, error) {
+	return c.GetAccountsBatchContext(context.Background(), namesOrIDs...)
+}
+
+// GetAccountsBatchContext is GetAccountsBatch bounded by ctx
+func (c *WalletClient) GetAccountsBatchContext(ctx context.Context, namesOrIDs ...string) (map[string]*types.Account, error) {
+	var (
+		requests = make([]RPCRequest, 0)
+		accounts = make(map[string]*types.Account)
+		unique   = make(map[string]bool)
+		keys     = make([]string, 0, len(namesOrIDs))
+	)
+
+	for _, key := range namesOrIDs {
+		if !unique[key] {
+			unique[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	for start := 0; start < len(keys); start += maxBatchSize {
+		end := start + maxBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		requests = append(requests, RPCRequest{Method: "get_accounts", Params: []interface{}{keys[start:end]}})
+	}
+
+	results, err := c.BatchCallContext(ctx, requests...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range results {
+		var list []*types.Account
+		if err := json.Unmarshal([]byte(r.Raw), &list); err != nil {
+			return nil, err
+		}
+		for _, a := range list {
+			if a == nil {
+				continue
+			}
+			accounts[a.ID.String()] = a
+			accounts[a.Name] = a
+		}
+	}
+
+	return accounts, nil
+}
+
+// GetAssetsBalanceBatch returns the balance of the asset for every account,
+// by name or id, in a single batch. Unknown accounts fail the whole batch.
+func (c *WalletClient) GetAssetsBalanceBatch(asset types.ObjectID, accounts ...string) ([]*Balance, error) {
+	return c.GetAssetsBalanceBatchContext(context.Background(), asset, accounts...)
+}
+
+// GetAssetsBalanceBatchContext is GetAssetsBalanceBatch bounded by ctx
+func (c *WalletClient) GetAssetsBalanceBatchContext(ctx context.Context, asset types.ObjectID, accounts ...string) ([]*Balance, error) {
+	requests := make([]RPCRequest, len(accounts))
+	for i, account := range accounts {
+		requests[i] = RPCRequest{
+			Method: "get_account_balances",
+			Params: []interface{}{account, []interface{}{asset.String()}},
+		}
+	}
+
+	results, err := c.BatchCallContext(ctx, requests...)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]*Balance, len(results))
+	for i, r := range results {
+		balances[i] = NewBalance(r)
+	}
+	return balances, nil
+}
+
+// GetAccountsBalancesBatch returns every non zero balance of the accounts, by
+// name or id, in a single batch. Unknown accounts fail the whole batch.
+func (c *WalletClient) GetAccountsBalancesBatch(accounts ...string) ([][]*Balance, error) {
+	return c.GetAccountsBalancesBatchContext(context.Background(), accounts...)
+}
+
+// GetAccountsBalancesBatchContext is GetAccountsBalancesBatch bounded by ctx
+func (c *WalletClient) GetAccountsBalancesBatchContext(ctx context.Context, accounts ...string) ([][]*Balance, error) {
+	requests := make([]RPCRequest, len(accounts))
+	for i, account := range accounts {
+		requests[i] = RPCRequest{
+			Method: "get_account_balances",
+			Params: []interface{}{account, []interface{}{}},
+		}
+	}
+
+	results, err := c.BatchCallContext(ctx, requests...)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([][]*Balance, len(results))
+	for i, r := range results {
+		balances[i] = NewBalances(r)
+	}
+	return balances, nil
+}
+
+// GetFullBalances returns the balances of the accounts, by name or id, keyed by
+// name or id as given, including the funds locked in limit orders, call orders
+// and vesting balances. It asks get_full_accounts, at most maxFullAccounts
+// accounts per request, in a single batch. Unknown accounts fail the call.
+func (c *WalletClient) GetFullBalances(accounts ...string) (map[string][]*FullBalance, error) {
+	return c.GetFullBalancesContext(context.Background(), accounts...)
+}
+
+// GetFullBalancesContext is GetFullBalances bounded by ctx
+func (c *WalletClient) GetFullBalancesContext(ctx context.Context, accounts ...string) (map[string][]*FullBalance, error) {
+	requests := make([]RPCRequest, 0)
+	for start := 0; start < len(accounts); start += maxFullAccounts {
+		end := start + maxFullAccounts
+		if end > len(accounts) {
+			end = len(accounts)
+		}
+		requests = append(requests, RPCRequest{Method: "get_full_accounts", Params: []interface{}{accounts[start:end], false}})
+	}
+
+	results, err := c.BatchCallContext(ctx, requests...)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make(map[string][]*FullBalance, len(accounts))
+	for _, r := range results {
+		for _, pair := range r.Array() {
+			full := pair.Get("1")
+			list, err := NewFullBalances(&full)
+			if err != nil {
+				return nil, fmt.Errorf("balances of %s: %v", pair.Get("0").String(), err)
+			}
+			balances[pair.Get("0").String()] = list
+		}
+	}
+	for _, account := range accounts {
+		if _, ok := balances[account]; !ok {
+			return nil, &RPCError{Kind: RPCErrorAccountNotFound, Method: "get_full_accounts", Message: fmt.Sprintf("account %s does not exist", account)}
+		}
+	}
+	return balances, nil
+}
@@ -0,0 +1,177 @@
+package bitshares
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testBatchNode answers get_accounts with one account per requested id,
+// rejecting json arrays unless batch is true
+func testBatchNode(batch bool, posts *int32) *httptest.Server {
+	account := func(id string) string {
+		return fmt.Sprintf(`{"id":"%s","name":"name-%s","options":{"memo_key":"","voting_account":"1.2.5"}}`, id, id)
+	}
+	answer := func(req map[string]interface{}) string {
+		ids := req["params"].([]interface{})[0].([]interface{})
+		accounts := make([]string, len(ids))
+		for i, id := range ids {
+			accounts[i] = account(id.(string))
+		}
+		return fmt.Sprintf(`{"id":%v,"jsonrpc":"2.0","result":[%s]}`, req["id"], strings.Join(accounts, ","))
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(posts, 1)
+		body, _ := ioutil.ReadAll(r.Body)
+		if strings.HasPrefix(string(body), "[") {
+			if !batch {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, `{"id":0,"jsonrpc":"2.0","error":{"code":0,"message":"Bad Cast:Invalid cast from array_type to Object"}}`)
+				return
+			}
+			var reqs []map[string]interface{}
+			json.Unmarshal(body, &reqs)
+			answers := make([]string, len(reqs))
+			for i := range reqs {
+				answers[len(reqs)-1-i] = answer(reqs[i])
+			}
+			fmt.Fprintf(w, "[%s]", strings.Join(answers, ","))
+			return
+		}
+		var req map[string]interface{}
+		json.Unmarshal(body, &req)
+		fmt.Fprint(w, answer(req))
+	}))
+}
+
+func TestWalletClient_GetAccountsBatch(t *testing.T) {
+	ids := make([]string, 0)
+	for i := 0; i < 250; i++ {
+		ids = append(ids, fmt.Sprintf("1.2.%d", i), fmt.Sprintf("1.2.%d", i))
+	}
+
+	for _, batch := range []bool{true, false} {
+		var posts int32
+		server := testBatchNode(batch, &posts)
+
+		c := NewWalletClient(server.URL, "", false)
+		accounts, err := c.GetAccountsBatch(ids...)
+		if err != nil {
+			t.Fatalf("GetAccountsBatch failed unexpected error: %v", err)
+		}
+		if len(accounts) != 500 {
+			t.Errorf("accounts = %d, want 500 keyed by id and name", len(accounts))
+		}
+		if a := accounts["1.2.42"]; a == nil || a.Name != "name-1.2.42" {
+			t.Errorf("wrong account of 1.2.42: %+v", a)
+		}
+
+		// 250 unique ids in 3 get_accounts requests sent in one batch
+		want := int32(1)
+		if !batch {
+			want = 1 + 3
+		}
+		if n := atomic.LoadInt32(&posts); n != want {
+			t.Errorf("batch=%v: %d posts, want %d", batch, n, want)
+		}
+		server.Close()
+	}
+}
+
+func TestWalletClient_BatchConcurrentPosts(t *testing.T) {
+	var posts, inflight, most int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&posts, 1)
+		body, _ := ioutil.ReadAll(r.Body)
+		if strings.HasPrefix(string(body), "[") {
+			// a witness_node over http
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"id":0,"jsonrpc":"2.0","error":{"code":0,"message":"Bad Cast:Invalid cast from array_type to Object"}}`)
+			return
+		}
+		n := atomic.AddInt32(&inflight, 1)
+		defer atomic.AddInt32(&inflight, -1)
+		for m := atomic.LoadInt32(&most); n > m && !atomic.CompareAndSwapInt32(&most, m, n); m = atomic.LoadInt32(&most) {
+		}
+		time.Sleep(20 * time.Millisecond)
+		var req map[string]interface{}
+		json.Unmarshal(body, &req)
+		fmt.Fprintf(w, `{"id":%v,"jsonrpc":"2.0","result":%v}`, req["id"], req["params"].([]interface{})[0])
+	}))
+	defer server.Close()
+
+	c := NewWalletClient(server.URL, "", false)
+	requests := make([]RPCRequest, 20)
+	for i := range requests {
+		requests[i] = RPCRequest{Method: "echo", Params: []interface{}{i}}
+	}
+
+	for round := 0; round < 2; round++ {
+		results, err := c.BatchCall(requests...)
+		if err != nil {
+			t.Fatalf("BatchCall failed unexpected error: %v", err)
+		}
+		for i, r := range results {
+			if r.Int() != int64(i) {
+				t.Errorf("result %d = %s", i, r.Raw)
+			}
+		}
+	}
+
+	// the array is tried once, then the requests are posted concurrently
+	if n := atomic.LoadInt32(&posts); n != 1+2*20 {
+		t.Errorf("%d posts, want %d", n, 1+2*20)
+	}
+	if m := atomic.LoadInt32(&most); m < 2 || m > maxBatchConcurrency {
+		t.Errorf("%d requests posted at once, want 2 to %d", m, maxBatchConcurrency)
+	}
+}
+
+func TestWalletClient_BatchBadGateway(t *testing.T) {
+	var posts, arrays int32
+	server := testBatchNode(true, &posts)
+	defer server.Close()
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if strings.HasPrefix(string(body), "[") && atomic.AddInt32(&arrays, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			fmt.Fprint(w, "<html><body><h1>502 Bad Gateway</h1></body></html>")
+			return
+		}
+		resp, err := http.Post(server.URL, "application/json", strings.NewReader(string(body)))
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	}))
+	defer proxy.Close()
+
+	c := NewWalletClient(proxy.URL, "", false)
+	c.RetryPolicy = RetryPolicy{MaxAttempts: 1}
+	if _, err := c.GetAccountsBatch("1.2.1", "1.2.2"); err == nil {
+		t.Errorf("a 502 page answered a batch")
+	}
+
+	// the passing 502 does not make the node a node without batch support
+	if c.pool.node(proxy.URL).noBatch != 0 {
+		t.Fatalf("node marked without batch support after a 502 page")
+	}
+	atomic.StoreInt32(&posts, 0)
+	if _, err := c.GetAccountsBatch("1.2.1", "1.2.2"); err != nil {
+		t.Fatalf("GetAccountsBatch failed unexpected error: %v", err)
+	}
+	if n := atomic.LoadInt32(&posts); n != 1 {
+		t.Errorf("%d posts, want 1 batch", n)
+	}
+}
@@ -0,0 +1,222 @@
+/*
+ * Copyright 2018 The OpenWallet Authors
+ * This file is part of the OpenWallet library.
+ *
+ * The OpenWallet library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The OpenWallet library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ */
+
+package bitshares
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/tidwall/gjson"
+)
+
+// CassetteMode tells what a cassette does with the calls of a WalletClient
+type CassetteMode int
+
+const (
+	CassetteOff    CassetteMode = iota // calls go to the nodes
+	CassetteRecord                     // calls go to the nodes and are written to the cassette
+	CassetteReplay                     // calls are answered from the cassette, without network
+)
+
+// ParseCassetteMode parses "", "off", "record" or "replay"
+func ParseCassetteMode(mode string) (CassetteMode, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "off":
+		return CassetteOff, nil
+	case "record":
+		return CassetteRecord, nil
+	case "replay":
+		return CassetteReplay, nil
+	}
+	return CassetteOff, fmt.Errorf("unknown cassette mode: %s", mode)
+}
+
+// Interaction is a call and its answer, one json object per line of a cassette file
+type Interaction struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+// Cassette records the calls of a WalletClient to a file and replays them.
+// Identical calls are replayed in the order they were recorded; once the
+// recorded answers are used up, the last one is served again.
+type Cassette struct {
+	Path string
+	Mode CassetteMode
+
+	mutex        sync.Mutex // protects the following
+	interactions map[string][]*Interaction
+	served       map[string]int
+	file         *os.File
+}
+
+// NewCassette opens a cassette file. Recording truncates the file, replaying
+// reads it whole.
+func NewCassette(path string, mode CassetteMode) (*Cassette, error) {
+	c := Cassette{
+		Path:         path,
+		Mode:         mode,
+		interactions: make(map[string][]*Interaction),
+		served:       make(map[string]int),
+	}
+
+	switch mode {
+	case CassetteRecord:
+		file, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		c.file = file
+	case CassetteReplay:
+		if err := c.load(); err != nil {
+			return nil, err
+		}
+	}
+	return &c, nil
+}
+
+// Close closes the cassette file
+func (c *Cassette) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.file == nil {
+		return nil
+	}
+	err := c.file.Close()
+	c.file = nil
+	return err
+}
+
+func (c *Cassette) load() error {
+	file, err := os.Open(c.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var it Interaction
+		if err := json.Unmarshal(scanner.Bytes(), &it); err != nil {
+			return fmt.Errorf("cassette %s line %d: %v", c.Path, line, err)
+		}
+		key := cassetteKey(it.Method, it.Params)
+		c.interactions[key] = append(c.interactions[key], &it)
+	}
+	return scanner.Err()
+}
+
+// cassetteKey identifies a call by its method and compacted params
+func cassetteKey(method string, params json.RawMessage) string {
+	var b bytes.Buffer
+	if err := json.Compact(&b, params); err != nil {
+		return method + " " + string(params)
+	}
+	return method + " " + b.String()
+}
+
+// record writes a call answered by a node. Calls that did not reach a node
+// are not recorded.
+func (c *Cassette) record(method string, params interface{}, result *gjson.Result, err error) {
+	if err != nil {
+		if _, ok := err.(*RPCError); !ok {
+			return
+		}
+	}
+
+	raw, merr := json.Marshal(params)
+	if merr != nil {
+		return
+	}
+	it := Interaction{Method: method, Params: raw}
+	if err != nil {
+		it.Error, _ = json.Marshal(err.(*RPCError).object())
+	} else if result != nil {
+		it.Result = json.RawMessage(result.Raw)
+		if len(it.Result) == 0 {
+			it.Result = json.RawMessage("null")
+		}
+	}
+
+	line, merr := json.Marshal(&it)
+	if merr != nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.file != nil {
+		c.file.Write(append(line, '\n'))
+	}
+}
+
+// replay answers a call from the recorded interactions
+func (c *Cassette) replay(method string, params interface{}) (*gjson.Result, error) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	key := cassetteKey(method, raw)
+
+	c.mutex.Lock()
+	list := c.interactions[key]
+	i := c.served[key]
+	if i < len(list)-1 {
+		c.served[key] = i + 1
+	}
+	c.mutex.Unlock()
+
+	if len(list) == 0 {
+		return nil, fmt.Errorf("cassette %s has no answer to %s", c.Path, key)
+	}
+
+	it := list[i]
+	if len(it.Error) > 0 {
+		e := NewRPCError(gjson.ParseBytes(it.Error))
+		e.Method = method
+		return nil, e
+	}
+	result := gjson.ParseBytes(it.Result)
+	return &result, nil
+}
+
+// object returns the error in the graphene format parsed by NewRPCError
+func (e *RPCError) object() map[string]interface{} {
+	stack := make([]map[string]string, 0, len(e.Stack))
+	for _, format := range e.Stack {
+		stack = append(stack, map[string]string{"format": format})
+	}
+	return map[string]interface{}{
+		"code":    e.Code,
+		"message": e.Message,
+		"data": map[string]interface{}{
+			"code":  e.ExceptionCode,
+			"name":  e.Name,
+			"stack": stack,
+		},
+	}
+}
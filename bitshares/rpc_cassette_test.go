@@ -0,0 +1,73 @@
+package bitshares
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/blocktree/bitshares-adapter/bitsharestest"
+	"github.com/blocktree/bitshares-adapter/types"
+)
+
+func TestWalletClient_Cassette(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	core := types.MustParseObjectID(bitsharestest.CoreAssetID)
+
+	node := bitsharestest.NewNode()
+	node.CreateAccount("alice", "")
+	node.CreateAccount("bob", "")
+	node.SetBalance("alice", bitsharestest.CoreAssetID, 100000)
+	node.Transfer("alice", "bob", bitsharestest.CoreAssetID, 1000)
+	block := node.MintBlock()
+
+	// record a session
+	recorder, err := NewCassette(path, CassetteRecord)
+	if err != nil {
+		t.Fatalf("NewCassette failed unexpected error: %v", err)
+	}
+	c := NewWalletClient(node.URL, node.URL, false)
+	c.SetCassette(recorder)
+
+	recorded, err := c.GetBlockByHeight(block.Height)
+	if err != nil {
+		t.Fatalf("GetBlockByHeight failed unexpected error: %v", err)
+	}
+	if _, err := c.GetAccountsBatch("alice", "bob"); err != nil {
+		t.Fatalf("GetAccountsBatch failed unexpected error: %v", err)
+	}
+	_, recordedErr := c.GetAssetsBalanceBatch(core, "nobody")
+	if recordedErr == nil {
+		t.Fatalf("balance of unknown account should fail")
+	}
+	c.Close()
+	node.Close()
+
+	// replay it without the node
+	player, err := NewCassette(path, CassetteReplay)
+	if err != nil {
+		t.Fatalf("NewCassette failed unexpected error: %v", err)
+	}
+	c = NewWalletClient(node.URL, "", false)
+	c.SetCassette(player)
+
+	replayed, err := c.GetBlockByHeight(block.Height)
+	if err != nil {
+		t.Fatalf("replay GetBlockByHeight failed unexpected error: %v", err)
+	}
+	if replayed.BlockID != recorded.BlockID || len(replayed.Transactions) != 1 {
+		t.Errorf("replayed block %s with %d transactions, want %s", replayed.BlockID, len(replayed.Transactions), recorded.BlockID)
+	}
+
+	accounts, err := c.GetAccountsBatch("alice", "bob")
+	if err != nil || accounts["bob"] == nil {
+		t.Errorf("replay GetAccountsBatch = %v, %v", accounts, err)
+	}
+
+	_, err = c.GetAssetsBalanceBatch(core, "nobody")
+	if err == nil || err.Error() != recordedErr.Error() || ErrorKind(err) != ErrorKind(recordedErr) {
+		t.Errorf("replayed error %v, want %v", err, recordedErr)
+	}
+
+	if _, err := c.GetBlockByHeight(block.Height + 1); err == nil {
+		t.Errorf("call not recorded should fail")
+	}
+}
@@ -0,0 +1,207 @@
+/*
+ * Copyright 2018 The OpenWallet Authors
+ * This file is part of the OpenWallet library.
+ *
+ * The OpenWallet library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The OpenWallet library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ */
+
+package bitshares
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blocktree/openwallet/v2/openwallet"
+	"github.com/tidwall/gjson"
+)
+
+// RPCErrorKind classifies the failures a caller may want to handle differently
+type RPCErrorKind int
+
+const (
+	RPCErrorUnknown             RPCErrorKind = iota // any other error answered by the node
+	RPCErrorNetwork                                 // the node could not be reached or answered garbage
+	RPCErrorAccountNotFound                         // the account does not exist
+	RPCErrorTxExpired                               // the transaction expired or its reference block is unknown
+	RPCErrorInsufficientFee                         // the fee paid is too low
+	RPCErrorInsufficientBalance                     // the account cannot pay the amount
+	RPCErrorDuplicateTx                             // the transaction is already known to the node
+	RPCErrorMissingAuth                             // the signatures do not satisfy the authorities
+)
+
+// rpcErrorKinds maps graphene exception names and message fragments to error kinds.
+// Names are checked first, then the message and the stack formats.
+var rpcErrorKinds = []struct {
+	kind      RPCErrorKind
+	names     []string
+	fragments []string
+}{
+	{RPCErrorMissingAuth, []string{"tx_missing_active_auth", "tx_missing_owner_auth", "tx_missing_other_auth", "tx_irrelevant_sig", "tx_duplicate_sig", "tx_irrelevant_approval"}, []string{"missing required active authority", "missing required owner authority", "missing required other authority"}},
+	{RPCErrorInsufficientFee, []string{"insufficient_fee"}, []string{"insufficient fee"}},
+	{RPCErrorInsufficientBalance, []string{"insufficient_balance", "transfer_insufficient_balance"}, []string{"insufficient balance", "insufficient_balance"}},
+	{RPCErrorDuplicateTx, []string{"duplicate_transaction"}, []string{"duplicate transaction", "trx_idx.indices().get<by_trx_id>().find(trx_id)"}},
+	{RPCErrorTxExpired, []string{"tx_expired", "invalid_ref_block"}, []string{"trx.expiration", "transaction expiration", "tapos", "ref_block"}},
+	{RPCErrorAccountNotFound, []string{"unknown_account"}, []string{"no such account", "unable to find account", "account not found", "unable to find object", "unknown account"}},
+}
+
+// RPCError is an error answered by a node, parsed from the graphene error object:
+//
+//	{"code":1,"message":"...","data":{"code":3030001,"name":"tx_missing_active_auth","message":"...","stack":[{"format":"..."}]}}
+type RPCError struct {
+	Method        string       // the rpc method called, empty if unknown
+	Code          int64        // json-rpc error code
+	Message       string       // json-rpc error message
+	ExceptionCode int64        // fc exception code
+	Name          string       // fc exception name, e.g. tx_missing_active_auth
+	Stack         []string     // the formats of the exception stack, innermost first
+	Kind          RPCErrorKind // classification of the error
+}
+
+func (e *RPCError) Error() string {
+	msg := e.Message
+	if len(e.Name) > 0 && !strings.Contains(msg, e.Name) {
+		msg = e.Name + ": " + msg
+	}
+	if len(e.Method) > 0 {
+		return fmt.Sprintf("[%d]%s: %s", e.Code, e.Method, msg)
+	}
+	return fmt.Sprintf("[%d]%s", e.Code, msg)
+}
+
+// NewRPCError parses the error object of a json-rpc response
+func NewRPCError(obj gjson.Result) *RPCError {
+	e := RPCError{
+		Code:          obj.Get("code").Int(),
+		Message:       obj.Get("message").String(),
+		ExceptionCode: obj.Get("data.code").Int(),
+		Name:          obj.Get("data.name").String(),
+	}
+	for _, frame := range obj.Get("data.stack").Array() {
+		e.Stack = append(e.Stack, frame.Get("format").String())
+	}
+	e.Kind = classifyRPCError(&e)
+	return &e
+}
+
+func classifyRPCError(e *RPCError) RPCErrorKind {
+	for _, k := range rpcErrorKinds {
+		for _, name := range k.names {
+			if e.Name == name {
+				return k.kind
+			}
+		}
+	}
+
+	text := strings.ToLower(e.Message + "\n" + strings.Join(e.Stack, "\n"))
+	for _, k := range rpcErrorKinds {
+		for _, fragment := range k.fragments {
+			if strings.Contains(text, strings.ToLower(fragment)) {
+				return k.kind
+			}
+		}
+	}
+	return RPCErrorUnknown
+}
+
+// ErrorKind returns the kind of any error returned by WalletClient. Errors
+// not answered by a node are network errors.
+func ErrorKind(err error) RPCErrorKind {
+	if err == nil {
+		return RPCErrorUnknown
+	}
+	if e, ok := err.(*RPCError); ok {
+		return e.Kind
+	}
+	return RPCErrorNetwork
+}
+
+// Error codes of the failures openwallet has no code for, in the range of the
+// openwallet transaction errors
+const (
+	ErrTransactionExpired   = 2101 // the transaction expired or its reference block is unknown
+	ErrDuplicateTransaction = 2102 // the transaction is already known to the node
+)
+
+// ConvertRPCError converts an error of WalletClient to an openwallet error.
+// defaultCode is used for the errors without a more specific code.
+func ConvertRPCError(err error, defaultCode uint64, prefix string) *openwallet.Error {
+	if err == nil {
+		return nil
+	}
+	if owErr, ok := err.(*openwallet.Error); ok {
+		return owErr
+	}
+
+	code := defaultCode
+	switch ErrorKind(err) {
+	case RPCErrorNetwork:
+		code = openwallet.ErrNetworkRequestFailed
+	case RPCErrorAccountNotFound:
+		code = openwallet.ErrAccountNotFound
+	case RPCErrorInsufficientFee:
+		code = openwallet.ErrInsufficientFees
+	case RPCErrorInsufficientBalance:
+		code = openwallet.ErrInsufficientBalanceOfAccount
+	case RPCErrorMissingAuth:
+		code = openwallet.ErrVerifyRawTransactionFailed
+	case RPCErrorTxExpired:
+		code = ErrTransactionExpired
+	case RPCErrorDuplicateTx:
+		code = ErrDuplicateTransaction
+	}
+
+	if len(prefix) > 0 {
+		return openwallet.Errorf(code, "%s: %v", prefix, err)
+	}
+	return openwallet.Errorf(code, "%v", err)
+}
+
+// RetryPolicy retries the transient failures of idempotent calls with an exponential backoff
+type RetryPolicy struct {
+	MaxAttempts    int           // total attempts, 1 disables retries
+	InitialBackoff time.Duration // wait before the first retry
+	MaxBackoff     time.Duration // upper bound of the wait
+	Multiplier     float64       // growth of the wait after each retry
+}
+
+// DefaultRetryPolicy is used by new clients, the retry* settings of the config override it
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+	Multiplier:     2,
+}
+
+// nonIdempotentMethods must never be sent twice
+var nonIdempotentMethods = map[string]bool{
+	"broadcast_transaction":               true,
+	"broadcast_transaction_synchronous":   true,
+	"broadcast_transaction_with_callback": true,
+	"broadcast_block":                     true,
+}
+
+// shouldRetry returns true when a failed call can safely be sent again
+func (p RetryPolicy) shouldRetry(method string, attempt int, err error) bool {
+	return attempt < p.MaxAttempts && !nonIdempotentMethods[method] && ErrorKind(err) == RPCErrorNetwork
+}
+
+// backoff returns the wait before the given retry, starting from 1
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 1; i < retry; i++ {
+		d *= p.Multiplier
+	}
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		return p.MaxBackoff
+	}
+	return time.Duration(d)
+}
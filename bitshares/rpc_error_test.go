@@ -0,0 +1,149 @@
+package bitshares
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/astaxie/beego/config"
+	"github.com/blocktree/openwallet/v2/openwallet"
+	"github.com/tidwall/gjson"
+)
+
+func TestNewRPCError(t *testing.T) {
+	tests := []struct {
+		raw  string
+		kind RPCErrorKind
+		code uint64
+	}{
+		{
+			raw:  `{"code":1,"message":"missing required active authority: Missing Active Authority 1.2.100","data":{"code":3030001,"name":"tx_missing_active_auth","message":"missing required active authority","stack":[{"format":"Missing Active Authority ${id}"}]}}`,
+			kind: RPCErrorMissingAuth,
+			code: openwallet.ErrVerifyRawTransactionFailed,
+		},
+		{
+			raw:  `{"code":1,"message":"Assert Exception: insufficient fee paid","data":{"code":10,"name":"assert_exception","message":"Assert Exception","stack":[{"format":"core_fee_paid >= required_core_fee: Insufficient Fee Paid"}]}}`,
+			kind: RPCErrorInsufficientFee,
+			code: openwallet.ErrInsufficientFees,
+		},
+		{
+			raw:  `{"code":1,"message":"Insufficient Balance: alice's balance of 1 BTS is less than required 2 BTS","data":{"code":3050001,"name":"insufficient_balance"}}`,
+			kind: RPCErrorInsufficientBalance,
+			code: openwallet.ErrInsufficientBalanceOfAccount,
+		},
+		{
+			raw:  `{"code":1,"message":"Assert Exception: now <= trx.expiration: ","data":{"code":10,"name":"assert_exception"}}`,
+			kind: RPCErrorTxExpired,
+			code: ErrTransactionExpired,
+		},
+		{
+			raw:  `{"code":1,"message":"transaction expiration exception","data":{"code":3030004,"name":"tx_expired"}}`,
+			kind: RPCErrorTxExpired,
+			code: ErrTransactionExpired,
+		},
+		{
+			raw:  `{"code":1,"message":"Assert Exception: tapos_block_summary.block_id._hash[1] == trx.ref_block_prefix: ","data":{"code":10,"name":"assert_exception","stack":[{"format":"transaction tapos exception"}]}}`,
+			kind: RPCErrorTxExpired,
+			code: ErrTransactionExpired,
+		},
+		{
+			raw:  `{"code":1,"message":"Assert Exception: trx_idx.indices().get<by_trx_id>().find(trx_id) == trx_idx.indices().get<by_trx_id>().end(): ","data":{"code":10,"name":"assert_exception"}}`,
+			kind: RPCErrorDuplicateTx,
+			code: ErrDuplicateTransaction,
+		},
+		{
+			raw:  `{"code":1,"message":"duplicate transaction","data":{"code":3030008,"name":"duplicate_transaction"}}`,
+			kind: RPCErrorDuplicateTx,
+			code: ErrDuplicateTransaction,
+		},
+		{
+			raw:  `{"code":1,"message":"Bad Cast:Invalid cast from string_type to Array","data":{"code":7,"name":"bad_cast_exception"}}`,
+			kind: RPCErrorUnknown,
+			code: openwallet.ErrSubmitRawTransactionFailed,
+		},
+	}
+
+	for i, test := range tests {
+		err := NewRPCError(gjson.Parse(test.raw))
+		if err.Kind != test.kind {
+			t.Errorf("case %d: kind = %d, want %d: %v", i, err.Kind, test.kind, err)
+		}
+		if owErr := ConvertRPCError(err, openwallet.ErrSubmitRawTransactionFailed, "push transaction"); owErr.Code() != test.code {
+			t.Errorf("case %d: code = %d, want %d", i, owErr.Code(), test.code)
+		}
+	}
+
+	if kind := ErrorKind(fmt.Errorf("connection refused")); kind != RPCErrorNetwork {
+		t.Errorf("plain error kind = %d, want network", kind)
+	}
+}
+
+func TestWalletClient_RetryPolicy(t *testing.T) {
+	var posts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&posts, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `{"id":1,"jsonrpc":"2.0","result":{"head_block_number":100}}`)
+	}))
+	defer server.Close()
+
+	c := NewWalletClient(server.URL, "", false)
+	c.RetryPolicy = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Multiplier: 2}
+
+	if _, err := c.call("get_dynamic_global_properties", []interface{}{}); err != nil {
+		t.Fatalf("call failed unexpected error: %v", err)
+	}
+	if n := atomic.LoadInt32(&posts); n != 3 {
+		t.Errorf("%d posts, want 3", n)
+	}
+
+	// broadcasts are never sent twice
+	atomic.StoreInt32(&posts, 0)
+	if _, err := c.call("broadcast_transaction", []interface{}{}); err == nil {
+		t.Errorf("broadcast should fail")
+	}
+	if n := atomic.LoadInt32(&posts); n != 1 {
+		t.Errorf("broadcast: %d posts, want 1", n)
+	}
+}
+
+func TestWalletManager_LoadRetryPolicy(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "bts")
+	if err != nil {
+		t.Fatalf("TempDir failed unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dataDir)
+
+	tests := []struct {
+		ini  string
+		want RetryPolicy
+	}{
+		{"", DefaultRetryPolicy},
+		{
+			"retryMaxAttempts = 5\nretryInitialBackoff = 100\nretryMaxBackoff = 2000\nretryMultiplier = 1.5\n",
+			RetryPolicy{MaxAttempts: 5, InitialBackoff: 100 * time.Millisecond, MaxBackoff: 2 * time.Second, Multiplier: 1.5},
+		},
+		{"retryMaxAttempts = 1\n", RetryPolicy{MaxAttempts: 1, InitialBackoff: DefaultRetryPolicy.InitialBackoff, MaxBackoff: DefaultRetryPolicy.MaxBackoff, Multiplier: DefaultRetryPolicy.Multiplier}},
+	}
+	for i, test := range tests {
+		c, err := config.NewConfigData("ini", []byte(test.ini+"dataDir = "+dataDir+"\n"))
+		if err != nil {
+			t.Fatalf("case %d: NewConfigData failed unexpected error: %v", i, err)
+		}
+		wm := NewWalletManager(nil)
+		if err := wm.LoadAssetsConfig(c); err != nil {
+			t.Fatalf("case %d: LoadAssetsConfig failed unexpected error: %v", i, err)
+		}
+		if wm.Config.RetryPolicy != test.want || wm.Api.RetryPolicy != test.want {
+			t.Errorf("case %d: retry policy = %+v, client %+v, want %+v", i, wm.Config.RetryPolicy, wm.Api.RetryPolicy, test.want)
+		}
+		wm.Close()
+	}
+}
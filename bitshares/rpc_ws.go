@@ -0,0 +1,397 @@
+/*
+ * Copyright 2018 The OpenWallet Authors
+ * This file is part of the OpenWallet library.
+ *
+ * The OpenWallet library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The OpenWallet library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ */
+
+package bitshares
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/blocktree/openwallet/v2/log"
+	"github.com/tidwall/gjson"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	wsDialTimeout       = 5 * time.Second
+	wsCallTimeout       = 30 * time.Second
+	wsMinReconnectDelay = 1 * time.Second
+	wsMaxReconnectDelay = 30 * time.Second
+)
+
+// WSClient is a persistent websocket JSON-RPC connection to a graphene node.
+// Besides plain calls it keeps track of the subscriptions made through it,
+// reconnects automatically when the connection drops and installs the
+// subscriptions again once the node is reachable.
+type WSClient struct {
+	URL   string
+	Debug bool
+
+	mutex     sync.Mutex // protects the following
+	conn      *websocket.Conn
+	requestID uint64
+	pending   map[uint64]chan gjson.Result
+	closed    bool
+
+	subMutex      sync.Mutex // protects the following
+	callbackID    uint64
+	subscriptions map[uint64]*wsSubscription
+}
+
+// wsSubscription is a subscription installed on the node, kept so that it can
+// be installed again after a reconnect.
+type wsSubscription struct {
+	method   string
+	params   []interface{}
+	callback func(notice gjson.Result)
+}
+
+// NewWSClient init a websocket rpc client
+func NewWSClient(url string, debug bool) *WSClient {
+	ws := WSClient{
+		URL:           url,
+		Debug:         debug,
+		pending:       make(map[uint64]chan gjson.Result),
+		subscriptions: make(map[uint64]*wsSubscription),
+	}
+	return &ws
+}
+
+// Connect dials the node. Once connected, the client keeps the connection
+// alive by itself until Close is called.
+func (ws *WSClient) Connect() error {
+	ws.mutex.Lock()
+	ws.closed = false
+	ws.mutex.Unlock()
+
+	return ws.dial()
+}
+
+// Close shuts the connection down and stops reconnecting.
+func (ws *WSClient) Close() error {
+	ws.mutex.Lock()
+	ws.closed = true
+	conn := ws.conn
+	ws.mutex.Unlock()
+
+	if conn != nil {
+		return conn.Close()
+	}
+	return nil
+}
+
+// IsConnected returns true while the connection to the node is up
+func (ws *WSClient) IsConnected() bool {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+	return ws.conn != nil
+}
+
+func (ws *WSClient) dial() error {
+	config, err := websocket.NewConfig(ws.URL, "http://localhost/")
+	if err != nil {
+		return err
+	}
+	config.Dialer = &net.Dialer{Timeout: wsDialTimeout}
+
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		return err
+	}
+
+	ws.mutex.Lock()
+	if ws.closed {
+		ws.mutex.Unlock()
+		conn.Close()
+		return fmt.Errorf("websocket client is closed")
+	}
+	ws.conn = conn
+	ws.mutex.Unlock()
+
+	go ws.receive(conn)
+
+	if ws.Debug {
+		log.Std.Info("websocket connected: %s", ws.URL)
+	}
+	return nil
+}
+
+// receive reads messages until the connection fails, then reconnects
+func (ws *WSClient) receive(conn *websocket.Conn) {
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(conn, &data); err != nil {
+			ws.disconnect(conn, err)
+			return
+		}
+
+		if ws.Debug {
+			log.Std.Info("websocket received: %s", data)
+		}
+
+		msg := gjson.ParseBytes(data)
+		if msg.Get("method").String() == "notice" {
+			ws.notify(msg.Get("params"))
+			continue
+		}
+
+		id := msg.Get("id").Uint()
+		ws.mutex.Lock()
+		ch, ok := ws.pending[id]
+		delete(ws.pending, id)
+		ws.mutex.Unlock()
+
+		if ok {
+			ch <- msg
+		}
+	}
+}
+
+// disconnect drops the broken connection, fails every pending call and starts
+// reconnecting unless the client has been closed.
+func (ws *WSClient) disconnect(conn *websocket.Conn, cause error) {
+	ws.mutex.Lock()
+	if ws.conn == conn {
+		ws.conn = nil
+	}
+	pending := ws.pending
+	ws.pending = make(map[uint64]chan gjson.Result)
+	closed := ws.closed
+	ws.mutex.Unlock()
+
+	conn.Close()
+
+	for _, ch := range pending {
+		close(ch)
+	}
+
+	if closed {
+		return
+	}
+
+	log.Std.Warning("websocket connection to %s lost: %v", ws.URL, cause)
+	go ws.reconnect()
+}
+
+// reconnect dials the node with an exponential backoff and installs the
+// subscriptions again.
+func (ws *WSClient) reconnect() {
+	delay := wsMinReconnectDelay
+	for {
+		ws.mutex.Lock()
+		closed := ws.closed
+		ws.mutex.Unlock()
+		if closed {
+			return
+		}
+
+		if err := ws.dial(); err == nil {
+			break
+		} else {
+			log.Std.Warning("websocket reconnect to %s failed: %v, retry in %v", ws.URL, err, delay)
+		}
+
+		time.Sleep(delay)
+		delay *= 2
+		if delay > wsMaxReconnectDelay {
+			delay = wsMaxReconnectDelay
+		}
+	}
+
+	ws.subMutex.Lock()
+	subscriptions := make([]*wsSubscription, 0, len(ws.subscriptions))
+	for _, sub := range ws.subscriptions {
+		subscriptions = append(subscriptions, sub)
+	}
+	ws.subMutex.Unlock()
+
+	for _, sub := range subscriptions {
+		if _, err := ws.Call("database", sub.method, sub.params); err != nil {
+			log.Std.Error("websocket resubscribe %s failed: %v", sub.method, err)
+		}
+	}
+}
+
+// notify dispatches a notice to the subscription it belongs to
+func (ws *WSClient) notify(params gjson.Result) {
+	arr := params.Array()
+	if len(arr) != 2 {
+		return
+	}
+
+	ws.subMutex.Lock()
+	sub, ok := ws.subscriptions[arr[0].Uint()]
+	ws.subMutex.Unlock()
+
+	if ok {
+		for _, notice := range arr[1].Array() {
+			sub.callback(notice)
+		}
+	}
+}
+
+// Call calls a method of the given api, e.g. "database" or "network_broadcast"
+func (ws *WSClient) Call(api, method string, params interface{}) (*gjson.Result, error) {
+	return ws.CallContext(context.Background(), api, method, params)
+}
+
+// CallContext is Call bounded by ctx
+func (ws *WSClient) CallContext(ctx context.Context, api, method string, params interface{}) (*gjson.Result, error) {
+	msg, err := ws.send(ctx, api, method, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := rpcError(msg); err != nil {
+		return nil, err
+	}
+	result := msg.Get("result")
+	return &result, nil
+}
+
+// Batch pipelines the requests over the connection and returns the whole
+// response of every request, in order.
+func (ws *WSClient) Batch(ctx context.Context, api string, requests []RPCRequest) ([]gjson.Result, error) {
+	var (
+		wg        sync.WaitGroup
+		responses = make([]gjson.Result, len(requests))
+		errs      = make([]error, len(requests))
+	)
+
+	for i, r := range requests {
+		wg.Add(1)
+		go func(i int, r RPCRequest) {
+			defer wg.Done()
+			responses[i], errs[i] = ws.send(ctx, api, r.Method, r.Params)
+		}(i, r)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return responses, nil
+}
+
+// send sends a request and waits for the whole response message until ctx is
+// done or wsCallTimeout elapses
+func (ws *WSClient) send(ctx context.Context, api, method string, params interface{}) (gjson.Result, error) {
+
+	if params == nil {
+		params = []interface{}{}
+	}
+
+	ws.mutex.Lock()
+	conn := ws.conn
+	if conn == nil {
+		ws.mutex.Unlock()
+		return gjson.Result{}, fmt.Errorf("websocket is not connected: %s", ws.URL)
+	}
+	ws.requestID++
+	id := ws.requestID
+	ch := make(chan gjson.Result, 1)
+	ws.pending[id] = ch
+	ws.mutex.Unlock()
+
+	body := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  "call",
+		"params":  []interface{}{api, method, params},
+	}
+
+	if ws.Debug {
+		log.Std.Info("websocket call: %s.%s %+v", api, method, params)
+	}
+
+	if err := websocket.JSON.Send(conn, body); err != nil {
+		ws.mutex.Lock()
+		delete(ws.pending, id)
+		ws.mutex.Unlock()
+		return gjson.Result{}, err
+	}
+
+	timer := time.NewTimer(wsCallTimeout)
+	defer timer.Stop()
+
+	select {
+	case msg, ok := <-ch:
+		if !ok {
+			return gjson.Result{}, fmt.Errorf("websocket connection lost while calling %s", method)
+		}
+		return msg, nil
+	case <-timer.C:
+		ws.mutex.Lock()
+		delete(ws.pending, id)
+		ws.mutex.Unlock()
+		return gjson.Result{}, fmt.Errorf("websocket call %s timeout", method)
+	case <-ctx.Done():
+		ws.mutex.Lock()
+		delete(ws.pending, id)
+		ws.mutex.Unlock()
+		return gjson.Result{}, ctx.Err()
+	}
+}
+
+// subscribe installs a subscription whose callback id is the first param
+func (ws *WSClient) subscribe(method string, callback func(notice gjson.Result), extra ...interface{}) error {
+	ws.subMutex.Lock()
+	ws.callbackID++
+	cbID := ws.callbackID
+	sub := &wsSubscription{
+		method:   method,
+		params:   append([]interface{}{cbID}, extra...),
+		callback: callback,
+	}
+	ws.subscriptions[cbID] = sub
+	ws.subMutex.Unlock()
+
+	if _, err := ws.Call("database", sub.method, sub.params); err != nil {
+		ws.subMutex.Lock()
+		delete(ws.subscriptions, cbID)
+		ws.subMutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+// SetBlockAppliedCallback subscribes to the id of every block applied by the node
+func (ws *WSClient) SetBlockAppliedCallback(callback func(blockID string)) error {
+	return ws.subscribe("set_block_applied_callback", func(notice gjson.Result) {
+		callback(notice.String())
+	})
+}
+
+// SetSubscribeCallback subscribes to the object changes pushed by the node
+func (ws *WSClient) SetSubscribeCallback(callback func(notice gjson.Result), clearFilter bool) error {
+	return ws.subscribe("set_subscribe_callback", callback, clearFilter)
+}
+
+// BlockNumFromID returns the block number encoded in the first 4 bytes of a block id
+func BlockNumFromID(blockID string) uint32 {
+	if len(blockID) < 8 {
+		return 0
+	}
+	num, err := strconv.ParseUint(blockID[:8], 16, 32)
+	if err != nil {
+		return 0
+	}
+	return uint32(num)
+}
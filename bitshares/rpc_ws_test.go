@@ -0,0 +1,89 @@
+package bitshares
+
+import (
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"golang.org/x/net/websocket"
+)
+
+// testWSNode answers every call with its method name, pushes a block id after
+// each set_block_applied_callback and drops the first connection on demand
+func testWSNode(t *testing.T, subscribes *int32, drop chan struct{}) *httptest.Server {
+	var conns int32
+	return httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		if atomic.AddInt32(&conns, 1) == 1 {
+			go func() {
+				<-drop
+				conn.Close()
+			}()
+		}
+		for {
+			var data []byte
+			if err := websocket.Message.Receive(conn, &data); err != nil {
+				return
+			}
+			req := gjson.ParseBytes(data)
+			method := req.Get("params.1").String()
+			websocket.JSON.Send(conn, map[string]interface{}{
+				"id":     req.Get("id").Uint(),
+				"result": method,
+			})
+			if method == "set_block_applied_callback" {
+				atomic.AddInt32(subscribes, 1)
+				websocket.JSON.Send(conn, map[string]interface{}{
+					"method": "notice",
+					"params": []interface{}{req.Get("params.2.0").Uint(), []interface{}{"0017ddab0a3a5b2b4e8f3b1bdc1e8cf2aa7ac0b6"}},
+				})
+			}
+		}
+	}))
+}
+
+func TestWSClient_SubscribeAndReconnect(t *testing.T) {
+	var subscribes int32
+	drop := make(chan struct{})
+	server := testWSNode(t, &subscribes, drop)
+	defer server.Close()
+
+	ws := NewWSClient("ws"+strings.TrimPrefix(server.URL, "http"), false)
+	if err := ws.Connect(); err != nil {
+		t.Fatalf("Connect failed unexpected error: %v", err)
+	}
+	defer ws.Close()
+
+	r, err := ws.Call("database", "get_dynamic_global_properties", nil)
+	if err != nil || r.String() != "get_dynamic_global_properties" {
+		t.Fatalf("Call failed unexpected result: %v, %v", r, err)
+	}
+
+	heights := make(chan uint32, 2)
+	err = ws.SetBlockAppliedCallback(func(blockID string) {
+		heights <- BlockNumFromID(blockID)
+	})
+	if err != nil {
+		t.Fatalf("SetBlockAppliedCallback failed unexpected error: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case h := <-heights:
+			if h != 1564075 {
+				t.Errorf("pushed height = %d, want 1564075", h)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no block pushed after %d subscribes", atomic.LoadInt32(&subscribes))
+		}
+		if i == 0 {
+			close(drop)
+		}
+	}
+
+	if n := atomic.LoadInt32(&subscribes); n != 2 {
+		t.Errorf("subscribes = %d, want 2", n)
+	}
+}
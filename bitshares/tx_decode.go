@@ -16,22 +16,23 @@
 package bitshares
 
 import (
+	"bytes"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"sort"
 	"time"
 
+	"github.com/blocktree/bitshares-adapter/addrdec"
 	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/blocktree/bitshares-adapter/txsigner"
 	"github.com/blocktree/bitshares-adapter/types"
-	"github.com/denkhaus/bitshares/config"
-	"github.com/denkhaus/bitshares/crypto"
-	"github.com/denkhaus/bitshares/operations"
-	bt "github.com/denkhaus/bitshares/types"
 
-	owcrypt "github.com/blocktree/go-owcrypt"
 	"github.com/blocktree/openwallet/v2/openwallet"
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/shopspring/decimal"
+	"github.com/tidwall/gjson"
 )
 
 // TransactionDecoder 交易单解析器
@@ -52,14 +53,16 @@ func (decoder *TransactionDecoder) CreateRawTransaction(wrapper openwallet.Walle
 
 	var (
 		accountID = rawTx.Account.AccountID
-		amountStr string
-		to        string
 		assetID   types.ObjectID
 		precise   uint64
 	)
 
-	assetID = types.MustParseObjectID(rawTx.Coin.Contract.Address)
-	precise = rawTx.Coin.Contract.Decimals
+	asset, owErr := decoder.resolveAsset(rawTx.Coin.Contract)
+	if owErr != nil {
+		return owErr
+	}
+	assetID = asset.ID
+	precise = uint64(asset.Precision)
 
 	//获取wallet
 	account, err := wrapper.GetAssetsAccountInfo(accountID)
@@ -71,77 +74,72 @@ func (decoder *TransactionDecoder) CreateRawTransaction(wrapper openwallet.Walle
 		return fmt.Errorf("[%s] have not been created", accountID)
 	}
 
-	for k, v := range rawTx.To {
-		amountStr = v
-		to = k
-		break
+	recipients := transferRecipients(rawTx.To)
+	if len(recipients) == 0 {
+		return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "receiver addresses is empty")
 	}
 
-	// 检查转出、目标账户是否存在
-	accounts, err := decoder.wm.Api.GetAccounts(account.Alias, to)
-	if err != nil {
-		return openwallet.Errorf(openwallet.ErrAccountNotAddress, "unexpected error: %v", err)
+	//提案从ExtParam的proposal.from账户转出，通常是多签账户
+	from := account.Alias
+	if proposal := rawTx.GetExtParam().Get("proposal"); proposal.Exists() {
+		from = proposal.Get("from").String()
+		if from == "" {
+			return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "the account of the proposal is empty")
+		}
 	}
 
-	fromAccount := accounts[0]
-	toAccount := accounts[1]
+	// 检查转出、目标账户是否存在
+	fromAccount, toAccounts, owErr := decoder.getTransferAccounts(from, recipients...)
+	if owErr != nil {
+		return owErr
+	}
 
 	// 检查转出账户余额
-	balance, err := decoder.wm.Api.GetAssetsBalance(fromAccount.ID, assetID)
+	balance, err := decoder.wm.Api.GetAssetsBalanceContext(decoder.wm.Context(), fromAccount.ID, assetID)
 	if err != nil {
-		return openwallet.Errorf(openwallet.ErrNetworkRequestFailed, "call rpc get unexpected error: %v", err)
+		return ConvertRPCError(err, openwallet.ErrCallFullNodeAPIFailed, "call rpc get unexpected error")
 	}
 	if balance == nil {
 		return openwallet.Errorf(openwallet.ErrInsufficientBalanceOfAccount, "all address's balance of account is not enough")
 	}
 
 	accountBalanceDec, _ := decimal.NewFromString(balance.Amount)
-	amountDec, _ := decimal.NewFromString(amountStr)
-	amountDec = amountDec.Shift(int32(precise))
-
-	if accountBalanceDec.LessThan(amountDec) {
-		return fmt.Errorf("the balance: %s is not enough", amountStr)
-	}
-
-	memo := rawTx.GetExtParam().Get("memo").String()
 
-	asset := bt.AssetIDFromObject(bt.NewAssetID(assetID.String()))
-	amount := bt.AssetAmount{
-		Asset:  asset,
-		Amount: bt.Int64(amountDec.IntPart()),
-	}
-
-	op := operations.TransferOperation{
-		Amount:     amount,
-		Extensions: bt.Extensions{},
-		From:       bt.AccountIDFromObject(bt.NewAccountID(fromAccount.ID.String())),
-		To:         bt.AccountIDFromObject(bt.NewAccountID(toAccount.ID.String())),
-	}
+	//每个收款账户一个转账操作，备注各自加密
+	memo := rawTx.GetExtParam().Get("memo")
+	ops := make(types.Operations, 0, len(recipients))
+	for i, to := range recipients {
+		amountDec, err := decimal.NewFromString(rawTx.To[to])
+		if err != nil || !amountDec.IsPositive() {
+			return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "invalid amount [%s] to [%s]", rawTx.To[to], to)
+		}
+		//小数位数不能超过资产精度，否则转账金额会被截断
+		amountDec = amountDec.Shift(int32(precise))
+		if !amountDec.Equal(amountDec.Truncate(0)) {
+			return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "amount [%s] to [%s] has more decimals than the precision %d of the asset", rawTx.To[to], to, precise)
+		}
 
-	fromPublicKey, _ := bt.NewPublicKeyFromString(fromAccount.Options.MemoKey)
-	toPublicKey, _ := bt.NewPublicKeyFromString(toAccount.Options.MemoKey)
+		amount := types.AssetAmount{AssetID: assetID, Amount: uint64(amountDec.IntPart())}
+		op := types.NewTransferOperation(fromAccount.ID, toAccounts[i].ID, amount, types.AssetAmount{})
 
-	if memo != "" {
-		m := bt.Memo{
-			From:  *fromPublicKey,
-			To:    *toPublicKey,
-			Nonce: bt.UInt64(rand.Uint64()),
+		if m := transferMemo(memo, to); m != "" {
+			encrypted, err := decoder.encryptMemo(fromAccount, toAccounts[i], m)
+			if err != nil {
+				return err
+			}
+			decoder.wm.Log.Debug("memo hash:", encrypted.Message)
+			op.Memo = encrypted
 		}
-		wif := decoder.wm.Config.MemoPrivateKey
-		encoding.Encrypt(&m, memo, wif)
-		decoder.wm.Log.Debug("memo hash:", m.Message)
-		op.Memo = &m
+		ops = append(ops, op)
 	}
 
-	ops := &bt.Operations{&op}
-
 	createTxErr := decoder.createRawTransaction(
 		wrapper,
 		rawTx,
+		asset,
 		&accountBalanceDec,
-		account.Alias,
-		ops,
-		memo)
+		fromAccount,
+		ops)
 	if createTxErr != nil {
 		return createTxErr
 	}
@@ -150,6 +148,74 @@ func (decoder *TransactionDecoder) CreateRawTransaction(wrapper openwallet.Walle
 
 }
 
+//transferRecipients 按账户名排序的收款账户，即交易中转账操作的顺序
+func transferRecipients(to map[string]string) []string {
+	recipients := make([]string, 0, len(to))
+	for k := range to {
+		recipients = append(recipients, k)
+	}
+	sort.Strings(recipients)
+	return recipients
+}
+
+//transferMemo 收款账户的备注，memo为字符串时所有收款账户共用，为对象时按账户名取各自的备注
+func transferMemo(memo gjson.Result, to string) string {
+	if memo.IsObject() {
+		return memo.Map()[to].String()
+	}
+	return memo.String()
+}
+
+//resolveAsset 合约地址可以是资产ID或资产符号
+func (decoder *TransactionDecoder) resolveAsset(contract openwallet.SmartContract) (*Asset, *openwallet.Error) {
+	assets, err := decoder.wm.Assets.ResolveBatch(decoder.wm.Context(), contract.Address)
+	if err != nil {
+		return nil, ConvertRPCError(err, openwallet.ErrCallFullNodeAPIFailed, "get asset")
+	}
+	asset, ok := assets[contract.Address]
+	if !ok {
+		return nil, openwallet.Errorf(openwallet.ErrContractNotFound, "asset [%s] does not exist", contract.Address)
+	}
+	return asset, nil
+}
+
+//encryptMemo 用备注私钥加密转账备注
+func (decoder *TransactionDecoder) encryptMemo(from, to *types.Account, memo string) (*types.Memo, error) {
+	nonce := rand.Uint64()
+	message, err := encoding.Encrypt(memo, from.Options.MemoKey, to.Options.MemoKey, nonce, decoder.wm.Config.MemoPrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("EncryptMemo: %v", err)
+	}
+	return &types.Memo{
+		From:    types.PublicKey(from.Options.MemoKey),
+		To:      types.PublicKey(to.Options.MemoKey),
+		Nonce:   types.Suint64(nonce),
+		Message: message,
+	}, nil
+}
+
+//getTransferAccounts 检查转出、目标账户是否存在，目标账户按to的顺序返回
+func (decoder *TransactionDecoder) getTransferAccounts(from string, to ...string) (*types.Account, []*types.Account, *openwallet.Error) {
+	accounts, err := decoder.wm.Accounts.ResolveBatch(decoder.wm.Context(), append([]string{from}, to...)...)
+	if err != nil {
+		return nil, nil, ConvertRPCError(err, openwallet.ErrAccountNotAddress, "unexpected error")
+	}
+
+	if accounts[from] == nil {
+		return nil, nil, openwallet.Errorf(openwallet.ErrAccountNotFound, "account [%s] does not exist", from)
+	}
+
+	toAccounts := make([]*types.Account, len(to))
+	for i, name := range to {
+		if accounts[name] == nil {
+			return nil, nil, openwallet.Errorf(openwallet.ErrAccountNotFound, "account [%s] does not exist", name)
+		}
+		toAccounts[i] = accounts[name]
+	}
+
+	return accounts[from], toAccounts, nil
+}
+
 //SignRawTransaction 签名交易单
 func (decoder *TransactionDecoder) SignRawTransaction(wrapper openwallet.WalletDAI, rawTx *openwallet.RawTransaction) error {
 
@@ -168,6 +234,9 @@ func (decoder *TransactionDecoder) SignRawTransaction(wrapper openwallet.WalletD
 		for _, keySignature := range keySignatures {
 
 			childKey, err := key.DerivedKeyWithPath(keySignature.Address.HDPath, keySignature.EccType)
+			if err != nil {
+				return err
+			}
 			keyBytes, err := childKey.GetPrivateKeyBytes()
 			if err != nil {
 				return err
@@ -180,15 +249,11 @@ func (decoder *TransactionDecoder) SignRawTransaction(wrapper openwallet.WalletD
 
 			decoder.wm.Log.Debug("hash:", hash)
 
-			//sig, err := txsigner.Default.SignTransactionHash(hash, keyBytes, decoder.wm.CurveType())
-			//if err != nil {
-			//	return fmt.Errorf("sign transaction hash failed, unexpected err: %v", err)
-			//}
-			signature, v, sigErr := owcrypt.Signature(keyBytes, nil, hash, decoder.wm.CurveType())
-			if sigErr == owcrypt.FAILURE {
-				return fmt.Errorf("sign transaction hash failed")
+			//节点只接受canonical签名
+			signature, err := txsigner.Default.SignTransactionHash(hash, keyBytes, decoder.wm.CurveType())
+			if err != nil {
+				return fmt.Errorf("sign transaction hash failed, unexpected err: %v", err)
 			}
-			signature = append(signature, v)
 
 			keySignature.Signature = hex.EncodeToString(signature)
 		}
@@ -209,74 +274,149 @@ func (decoder *TransactionDecoder) VerifyRawTransaction(wrapper openwallet.Walle
 		return fmt.Errorf("transaction signature is empty")
 	}
 
-	var tx bt.SignedTransaction
+	var tx types.Transaction
 	txHex, err := hex.DecodeString(rawTx.RawHex)
 	if err != nil {
 		return fmt.Errorf("transaction DecodeString failed, unexpected error: %v", err)
 	}
-	err = tx.UnmarshalJSON(txHex)
+	err = json.Unmarshal(txHex, &tx)
 	if err != nil {
 		return fmt.Errorf("transaction UnmarshalJSON failed, unexpected error: %v", err)
 	}
 
-	//支持多重签名
+	digest, err := tx.Digest(decoder.wm.Config.ChainID)
+	if err != nil {
+		return openwallet.Errorf(openwallet.ErrVerifyRawTransactionFailed, "calculate digest error: %v", err)
+	}
+
+	//支持多重签名，同一公钥的签名在交易中只加入一次，节点拒绝重复的签名
+	accounts := make(map[string]*types.Account)
+	signers := make(map[string]bool)
 	for accountID, keySignatures := range rawTx.Signatures {
 		decoder.wm.Log.Debug("accountID Signatures:", accountID)
-		for _, keySignature := range keySignatures {
-
-			messsage, _ := hex.DecodeString(keySignature.Message)
-			signature, _ := hex.DecodeString(keySignature.Signature)
-			//publicKey, _ := hex.DecodeString(keySignature.Address.PublicKey)
-
-			//验证签名，解压公钥，解压后首字节04要去掉
-			//uncompessedPublicKey := owcrypt.PointDecompress(publicKey, decoder.wm.CurveType())
 
-			//valid, compactSig, err := txsigner.Default.VerifyAndCombineSignature(messsage, uncompessedPublicKey[1:], signature)
-			//if !valid {
-			//	return fmt.Errorf("transaction verify failed: %v", err)
-			//}
+		account, owErr := decoder.walletAccount(wrapper, accountID)
+		if owErr != nil {
+			return owErr
+		}
+		accounts[accountID] = account
 
-			_, valid := owcrypt.RecoverPubkey(signature, messsage, decoder.wm.CurveType())
-			//valid, compactSig, err := eos_txsigner.Default.VerifyAndCombineSignature(messsage, uncompessedPublicKey[1:], signature)
-			if valid == owcrypt.FAILURE {
-				return fmt.Errorf("transaction verify failed: %v", err)
+		for _, keySignature := range keySignatures {
+			signer, compactSig, owErr := recoverSigner(keySignature, digest)
+			if owErr != nil {
+				return owErr
 			}
-			v := signature[len(signature)-1] //签名最后一字节是v
-
-			//验签通过后处理V值，符合节点验签
-			compactSig := signature[:len(signature)-1]
-			compactSig = append([]byte{v + 27 + 4}, compactSig...)
+			if keySignature.Address != nil && keySignature.Address.Address != signer {
+				return openwallet.Errorf(openwallet.ErrVerifyRawTransactionFailed, "transaction is signed by %s, want %s", signer, keySignature.Address.Address)
+			}
+			if !signers[signer] {
+				signers[signer] = true
+				if err := tx.AddSignature(compactSig); err != nil {
+					return openwallet.Errorf(openwallet.ErrVerifyRawTransactionFailed, "transaction verify failed: %v", err)
+				}
+			}
+		}
+	}
 
-			tx.Signatures = append(
-				tx.Signatures,
-				compactSig,
-			)
+	//每个账户的active权限须被交易的签名满足
+	for _, account := range accounts {
+		weight, err := decoder.authorityWeight(account.Active, signers, 0)
+		if err != nil {
+			return ConvertRPCError(err, openwallet.ErrVerifyRawTransactionFailed, "authority of account ["+account.Name+"]")
+		}
+		if threshold := account.Active.WeightThreshold; weight < threshold {
+			return openwallet.Errorf(openwallet.ErrVerifyRawTransactionFailed, "the signatures of account [%s] weigh %d, its active authority requires %d", account.Name, weight, threshold)
 		}
 	}
 
+	jsonTx, err := json.Marshal(&tx)
+	if err != nil {
+		return fmt.Errorf("transaction encode json failed, unexpected error: %v", err)
+	}
 	rawTx.IsCompleted = true
-	jsonTx, _ := tx.MarshalJSON()
 	rawTx.RawHex = hex.EncodeToString(jsonTx)
 
 	return nil
 }
 
+//recoverSigner 验证签名是交易哈希的canonical签名，返回签名公钥及节点验签的compact签名
+func recoverSigner(keySignature *openwallet.KeySignature, digest []byte) (string, []byte, *openwallet.Error) {
+	message, err := hex.DecodeString(keySignature.Message)
+	if err != nil || !bytes.Equal(message, digest) {
+		return "", nil, openwallet.Errorf(openwallet.ErrVerifyRawTransactionFailed, "signed message %s is not the transaction digest", keySignature.Message)
+	}
+
+	//签名最后一字节是v
+	signature, err := hex.DecodeString(keySignature.Signature)
+	if err != nil || len(signature) != 65 || signature[64] > 3 {
+		return "", nil, openwallet.Errorf(openwallet.ErrVerifyRawTransactionFailed, "invalid signature %s", keySignature.Signature)
+	}
+	compactSig := append([]byte{signature[64] + 27 + 4}, signature[:64]...)
+	if !txsigner.IsCanonical(compactSig) {
+		return "", nil, openwallet.Errorf(openwallet.ErrVerifyRawTransactionFailed, "signature %s is not canonical", keySignature.Signature)
+	}
+
+	publicKey, _, err := btcec.RecoverCompact(btcec.S256(), compactSig, digest)
+	if err != nil {
+		return "", nil, openwallet.Errorf(openwallet.ErrVerifyRawTransactionFailed, "recover public key of signature %s: %v", keySignature.Signature, err)
+	}
+	signer, err := addrdec.Default.AddressEncode(publicKey.SerializeCompressed())
+	if err != nil {
+		return "", nil, openwallet.Errorf(openwallet.ErrVerifyRawTransactionFailed, "encode public key: %v", err)
+	}
+	return signer, compactSig, nil
+}
+
+//maxAuthorityDepth 节点检查账户权限的最大嵌套深度，更深的账户权限不计入权重
+const maxAuthorityDepth = 2
+
+//authorityWeight 签名满足的权限权重之和：签名公钥的权重，及active权限被签名满足的账户的权重
+func (decoder *TransactionDecoder) authorityWeight(authority types.Permission, signers map[string]bool, depth int) (uint32, error) {
+	weight := uint32(0)
+	for _, auth := range authority.KeyAuths {
+		if signers[auth.Key.String()] {
+			weight += uint32(auth.Weight)
+		}
+	}
+	if depth == maxAuthorityDepth {
+		return weight, nil
+	}
+
+	for _, auth := range authority.AccountAuths {
+		account, err := decoder.wm.Accounts.Resolve(decoder.wm.Context(), auth.Account.String())
+		if err != nil {
+			return 0, err
+		}
+		if account == nil {
+			return 0, fmt.Errorf("account %s of the authority does not exist", auth.Account.String())
+		}
+		accountWeight, err := decoder.authorityWeight(account.Active, signers, depth+1)
+		if err != nil {
+			return 0, err
+		}
+		if accountWeight >= account.Active.WeightThreshold {
+			weight += uint32(auth.Weight)
+		}
+	}
+	return weight, nil
+}
+
 // SubmitRawTransaction 广播交易单
 func (decoder *TransactionDecoder) SubmitRawTransaction(wrapper openwallet.WalletDAI, rawTx *openwallet.RawTransaction) (*openwallet.Transaction, error) {
 
-	var stx bt.SignedTransaction
+	var stx types.Transaction
 	txHex, err := hex.DecodeString(rawTx.RawHex)
 	if err != nil {
 		return nil, fmt.Errorf("transaction decode hex failed, unexpected error: %v", err)
 	}
-	err = stx.UnmarshalJSON(txHex)
+	err = json.Unmarshal(txHex, &stx)
 	if err != nil {
 		return nil, fmt.Errorf("transaction decode json failed, unexpected error: %v", err)
 	}
 
-	resp, err := decoder.wm.Api.BroadcastTransaction(&stx)
+	resp, err := decoder.wm.Api.BroadcastTransactionContext(decoder.wm.Context(), &stx)
 	if err != nil {
-		return nil, fmt.Errorf("push transaction: %s", err)
+		return nil, ConvertRPCError(err, openwallet.ErrSubmitRawTransactionFailed, "push transaction")
 	}
 
 	decoder.wm.Log.Info("Transaction [%s] submitted to the network successfully.", resp.ID)
@@ -285,7 +425,17 @@ func (decoder *TransactionDecoder) SubmitRawTransaction(wrapper openwallet.Walle
 	rawTx.IsSubmit = true
 
 	decimals := int32(rawTx.Coin.Contract.Decimals)
-	fees := "0"
+
+	//手续费为创建交易单时计算的金额，以扩展参数feeAsset的资产计，未指定时为核心资产
+	fees := rawTx.Fees
+	if len(fees) == 0 {
+		fees = "0"
+	}
+	if len(rawTx.GetExtParam().Get("feeAsset").String()) == 0 {
+		if err := rawTx.SetExtParam("feeAsset", CoreAssetID); err != nil {
+			return nil, err
+		}
+	}
 
 	//记录一个交易单
 	tx := &openwallet.Transaction{
@@ -306,9 +456,32 @@ func (decoder *TransactionDecoder) SubmitRawTransaction(wrapper openwallet.Walle
 	return tx, nil
 }
 
-//GetRawTransactionFeeRate 获取交易单的费率
+//GetRawTransactionFeeRate 获取交易单的费率，即当前不带备注的转账手续费
 func (decoder *TransactionDecoder) GetRawTransactionFeeRate() (feeRate string, unit string, err error) {
-	return "", "", nil
+	schedule, err := decoder.wm.Fees.Schedule(decoder.wm.Context())
+	if err != nil {
+		return "", "", ConvertRPCError(err, openwallet.ErrCallFullNodeAPIFailed, "get fee schedule")
+	}
+	fee, err := schedule.CoreFee(&types.TransferOperation{})
+	if err != nil {
+		return "", "", err
+	}
+	core, err := decoder.wm.Assets.Resolve(decoder.wm.Context(), CoreAssetID)
+	if err != nil {
+		return "", "", ConvertRPCError(err, openwallet.ErrCallFullNodeAPIFailed, "get core asset")
+	}
+	return core.Amount(fee), "TX", nil
+}
+
+//requiredFees 按缓存的手续费表本地计算手续费，无法本地计算时向节点查询
+func (decoder *TransactionDecoder) requiredFees(ops types.Operations, assetID string) ([]types.AssetAmount, error) {
+	fees, err := decoder.wm.Fees.RequiredFees(decoder.wm.Context(), ops, assetID)
+	if err == nil {
+		return fees, nil
+	}
+
+	decoder.wm.Log.Debugf("calculate fees locally failed, ask the node: %v", err)
+	return decoder.wm.Api.GetRequiredFeeContext(decoder.wm.Context(), ops, assetID)
 }
 
 //CreateSummaryRawTransaction 创建汇总交易
@@ -341,8 +514,12 @@ func (decoder *TransactionDecoder) CreateSummaryRawTransactionWithError(wrapper
 		precise    uint64
 	)
 
-	assetID = types.MustParseObjectID(sumRawTx.Coin.Contract.Address)
-	precise = sumRawTx.Coin.Contract.Decimals
+	asset, owErr := decoder.resolveAsset(sumRawTx.Coin.Contract)
+	if owErr != nil {
+		return nil, owErr
+	}
+	assetID = asset.ID
+	precise = uint64(asset.Precision)
 
 	minTransfer, _ := decimal.NewFromString(sumRawTx.MinTransfer)
 	retainedBalance, _ := decimal.NewFromString(sumRawTx.RetainedBalance)
@@ -362,16 +539,14 @@ func (decoder *TransactionDecoder) CreateSummaryRawTransactionWithError(wrapper
 	}
 
 	// 检查转出、目标账户是否存在
-	accounts, err := decoder.wm.Api.GetAccounts(account.Alias, sumRawTx.SummaryAddress)
-	if err != nil {
-		return nil, openwallet.Errorf(openwallet.ErrAccountNotAddress, "unexpected error: %v", err)
+	fromAccount, toAccounts, owErr := decoder.getTransferAccounts(account.Alias, sumRawTx.SummaryAddress)
+	if owErr != nil {
+		return nil, owErr
 	}
-
-	fromAccount := accounts[0]
-	toAccount := accounts[1]
+	toAccount := toAccounts[0]
 
 	// 检查转出账户余额
-	balance, err := decoder.wm.Api.GetAssetsBalance(fromAccount.ID, assetID)
+	balance, err := decoder.wm.Api.GetAssetsBalanceContext(decoder.wm.Context(), fromAccount.ID, assetID)
 	if err != nil || balance == nil {
 		return nil, openwallet.Errorf(openwallet.ErrInsufficientBalanceOfAccount, "all address's balance of account is not enough")
 	}
@@ -404,47 +579,26 @@ func (decoder *TransactionDecoder) CreateSummaryRawTransactionWithError(wrapper
 		Required: 1,
 	}
 
-	asset := bt.AssetIDFromObject(bt.NewAssetID(assetID.String()))
-	amount := bt.AssetAmount{
-		Asset:  asset,
-		Amount: bt.Int64(amountInt64),
-	}
-
-	op := operations.TransferOperation{
-		Amount:     amount,
-		Extensions: bt.Extensions{},
-		From:       bt.AccountIDFromObject(bt.NewAccountID(fromAccount.ID.String())),
-		To:         bt.AccountIDFromObject(bt.NewAccountID(toAccount.ID.String())),
-	}
-
-	fromPublicKey, _ := bt.NewPublicKeyFromString(fromAccount.Options.MemoKey)
-	toPublicKey, _ := bt.NewPublicKeyFromString(toAccount.Options.MemoKey)
+	amount := types.AssetAmount{AssetID: assetID, Amount: uint64(amountInt64)}
+	op := types.NewTransferOperation(fromAccount.ID, toAccount.ID, amount, types.AssetAmount{})
 
 	if memo != "" {
-		m := bt.Memo{
-			From:  *fromPublicKey,
-			To:    *toPublicKey,
-			Nonce: bt.UInt64(rand.Uint64()),
+		m, err := decoder.encryptMemo(fromAccount, toAccount, memo)
+		if err != nil {
+			return nil, err
 		}
-		keyBag := crypto.NewKeyBag()
-		keyBag.Add(decoder.wm.Config.MemoPrivateKey)
-
-		if err := keyBag.EncryptMemo(&m, memo); err != nil {
-			return nil, fmt.Errorf("EncryptMemo: %v", err)
-		}
-
-		op.Memo = &m
+		op.Memo = m
 	}
 
-	ops := &bt.Operations{&op}
+	ops := types.Operations{op}
 
 	createTxErr := decoder.createRawTransaction(
 		wrapper,
 		rawTx,
+		asset,
 		&accountBalanceDec,
-		account.Alias,
-		ops,
-		memo)
+		fromAccount,
+		ops)
 	rawTxWithErr := &openwallet.RawTransactionWithError{
 		RawTx: rawTx,
 		Error: createTxErr,
@@ -460,73 +614,122 @@ func (decoder *TransactionDecoder) CreateSummaryRawTransactionWithError(wrapper
 func (decoder *TransactionDecoder) createRawTransaction(
 	wrapper openwallet.WalletDAI,
 	rawTx *openwallet.RawTransaction,
+	asset *Asset,
 	balanceDec *decimal.Decimal,
-	from string,
-	ops *bt.Operations,
-	memo string) *openwallet.Error {
+	fromAccount *types.Account,
+	ops types.Operations) *openwallet.Error {
 
 	var (
-		to               string
 		accountTotalSent = decimal.Zero
 		txFrom           = make([]string, 0)
 		txTo             = make([]string, 0)
-		keySignList      = make([]*openwallet.KeySignature, 0)
-		accountID        = rawTx.Account.AccountID
 		amountDec        = decimal.Zero
-		curveType        = decoder.wm.Config.CurveType
-		assetID          = bt.NewAssetID(rawTx.Coin.Contract.Address)
-		precise          = rawTx.Coin.Contract.Decimals
-		operations       = bt.Operations(*ops)
+		precise          = asset.Precision
+		from             = fromAccount.Name
 	)
 
-	for k, v := range rawTx.To {
-		to = k
-		amountDec, _ = decimal.NewFromString(v)
-		break
-	}
+	//所有收款账户的转账总额
+	for _, to := range transferRecipients(rawTx.To) {
+		amount, _ := decimal.NewFromString(rawTx.To[to])
+		amountDec = amountDec.Add(amount)
+		txTo = append(txTo, fmt.Sprintf("%s:%s", to, amount.String()))
 
-	fees, err := decoder.wm.Api.GetRequiredFee(operations, assetID.String())
-	if err != nil {
-		return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "can't get fees: %v", err)
+		//计算账户的实际转账amount
+		if from != to {
+			accountTotalSent = accountTotalSent.Add(amount)
+		}
 	}
 
-	feesDec := decimal.Zero
-	for _, fee := range fees {
-		feesDec = feesDec.Add(decimal.New(int64(fee.Amount), 0))
+	if balanceDec.LessThan(amountDec.Shift(int32(precise))) {
+		return openwallet.Errorf(openwallet.ErrInsufficientBalanceOfAccount, "the balance: %s is not enough", balanceDec.Shift(-int32(precise)))
 	}
 
-	if balanceDec.LessThan(amountDec.Add(feesDec)) {
-		return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "the balance: %s is not enough", balanceDec.Shift(-int32(precise)))
+	payment, owErr := decoder.selectFeePayment(rawTx, ops, fromAccount, asset, amountDec.Shift(int32(precise)), *balanceDec)
+	if owErr != nil {
+		return owErr
 	}
-
-	if err := operations.ApplyFees(fees); err != nil {
-		return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "ApplyFees")
+	if err := ops.SetFees(payment.fees); err != nil {
+		return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "SetFees: %v", err)
 	}
 
-	info, err := decoder.wm.Api.GetBlockchainInfo()
+	info, err := decoder.wm.Api.GetBlockchainInfoContext(decoder.wm.Context())
 	if err != nil {
 		return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "GetBlockchainInfo: %v", err)
 	}
 
-	j, _ := json.Marshal(info.HeadBlockID)
-	headBlockID := bt.String{}
-	headBlockID.UnmarshalJSON(j)
-	props := &bt.DynamicGlobalProperties{
-		HeadBlockID:              headBlockID,
-		HeadBlockNumber:          bt.UInt32(info.HeadBlockNum),
-		LastIrreversibleBlockNum: bt.UInt32(info.LastIrreversibleBlockNum),
+	//每个转账操作的收款账户
+	recipients := transferRecipients(rawTx.To)
+
+	//多签账户的转账以提案提交，钱包账户作为提案人支付提案手续费，
+	//提案执行时多签账户支付转账的手续费
+	var proposed *feePayment
+	if proposal := rawTx.GetExtParam().Get("proposal"); proposal.Exists() {
+		proposed = payment
+		ops, payment, owErr = decoder.proposeOperations(wrapper, rawTx, proposal, info.Timestamp, ops)
+		if owErr != nil {
+			return owErr
+		}
+		accountTotalSent = decimal.Zero
 	}
 
-	tx, err := bt.NewSignedTransactionWithBlockData(props)
-	if err != nil {
-		return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "NewTransaction")
+	if owErr := decoder.buildRawTransaction(wrapper, rawTx, info, ops); owErr != nil {
+		return owErr
 	}
 
-	tx.Operations = operations
-	signer := crypto.NewTransactionSigner(tx)
+	accountTotalSent = decimal.Zero.Sub(accountTotalSent)
+
+	txFrom = []string{fmt.Sprintf("%s:%s", from, amountDec.String())}
+
+	//Fees为钱包账户广播时支付的手续费，提案的转账手续费另记在proposedFees
+	rawTx.Fees = payment.asset.Amount(payment.total)
+	if err := rawTx.SetExtParam("feeAsset", payment.asset.ID.String()); err != nil {
+		return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "SetExtParam: %v", err)
+	}
+	fees := operationFees(payment, recipients)
+	if proposed != nil {
+		fees = operationFees(payment, nil)
+		for _, fee := range operationFees(proposed, recipients) {
+			fee.Proposed = true
+			fees = append(fees, fee)
+		}
+		if err := rawTx.SetExtParam("proposedFees", proposed.asset.Amount(proposed.total)); err != nil {
+			return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "SetExtParam: %v", err)
+		}
+		if err := rawTx.SetExtParam("proposedFeeAsset", proposed.asset.ID.String()); err != nil {
+			return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "SetExtParam: %v", err)
+		}
+	}
+	if err := rawTx.SetExtParam("operationFees", fees); err != nil {
+		return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "SetExtParam: %v", err)
+	}
+	rawTx.TxAmount = accountTotalSent.String()
+	rawTx.TxFrom = txFrom
+	rawTx.TxTo = txTo
+
+	return nil
+}
+
+//buildRawTransaction 用已设置手续费的操作创建交易，计算交易哈希并生成钱包账户的待签名列表
+func (decoder *TransactionDecoder) buildRawTransaction(
+	wrapper openwallet.WalletDAI,
+	rawTx *openwallet.RawTransaction,
+	info *BlockchainInfo,
+	ops types.Operations) *openwallet.Error {
+
+	var (
+		keySignList = make([]*openwallet.KeySignature, 0)
+		accountID   = rawTx.Account.AccountID
+		curveType   = decoder.wm.Config.CurveType
+	)
+
+	tx, err := types.NewTransaction(info.HeadBlockID, info.Timestamp.Add(types.DefaultTxExpiration))
+	if err != nil {
+		return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "NewTransaction: %v", err)
+	}
+	tx.Operations = ops
 
 	//交易哈希
-	digest, err := signer.Digest(config.Current())
+	digest, err := tx.Digest(decoder.wm.Config.ChainID)
 	if err != nil {
 		return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "Calculate digest error: %v", err)
 	}
@@ -552,28 +755,335 @@ func (decoder *TransactionDecoder) createRawTransaction(
 		keySignList = append(keySignList, &signature)
 	}
 
-	//计算账户的实际转账amount
-	if from != to {
-		accountTotalSent = accountTotalSent.Add(amountDec)
-	}
-	accountTotalSent = decimal.Zero.Sub(accountTotalSent)
-
-	txFrom = []string{fmt.Sprintf("%s:%s", from, amountDec.String())}
-	txTo = []string{fmt.Sprintf("%s:%s", to, amountDec.String())}
-
 	if rawTx.Signatures == nil {
 		rawTx.Signatures = make(map[string][]*openwallet.KeySignature)
 	}
 
-	jsonTx, _ := tx.MarshalJSON()
+	jsonTx, err := json.Marshal(tx)
+	if err != nil {
+		return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "encode transaction: %v", err)
+	}
 	rawTx.RawHex = hex.EncodeToString(jsonTx)
-	rawTx.Signatures[rawTx.Account.AccountID] = keySignList
+	rawTx.Signatures[accountID] = keySignList
 	rawTx.FeeRate = "0"
-	rawTx.Fees = feesDec.String()
 	rawTx.IsBuilt = true
-	rawTx.TxAmount = accountTotalSent.String()
-	rawTx.TxFrom = txFrom
-	rawTx.TxTo = txTo
 
 	return nil
 }
+
+//defaultProposalExpiration 提案默认的有效期
+const defaultProposalExpiration = 24 * time.Hour
+
+//proposeOperations 把操作包装为提案，提案人是钱包账户，用BTS支付提案手续费。
+//proposal的expiration为提案有效秒数，默认24小时；reviewPeriod为审核期秒数，默认没有审核期
+func (decoder *TransactionDecoder) proposeOperations(
+	wrapper openwallet.WalletDAI,
+	rawTx *openwallet.RawTransaction,
+	proposal gjson.Result,
+	now time.Time,
+	ops types.Operations) (types.Operations, *feePayment, *openwallet.Error) {
+
+	proposer, owErr := decoder.walletAccount(wrapper, rawTx.Account.AccountID)
+	if owErr != nil {
+		return nil, nil, owErr
+	}
+
+	expiration := defaultProposalExpiration
+	if seconds := proposal.Get("expiration").Int(); seconds > 0 {
+		expiration = time.Duration(seconds) * time.Second
+	}
+	reviewPeriod := time.Duration(proposal.Get("reviewPeriod").Int()) * time.Second
+	if reviewPeriod < 0 || reviewPeriod >= expiration {
+		return nil, nil, openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "review period %v must be shorter than the expiration %v", reviewPeriod, expiration)
+	}
+
+	proposed := types.Operations{types.NewProposalCreateOperation(proposer.ID, now.Add(expiration), reviewPeriod, ops...)}
+	payment, owErr := decoder.coreFeePayment(proposed, proposer)
+	if owErr != nil {
+		return nil, nil, owErr
+	}
+	if err := proposed.SetFees(payment.fees); err != nil {
+		return nil, nil, openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "SetFees: %v", err)
+	}
+	return proposed, payment, nil
+}
+
+//CreateProposalApprovalRawTransaction 创建批准提案的交易单，approve为false时撤销钱包账户的批准
+func (decoder *TransactionDecoder) CreateProposalApprovalRawTransaction(wrapper openwallet.WalletDAI, rawTx *openwallet.RawTransaction, proposalID string, approve bool) error {
+	account, proposal, owErr := decoder.getProposal(wrapper, rawTx, proposalID)
+	if owErr != nil {
+		return owErr
+	}
+
+	op := types.NewProposalUpdateOperation(account.ID, proposal.ID)
+	approved := proposal.Approved(account.ID)
+	switch {
+	case approve && approved:
+		return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "account [%s] has approved proposal [%s]", account.Name, proposalID)
+	case !approve && !approved:
+		return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "account [%s] has not approved proposal [%s]", account.Name, proposalID)
+	case approve:
+		op.ActiveApprovalsToAdd = []types.ObjectID{account.ID}
+	default:
+		op.ActiveApprovalsToRemove = []types.ObjectID{account.ID}
+	}
+
+	if owErr := decoder.createProposalRawTransaction(wrapper, rawTx, account, proposal, op); owErr != nil {
+		return owErr
+	}
+	return nil
+}
+
+//CreateProposalDeleteRawTransaction 创建删除提案的交易单，钱包账户须是提案需要批准的账户
+func (decoder *TransactionDecoder) CreateProposalDeleteRawTransaction(wrapper openwallet.WalletDAI, rawTx *openwallet.RawTransaction, proposalID string) error {
+	account, proposal, owErr := decoder.getProposal(wrapper, rawTx, proposalID)
+	if owErr != nil {
+		return owErr
+	}
+
+	if !proposal.Requires(account.ID) {
+		return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "account [%s] is not authoritative for proposal [%s]", account.Name, proposalID)
+	}
+	usingOwnerAuthority := !containsID(proposal.RequiredActiveApprovals, account.ID)
+	op := types.NewProposalDeleteOperation(account.ID, proposal.ID, usingOwnerAuthority)
+
+	if owErr := decoder.createProposalRawTransaction(wrapper, rawTx, account, proposal, op); owErr != nil {
+		return owErr
+	}
+	return nil
+}
+
+//getProposal 获取钱包账户及待处理的提案
+func (decoder *TransactionDecoder) getProposal(wrapper openwallet.WalletDAI, rawTx *openwallet.RawTransaction, proposalID string) (*types.Account, *Proposal, *openwallet.Error) {
+	account, owErr := decoder.walletAccount(wrapper, rawTx.Account.AccountID)
+	if owErr != nil {
+		return nil, nil, owErr
+	}
+
+	id, err := types.ParseObjectID(proposalID)
+	if err != nil || id.Space != 1 || id.Type != 10 {
+		return nil, nil, openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "invalid proposal id [%s]", proposalID)
+	}
+	proposal, err := decoder.wm.Api.GetProposalContext(decoder.wm.Context(), id)
+	if err != nil {
+		return nil, nil, ConvertRPCError(err, openwallet.ErrCreateRawTransactionFailed, "get proposal")
+	}
+	return account, proposal, nil
+}
+
+//walletAccount 钱包资产账户对应的链上账户
+func (decoder *TransactionDecoder) walletAccount(wrapper openwallet.WalletDAI, accountID string) (*types.Account, *openwallet.Error) {
+	account, err := wrapper.GetAssetsAccountInfo(accountID)
+	if err != nil {
+		return nil, openwallet.ConvertError(err)
+	}
+	if account.Alias == "" {
+		return nil, openwallet.Errorf(openwallet.ErrAccountNotFound, "[%s] have not been created", accountID)
+	}
+
+	a, err := decoder.wm.Accounts.Resolve(decoder.wm.Context(), account.Alias)
+	if err != nil {
+		return nil, ConvertRPCError(err, openwallet.ErrAccountNotAddress, "unexpected error")
+	}
+	if a == nil {
+		return nil, openwallet.Errorf(openwallet.ErrAccountNotFound, "account [%s] does not exist", account.Alias)
+	}
+	return a, nil
+}
+
+//createProposalRawTransaction 创建钱包账户处理提案的交易单，手续费用BTS支付
+func (decoder *TransactionDecoder) createProposalRawTransaction(
+	wrapper openwallet.WalletDAI,
+	rawTx *openwallet.RawTransaction,
+	account *types.Account,
+	proposal *Proposal,
+	op types.Operation) *openwallet.Error {
+
+	ops := types.Operations{op}
+	payment, owErr := decoder.coreFeePayment(ops, account)
+	if owErr != nil {
+		return owErr
+	}
+	if err := ops.SetFees(payment.fees); err != nil {
+		return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "SetFees: %v", err)
+	}
+
+	info, err := decoder.wm.Api.GetBlockchainInfoContext(decoder.wm.Context())
+	if err != nil {
+		return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "GetBlockchainInfo: %v", err)
+	}
+
+	if owErr := decoder.buildRawTransaction(wrapper, rawTx, info, ops); owErr != nil {
+		return owErr
+	}
+
+	rawTx.Fees = payment.asset.Amount(payment.total)
+	if err := rawTx.SetExtParam("feeAsset", payment.asset.ID.String()); err != nil {
+		return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "SetExtParam: %v", err)
+	}
+	if err := rawTx.SetExtParam("proposal", proposal.ID.String()); err != nil {
+		return openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "SetExtParam: %v", err)
+	}
+	rawTx.TxAmount = "0"
+	rawTx.TxFrom = []string{}
+	rawTx.TxTo = []string{}
+
+	return nil
+}
+
+//coreFeePayment 账户用BTS支付操作的手续费
+func (decoder *TransactionDecoder) coreFeePayment(ops types.Operations, from *types.Account) (*feePayment, *openwallet.Error) {
+	core, owErr := decoder.resolveAsset(openwallet.SmartContract{Address: CoreAssetID})
+	if owErr != nil {
+		return nil, owErr
+	}
+	coreFees, err := decoder.requiredFees(ops, CoreAssetID)
+	if err != nil {
+		return nil, ConvertRPCError(err, openwallet.ErrCreateRawTransactionFailed, "can't get fees")
+	}
+	b, err := decoder.wm.Api.GetAssetsBalanceContext(decoder.wm.Context(), from.ID, core.ID)
+	if err != nil {
+		return nil, ConvertRPCError(err, openwallet.ErrCallFullNodeAPIFailed, "call rpc get unexpected error")
+	}
+	balance := decimal.Zero
+	if b != nil {
+		balance, _ = decimal.NewFromString(b.Amount)
+	}
+	return decoder.feePaymentIn(core, ops, coreFees, from, core, decimal.Zero, balance)
+}
+
+//feePayment 交易手续费的支付账户、支付资产及每个操作的手续费
+type feePayment struct {
+	payer string
+	asset *Asset
+	fees  []types.AssetAmount
+	total uint64
+}
+
+//operationFee 交易中一个操作的手续费
+type operationFee struct {
+	Index    int    `json:"index"`              //操作序号，提案的操作为其在提案中的序号
+	Proposed bool   `json:"proposed,omitempty"` //提案的操作，提案执行时支付
+	To       string `json:"to,omitempty"`       //转账操作的收款账户
+	Account  string `json:"account"`            //支付手续费的账户
+	Asset    string `json:"asset"`              //支付手续费的资产
+	Fee      string `json:"fee"`                //手续费
+}
+
+//operationFees 按操作序号列出每个操作的手续费，recipients为转账操作的收款账户
+func operationFees(payment *feePayment, recipients []string) []operationFee {
+	fees := make([]operationFee, len(payment.fees))
+	for i, fee := range payment.fees {
+		fees[i] = operationFee{Index: i, Account: payment.payer, Asset: payment.asset.ID.String(), Fee: payment.asset.Amount(fee.Amount)}
+		if i < len(recipients) {
+			fees[i].To = recipients[i]
+		}
+	}
+	return fees
+}
+
+//selectFeePayment 选择支付手续费的资产。ExtParam指定了feeAsset时只用该资产支付；
+//否则账户BTS足够时用BTS支付，不足时用转账资产支付，由其手续费池兑换BTS
+func (decoder *TransactionDecoder) selectFeePayment(
+	rawTx *openwallet.RawTransaction,
+	ops types.Operations,
+	from *types.Account,
+	asset *Asset,
+	amount decimal.Decimal,
+	balance decimal.Decimal) (*feePayment, *openwallet.Error) {
+
+	coreFees, err := decoder.requiredFees(ops, CoreAssetID)
+	if err != nil {
+		return nil, ConvertRPCError(err, openwallet.ErrCreateRawTransactionFailed, "can't get fees")
+	}
+
+	var candidates []*Asset
+	if feeAsset := rawTx.GetExtParam().Get("feeAsset").String(); feeAsset != "" {
+		a, owErr := decoder.resolveAsset(openwallet.SmartContract{Address: feeAsset})
+		if owErr != nil {
+			return nil, owErr
+		}
+		candidates = []*Asset{a}
+	} else {
+		core, owErr := decoder.resolveAsset(openwallet.SmartContract{Address: CoreAssetID})
+		if owErr != nil {
+			return nil, owErr
+		}
+		candidates = []*Asset{core}
+		if asset.ID != core.ID {
+			candidates = append(candidates, asset)
+		}
+	}
+
+	var reason *openwallet.Error
+	for _, feeAsset := range candidates {
+		payment, owErr := decoder.feePaymentIn(feeAsset, ops, coreFees, from, asset, amount, balance)
+		if owErr == nil {
+			decoder.wm.Log.Debugf("fees: %s %s", feeAsset.Amount(payment.total), feeAsset.Symbol)
+			return payment, nil
+		}
+		decoder.wm.Log.Debugf("can not pay fees in %s: %v", feeAsset.Symbol, owErr)
+		reason = owErr
+	}
+	return nil, reason
+}
+
+//feePaymentIn 用feeAsset支付手续费，非BTS资产的手续费池须足够兑换BTS手续费，
+//并按资产检查余额：与转账资产相同时余额须足够转账数量加手续费
+func (decoder *TransactionDecoder) feePaymentIn(
+	feeAsset *Asset,
+	ops types.Operations,
+	coreFees []types.AssetAmount,
+	from *types.Account,
+	asset *Asset,
+	amount decimal.Decimal,
+	balance decimal.Decimal) (*feePayment, *openwallet.Error) {
+
+	payment := &feePayment{payer: from.Name, asset: feeAsset, fees: coreFees}
+	if feeAsset.ID.String() != CoreAssetID {
+		fees, err := decoder.requiredFees(ops, feeAsset.ID.String())
+		if err != nil {
+			return nil, ConvertRPCError(err, openwallet.ErrCreateRawTransactionFailed, "can't get fees")
+		}
+		payment.fees = fees
+
+		coreTotal := uint64(0)
+		for _, fee := range coreFees {
+			coreTotal += fee.Amount
+		}
+		data, err := decoder.wm.Api.GetAssetDynamicDataContext(decoder.wm.Context(), feeAsset)
+		if err != nil {
+			return nil, ConvertRPCError(err, openwallet.ErrCallFullNodeAPIFailed, "get fee pool")
+		}
+		if uint64(data.FeePool) < coreTotal {
+			return nil, openwallet.Errorf(openwallet.ErrInsufficientFees, "the fee pool of %s: %d can not pay fees of %d %s", feeAsset.Symbol, data.FeePool, coreTotal, CoreAssetID)
+		}
+	}
+
+	for _, fee := range payment.fees {
+		if fee.AssetID != feeAsset.ID {
+			return nil, openwallet.Errorf(openwallet.ErrCreateRawTransactionFailed, "fee in %s, want %s", fee.AssetID.String(), feeAsset.ID.String())
+		}
+		payment.total += fee.Amount
+	}
+
+	//按资产检查余额
+	required := decimal.New(int64(payment.total), 0)
+	feeBalance := balance
+	if feeAsset.ID == asset.ID {
+		required = required.Add(amount)
+	} else {
+		b, err := decoder.wm.Api.GetAssetsBalanceContext(decoder.wm.Context(), from.ID, feeAsset.ID)
+		if err != nil {
+			return nil, ConvertRPCError(err, openwallet.ErrCallFullNodeAPIFailed, "call rpc get unexpected error")
+		}
+		feeBalance = decimal.Zero
+		if b != nil {
+			feeBalance, _ = decimal.NewFromString(b.Amount)
+		}
+	}
+	if feeBalance.LessThan(required) {
+		return nil, openwallet.Errorf(openwallet.ErrInsufficientFees, "the balance: %s %s is not enough to pay fees %s", feeAsset.Amount(uint64(feeBalance.IntPart())), feeAsset.Symbol, feeAsset.Amount(payment.total))
+	}
+	return payment, nil
+}
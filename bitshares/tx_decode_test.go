@@ -0,0 +1,765 @@
+package bitshares
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/blocktree/bitshares-adapter/addrdec"
+	"github.com/blocktree/bitshares-adapter/bitsharestest"
+	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/blocktree/bitshares-adapter/txsigner"
+	"github.com/blocktree/bitshares-adapter/types"
+	"github.com/blocktree/openwallet/v2/openwallet"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/shopspring/decimal"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	testAliceWIF = "5JBWV6pN7wQzggN7gPYzY9yVdvp8ptiYLhPydzQr7jnoMXu527y"
+	testAliceKey = "BTS4vF8KDspj7fKBprtKvc1uamTL4qArHDjJfxyjdTzZWg3C7WSNK"
+	testBobWIF   = "5JBWV6pN7wQzggN7gPYzY9yVdvp8ptiYLhPydzQDmYwPYW6Lx5n"
+	testBobKey   = "BTS5FdaHSyyNhZeD3jixgkt7rp1wPRyAUDB9qPEpDoLPNVAZQJysF"
+)
+
+// testSignRawTransaction signs the messages of the transaction as SignRawTransaction
+// does with the keys of the wallet
+func testSignRawTransaction(t *testing.T, rawTx *openwallet.RawTransaction, wif string) {
+	for _, keySignature := range rawTx.Signatures[rawTx.Account.AccountID] {
+		hash, _ := hex.DecodeString(keySignature.Message)
+		signature, err := txsigner.Default.SignTransactionHashWithWIF(hash, wif)
+		if err != nil {
+			t.Fatalf("sign %s failed unexpected error: %v", keySignature.Message, err)
+		}
+		keySignature.Signature = hex.EncodeToString(signature)
+	}
+}
+
+// testCoreAmount returns an amount of the core asset in its units
+func testCoreAmount(amount uint64) string {
+	return decimal.New(int64(amount), -int32(bitsharestest.CoreAssetPrecision)).String()
+}
+
+func TestTransactionDecoder_FakeNode(t *testing.T) {
+	node := bitsharestest.NewNode()
+	defer node.Close()
+	node.CreateAccount("alice", testAliceKey)
+	node.CreateAccount("bob", testBobKey)
+	node.SetBalance("alice", CoreAssetID, 100000)
+	// 107 bytes of memo: 20000 + 107 * 100000 / 1024
+	node.SetRequiredFee(types.TransferOpType, CoreAssetID, 30449)
+
+	bs, _ := testFakeNodeScanner(node)
+	wm := bs.wm
+	wm.Config.MemoPrivateKey = testAliceWIF
+	decoder := wm.TxDecoder.(*TransactionDecoder)
+
+	wallet := bitsharestest.NewWalletDAI()
+	account := wallet.AddAccount("A", "alice", testAliceKey)
+	rawTx := &openwallet.RawTransaction{
+		Coin: openwallet.Coin{
+			Symbol:     "BTS",
+			IsContract: true,
+			Contract:   openwallet.SmartContract{Address: CoreAssetID, Decimals: 5},
+		},
+		Account:  account,
+		To:       map[string]string{"bob": "0.01"},
+		ExtParam: `{"memo":"withdraw 1234"}`,
+	}
+
+	if err := decoder.CreateRawTransaction(wallet, rawTx); err != nil {
+		t.Fatalf("CreateRawTransaction failed unexpected error: %v", err)
+	}
+	if len(rawTx.Signatures["A"]) != 1 {
+		t.Fatalf("signatures = %v, want one of alice's key", rawTx.Signatures)
+	}
+
+	testSignRawTransaction(t, rawTx, testAliceWIF)
+	if err := decoder.VerifyRawTransaction(wallet, rawTx); err != nil {
+		t.Fatalf("VerifyRawTransaction failed unexpected error: %v", err)
+	}
+
+	// the signed transaction is the adapter's own, signed by the key for the mainnet
+	raw, _ := hex.DecodeString(rawTx.RawHex)
+	var tx types.Transaction
+	if err := json.Unmarshal(raw, &tx); err != nil {
+		t.Fatalf("signed transaction %s: %v", raw, err)
+	}
+	signees, err := tx.Signees(types.ChainIDBTS)
+	if err != nil || len(signees) != 1 {
+		t.Fatalf("signees = %x, %v", signees, err)
+	}
+	if signee, _ := addrdec.Default.AddressEncode(signees[0]); signee != testAliceKey {
+		t.Errorf("signee = %s, want %s", signee, testAliceKey)
+	}
+
+	submitted, err := decoder.SubmitRawTransaction(wallet, rawTx)
+	if err != nil {
+		t.Fatalf("SubmitRawTransaction failed unexpected error: %v", err)
+	}
+	block := node.MintBlock()
+	if len(block.TransactionIDs) != 1 || block.TransactionIDs[0] != submitted.TxID {
+		t.Errorf("submitted %s, block has %v", submitted.TxID, block.TransactionIDs)
+	}
+	if feeAsset := gjson.Get(submitted.ExtParam, "feeAsset").String(); submitted.Fees != "0.30449" || feeAsset != CoreAssetID {
+		t.Errorf("submitted fees = %s in %s, want 0.30449 in %s", submitted.Fees, feeAsset, CoreAssetID)
+	}
+	if got := node.Balance("bob", CoreAssetID); got != 1000 {
+		t.Errorf("bob balance = %d, want 1000", got)
+	}
+
+	// the fee includes the price of the memo, the receiver reads it
+	transfer := tx.Operations[0].(*types.TransferOperation)
+	if fee := transfer.Fee.Amount; fee != 30449 || rawTx.Fees != "0.30449" {
+		t.Errorf("fee = %d, raw transaction fees %s, want 30449", fee, rawTx.Fees)
+	}
+	memo, err := encoding.Decrypt(transfer.Memo.Message, transfer.Memo.From.String(), transfer.Memo.To.String(), uint64(transfer.Memo.Nonce), testBobWIF)
+	if err != nil || memo != "withdraw 1234" {
+		t.Errorf("memo = %q, %v", memo, err)
+	}
+}
+
+func TestTransactionDecoder_MultipleRecipients(t *testing.T) {
+	node := bitsharestest.NewNode()
+	defer node.Close()
+	node.CreateAccount("alice", testAliceKey)
+	bobID := node.CreateAccount("bob", testBobKey)
+	carolID := node.CreateAccount("carol", "")
+	node.SetBalance("alice", CoreAssetID, 100000)
+
+	bs, _ := testFakeNodeScanner(node)
+	wm := bs.wm
+	wm.Config.MemoPrivateKey = testAliceWIF
+	decoder := wm.TxDecoder.(*TransactionDecoder)
+
+	wallet := bitsharestest.NewWalletDAI()
+	account := wallet.AddAccount("A", "alice", testAliceKey)
+	rawTx := &openwallet.RawTransaction{
+		Coin: openwallet.Coin{
+			Symbol:     "BTS",
+			IsContract: true,
+			Contract:   openwallet.SmartContract{Address: CoreAssetID, Decimals: 5},
+		},
+		Account:  account,
+		To:       map[string]string{"carol": "0.02", "bob": "0.01"},
+		ExtParam: `{"memo":{"bob":"withdraw 1234"}}`,
+	}
+
+	if err := decoder.CreateRawTransaction(wallet, rawTx); err != nil {
+		t.Fatalf("CreateRawTransaction failed unexpected error: %v", err)
+	}
+	if want := []string{"bob:0.01", "carol:0.02"}; strings.Join(rawTx.TxTo, ",") != strings.Join(want, ",") {
+		t.Errorf("TxTo = %v, want %v", rawTx.TxTo, want)
+	}
+	if want := []string{"alice:0.03"}; strings.Join(rawTx.TxFrom, ",") != strings.Join(want, ",") {
+		t.Errorf("TxFrom = %v, want %v", rawTx.TxFrom, want)
+	}
+	if rawTx.TxAmount != "-0.03" {
+		t.Errorf("TxAmount = %s, want -0.03", rawTx.TxAmount)
+	}
+
+	testSignRawTransaction(t, rawTx, testAliceWIF)
+	if err := decoder.VerifyRawTransaction(wallet, rawTx); err != nil {
+		t.Fatalf("VerifyRawTransaction failed unexpected error: %v", err)
+	}
+	raw, _ := hex.DecodeString(rawTx.RawHex)
+	var tx types.Transaction
+	if err := json.Unmarshal(raw, &tx); err != nil {
+		t.Fatalf("signed transaction %s: %v", raw, err)
+	}
+	if len(tx.Operations) != 2 {
+		t.Fatalf("operations = %d, want 2", len(tx.Operations))
+	}
+
+	// the fees of every transfer are paid, the memo only goes to bob and its
+	// 107 bytes cost 107 * 100000 / 1024
+	fees := uint64(0)
+	for i, want := range []struct {
+		to  string
+		fee uint64
+	}{{bobID, 30449}, {carolID, 20000}} {
+		transfer := tx.Operations[i].(*types.TransferOperation)
+		if transfer.To.String() != want.to || transfer.Fee.Amount != want.fee {
+			t.Errorf("operation %d to %s with fee %d, want %s with %d", i, transfer.To.String(), transfer.Fee.Amount, want.to, want.fee)
+		}
+		if (transfer.Memo != nil) != (want.to == bobID) {
+			t.Errorf("operation %d memo = %v", i, transfer.Memo)
+		}
+		fees += transfer.Fee.Amount
+	}
+	if rawTx.Fees != "0.50449" {
+		t.Errorf("raw transaction fees %s, want 0.50449", rawTx.Fees)
+	}
+
+	if _, err := decoder.SubmitRawTransaction(wallet, rawTx); err != nil {
+		t.Fatalf("SubmitRawTransaction failed unexpected error: %v", err)
+	}
+	node.MintBlock()
+	if got := node.Balance("bob", CoreAssetID); got != 1000 {
+		t.Errorf("bob balance = %d, want 1000", got)
+	}
+	if got := node.Balance("carol", CoreAssetID); got != 2000 {
+		t.Errorf("carol balance = %d, want 2000", got)
+	}
+	if got := node.Balance("alice", CoreAssetID); got != int64(100000-3000-fees) {
+		t.Errorf("alice balance = %d, want %d", got, 100000-3000-fees)
+	}
+
+	// the balance must cover the whole batch
+	rawTx = &openwallet.RawTransaction{
+		Coin:    rawTx.Coin,
+		Account: account,
+		To:      map[string]string{"bob": "0.5", "carol": "0.5"},
+	}
+	if err := decoder.CreateRawTransaction(wallet, rawTx); err == nil {
+		t.Error("CreateRawTransaction of more than the balance succeeded")
+	}
+}
+
+func TestTransactionDecoder_InsufficientBalance(t *testing.T) {
+	node := bitsharestest.NewNode()
+	defer node.Close()
+	node.CreateAccount("alice", testAliceKey)
+	node.CreateAccount("bob", testBobKey)
+	node.CreateAccount("carol", testBobKey)
+	node.SetBalance("alice", CoreAssetID, 1000000)
+
+	bs, _ := testFakeNodeScanner(node)
+	decoder := bs.wm.TxDecoder.(*TransactionDecoder)
+	wallet := bitsharestest.NewWalletDAI()
+	account := wallet.AddAccount("A", "alice", testAliceKey)
+
+	// each transfer is covered by the balance, not both
+	rawTx := &openwallet.RawTransaction{
+		Coin: openwallet.Coin{
+			Symbol:     "BTS",
+			IsContract: true,
+			Contract:   openwallet.SmartContract{Address: CoreAssetID, Decimals: 5},
+		},
+		Account: account,
+		To:      map[string]string{"bob": "6", "carol": "6"},
+	}
+	err := decoder.CreateRawTransaction(wallet, rawTx)
+	owErr, ok := err.(*openwallet.Error)
+	if !ok || owErr.Code() != openwallet.ErrInsufficientBalanceOfAccount {
+		t.Fatalf("CreateRawTransaction = %v, want insufficient balance", err)
+	}
+	if !strings.Contains(err.Error(), "the balance: 10 is not enough") {
+		t.Errorf("error %q does not report the balance of 10 BTS", err)
+	}
+}
+
+func TestTransactionDecoder_AmountPrecision(t *testing.T) {
+	node := bitsharestest.NewNode()
+	defer node.Close()
+	node.CreateAccount("alice", testAliceKey)
+	node.CreateAccount("bob", testBobKey)
+	node.SetBalance("alice", CoreAssetID, 1000000)
+
+	bs, _ := testFakeNodeScanner(node)
+	decoder := bs.wm.TxDecoder.(*TransactionDecoder)
+	wallet := bitsharestest.NewWalletDAI()
+	account := wallet.AddAccount("A", "alice", testAliceKey)
+
+	// BTS has 5 decimals
+	tests := []struct {
+		amount string
+		want   uint64
+		valid  bool
+	}{
+		{"0.00001", 1, true},
+		{"1.2", 120000, true},
+		{"1.20000000", 120000, true},
+		{"0.000001", 0, false},
+		{"1.234567", 0, false},
+	}
+	for _, test := range tests {
+		rawTx := &openwallet.RawTransaction{
+			Coin: openwallet.Coin{
+				Symbol:     "BTS",
+				IsContract: true,
+				Contract:   openwallet.SmartContract{Address: CoreAssetID, Decimals: 5},
+			},
+			Account: account,
+			To:      map[string]string{"bob": test.amount},
+		}
+		err := decoder.CreateRawTransaction(wallet, rawTx)
+		if !test.valid {
+			if err == nil || err.(*openwallet.Error).Code() != openwallet.ErrCreateRawTransactionFailed {
+				t.Errorf("amount %s: CreateRawTransaction = %v, want rejected", test.amount, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("amount %s: CreateRawTransaction failed unexpected error: %v", test.amount, err)
+			continue
+		}
+		raw, _ := hex.DecodeString(rawTx.RawHex)
+		var tx types.Transaction
+		if err := json.Unmarshal(raw, &tx); err != nil {
+			t.Fatalf("amount %s: transaction %s: %v", test.amount, raw, err)
+		}
+		if got := tx.Operations[0].(*types.TransferOperation).Amount.Amount; got != test.want {
+			t.Errorf("amount %s: transfer of %d, want %d", test.amount, got, test.want)
+		}
+	}
+}
+
+func TestTransactionDecoder_FeeAsset(t *testing.T) {
+	node := bitsharestest.NewNode()
+	defer node.Close()
+	node.CreateAccount("alice", testAliceKey)
+	node.CreateAccount("bob", testBobKey)
+	usd := node.CreateAsset("USD", 4, 0)
+	// 10 BTS satoshis for a USD satoshi, the transfer fee is 2000 USD satoshis
+	node.SetCoreExchangeRate(usd, 10, 1)
+	node.SetRequiredFee(types.TransferOpType, usd, 2000)
+	node.SetBalance("alice", usd, 1000000)
+	node.SetFeePool(usd, 100000)
+
+	bs, _ := testFakeNodeScanner(node)
+	decoder := bs.wm.TxDecoder.(*TransactionDecoder)
+	wallet := bitsharestest.NewWalletDAI()
+	account := wallet.AddAccount("A", "alice", testAliceKey)
+
+	transfer := func(extParam string) (*openwallet.RawTransaction, error) {
+		rawTx := &openwallet.RawTransaction{
+			Coin: openwallet.Coin{
+				Symbol:     "BTS",
+				IsContract: true,
+				Contract:   openwallet.SmartContract{Address: usd, Decimals: 4},
+			},
+			Account:  account,
+			To:       map[string]string{"bob": "10"},
+			ExtParam: extParam,
+		}
+		return rawTx, decoder.CreateRawTransaction(wallet, rawTx)
+	}
+
+	// without BTS the fee is paid in USD by the fee pool
+	rawTx, err := transfer("")
+	if err != nil {
+		t.Fatalf("CreateRawTransaction failed unexpected error: %v", err)
+	}
+	if feeAsset := rawTx.GetExtParam().Get("feeAsset").String(); feeAsset != usd || rawTx.Fees != "0.2" {
+		t.Errorf("fees = %s in %s, want 0.2 in %s", rawTx.Fees, feeAsset, usd)
+	}
+	testSignRawTransaction(t, rawTx, testAliceWIF)
+	if err := decoder.VerifyRawTransaction(wallet, rawTx); err != nil {
+		t.Fatalf("VerifyRawTransaction failed unexpected error: %v", err)
+	}
+	submitted, err := decoder.SubmitRawTransaction(wallet, rawTx)
+	if err != nil {
+		t.Fatalf("SubmitRawTransaction failed unexpected error: %v", err)
+	}
+	if feeAsset := gjson.Get(submitted.ExtParam, "feeAsset").String(); submitted.Fees != "0.2" || feeAsset != usd {
+		t.Errorf("submitted fees = %s in %s, want 0.2 in %s", submitted.Fees, feeAsset, usd)
+	}
+	node.MintBlock()
+	if got := node.Balance("alice", usd); got != 1000000-100000-2000 {
+		t.Errorf("alice balance = %d, want %d", got, 1000000-100000-2000)
+	}
+
+	// with BTS the fee is paid in BTS, unless the fee asset is given
+	node.SetBalance("alice", CoreAssetID, 100000)
+	if rawTx, err = transfer(""); err != nil || rawTx.GetExtParam().Get("feeAsset").String() != CoreAssetID {
+		t.Errorf("fees = %s in %s, %v, want BTS", rawTx.Fees, rawTx.GetExtParam().Get("feeAsset").String(), err)
+	}
+	if rawTx, err = transfer(`{"feeAsset":"USD"}`); err != nil || rawTx.GetExtParam().Get("feeAsset").String() != usd {
+		t.Errorf("fees = %s in %s, %v, want USD", rawTx.Fees, rawTx.GetExtParam().Get("feeAsset").String(), err)
+	}
+
+	// the fee pool must cover the fees
+	node.SetFeePool(usd, bitsharestest.DefaultTransferFee-1)
+	node.SetBalance("alice", CoreAssetID, 0)
+	if _, err := transfer(""); err == nil || err.(*openwallet.Error).Code() != openwallet.ErrInsufficientFees {
+		t.Errorf("CreateRawTransaction with an empty fee pool = %v, want insufficient fees", err)
+	}
+	if _, err := transfer(`{"feeAsset":"1.3.0"}`); err == nil || err.(*openwallet.Error).Code() != openwallet.ErrInsufficientFees {
+		t.Errorf("CreateRawTransaction paying BTS without BTS = %v, want insufficient fees", err)
+	}
+}
+
+func TestTransactionDecoder_OperationFees(t *testing.T) {
+	node := bitsharestest.NewNode()
+	defer node.Close()
+	node.CreateAccount("alice", testAliceKey)
+	node.CreateAccount("bob", testBobKey)
+	node.CreateAccount("carol", testBobKey)
+	usd := node.CreateAsset("USD", 4, 0)
+	// 10 BTS satoshis for a USD satoshi, the transfer fee is 2000 USD satoshis
+	node.SetCoreExchangeRate(usd, 10, 1)
+	node.SetRequiredFee(types.TransferOpType, usd, 2000)
+	node.SetBalance("alice", usd, 1000000)
+	node.SetFeePool(usd, 100000)
+
+	bs, _ := testFakeNodeScanner(node)
+	decoder := bs.wm.TxDecoder.(*TransactionDecoder)
+	wallet := bitsharestest.NewWalletDAI()
+	account := wallet.AddAccount("A", "alice", testAliceKey)
+
+	rawTx := &openwallet.RawTransaction{
+		Coin: openwallet.Coin{
+			Symbol:     "BTS",
+			IsContract: true,
+			Contract:   openwallet.SmartContract{Address: usd, Decimals: 4},
+		},
+		Account: account,
+		To:      map[string]string{"carol": "2", "bob": "10"},
+	}
+	if err := decoder.CreateRawTransaction(wallet, rawTx); err != nil {
+		t.Fatalf("CreateRawTransaction failed unexpected error: %v", err)
+	}
+	if feeAsset := rawTx.GetExtParam().Get("feeAsset").String(); feeAsset != usd || rawTx.Fees != "0.4" {
+		t.Errorf("fees = %s in %s, want 0.4 in %s", rawTx.Fees, feeAsset, usd)
+	}
+
+	// one fee for each transfer, in the order of the operations
+	fees := rawTx.GetExtParam().Get("operationFees").Array()
+	testOperationFees(t, fees, []operationFee{
+		{Index: 0, To: "bob", Account: "alice", Asset: usd, Fee: "0.2"},
+		{Index: 1, To: "carol", Account: "alice", Asset: usd, Fee: "0.2"},
+	})
+}
+
+func testOperationFees(t *testing.T, fees []gjson.Result, want []operationFee) {
+	t.Helper()
+	if len(fees) != len(want) {
+		t.Fatalf("operation fees = %v, want %v", fees, want)
+	}
+	for i, fee := range fees {
+		got := operationFee{}
+		if err := json.Unmarshal([]byte(fee.Raw), &got); err != nil {
+			t.Fatalf("operation fee %d: %v", i, err)
+		}
+		if got != want[i] {
+			t.Errorf("operation fee %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestTransactionDecoder_Proposal(t *testing.T) {
+	node := bitsharestest.NewNode()
+	defer node.Close()
+	aliceID := node.CreateAccount("alice", testAliceKey)
+	bobID := node.CreateAccount("bob", testBobKey)
+	carolID := node.CreateAccount("carol", "")
+	treasuryID := node.CreateAccount("treasury", "")
+	vaultID := node.CreateAccount("vault", testAliceKey)
+	// the treasury is controlled by two of its three members
+	node.SetActiveAuthority("treasury", types.Permission{
+		WeightThreshold: 2,
+		AccountAuths: []types.AccountAuth{
+			{Account: types.MustParseObjectID(aliceID), Weight: 1},
+			{Account: types.MustParseObjectID(bobID), Weight: 1},
+			{Account: types.MustParseObjectID(carolID), Weight: 1},
+		},
+	})
+	node.SetBalance("treasury", CoreAssetID, 100000)
+	node.SetBalance("vault", CoreAssetID, 100000)
+	// 40 bytes of proposal_create: 10000 + 40 * 100000 / 1024, and
+	// 19 bytes of proposal_update: 10000 + 19 * 100000 / 1024
+	node.SetRequiredFee(types.ProposalCreateOpType, CoreAssetID, 13906)
+	node.SetRequiredFee(types.ProposalUpdateOpType, CoreAssetID, 11855)
+	node.SetBalance("alice", CoreAssetID, 100000)
+	node.SetBalance("bob", CoreAssetID, 100000)
+
+	bs, _ := testFakeNodeScanner(node)
+	wm := bs.wm
+	decoder := wm.TxDecoder.(*TransactionDecoder)
+	wallet := bitsharestest.NewWalletDAI()
+	alice := wallet.AddAccount("A", "alice", testAliceKey)
+	bob := wallet.AddAccount("B", "bob", testBobKey)
+	vault := wallet.AddAccount("V", "vault", testAliceKey)
+	coin := openwallet.Coin{
+		Symbol:     "BTS",
+		IsContract: true,
+		Contract:   openwallet.SmartContract{Address: CoreAssetID, Decimals: 5},
+	}
+
+	submit := func(rawTx *openwallet.RawTransaction, wif string) {
+		t.Helper()
+		testSignRawTransaction(t, rawTx, wif)
+		if err := decoder.VerifyRawTransaction(wallet, rawTx); err != nil {
+			t.Fatalf("VerifyRawTransaction failed unexpected error: %v", err)
+		}
+		if _, err := decoder.SubmitRawTransaction(wallet, rawTx); err != nil {
+			t.Fatalf("SubmitRawTransaction failed unexpected error: %v", err)
+		}
+		node.MintBlock()
+	}
+	propose := func(from string) *Proposal {
+		t.Helper()
+		rawTx := &openwallet.RawTransaction{
+			Coin:     coin,
+			Account:  alice,
+			To:       map[string]string{"carol": "0.1"},
+			ExtParam: `{"proposal":{"from":"` + from + `","expiration":3600}}`,
+		}
+		if err := decoder.CreateRawTransaction(wallet, rawTx); err != nil {
+			t.Fatalf("CreateRawTransaction failed unexpected error: %v", err)
+		}
+		// alice pays the proposal, the treasury the transfer once executed
+		if rawTx.TxAmount != "0" || strings.Join(rawTx.TxFrom, ",") != from+":0.1" {
+			t.Errorf("TxAmount = %s, TxFrom = %v", rawTx.TxAmount, rawTx.TxFrom)
+		}
+		ext := rawTx.GetExtParam()
+		if rawTx.Fees != "0.13906" || ext.Get("feeAsset").String() != CoreAssetID {
+			t.Errorf("fees = %s in %s, want 0.13906 BTS of the proposal", rawTx.Fees, ext.Get("feeAsset").String())
+		}
+		if ext.Get("proposedFees").String() != "0.2" || ext.Get("proposedFeeAsset").String() != CoreAssetID {
+			t.Errorf("proposed fees = %s in %s, want 0.2 BTS of the transfer", ext.Get("proposedFees").String(), ext.Get("proposedFeeAsset").String())
+		}
+		testOperationFees(t, ext.Get("operationFees").Array(), []operationFee{
+			{Index: 0, Account: "alice", Asset: CoreAssetID, Fee: "0.13906"},
+			{Index: 0, Proposed: true, To: "carol", Account: from, Asset: CoreAssetID, Fee: "0.2"},
+		})
+		submit(rawTx, testAliceWIF)
+
+		proposals, err := wm.GetPendingProposals(from, "alice")
+		if err != nil || len(proposals) != 1 {
+			t.Fatalf("pending proposals = %v, %v, want one", proposals, err)
+		}
+		return proposals[0]
+	}
+	update := func(account *openwallet.AssetsAccount, proposal *Proposal, approve bool) (*openwallet.RawTransaction, error) {
+		rawTx := &openwallet.RawTransaction{Coin: coin, Account: account}
+		return rawTx, decoder.CreateProposalApprovalRawTransaction(wallet, rawTx, proposal.ID.String(), approve)
+	}
+
+	proposal := propose("treasury")
+	if !proposal.Requires(types.MustParseObjectID(treasuryID)) || proposal.Proposer.String() != aliceID {
+		t.Errorf("proposal requires %v, proposed by %s", proposal.RequiredActiveApprovals, proposal.Proposer.String())
+	}
+	transfer := proposal.ProposedTransaction.Operations[0].(*types.TransferOperation)
+	if transfer.From.String() != treasuryID || transfer.To.String() != carolID || transfer.Amount.Amount != 10000 {
+		t.Errorf("proposed transfer %s -> %s of %d", transfer.From.String(), transfer.To.String(), transfer.Amount.Amount)
+	}
+	if got := node.Balance("alice", CoreAssetID); got != 100000-13906 {
+		t.Errorf("alice balance = %d, want %d", got, 100000-13906)
+	}
+
+	// one approval is not enough, an account approves once
+	rawTx, err := update(alice, proposal, true)
+	if err != nil {
+		t.Fatalf("CreateProposalApprovalRawTransaction failed unexpected error: %v", err)
+	}
+	// the update pays for its size as the proposal does
+	if rawTx.Fees != "0.11855" {
+		t.Errorf("approval fees = %s, want 0.11855", rawTx.Fees)
+	}
+	submit(rawTx, testAliceWIF)
+	if got := node.Balance("carol", CoreAssetID); got != 0 {
+		t.Errorf("carol balance = %d before the second approval", got)
+	}
+	if proposal, err = wm.Api.GetProposal(proposal.ID); err != nil || !proposal.Approved(types.MustParseObjectID(aliceID)) {
+		t.Fatalf("proposal = %v, %v, want approved by alice", proposal, err)
+	}
+	if _, err := update(alice, proposal, true); err == nil {
+		t.Error("CreateProposalApprovalRawTransaction approved twice")
+	}
+
+	// the second approval executes the transfer
+	rawTx, err = update(bob, proposal, true)
+	if err != nil {
+		t.Fatalf("CreateProposalApprovalRawTransaction failed unexpected error: %v", err)
+	}
+	submit(rawTx, testBobWIF)
+	if got := node.Balance("carol", CoreAssetID); got != 10000 {
+		t.Errorf("carol balance = %d, want 10000", got)
+	}
+	if got := node.Balance("treasury", CoreAssetID); got != int64(100000-10000-transfer.Fee.Amount) {
+		t.Errorf("treasury balance = %d, want %d", got, 100000-10000-transfer.Fee.Amount)
+	}
+	if proposals, err := wm.GetPendingProposals("treasury"); err != nil || len(proposals) != 0 {
+		t.Errorf("pending proposals = %v, %v after the execution", proposals, err)
+	}
+
+	// an approval is withdrawn, a proposal is deleted by the account it requires
+	proposal = propose("vault")
+	rawTx, _ = update(alice, proposal, true)
+	submit(rawTx, testAliceWIF)
+	rawTx, err = update(alice, proposal, false)
+	if err != nil {
+		t.Fatalf("CreateProposalApprovalRawTransaction failed unexpected error: %v", err)
+	}
+	submit(rawTx, testAliceWIF)
+	if proposal, err = wm.Api.GetProposal(proposal.ID); err != nil || proposal.Approved(types.MustParseObjectID(aliceID)) {
+		t.Fatalf("proposal = %v, %v, want the approval of alice withdrawn", proposal, err)
+	}
+
+	rawTx = &openwallet.RawTransaction{Coin: coin, Account: bob}
+	if err := decoder.CreateProposalDeleteRawTransaction(wallet, rawTx, proposal.ID.String()); err == nil {
+		t.Error("CreateProposalDeleteRawTransaction by an account the proposal does not require succeeded")
+	}
+	if !proposal.Requires(types.MustParseObjectID(vaultID)) {
+		t.Errorf("proposal requires %v, want vault", proposal.RequiredActiveApprovals)
+	}
+	rawTx = &openwallet.RawTransaction{Coin: coin, Account: vault}
+	if err := decoder.CreateProposalDeleteRawTransaction(wallet, rawTx, proposal.ID.String()); err != nil {
+		t.Fatalf("CreateProposalDeleteRawTransaction failed unexpected error: %v", err)
+	}
+	submit(rawTx, testAliceWIF)
+	if _, err := wm.Api.GetProposal(proposal.ID); err == nil {
+		t.Error("deleted proposal still exists")
+	}
+	if _, err := update(bob, proposal, true); err == nil {
+		t.Error("CreateProposalApprovalRawTransaction of a deleted proposal succeeded")
+	}
+}
+
+func TestTransactionDecoder_VerifySigners(t *testing.T) {
+	node := bitsharestest.NewNode()
+	defer node.Close()
+	node.CreateAccount("alice", testAliceKey)
+	node.CreateAccount("bob", testBobKey)
+	node.SetBalance("alice", CoreAssetID, 100000)
+	// alice is controlled by both keys
+	node.SetActiveAuthority("alice", types.Permission{
+		WeightThreshold: 2,
+		KeyAuths: []types.KeyAuth{
+			{Key: types.PublicKey(testAliceKey), Weight: 1},
+			{Key: types.PublicKey(testBobKey), Weight: 1},
+		},
+	})
+
+	bs, _ := testFakeNodeScanner(node)
+	decoder := bs.wm.TxDecoder.(*TransactionDecoder)
+	wallet := bitsharestest.NewWalletDAI()
+	single := wallet.AddAccount("A", "alice", testAliceKey)
+	both := wallet.AddAccount("M", "alice", testAliceKey, testBobKey)
+
+	newTx := func(account *openwallet.AssetsAccount) *openwallet.RawTransaction {
+		t.Helper()
+		rawTx := &openwallet.RawTransaction{
+			Coin: openwallet.Coin{
+				Symbol:     "BTS",
+				IsContract: true,
+				Contract:   openwallet.SmartContract{Address: CoreAssetID, Decimals: 5},
+			},
+			Account: account,
+			To:      map[string]string{"bob": "0.01"},
+		}
+		if err := decoder.CreateRawTransaction(wallet, rawTx); err != nil {
+			t.Fatalf("CreateRawTransaction failed unexpected error: %v", err)
+		}
+		return rawTx
+	}
+	verifyFails := func(rawTx *openwallet.RawTransaction, reason string) {
+		t.Helper()
+		err := decoder.VerifyRawTransaction(wallet, rawTx)
+		if err == nil || err.(*openwallet.Error).Code() != openwallet.ErrVerifyRawTransactionFailed || !strings.Contains(err.Error(), reason) {
+			t.Errorf("VerifyRawTransaction = %v, want %q", err, reason)
+		}
+	}
+
+	// the key signing is not the one of the address
+	rawTx := newTx(single)
+	testSignRawTransaction(t, rawTx, testBobWIF)
+	verifyFails(rawTx, "is signed by "+testBobKey)
+
+	// the same signature with the high s is valid but rejected by the nodes
+	rawTx = newTx(single)
+	testSignRawTransaction(t, rawTx, testAliceWIF)
+	keySignature := rawTx.Signatures["A"][0]
+	signature, _ := hex.DecodeString(keySignature.Signature)
+	s := new(big.Int).Sub(btcec.S256().N, new(big.Int).SetBytes(signature[32:64]))
+	copy(signature[32:64], s.Bytes())
+	signature[64] ^= 1
+	keySignature.Signature = hex.EncodeToString(signature)
+	verifyFails(rawTx, "is not canonical")
+
+	// one key does not reach the threshold
+	rawTx = newTx(single)
+	testSignRawTransaction(t, rawTx, testAliceWIF)
+	verifyFails(rawTx, "weigh 1, its active authority requires 2")
+
+	rawTx = newTx(both)
+	wifs := map[string]string{testAliceKey: testAliceWIF, testBobKey: testBobWIF}
+	for _, keySignature := range rawTx.Signatures["M"] {
+		hash, _ := hex.DecodeString(keySignature.Message)
+		signature, err := txsigner.Default.SignTransactionHashWithWIF(hash, wifs[keySignature.Address.Address])
+		if err != nil {
+			t.Fatalf("sign %s failed unexpected error: %v", keySignature.Message, err)
+		}
+		keySignature.Signature = hex.EncodeToString(signature)
+	}
+	if err := decoder.VerifyRawTransaction(wallet, rawTx); err != nil {
+		t.Fatalf("VerifyRawTransaction failed unexpected error: %v", err)
+	}
+	raw, _ := hex.DecodeString(rawTx.RawHex)
+	var tx types.Transaction
+	if err := json.Unmarshal(raw, &tx); err != nil || len(tx.Signatures) != 2 {
+		t.Fatalf("signed transaction %s: %v, want two signatures", raw, err)
+	}
+	if _, err := decoder.SubmitRawTransaction(wallet, rawTx); err != nil {
+		t.Fatalf("SubmitRawTransaction failed unexpected error: %v", err)
+	}
+}
+
+func TestTransactionDecoder_VerifyAccountAuthority(t *testing.T) {
+	node := bitsharestest.NewNode()
+	defer node.Close()
+	bobID := node.CreateAccount("bob", testBobKey)
+	// vault is controlled by bob, deep by vault and deeper by deep
+	vaultID := node.CreateAccount("vault", testAliceKey)
+	deepID := node.CreateAccount("deep", testAliceKey)
+	node.CreateAccount("deeper", testAliceKey)
+	node.SetActiveAuthority("vault", types.Permission{WeightThreshold: 1, AccountAuths: []types.AccountAuth{{Account: types.MustParseObjectID(bobID), Weight: 1}}})
+	node.SetActiveAuthority("deep", types.Permission{WeightThreshold: 1, AccountAuths: []types.AccountAuth{{Account: types.MustParseObjectID(vaultID), Weight: 1}}})
+	node.SetActiveAuthority("deeper", types.Permission{WeightThreshold: 1, AccountAuths: []types.AccountAuth{{Account: types.MustParseObjectID(deepID), Weight: 1}}})
+	for _, name := range []string{"bob", "vault", "deep", "deeper"} {
+		node.SetBalance(name, CoreAssetID, 100000)
+	}
+
+	bs, _ := testFakeNodeScanner(node)
+	decoder := bs.wm.TxDecoder.(*TransactionDecoder)
+	wallet := bitsharestest.NewWalletDAI()
+	bob := wallet.AddAccount("B", "bob", testBobKey)
+
+	// the key of bob signs for the accounts bob controls
+	signedByBob := func(name string) *openwallet.RawTransaction {
+		t.Helper()
+		rawTx := &openwallet.RawTransaction{
+			Coin: openwallet.Coin{
+				Symbol:     "BTS",
+				IsContract: true,
+				Contract:   openwallet.SmartContract{Address: CoreAssetID, Decimals: 5},
+			},
+			Account: wallet.AddAccount(strings.ToUpper(name), name, testBobKey),
+			To:      map[string]string{"bob": "0.01"},
+		}
+		if err := decoder.CreateRawTransaction(wallet, rawTx); err != nil {
+			t.Fatalf("CreateRawTransaction failed unexpected error: %v", err)
+		}
+		testSignRawTransaction(t, rawTx, testBobWIF)
+		return rawTx
+	}
+
+	for _, name := range []string{"vault", "deep"} {
+		if err := decoder.VerifyRawTransaction(wallet, signedByBob(name)); err != nil {
+			t.Errorf("VerifyRawTransaction of %s failed unexpected error: %v", name, err)
+		}
+	}
+
+	// the nodes do not look deeper than two accounts
+	err := decoder.VerifyRawTransaction(wallet, signedByBob("deeper"))
+	if err == nil || !strings.Contains(err.Error(), "weigh 0, its active authority requires 1") {
+		t.Errorf("VerifyRawTransaction of deeper = %v, want the authority unsatisfied", err)
+	}
+
+	// the signature of bob for vault and for bob is added once
+	rawTx := signedByBob("vault")
+	rawTx.Signatures[bob.AccountID] = rawTx.Signatures[rawTx.Account.AccountID]
+	if err := decoder.VerifyRawTransaction(wallet, rawTx); err != nil {
+		t.Fatalf("VerifyRawTransaction failed unexpected error: %v", err)
+	}
+	raw, _ := hex.DecodeString(rawTx.RawHex)
+	var tx types.Transaction
+	if err := json.Unmarshal(raw, &tx); err != nil || len(tx.Signatures) != 1 {
+		t.Errorf("signed transaction %s: %v, want one signature", raw, err)
+	}
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2018 The OpenWallet Authors
+ * This file is part of the OpenWallet library.
+ *
+ * The OpenWallet library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The OpenWallet library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ */
+
+package bitsharestest
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/blocktree/openwallet/v2/openwallet"
+)
+
+// BlockchainDAI keeps the scanner's block heads and unscanned records in memory
+type BlockchainDAI struct {
+	openwallet.BlockchainDAIBase
+
+	mutex  sync.Mutex // protects the following
+	head   map[string]*openwallet.BlockHeader
+	blocks map[string]map[uint64]*openwallet.BlockHeader
+	unscan map[string]*openwallet.UnscanRecord
+}
+
+// NewBlockchainDAI returns an empty BlockchainDAI
+func NewBlockchainDAI() *BlockchainDAI {
+	return &BlockchainDAI{
+		head:   make(map[string]*openwallet.BlockHeader),
+		blocks: make(map[string]map[uint64]*openwallet.BlockHeader),
+		unscan: make(map[string]*openwallet.UnscanRecord),
+	}
+}
+
+func (dai *BlockchainDAI) SaveCurrentBlockHead(header *openwallet.BlockHeader) error {
+	dai.mutex.Lock()
+	defer dai.mutex.Unlock()
+	dai.head[header.Symbol] = header
+	return nil
+}
+
+func (dai *BlockchainDAI) GetCurrentBlockHead(symbol string) (*openwallet.BlockHeader, error) {
+	dai.mutex.Lock()
+	defer dai.mutex.Unlock()
+	header, ok := dai.head[symbol]
+	if !ok {
+		return nil, fmt.Errorf("no block head of %s", symbol)
+	}
+	return header, nil
+}
+
+func (dai *BlockchainDAI) SaveLocalBlockHead(header *openwallet.BlockHeader) error {
+	dai.mutex.Lock()
+	defer dai.mutex.Unlock()
+	if dai.blocks[header.Symbol] == nil {
+		dai.blocks[header.Symbol] = make(map[uint64]*openwallet.BlockHeader)
+	}
+	dai.blocks[header.Symbol][header.Height] = header
+	return nil
+}
+
+func (dai *BlockchainDAI) GetLocalBlockHeadByHeight(height uint64, symbol string) (*openwallet.BlockHeader, error) {
+	dai.mutex.Lock()
+	defer dai.mutex.Unlock()
+	header, ok := dai.blocks[symbol][height]
+	if !ok {
+		return nil, fmt.Errorf("no block %d of %s", height, symbol)
+	}
+	return header, nil
+}
+
+func (dai *BlockchainDAI) SaveUnscanRecord(record *openwallet.UnscanRecord) error {
+	dai.mutex.Lock()
+	defer dai.mutex.Unlock()
+	dai.unscan[record.ID] = record
+	return nil
+}
+
+func (dai *BlockchainDAI) DeleteUnscanRecordByHeight(height uint64, symbol string) error {
+	dai.mutex.Lock()
+	defer dai.mutex.Unlock()
+	for id, r := range dai.unscan {
+		if r.BlockHeight == height && r.Symbol == symbol {
+			delete(dai.unscan, id)
+		}
+	}
+	return nil
+}
+
+func (dai *BlockchainDAI) DeleteUnscanRecordByID(id string, symbol string) error {
+	dai.mutex.Lock()
+	defer dai.mutex.Unlock()
+	delete(dai.unscan, id)
+	return nil
+}
+
+func (dai *BlockchainDAI) GetUnscanRecords(symbol string) ([]*openwallet.UnscanRecord, error) {
+	dai.mutex.Lock()
+	defer dai.mutex.Unlock()
+	records := make([]*openwallet.UnscanRecord, 0)
+	for _, r := range dai.unscan {
+		if r.Symbol == symbol {
+			records = append(records, r)
+		}
+	}
+	return records, nil
+}
+
+func (dai *BlockchainDAI) SetMaxBlockCache(max uint64, symbol string) error {
+	return nil
+}
@@ -0,0 +1,1681 @@
+/*
+ * Copyright 2018 The OpenWallet Authors
+ * This file is part of the OpenWallet library.
+ *
+ * The OpenWallet library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The OpenWallet library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ */
+
+// Package bitsharestest provides an in-process graphene node for testing the
+// adapter without a network connection.
+package bitsharestest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blocktree/bitshares-adapter/addrdec"
+	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/blocktree/bitshares-adapter/txsigner"
+	"github.com/blocktree/bitshares-adapter/types"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// CoreAssetID is the asset fees are paid in
+	CoreAssetID = "1.3.0"
+	// CoreAssetSymbol and CoreAssetPrecision are the symbol and the precision of the core asset
+	CoreAssetSymbol    = "BTS"
+	CoreAssetPrecision = 5
+	// BlockInterval is the time between two minted blocks
+	BlockInterval = 3 * time.Second
+	// DefaultTransferFee is the fee of a transfer in the core asset, in the fee
+	// schedule and required by the node
+	DefaultTransferFee = 20000
+	// DefaultProposalFee is the fee of a proposal_create, a proposal_update and a
+	// proposal_delete in the core asset, in the fee schedule and required by the node
+	DefaultProposalFee = 10000
+	// DefaultPricePerKbyte is the price of a kilobyte of transfer memo or proposal in the core asset
+	DefaultPricePerKbyte = 100000
+	// FeeScaleBase is the fee scale of 100%
+	FeeScaleBase = 10000
+	// MaintenanceInterval is the time between two maintenances, when the fee schedule may change
+	MaintenanceInterval = time.Minute
+	// IrreversibleDepth is the number of blocks between the head and the last irreversible block
+	IrreversibleDepth = 15
+)
+
+// GenesisTime is the timestamp of the first block
+var GenesisTime = time.Date(2019, 7, 17, 0, 0, 0, 0, time.UTC)
+
+// Block is a block of the fake chain. Its transactions carry their operation
+// results and its header is signed by the witness, as on a real chain.
+type Block struct {
+	Height           uint32
+	ID               string
+	Previous         string
+	Timestamp        time.Time
+	Witness          string
+	MerkleRoot       string
+	WitnessSignature string
+	Transactions     []json.RawMessage
+	TransactionIDs   []string
+}
+
+// Account is an account of the fake chain
+type Account struct {
+	ID      string
+	Name    string
+	MemoKey string
+
+	// Active is the active authority, the memo key alone when nil
+	Active *types.Permission
+}
+
+// Asset is an asset of the fake chain
+type Asset struct {
+	ID        string
+	Symbol    string
+	Precision uint8
+	Flags     uint16
+
+	// core_exchange_rate: CoreAmount of the core asset for Amount of the asset
+	CoreAmount int64
+	Amount     int64
+
+	// FeePool is the core asset paying the fees paid in the asset
+	FeePool int64
+}
+
+// lockedObject is a limit order, a call order or a vesting balance holding funds
+// out of the balance of its owner
+type lockedObject struct {
+	id    string
+	owner string
+	json  map[string]interface{}
+}
+
+// proposal is a proposed transaction waiting for approvals. It is executed once
+// approved, or at its expiration when it has a review period.
+type proposal struct {
+	id         string
+	proposer   string
+	expiration time.Time
+	review     *time.Time
+	ops        types.Operations
+	required   []string        // accounts whose active authority is required
+	approvals  map[string]bool // available active approvals
+	keys       map[string]bool // available key approvals
+	failReason string
+}
+
+// pendingTx is a transaction accepted by the node but not in a block yet
+type pendingTx struct {
+	id  string
+	raw json.RawMessage
+}
+
+// rpcFailure is an error answered to a call, in the graphene format
+type rpcFailure struct {
+	name    string
+	message string
+}
+
+func (f *rpcFailure) Error() string {
+	return f.name + ": " + f.message
+}
+
+func fail(name, format string, args ...interface{}) *rpcFailure {
+	return &rpcFailure{name: name, message: fmt.Sprintf(format, args...)}
+}
+
+// Node is a fake witness_node serving the database and network_broadcast APIs
+// over HTTP from an in-memory ledger. Broadcast transactions are applied at once and
+// included in the next minted block.
+type Node struct {
+	URL string
+
+	// Batch makes the node answer json-rpc batch arrays over http. A witness_node
+	// does not: it answers an array with a bad_cast_exception, as the node does
+	// when Batch is false.
+	Batch bool
+
+	// BlockInfo makes get_block answer with the block_id and transaction_ids, as the
+	// cli_wallet does
+	BlockInfo bool
+
+	server *httptest.Server
+
+	mutex     sync.Mutex // protects the following
+	blocks    []*Block
+	accounts  []*Account
+	assets    []*Asset
+	balances  map[string]map[string]int64 // account id -> asset id -> amount
+	fees      map[types.OpType]int64
+	kbyte     int64                             // price per kilobyte of transfer memo or proposal
+	scale     int64                             // fee scale, FeeScaleBase is 100%
+	required  map[types.OpType]map[string]int64 // fee required for an operation type in each asset
+	pending   []pendingTx
+	known     map[string]bool // ids of the transactions accepted
+	forks     int
+	calls     map[string]int
+	locked    []lockedObject // limit orders, call orders and vesting balances
+	proposals []*proposal
+	proposed  int // proposals ever created, the instance of the next one
+}
+
+// NewNode starts a fake node with a single block
+func NewNode() *Node {
+	n := Node{
+		assets:   []*Asset{{ID: CoreAssetID, Symbol: CoreAssetSymbol, Precision: CoreAssetPrecision, CoreAmount: 1, Amount: 1}},
+		balances: make(map[string]map[string]int64),
+		fees: map[types.OpType]int64{
+			types.TransferOpType:       DefaultTransferFee,
+			types.ProposalCreateOpType: DefaultProposalFee,
+			types.ProposalUpdateOpType: DefaultProposalFee,
+			types.ProposalDeleteOpType: DefaultProposalFee,
+		},
+		kbyte: DefaultPricePerKbyte,
+		scale: FeeScaleBase,
+		required: map[types.OpType]map[string]int64{
+			types.TransferOpType:       {CoreAssetID: DefaultTransferFee},
+			types.ProposalCreateOpType: {CoreAssetID: DefaultProposalFee},
+			types.ProposalUpdateOpType: {CoreAssetID: DefaultProposalFee},
+			types.ProposalDeleteOpType: {CoreAssetID: DefaultProposalFee},
+		},
+		known: make(map[string]bool),
+		calls: make(map[string]int),
+	}
+	n.mint()
+	n.server = httptest.NewServer(http.HandlerFunc(n.serveHTTP))
+	n.URL = n.server.URL
+	return &n
+}
+
+// Close shuts the node down
+func (n *Node) Close() {
+	n.server.Close()
+}
+
+// CreateAccount registers an account and returns its id. The id of an
+// existing account is returned as is.
+func (n *Node) CreateAccount(name, memoKey string) string {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if a := n.account(name); a != nil {
+		return a.ID
+	}
+	a := &Account{
+		ID:      fmt.Sprintf("1.2.%d", len(n.accounts)),
+		Name:    name,
+		MemoKey: memoKey,
+	}
+	n.accounts = append(n.accounts, a)
+	return a.ID
+}
+
+// CreateAsset registers an asset and returns its id. The id of an existing
+// asset is returned as is.
+func (n *Node) CreateAsset(symbol string, precision uint8, flags uint16) string {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if a := n.asset(symbol); a != nil {
+		return a.ID
+	}
+	a := &Asset{
+		ID:         fmt.Sprintf("1.3.%d", len(n.assets)),
+		Symbol:     symbol,
+		Precision:  precision,
+		Flags:      flags,
+		CoreAmount: 1,
+		Amount:     1,
+	}
+	n.assets = append(n.assets, a)
+	return a.ID
+}
+
+// SetMemoKey changes the memo key of an account, by name or id, as an
+// account_update would. No transaction is recorded.
+func (n *Node) SetMemoKey(account, memoKey string) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	a := n.account(account)
+	if a == nil {
+		panic("unknown account " + account)
+	}
+	a.MemoKey = memoKey
+}
+
+// SetActiveAuthority changes the active authority of an account, by name or id,
+// as an account_update would. No transaction is recorded.
+func (n *Node) SetActiveAuthority(account string, authority types.Permission) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	a := n.account(account)
+	if a == nil {
+		panic("unknown account " + account)
+	}
+	a.Active = &authority
+}
+
+// AddLimitOrder records a limit order of the account selling amount of the asset
+// for the core asset. The balance of the account is left unchanged.
+func (n *Node) AddLimitOrder(account, asset string, amount int64) string {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	a := n.lockedOwner(account)
+	id := fmt.Sprintf("1.7.%d", len(n.locked))
+	n.locked = append(n.locked, lockedObject{id: id, owner: a.ID, json: map[string]interface{}{
+		"id":         id,
+		"expiration": "2106-02-07T06:28:15",
+		"seller":     a.ID,
+		"for_sale":   amount,
+		"sell_price": map[string]interface{}{
+			"base":  map[string]interface{}{"amount": amount, "asset_id": asset},
+			"quote": map[string]interface{}{"amount": amount, "asset_id": CoreAssetID},
+		},
+		"deferred_fee": 0,
+	}})
+	return id
+}
+
+// AddCallOrder records a call order of the account borrowing debt of the debt asset
+// against collateral of the collateral asset. The balance of the account is left unchanged.
+func (n *Node) AddCallOrder(account, collateralAsset string, collateral int64, debtAsset string, debt int64) string {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	a := n.lockedOwner(account)
+	id := fmt.Sprintf("1.8.%d", len(n.locked))
+	n.locked = append(n.locked, lockedObject{id: id, owner: a.ID, json: map[string]interface{}{
+		"id":         id,
+		"borrower":   a.ID,
+		"collateral": collateral,
+		"debt":       debt,
+		"call_price": map[string]interface{}{
+			"base":  map[string]interface{}{"amount": collateral, "asset_id": collateralAsset},
+			"quote": map[string]interface{}{"amount": debt, "asset_id": debtAsset},
+		},
+	}})
+	return id
+}
+
+// AddVestingBalance records a vesting balance of the account. The balance of the
+// account is left unchanged.
+func (n *Node) AddVestingBalance(account, asset string, amount int64) string {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	a := n.lockedOwner(account)
+	id := fmt.Sprintf("1.13.%d", len(n.locked))
+	n.locked = append(n.locked, lockedObject{id: id, owner: a.ID, json: map[string]interface{}{
+		"id":      id,
+		"owner":   a.ID,
+		"balance": map[string]interface{}{"amount": amount, "asset_id": asset},
+		"policy": []interface{}{1, map[string]interface{}{
+			"vesting_seconds":                 86400,
+			"start_claim":                     "1970-01-01T00:00:00",
+			"coin_seconds_earned":             "0",
+			"coin_seconds_earned_last_update": "2019-07-17T00:00:00",
+		}},
+	}})
+	return id
+}
+
+// SetBalance sets the balance of an account, by name or id
+func (n *Node) SetBalance(account, asset string, amount int64) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	a := n.account(account)
+	if a == nil {
+		panic("unknown account " + account)
+	}
+	n.balanceOf(a.ID)[asset] = amount
+}
+
+// Balance returns the balance of an account, by name or id
+func (n *Node) Balance(account, asset string) int64 {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	a := n.account(account)
+	if a == nil {
+		return 0
+	}
+	return n.balances[a.ID][asset]
+}
+
+// SetFee sets the fee of an operation type in the core asset in the fee schedule,
+// before scaling. The fee the node requires is set by SetRequiredFee.
+func (n *Node) SetFee(opType types.OpType, amount int64) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.fees[opType] = amount
+}
+
+// SetPricePerKbyte sets the price of a kilobyte of transfer memo or proposal in the core asset
+// in the fee schedule, before scaling
+func (n *Node) SetPricePerKbyte(amount int64) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.kbyte = amount
+}
+
+// SetFeeScale sets the scale of every fee in the fee schedule, FeeScaleBase is 100%
+func (n *Node) SetFeeScale(scale int64) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.scale = scale
+}
+
+// SetRequiredFee sets the fee the node requires for an operation type paid in the
+// asset, which get_required_fees answers. The fees are not calculated from the
+// fee schedule: each fixture states the fees it expects, the price of a memo or
+// of the proposal data included. The fee in the core asset is also the one a
+// fee in another asset takes from its fee pool.
+func (n *Node) SetRequiredFee(opType types.OpType, asset string, amount int64) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.required[opType] == nil {
+		n.required[opType] = make(map[string]int64)
+	}
+	n.required[opType][asset] = amount
+}
+
+// SetCoreExchangeRate sets the core_exchange_rate of an asset: coreAmount of the
+// core asset for amount of the asset
+func (n *Node) SetCoreExchangeRate(asset string, coreAmount, amount int64) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	a := n.asset(asset)
+	if a == nil {
+		panic("unknown asset " + asset)
+	}
+	a.CoreAmount, a.Amount = coreAmount, amount
+}
+
+// SetFeePool sets the fee pool of an asset, the core asset its fees are paid with
+func (n *Node) SetFeePool(asset string, amount int64) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	a := n.asset(asset)
+	if a == nil {
+		panic("unknown asset " + asset)
+	}
+	a.FeePool = amount
+}
+
+// Transfer applies a transfer paying the fee required in the core asset, as
+// if it had been broadcast, and returns the id of its transaction. The node does
+// not hold the private keys: the transaction is not signed and its authority is
+// not checked.
+func (n *Node) Transfer(from, to, asset string, amount int64) (string, error) {
+	n.mutex.Lock()
+	fromAccount, toAccount := n.account(from), n.account(to)
+	head := n.blocks[len(n.blocks)-1]
+	n.mutex.Unlock()
+
+	if fromAccount == nil || toAccount == nil {
+		return "", fmt.Errorf("unknown account %s or %s", from, to)
+	}
+
+	op := types.NewTransferOperation(
+		types.MustParseObjectID(fromAccount.ID),
+		types.MustParseObjectID(toAccount.ID),
+		types.AssetAmount{Amount: uint64(amount), AssetID: types.MustParseObjectID(asset)},
+		types.AssetAmount{Amount: uint64(n.requiredFee(types.TransferOpType)), AssetID: types.MustParseObjectID(CoreAssetID)},
+	)
+	tx, err := types.NewTransaction(head.ID, head.Timestamp.Add(time.Hour))
+	if err != nil {
+		return "", err
+	}
+	tx.PushOperation(op)
+	tx.Signatures = []string{strings.Repeat("00", 65)}
+
+	raw, err := json.Marshal(tx)
+	if err != nil {
+		return "", err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	id, failure := n.broadcast(raw, false)
+	if failure != nil {
+		return "", failure
+	}
+	return id, nil
+}
+
+// MintBlock produces a block with every pending transaction
+func (n *Node) MintBlock() *Block {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return n.mint()
+}
+
+// MintBlocks produces count blocks
+func (n *Node) MintBlocks(count int) {
+	for i := 0; i < count; i++ {
+		n.MintBlock()
+	}
+}
+
+// Fork replaces the last depth blocks with a longer branch of depth+1
+// blocks. The transactions of the replaced blocks go into the new branch.
+func (n *Node) Fork(depth int) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if depth >= len(n.blocks) {
+		depth = len(n.blocks) - 1
+	}
+
+	orphans := make([]pendingTx, 0)
+	for _, b := range n.blocks[len(n.blocks)-depth:] {
+		for i, raw := range b.Transactions {
+			orphans = append(orphans, pendingTx{id: b.TransactionIDs[i], raw: raw})
+		}
+	}
+	n.blocks = n.blocks[:len(n.blocks)-depth]
+	n.pending = append(orphans, n.pending...)
+	n.forks++
+
+	for i := 0; i <= depth; i++ {
+		n.mint()
+	}
+}
+
+// HeadBlock returns the last block
+func (n *Node) HeadBlock() *Block {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return n.blocks[len(n.blocks)-1]
+}
+
+// Block returns the block at the height, nil if not minted yet
+func (n *Node) Block(height uint32) *Block {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return n.block(height)
+}
+
+// Calls returns how many times a method has been called
+func (n *Node) Calls(method string) int {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return n.calls[method]
+}
+
+func (n *Node) mint() *Block {
+	// every fork is produced by another witness, so its blocks get other ids
+	b := &Block{
+		Height:  uint32(len(n.blocks) + 1),
+		Witness: fmt.Sprintf("1.6.%d", n.forks+1),
+	}
+	if len(n.blocks) > 0 {
+		b.Previous = n.blocks[len(n.blocks)-1].ID
+	} else {
+		b.Previous = strings.Repeat("0", 40)
+	}
+	b.Timestamp = GenesisTime.Add(time.Duration(b.Height) * BlockInterval)
+	n.expireProposals(b.Timestamp)
+
+	for _, tx := range n.pending {
+		b.Transactions = append(b.Transactions, processed(tx.raw))
+		b.TransactionIDs = append(b.TransactionIDs, tx.id)
+	}
+	n.pending = nil
+
+	root, err := merkleRoot(b.Transactions)
+	if err != nil {
+		panic(err)
+	}
+	b.MerkleRoot = root
+
+	header := blockHeader(b)
+	if err := header.Sign(witnessKey(b.Witness)); err != nil {
+		panic(err)
+	}
+	b.WitnessSignature = header.WitnessSignature
+	if b.ID, err = header.ID(); err != nil {
+		panic(err)
+	}
+
+	n.blocks = append(n.blocks, b)
+	return b
+}
+
+// blockHeader returns the signed header of the block
+func blockHeader(b *Block) *types.BlockHeader {
+	signature := b.WitnessSignature
+	if len(signature) == 0 {
+		signature = strings.Repeat("00", 65)
+	}
+	return &types.BlockHeader{
+		Previous:              b.Previous,
+		Timestamp:             types.NewTime(b.Timestamp),
+		Witness:               types.MustParseObjectID(b.Witness),
+		TransactionMerkleRoot: b.MerkleRoot,
+		WitnessSignature:      signature,
+	}
+}
+
+// processed adds the results of the operations to a transaction, as it is kept in a block
+func processed(raw json.RawMessage) json.RawMessage {
+	var tx map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &tx); err != nil {
+		panic(err)
+	}
+	var ops []json.RawMessage
+	json.Unmarshal(tx["operations"], &ops)
+
+	results := make([]interface{}, len(ops))
+	for i := range results {
+		results[i] = []interface{}{types.VoidResult, struct{}{}}
+	}
+	tx["operation_results"], _ = json.Marshal(results)
+
+	b, err := json.Marshal(tx)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// merkleRoot returns the transaction_merkle_root of a block with the transactions
+func merkleRoot(raws []json.RawMessage) (string, error) {
+	txs := make([]*types.Transaction, len(raws))
+	for i, raw := range raws {
+		txs[i] = &types.Transaction{}
+		if err := json.Unmarshal(raw, txs[i]); err != nil {
+			return "", err
+		}
+	}
+	return types.MerkleRoot(txs)
+}
+
+// witnessKey returns the private signing key of a witness
+func witnessKey(witness string) *btcec.PrivateKey {
+	seed := sha256.Sum256([]byte("bitsharestest witness " + witness))
+	key, _ := btcec.PrivKeyFromBytes(btcec.S256(), seed[:])
+	return key
+}
+
+// WitnessSigningKey returns the public signing key of a witness, in the BTS... form
+func WitnessSigningKey(witness string) string {
+	key, _ := addrdec.Default.AddressEncode(witnessKey(witness).PubKey().SerializeCompressed())
+	return key
+}
+
+func (n *Node) block(height uint32) *Block {
+	if height == 0 || int(height) > len(n.blocks) {
+		return nil
+	}
+	return n.blocks[height-1]
+}
+
+func (n *Node) account(nameOrID string) *Account {
+	for _, a := range n.accounts {
+		if a.ID == nameOrID || a.Name == nameOrID {
+			return a
+		}
+	}
+	return nil
+}
+
+func (n *Node) asset(symbolOrID string) *Asset {
+	for _, a := range n.assets {
+		if a.ID == symbolOrID || a.Symbol == symbolOrID {
+			return a
+		}
+	}
+	return nil
+}
+
+func (n *Node) lockedOwner(account string) *Account {
+	a := n.account(account)
+	if a == nil {
+		panic("unknown account " + account)
+	}
+	return a
+}
+
+// lockedObjects returns the objects of the owner whose id starts with the prefix
+func (n *Node) lockedObjects(owner, prefix string) []interface{} {
+	objects := make([]interface{}, 0)
+	for _, o := range n.locked {
+		if o.owner == owner && strings.HasPrefix(o.id, prefix) {
+			objects = append(objects, o.json)
+		}
+	}
+	return objects
+}
+
+func (n *Node) balanceOf(accountID string) map[string]int64 {
+	b, ok := n.balances[accountID]
+	if !ok {
+		b = make(map[string]int64)
+		n.balances[accountID] = b
+	}
+	return b
+}
+
+// requiredFee returns the fee required for an operation type in the core asset
+func (n *Node) requiredFee(opType types.OpType) int64 {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return n.required[opType][CoreAssetID]
+}
+
+// requiredFeeIn returns the fee required for an operation type in the asset
+func (n *Node) requiredFeeIn(opType types.OpType, assetID string) (int64, *rpcFailure) {
+	fee, ok := n.required[opType][assetID]
+	if !ok {
+		return 0, fail("assert_exception", "no fee of operation %d in %s is set in the fake node", opType, assetID)
+	}
+	return fee, nil
+}
+
+// serialize returns the serialization of a value, of a transaction without signatures
+func serialize(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	if err := encoding.NewEncoder(&b).Encode(v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func (n *Node) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	req := gjson.ParseBytes(body)
+	if req.IsArray() {
+		if !n.Batch {
+			writeResponse(w, 0, nil, fail("bad_cast_exception", "Bad Cast:Invalid cast from array_type to Object"))
+			return
+		}
+		responses := make([]json.RawMessage, 0)
+		for _, item := range req.Array() {
+			result, failure := n.handle(item.Get("method").String(), item.Get("params"))
+			responses = append(responses, response(item.Get("id").Value(), result, failure))
+		}
+		raw, _ := json.Marshal(responses)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(raw)
+		return
+	}
+
+	result, failure := n.handle(req.Get("method").String(), req.Get("params"))
+	writeResponse(w, req.Get("id").Value(), result, failure)
+}
+
+func response(id interface{}, result interface{}, failure *rpcFailure) json.RawMessage {
+	resp := map[string]interface{}{
+		"id":      id,
+		"jsonrpc": "2.0",
+	}
+	if failure != nil {
+		resp["error"] = map[string]interface{}{
+			"code":    1,
+			"message": failure.message,
+			"data": map[string]interface{}{
+				"code":    10,
+				"name":    failure.name,
+				"message": failure.message,
+				"stack":   []interface{}{map[string]interface{}{"format": failure.message}},
+			},
+		}
+	} else {
+		resp["result"] = result
+	}
+	raw, _ := json.Marshal(resp)
+	return raw
+}
+
+func writeResponse(w http.ResponseWriter, id interface{}, result interface{}, failure *rpcFailure) {
+	w.Header().Set("Content-Type", "application/json")
+	if failure != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	w.Write(response(id, result, failure))
+}
+
+// handle answers a call, unwrapping the ["api", "method", params] form of "call"
+func (n *Node) handle(method string, params gjson.Result) (interface{}, *rpcFailure) {
+	api := ""
+	if method == "call" {
+		arr := params.Array()
+		if len(arr) != 3 {
+			return nil, fail("assert_exception", "call expects [api, method, params]")
+		}
+		api, method, params = arr[0].String(), arr[1].String(), arr[2]
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.calls[method]++
+	args := params.Array()
+
+	switch method {
+	case "get_dynamic_global_properties":
+		return n.dynamicGlobalProperties(), nil
+	case "get_global_properties":
+		return n.globalProperties(), nil
+	case "get_block":
+		if len(args) != 1 {
+			return nil, fail("assert_exception", "get_block expects [block_num]")
+		}
+		b := n.block(uint32(args[0].Uint()))
+		if b == nil {
+			return nil, nil
+		}
+		block := blockJSON(b)
+		if n.BlockInfo {
+			block["block_id"] = b.ID
+			block["transaction_ids"] = append([]string{}, b.TransactionIDs...)
+		}
+		return block, nil
+	case "get_objects":
+		if len(args) != 1 {
+			return nil, fail("assert_exception", "get_objects expects [ids]")
+		}
+		objects := make([]interface{}, 0)
+		for _, id := range args[0].Array() {
+			objects = append(objects, n.object(id.String()))
+		}
+		return objects, nil
+	case "get_transaction":
+		if len(args) != 2 {
+			return nil, fail("assert_exception", "get_transaction expects [block_num, trx_in_block]")
+		}
+		b := n.block(uint32(args[0].Uint()))
+		i := int(args[1].Int())
+		if b == nil || i < 0 || i >= len(b.Transactions) {
+			return nil, fail("assert_exception", "Assert Exception: opt_block->transactions.size() > trx_in_block")
+		}
+		return b.Transactions[i], nil
+	case "get_accounts":
+		if len(args) < 1 {
+			return nil, fail("assert_exception", "get_accounts expects [account_names_or_ids]")
+		}
+		accounts := make([]interface{}, 0)
+		for _, key := range args[0].Array() {
+			if a := n.account(key.String()); a != nil {
+				accounts = append(accounts, accountJSON(a))
+			} else {
+				accounts = append(accounts, nil)
+			}
+		}
+		return accounts, nil
+	case "get_assets", "lookup_asset_symbols":
+		if len(args) < 1 {
+			return nil, fail("assert_exception", "%s expects [asset_symbols_or_ids]", method)
+		}
+		assets := make([]interface{}, 0)
+		for _, key := range args[0].Array() {
+			a := n.asset(key.String())
+			if method == "get_assets" && a != nil && a.ID != key.String() {
+				a = nil
+			}
+			if a != nil {
+				assets = append(assets, assetJSON(a))
+			} else {
+				assets = append(assets, nil)
+			}
+		}
+		return assets, nil
+	case "get_full_accounts":
+		if len(args) != 2 {
+			return nil, fail("assert_exception", "get_full_accounts expects [names_or_ids, subscribe]")
+		}
+		// unknown accounts are left out
+		accounts := make([]interface{}, 0)
+		for _, key := range args[0].Array() {
+			if a := n.account(key.String()); a != nil {
+				accounts = append(accounts, []interface{}{key.String(), n.fullAccount(a)})
+			}
+		}
+		return accounts, nil
+	case "get_vesting_balances":
+		if len(args) != 1 {
+			return nil, fail("assert_exception", "get_vesting_balances expects [account_name_or_id]")
+		}
+		a := n.account(args[0].String())
+		if a == nil {
+			return nil, fail("assert_exception", "Assert Exception: account: no such account: %s", args[0].String())
+		}
+		return n.lockedObjects(a.ID, "1.13."), nil
+	case "lookup_accounts":
+		if len(args) != 2 {
+			return nil, fail("assert_exception", "lookup_accounts expects [lower_bound_name, limit]")
+		}
+		return n.lookupAccounts(args[0].String(), int(args[1].Int())), nil
+	case "get_account_balances":
+		if len(args) != 2 {
+			return nil, fail("assert_exception", "get_account_balances expects [account_name_or_id, assets]")
+		}
+		a := n.account(args[0].String())
+		if a == nil {
+			return nil, fail("assert_exception", "Assert Exception: account: no such account: %s", args[0].String())
+		}
+		return n.accountBalances(a.ID, args[1].Array()), nil
+	case "get_proposed_transactions":
+		if len(args) != 1 {
+			return nil, fail("assert_exception", "get_proposed_transactions expects [account_id_or_name]")
+		}
+		a := n.account(args[0].String())
+		if a == nil {
+			return nil, fail("assert_exception", "Assert Exception: account: no such account: %s", args[0].String())
+		}
+		return n.proposedTransactions(a.ID), nil
+	case "get_required_fees":
+		if len(args) != 2 {
+			return nil, fail("assert_exception", "get_required_fees expects [ops, asset_id]")
+		}
+		return n.requiredFees(args[0], args[1].String())
+	case "get_transaction_hex_without_sig":
+		if len(args) != 1 {
+			return nil, fail("assert_exception", "get_transaction_hex_without_sig expects [transaction]")
+		}
+		var tx types.Transaction
+		if err := json.Unmarshal([]byte(args[0].Raw), &tx); err != nil {
+			return nil, fail("parse_error_exception", "%v", err)
+		}
+		raw, err := serialize(&tx)
+		if err != nil {
+			return nil, fail("assert_exception", "%v", err)
+		}
+		return hex.EncodeToString(raw), nil
+	case "broadcast_transaction", "broadcast_transaction_synchronous":
+		if api != "network_broadcast" {
+			break
+		}
+		if len(args) != 1 {
+			return nil, fail("assert_exception", "%s expects [transaction]", method)
+		}
+		id, failure := n.broadcast(json.RawMessage(args[0].Raw), true)
+		if failure != nil {
+			return nil, failure
+		}
+		if method == "broadcast_transaction" {
+			return nil, nil
+		}
+		// the synchronous broadcast answers once the transaction is in a block
+		b := n.mint()
+		return map[string]interface{}{
+			"id":        id,
+			"block_num": b.Height,
+			"trx_num":   len(b.Transactions) - 1,
+			"expired":   false,
+		}, nil
+	}
+
+	return nil, fail("assert_exception", "Assert Exception: itr != _by_name.end(): no method with name '%s'", method)
+}
+
+// object returns the object with the id, nil when there is none
+func (n *Node) object(id string) interface{} {
+	objectID, err := types.ParseObjectID(id)
+	if err != nil {
+		return nil
+	}
+	switch {
+	case objectID.Space == 1 && objectID.Type == 3:
+		if a := n.asset(id); a != nil {
+			return assetJSON(a)
+		}
+	case objectID.Space == 2 && objectID.Type == 3:
+		if a := n.asset(fmt.Sprintf("1.3.%d", objectID.ID)); a != nil {
+			return map[string]interface{}{
+				"id":                  id,
+				"current_supply":      "1000000000000000",
+				"confidential_supply": "0",
+				"accumulated_fees":    0,
+				"fee_pool":            a.FeePool,
+			}
+		}
+	case objectID.Space == 1 && objectID.Type == 10:
+		if p := n.proposal(id); p != nil {
+			return proposalJSON(p)
+		}
+	case objectID.Space == 1 && objectID.Type == 6 && objectID.ID > 0 && int(objectID.ID) <= n.forks+1:
+		return map[string]interface{}{
+			"id":              id,
+			"witness_account": "1.2.0",
+			"signing_key":     WitnessSigningKey(id),
+		}
+	}
+	return nil
+}
+
+// nextMaintenance returns the time of the first maintenance after t
+func nextMaintenance(t time.Time) time.Time {
+	intervals := t.Sub(GenesisTime)/MaintenanceInterval + 1
+	return GenesisTime.Add(intervals * MaintenanceInterval)
+}
+
+func (n *Node) dynamicGlobalProperties() map[string]interface{} {
+	head := n.blocks[len(n.blocks)-1]
+	irreversible := 0
+	if int(head.Height) > IrreversibleDepth {
+		irreversible = int(head.Height) - IrreversibleDepth
+	}
+	return map[string]interface{}{
+		"id":                          "2.1.0",
+		"head_block_number":           head.Height,
+		"head_block_id":               head.ID,
+		"time":                        head.Timestamp.Format("2006-01-02T15:04:05"),
+		"current_witness":             head.Witness,
+		"last_irreversible_block_num": irreversible,
+		"next_maintenance_time":       nextMaintenance(head.Timestamp).Format("2006-01-02T15:04:05"),
+	}
+}
+
+// blockJSON returns the block as the database api of a witness_node does, without
+// the block_id and transaction_ids added by the cli_wallet
+func blockJSON(b *Block) map[string]interface{} {
+	transactions := b.Transactions
+	if transactions == nil {
+		transactions = []json.RawMessage{}
+	}
+	return map[string]interface{}{
+		"previous":                b.Previous,
+		"timestamp":               b.Timestamp.Format("2006-01-02T15:04:05"),
+		"witness":                 b.Witness,
+		"transaction_merkle_root": b.MerkleRoot,
+		"extensions":              []interface{}{},
+		"witness_signature":       b.WitnessSignature,
+		"transactions":            transactions,
+	}
+}
+
+func accountJSON(a *Account) map[string]interface{} {
+	var authority interface{} = map[string]interface{}{
+		"weight_threshold": 1,
+		"account_auths":    []interface{}{},
+		"key_auths":        [][]interface{}{{a.MemoKey, 1}},
+		"address_auths":    []interface{}{},
+	}
+	owner := authority
+	if a.Active != nil {
+		authority = a.Active
+	}
+	return map[string]interface{}{
+		"id":                               a.ID,
+		"membership_expiration_date":       "1970-01-01T00:00:00",
+		"registrar":                        "1.2.0",
+		"referrer":                         "1.2.0",
+		"lifetime_referrer":                "1.2.0",
+		"network_fee_percentage":           2000,
+		"lifetime_referrer_fee_percentage": 3000,
+		"referrer_rewards_percentage":      0,
+		"name":                             a.Name,
+		"owner":                            owner,
+		"active":                           authority,
+		"options": map[string]interface{}{
+			"memo_key":       a.MemoKey,
+			"voting_account": "1.2.5",
+			"num_witness":    0,
+			"num_committee":  0,
+			"votes":          []interface{}{},
+			"extensions":     []interface{}{},
+		},
+		"statistics": "2.6." + strings.TrimPrefix(a.ID, "1.2."),
+	}
+}
+
+func assetJSON(a *Asset) map[string]interface{} {
+	instance := strings.TrimPrefix(a.ID, "1.3.")
+	return map[string]interface{}{
+		"id":                    a.ID,
+		"symbol":                a.Symbol,
+		"precision":             a.Precision,
+		"issuer":                "1.2.0",
+		"dynamic_asset_data_id": "2.3." + instance,
+		"options": map[string]interface{}{
+			"max_supply":         "1000000000000000",
+			"market_fee_percent": 0,
+			"max_market_fee":     "1000000000000000",
+			"issuer_permissions": 0,
+			"flags":              a.Flags,
+			"core_exchange_rate": map[string]interface{}{
+				"base":  map[string]interface{}{"amount": a.CoreAmount, "asset_id": CoreAssetID},
+				"quote": map[string]interface{}{"amount": a.Amount, "asset_id": a.ID},
+			},
+			"whitelist_authorities": []interface{}{},
+			"blacklist_authorities": []interface{}{},
+			"whitelist_markets":     []interface{}{},
+			"blacklist_markets":     []interface{}{},
+			"description":           "",
+			"extensions":            []interface{}{},
+		},
+	}
+}
+
+// fullAccount returns the account with its balances and the objects locking its funds
+func (n *Node) fullAccount(a *Account) map[string]interface{} {
+	ids := make([]string, 0, len(n.balances[a.ID]))
+	for asset := range n.balances[a.ID] {
+		ids = append(ids, asset)
+	}
+	sort.Strings(ids)
+	balances := make([]interface{}, 0, len(ids))
+	for i, asset := range ids {
+		balances = append(balances, map[string]interface{}{
+			"id":         fmt.Sprintf("2.5.%d", i),
+			"owner":      a.ID,
+			"asset_type": asset,
+			"balance":    n.balances[a.ID][asset],
+		})
+	}
+
+	return map[string]interface{}{
+		"account":          accountJSON(a),
+		"balances":         balances,
+		"vesting_balances": n.lockedObjects(a.ID, "1.13."),
+		"limit_orders":     n.lockedObjects(a.ID, "1.7."),
+		"call_orders":      n.lockedObjects(a.ID, "1.8."),
+		"settle_orders":    []interface{}{},
+		"proposals":        []interface{}{},
+		"assets":           []interface{}{},
+		"withdraws":        []interface{}{},
+	}
+}
+
+func (n *Node) lookupAccounts(lowerBound string, limit int) [][]string {
+	names := make([]string, 0, len(n.accounts))
+	for _, a := range n.accounts {
+		if a.Name >= lowerBound {
+			names = append(names, a.Name)
+		}
+	}
+	sort.Strings(names)
+	if limit >= 0 && len(names) > limit {
+		names = names[:limit]
+	}
+
+	result := make([][]string, 0, len(names))
+	for _, name := range names {
+		result = append(result, []string{name, n.account(name).ID})
+	}
+	return result
+}
+
+func (n *Node) accountBalances(accountID string, assets []gjson.Result) []map[string]interface{} {
+	balances := n.balances[accountID]
+	result := make([]map[string]interface{}, 0)
+
+	if len(assets) == 0 {
+		ids := make([]string, 0, len(balances))
+		for asset, amount := range balances {
+			if amount > 0 {
+				ids = append(ids, asset)
+			}
+		}
+		sort.Strings(ids)
+		for _, asset := range ids {
+			result = append(result, map[string]interface{}{"amount": balances[asset], "asset_id": asset})
+		}
+		return result
+	}
+
+	for _, asset := range assets {
+		result = append(result, map[string]interface{}{"amount": balances[asset.String()], "asset_id": asset.String()})
+	}
+	return result
+}
+
+func (n *Node) requiredFees(ops gjson.Result, assetID string) (interface{}, *rpcFailure) {
+	asset := n.asset(assetID)
+	if asset == nil {
+		return nil, fail("assert_exception", "Assert Exception: unable to find asset %s", assetID)
+	}
+	var operations types.Operations
+	if err := json.Unmarshal([]byte(ops.Raw), &operations); err != nil {
+		return nil, fail("parse_error_exception", "%v", err)
+	}
+	return n.operationFees(operations, asset)
+}
+
+// operationFees returns the fees of the operations in the asset. The fee of a
+// proposal_create comes with the fees of its proposed operations, [fee, [fees]].
+func (n *Node) operationFees(operations types.Operations, asset *Asset) ([]interface{}, *rpcFailure) {
+	fees := make([]interface{}, 0, len(operations))
+	for _, op := range operations {
+		fee, failure := n.requiredFeeIn(op.Type(), asset.ID)
+		if failure != nil {
+			return nil, failure
+		}
+		var result interface{} = map[string]interface{}{
+			"amount":   fee,
+			"asset_id": asset.ID,
+		}
+		if create, ok := op.(*types.ProposalCreateOperation); ok {
+			proposed := make(types.Operations, len(create.ProposedOps))
+			for i, p := range create.ProposedOps {
+				proposed[i] = p.Op
+			}
+			inner, failure := n.operationFees(proposed, asset)
+			if failure != nil {
+				return nil, failure
+			}
+			result = []interface{}{result, inner}
+		}
+		fees = append(fees, result)
+	}
+	return fees, nil
+}
+
+// globalProperties returns the chain parameters with the fee schedule
+func (n *Node) globalProperties() map[string]interface{} {
+	opTypes := make([]int, 0, len(n.fees))
+	for opType := range n.fees {
+		opTypes = append(opTypes, int(opType))
+	}
+	sort.Ints(opTypes)
+
+	parameters := make([]interface{}, 0, len(opTypes))
+	for _, opType := range opTypes {
+		fee := map[string]interface{}{"fee": n.fees[types.OpType(opType)]}
+		switch types.OpType(opType) {
+		case types.TransferOpType, types.ProposalCreateOpType, types.ProposalUpdateOpType:
+			fee["price_per_kbyte"] = n.kbyte
+		}
+		parameters = append(parameters, []interface{}{opType, fee})
+	}
+
+	return map[string]interface{}{
+		"id": "2.0.0",
+		"parameters": map[string]interface{}{
+			"current_fees": map[string]interface{}{
+				"parameters": parameters,
+				"scale":      n.scale,
+			},
+			"block_interval":       int(BlockInterval / time.Second),
+			"maintenance_interval": int(MaintenanceInterval / time.Second),
+		},
+		"active_witnesses": []string{"1.6.1"},
+	}
+}
+
+// broadcast checks and applies a signed transaction, which is included in the next
+// block. When signed, the signatures must satisfy the authorities the operations require.
+func (n *Node) broadcast(raw json.RawMessage, signed bool) (string, *rpcFailure) {
+	var tx types.Transaction
+	if err := json.Unmarshal(raw, &tx); err != nil {
+		return "", fail("parse_error_exception", "%v", err)
+	}
+	id, err := tx.ID()
+	if err != nil {
+		return "", fail("assert_exception", "%v", err)
+	}
+
+	if n.known[id] {
+		return "", fail("assert_exception", "Assert Exception: trx_idx.indices().get<by_trx_id>().find(trx_id) == trx_idx.indices().get<by_trx_id>().end(): ")
+	}
+	head := n.blocks[len(n.blocks)-1]
+	if tx.Expiration.Time == nil || tx.Expiration.Before(head.Timestamp) {
+		return "", fail("assert_exception", "Assert Exception: now <= trx.expiration: ")
+	}
+	if len(tx.Signatures) == 0 {
+		return "", fail("tx_missing_active_auth", "missing required active authority: Missing Active Authority")
+	}
+	var signees map[string]bool
+	if signed {
+		var failure *rpcFailure
+		if signees, failure = signers(&tx); failure != nil {
+			return "", failure
+		}
+	}
+
+	// check every operation before applying any
+	debits := make(map[string]map[string]int64)
+	pools := make(map[string]int64) // core fees paid by the fee pool of each asset
+	debit := func(account, asset string, amount int64) {
+		if debits[account] == nil {
+			debits[account] = make(map[string]int64)
+		}
+		debits[account][asset] += amount
+	}
+	for _, op := range tx.Operations {
+		payer, fee, failure := n.checkOperation(op, head.Timestamp)
+		if failure != nil {
+			return "", failure
+		}
+		if signed {
+			if failure := n.checkAuthorities(op, signees); failure != nil {
+				return "", failure
+			}
+		}
+		if payer == "" {
+			continue
+		}
+		feeAsset := n.asset(fee.AssetID.String())
+		if feeAsset == nil {
+			return "", fail("assert_exception", "Assert Exception: unable to find asset %s", fee.AssetID.String())
+		}
+		required, failure := n.requiredFeeIn(op.Type(), feeAsset.ID)
+		if failure != nil {
+			return "", failure
+		}
+		if int64(fee.Amount) < required {
+			return "", fail("insufficient_fee", "Insufficient Fee Paid: core_fee_paid >= required_core_fee")
+		}
+		if feeAsset.ID != CoreAssetID {
+			pools[feeAsset.ID] += n.required[op.Type()][CoreAssetID]
+			if feeAsset.FeePool < pools[feeAsset.ID] {
+				return "", fail("assert_exception", "Assert Exception: d.get_balance(fee_asset_dyn_data.fee_pool) >= core_fee_paid: Fee pool balance of '%d' is less than the %d required to convert %s", feeAsset.FeePool, pools[feeAsset.ID], feeAsset.Symbol)
+			}
+		}
+		debit(payer, feeAsset.ID, int64(fee.Amount))
+		if transfer, ok := op.(*types.TransferOperation); ok {
+			debit(payer, transfer.Amount.AssetID.String(), int64(transfer.Amount.Amount))
+		}
+	}
+	for account, assets := range debits {
+		for asset, amount := range assets {
+			if n.balances[account][asset] < amount {
+				return "", fail("insufficient_balance", "Insufficient Balance: %s's balance of %d %s is less than required %d", account, n.balances[account][asset], asset, amount)
+			}
+		}
+	}
+
+	for _, op := range tx.Operations {
+		n.applyOperation(op, head.Timestamp)
+	}
+	for asset, fee := range pools {
+		n.asset(asset).FeePool -= fee
+	}
+
+	n.known[id] = true
+	n.pending = append(n.pending, pendingTx{id: id, raw: raw})
+	return id, nil
+}
+
+// signers returns the public keys signing the transaction for the mainnet. Every
+// signature must be canonical and each key sign once.
+func signers(tx *types.Transaction) (map[string]bool, *rpcFailure) {
+	for _, sig := range tx.Signatures {
+		signature, err := hex.DecodeString(sig)
+		if err != nil || !txsigner.IsCanonical(signature) {
+			return nil, fail("assert_exception", "Assert Exception: is_canonical(c): signature is not canonical")
+		}
+	}
+	signees, err := tx.Signees(types.ChainIDBTS)
+	if err != nil {
+		return nil, fail("assert_exception", "%v", err)
+	}
+	keys := make(map[string]bool, len(signees))
+	for _, signee := range signees {
+		key, err := addrdec.Default.AddressEncode(signee)
+		if err != nil {
+			return nil, fail("assert_exception", "%v", err)
+		}
+		if keys[key] {
+			return nil, fail("tx_duplicate_sig", "duplicate signature included: Duplicate Signature detected")
+		}
+		keys[key] = true
+	}
+	return keys, nil
+}
+
+// checkAuthorities checks that the keys signing the transaction satisfy the active
+// authority of every account the operation requires, and sign the key approvals of
+// a proposal_update. Operations other than transfers and proposals require none.
+func (n *Node) checkAuthorities(op types.Operation, keys map[string]bool) *rpcFailure {
+	var accounts []types.ObjectID
+	switch op := op.(type) {
+	case *types.TransferOperation:
+		accounts = append(accounts, op.From)
+	case *types.ProposalCreateOperation:
+		accounts = append(accounts, op.FeePayingAccount)
+	case *types.ProposalUpdateOperation:
+		accounts = append(accounts, op.FeePayingAccount)
+		accounts = append(accounts, op.ActiveApprovalsToAdd...)
+		accounts = append(accounts, op.ActiveApprovalsToRemove...)
+		for _, key := range append(op.KeyApprovalsToAdd, op.KeyApprovalsToRemove...) {
+			if !keys[key.String()] {
+				return fail("tx_missing_other_auth", "missing required other authority: Missing Authority %s", key)
+			}
+		}
+	case *types.ProposalDeleteOperation:
+		accounts = append(accounts, op.FeePayingAccount)
+	}
+	for _, account := range accounts {
+		if !n.satisfied(account.String(), nil, keys, 0) {
+			return fail("tx_missing_active_auth", "missing required active authority: Missing Active Authority %s", account.String())
+		}
+	}
+	return nil
+}
+
+// checkOperation checks an operation against the ledger and returns the account
+// paying its fee with the fee. Operations other than transfers and proposals are
+// accepted without any check, with no payer.
+func (n *Node) checkOperation(op types.Operation, now time.Time) (string, types.AssetAmount, *rpcFailure) {
+	switch op := op.(type) {
+	case *types.TransferOperation:
+		from, to := n.account(op.From.String()), n.account(op.To.String())
+		if from == nil || to == nil {
+			return "", op.Fee, fail("assert_exception", "Assert Exception: unable to find account %s or %s", op.From.String(), op.To.String())
+		}
+		return from.ID, op.Fee, nil
+	case *types.ProposalCreateOperation:
+		payer := n.account(op.FeePayingAccount.String())
+		if payer == nil {
+			return "", op.Fee, fail("assert_exception", "Assert Exception: unable to find account %s", op.FeePayingAccount.String())
+		}
+		if op.ExpirationTime.Time == nil || !op.ExpirationTime.After(now) {
+			return "", op.Fee, fail("assert_exception", "Assert Exception: o.expiration_time > block_time: Proposal has already expired on creation.")
+		}
+		if len(op.ProposedOps) == 0 {
+			return "", op.Fee, fail("assert_exception", "Assert Exception: !proposed_ops.empty(): ")
+		}
+		for _, proposed := range op.ProposedOps {
+			transfer, ok := proposed.Op.(*types.TransferOperation)
+			if !ok {
+				return "", op.Fee, fail("assert_exception", "operation %d can not be proposed to the fake node", proposed.Op.Type())
+			}
+			if n.account(transfer.From.String()) == nil || n.account(transfer.To.String()) == nil {
+				return "", op.Fee, fail("assert_exception", "Assert Exception: unable to find account %s or %s", transfer.From.String(), transfer.To.String())
+			}
+		}
+		return payer.ID, op.Fee, nil
+	case *types.ProposalUpdateOperation:
+		payer := n.account(op.FeePayingAccount.String())
+		if payer == nil {
+			return "", op.Fee, fail("assert_exception", "Assert Exception: unable to find account %s", op.FeePayingAccount.String())
+		}
+		p := n.proposal(op.Proposal.String())
+		if p == nil {
+			return "", op.Fee, fail("assert_exception", "Assert Exception: unable to find proposal %s", op.Proposal.String())
+		}
+		if len(op.OwnerApprovalsToAdd) > 0 || len(op.OwnerApprovalsToRemove) > 0 {
+			return "", op.Fee, fail("assert_exception", "owner approvals are not supported by the fake node")
+		}
+		for _, id := range op.ActiveApprovalsToAdd {
+			if n.account(id.String()) == nil || p.approvals[id.String()] {
+				return "", op.Fee, fail("assert_exception", "Assert Exception: _proposal->available_active_approvals.find(id) == _proposal->available_active_approvals.end(): Tried to add an active approval of %s already present", id.String())
+			}
+		}
+		for _, id := range op.ActiveApprovalsToRemove {
+			if !p.approvals[id.String()] {
+				return "", op.Fee, fail("assert_exception", "Assert Exception: _proposal->available_active_approvals.find(id) != _proposal->available_active_approvals.end(): Tried to remove an active approval of %s not present", id.String())
+			}
+		}
+		for _, key := range op.KeyApprovalsToAdd {
+			if p.keys[key.String()] {
+				return "", op.Fee, fail("assert_exception", "Assert Exception: _proposal->available_key_approvals.find(id) == _proposal->available_key_approvals.end(): Tried to add a key approval %s already present", key)
+			}
+		}
+		for _, key := range op.KeyApprovalsToRemove {
+			if !p.keys[key.String()] {
+				return "", op.Fee, fail("assert_exception", "Assert Exception: _proposal->available_key_approvals.find(id) != _proposal->available_key_approvals.end(): Tried to remove a key approval %s not present", key)
+			}
+		}
+		return payer.ID, op.Fee, nil
+	case *types.ProposalDeleteOperation:
+		payer := n.account(op.FeePayingAccount.String())
+		if payer == nil {
+			return "", op.Fee, fail("assert_exception", "Assert Exception: unable to find account %s", op.FeePayingAccount.String())
+		}
+		p := n.proposal(op.Proposal.String())
+		if p == nil {
+			return "", op.Fee, fail("assert_exception", "Assert Exception: unable to find proposal %s", op.Proposal.String())
+		}
+		for _, required := range p.required {
+			if required == payer.ID {
+				return payer.ID, op.Fee, nil
+			}
+		}
+		return "", op.Fee, fail("assert_exception", "Assert Exception: trx_state->skip_fee_schedule_check || _proposal->required_active_approvals.count(o.fee_paying_account) || _proposal->required_owner_approvals.count(o.fee_paying_account): Provided authority is not authoritative for this proposal.")
+	}
+	return "", types.AssetAmount{}, nil
+}
+
+// applyOperation applies a checked operation
+func (n *Node) applyOperation(op types.Operation, now time.Time) {
+	switch op := op.(type) {
+	case *types.TransferOperation:
+		n.applyTransfer(op)
+	case *types.ProposalCreateOperation:
+		n.balanceOf(op.FeePayingAccount.String())[op.Fee.AssetID.String()] -= int64(op.Fee.Amount)
+		p := &proposal{
+			id:         fmt.Sprintf("1.10.%d", n.proposed),
+			proposer:   op.FeePayingAccount.String(),
+			expiration: *op.ExpirationTime.Time,
+			approvals:  make(map[string]bool),
+			keys:       make(map[string]bool),
+		}
+		n.proposed++
+		if op.ReviewPeriodSeconds != nil {
+			review := p.expiration.Add(-time.Duration(*op.ReviewPeriodSeconds) * time.Second)
+			p.review = &review
+		}
+		required := make(map[string]bool)
+		for _, proposed := range op.ProposedOps {
+			p.ops = append(p.ops, proposed.Op)
+			from := proposed.Op.(*types.TransferOperation).From.String()
+			if !required[from] {
+				required[from] = true
+				p.required = append(p.required, from)
+			}
+		}
+		sort.Strings(p.required)
+		n.proposals = append(n.proposals, p)
+	case *types.ProposalUpdateOperation:
+		n.balanceOf(op.FeePayingAccount.String())[op.Fee.AssetID.String()] -= int64(op.Fee.Amount)
+		p := n.proposal(op.Proposal.String())
+		for _, id := range op.ActiveApprovalsToAdd {
+			p.approvals[id.String()] = true
+		}
+		for _, id := range op.ActiveApprovalsToRemove {
+			delete(p.approvals, id.String())
+		}
+		for _, key := range op.KeyApprovalsToAdd {
+			p.keys[key.String()] = true
+		}
+		for _, key := range op.KeyApprovalsToRemove {
+			delete(p.keys, key.String())
+		}
+		if p.review == nil && n.authorized(p) {
+			n.execute(p)
+		}
+	case *types.ProposalDeleteOperation:
+		n.balanceOf(op.FeePayingAccount.String())[op.Fee.AssetID.String()] -= int64(op.Fee.Amount)
+		n.removeProposal(op.Proposal.String())
+	}
+}
+
+// applyTransfer moves the amount and pays the fee of a transfer
+func (n *Node) applyTransfer(transfer *types.TransferOperation) {
+	from, to := transfer.From.String(), transfer.To.String()
+	n.balanceOf(from)[transfer.Amount.AssetID.String()] -= int64(transfer.Amount.Amount)
+	n.balanceOf(from)[transfer.Fee.AssetID.String()] -= int64(transfer.Fee.Amount)
+	n.balanceOf(to)[transfer.Amount.AssetID.String()] += int64(transfer.Amount.Amount)
+}
+
+func (n *Node) proposal(id string) *proposal {
+	for _, p := range n.proposals {
+		if p.id == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (n *Node) removeProposal(id string) {
+	for i, p := range n.proposals {
+		if p.id == id {
+			n.proposals = append(n.proposals[:i], n.proposals[i+1:]...)
+			return
+		}
+	}
+}
+
+// authorized reports whether the approvals of the proposal satisfy the active
+// authority of every required account. Signatures are not checked.
+func (n *Node) authorized(p *proposal) bool {
+	for _, required := range p.required {
+		if !n.satisfied(required, p.approvals, p.keys, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// satisfied reports whether the active authority of the account is satisfied by
+// the approving accounts and keys, following account authorities two levels deep
+// as the chain does
+func (n *Node) satisfied(accountID string, accounts, keys map[string]bool, depth int) bool {
+	if accounts[accountID] {
+		return true
+	}
+	a := n.account(accountID)
+	if a == nil {
+		return false
+	}
+	authority := a.Active
+	if authority == nil {
+		authority = &types.Permission{WeightThreshold: 1, KeyAuths: []types.KeyAuth{{Key: types.PublicKey(a.MemoKey), Weight: 1}}}
+	}
+
+	weight := uint32(0)
+	for _, auth := range authority.KeyAuths {
+		if keys[auth.Key.String()] {
+			weight += uint32(auth.Weight)
+		}
+	}
+	if depth < 2 {
+		for _, auth := range authority.AccountAuths {
+			if n.satisfied(auth.Account.String(), accounts, keys, depth+1) {
+				weight += uint32(auth.Weight)
+			}
+		}
+	}
+	return weight >= authority.WeightThreshold
+}
+
+// execute applies the proposed transfers when every balance is enough and removes
+// the proposal, or records why it failed and keeps it
+func (n *Node) execute(p *proposal) {
+	debits := make(map[string]map[string]int64)
+	for _, op := range p.ops {
+		transfer := op.(*types.TransferOperation)
+		from := transfer.From.String()
+		if debits[from] == nil {
+			debits[from] = make(map[string]int64)
+		}
+		debits[from][transfer.Amount.AssetID.String()] += int64(transfer.Amount.Amount)
+		debits[from][transfer.Fee.AssetID.String()] += int64(transfer.Fee.Amount)
+	}
+	for account, assets := range debits {
+		for asset, amount := range assets {
+			if n.balances[account][asset] < amount {
+				p.failReason = fmt.Sprintf("Insufficient Balance: %s's balance of %d %s is less than required %d", account, n.balances[account][asset], asset, amount)
+				return
+			}
+		}
+	}
+
+	for _, op := range p.ops {
+		n.applyTransfer(op.(*types.TransferOperation))
+	}
+	n.removeProposal(p.id)
+}
+
+// expireProposals executes the approved proposals with a review period which
+// expire by the time, and removes every expired proposal
+func (n *Node) expireProposals(now time.Time) {
+	for _, p := range append([]*proposal(nil), n.proposals...) {
+		if p.expiration.After(now) {
+			continue
+		}
+		if p.review != nil && n.authorized(p) {
+			n.execute(p)
+		}
+		n.removeProposal(p.id)
+	}
+}
+
+// proposedTransactions returns the proposals the account proposed, is required by or approved
+func (n *Node) proposedTransactions(accountID string) []interface{} {
+	proposals := make([]interface{}, 0)
+	for _, p := range n.proposals {
+		involved := p.proposer == accountID || p.approvals[accountID]
+		for _, required := range p.required {
+			involved = involved || required == accountID
+		}
+		if involved {
+			proposals = append(proposals, proposalJSON(p))
+		}
+	}
+	return proposals
+}
+
+func proposalJSON(p *proposal) map[string]interface{} {
+	sorted := func(set map[string]bool) []string {
+		keys := make([]string, 0, len(set))
+		for key := range set {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return keys
+	}
+
+	expiration := p.expiration.Format("2006-01-02T15:04:05")
+	proposal := map[string]interface{}{
+		"id":              p.id,
+		"expiration_time": expiration,
+		"proposed_transaction": map[string]interface{}{
+			"ref_block_num":    0,
+			"ref_block_prefix": 0,
+			"expiration":       expiration,
+			"operations":       p.ops,
+			"extensions":       []interface{}{},
+		},
+		"required_active_approvals":  p.required,
+		"available_active_approvals": sorted(p.approvals),
+		"required_owner_approvals":   []string{},
+		"available_owner_approvals":  []string{},
+		"available_key_approvals":    sorted(p.keys),
+		"proposer":                   p.proposer,
+		"fail_reason":                p.failReason,
+	}
+	if p.review != nil {
+		proposal["review_period_time"] = p.review.Format("2006-01-02T15:04:05")
+	}
+	return proposal
+}
@@ -0,0 +1,202 @@
+package bitsharestest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blocktree/bitshares-adapter/addrdec"
+	"github.com/blocktree/bitshares-adapter/bitshares"
+	"github.com/blocktree/bitshares-adapter/types"
+	"github.com/btcsuite/btcd/btcec"
+)
+
+func TestNode_WalletClient(t *testing.T) {
+	node := NewNode()
+	defer node.Close()
+
+	alice := node.CreateAccount("alice", "BTS6Mbc6SdKx6PzYzSmUkhB5XJjNWkEdtYWz2gUPxUJHcvxXEUdYT")
+	node.CreateAccount("bob", "BTS6Mbc6SdKx6PzYzSmUkhB5XJjNWkEdtYWz2gUPxUJHcvxXEUdYT")
+	node.SetBalance("alice", CoreAssetID, 100000000)
+
+	txID, err := node.Transfer("alice", "bob", CoreAssetID, 1000)
+	if err != nil {
+		t.Fatalf("Transfer failed unexpected error: %v", err)
+	}
+	block := node.MintBlock()
+
+	c := bitshares.NewWalletClient(node.URL, node.URL, false)
+
+	info, err := c.GetBlockchainInfo()
+	if err != nil {
+		t.Fatalf("GetBlockchainInfo failed unexpected error: %v", err)
+	}
+	if info.HeadBlockNum != uint64(block.Height) || info.HeadBlockID != block.ID {
+		t.Errorf("head = %d %s, want %d %s", info.HeadBlockNum, info.HeadBlockID, block.Height, block.ID)
+	}
+
+	b, err := c.GetBlockByHeight(block.Height)
+	if err != nil {
+		t.Fatalf("GetBlockByHeight failed unexpected error: %v", err)
+	}
+	if b.BlockID != block.ID {
+		t.Errorf("block id = %s, want %s", b.BlockID, block.ID)
+	}
+	if len(b.Transactions) != 1 || len(b.TransactionIDs) != 1 || b.TransactionIDs[0] != txID {
+		t.Fatalf("block transactions = %d %v, want %s", len(b.Transactions), b.TransactionIDs, txID)
+	}
+	if id, err := c.GetTransactionID(b.Transactions[0]); err != nil || id != txID {
+		t.Errorf("GetTransactionID = %s, %v, want %s", id, err, txID)
+	}
+	if calls := node.Calls("get_transaction_hex_without_sig"); calls != 0 {
+		t.Errorf("transaction ids of known operations asked to the node %d times", calls)
+	}
+
+	id, err := c.GetAccountID("alice")
+	if err != nil || id.String() != alice {
+		t.Errorf("GetAccountID = %v, %v, want %s", id, err, alice)
+	}
+
+	accounts, err := c.GetAccounts("alice", "nobody")
+	if err != nil || len(accounts) != 2 || accounts[0].Name != "alice" || accounts[1] != nil {
+		t.Errorf("GetAccounts = %v, %v", accounts, err)
+	}
+
+	balance, err := c.GetAssetsBalance(types.MustParseObjectID(alice), types.MustParseObjectID(CoreAssetID))
+	if err != nil {
+		t.Fatalf("GetAssetsBalance failed unexpected error: %v", err)
+	}
+	if want := "99979000"; balance.Amount != want {
+		t.Errorf("balance = %s, want %s", balance.Amount, want)
+	}
+
+	// like a witness_node, the node rejects batch arrays unless told otherwise
+	for _, batch := range []bool{false, true} {
+		node.Batch = batch
+		accounts, err := c.GetAccountsBatch("alice", "bob")
+		if err != nil || len(accounts) != 4 {
+			t.Errorf("batch=%v: GetAccountsBatch = %v, %v", batch, accounts, err)
+		}
+	}
+}
+
+func TestNode_Broadcast(t *testing.T) {
+	node := NewNode()
+	defer node.Close()
+
+	node.CreateAccount("alice", "")
+	node.CreateAccount("bob", "")
+
+	_, err := node.Transfer("alice", "bob", CoreAssetID, 1000)
+	if err == nil {
+		t.Fatalf("transfer without balance should fail")
+	}
+
+	node.SetBalance("alice", CoreAssetID, 1000)
+	node.SetRequiredFee(types.TransferOpType, CoreAssetID, 0)
+	if _, err := node.Transfer("alice", "bob", CoreAssetID, 1000); err != nil {
+		t.Fatalf("Transfer failed unexpected error: %v", err)
+	}
+	if got := node.Balance("bob", CoreAssetID); got != 1000 {
+		t.Errorf("bob balance = %d, want 1000", got)
+	}
+}
+
+func TestNode_Fork(t *testing.T) {
+	node := NewNode()
+	defer node.Close()
+
+	node.CreateAccount("alice", "")
+	node.CreateAccount("bob", "")
+	node.SetBalance("alice", CoreAssetID, 100000)
+
+	node.MintBlocks(3)
+	txID, _ := node.Transfer("alice", "bob", CoreAssetID, 1000)
+	orphan := node.MintBlock()
+
+	node.Fork(1)
+
+	head := node.HeadBlock()
+	if head.Height != orphan.Height+1 {
+		t.Errorf("head height = %d, want %d", head.Height, orphan.Height+1)
+	}
+	replaced := node.Block(orphan.Height)
+	if replaced.ID == orphan.ID {
+		t.Errorf("block %d has not been replaced", orphan.Height)
+	}
+	if replaced.Previous != orphan.Previous {
+		t.Errorf("fork does not start at block %d", orphan.Height-1)
+	}
+	if len(replaced.TransactionIDs) != 1 || replaced.TransactionIDs[0] != txID {
+		t.Errorf("orphaned transaction not included in the new branch: %v", replaced.TransactionIDs)
+	}
+}
+
+func TestNode_NetworkBroadcast(t *testing.T) {
+	node := NewNode()
+	defer node.Close()
+
+	key, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("NewPrivateKey failed unexpected error: %v", err)
+	}
+	other, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("NewPrivateKey failed unexpected error: %v", err)
+	}
+	publicKey, err := addrdec.Default.AddressEncode(key.PubKey().SerializeCompressed())
+	if err != nil {
+		t.Fatalf("AddressEncode failed unexpected error: %v", err)
+	}
+
+	alice := node.CreateAccount("alice", publicKey)
+	bob := node.CreateAccount("bob", "")
+	node.SetBalance("alice", CoreAssetID, 100000)
+
+	c := bitshares.NewWalletClient(node.URL, "", false)
+
+	core := types.MustParseObjectID(CoreAssetID)
+	signed := func(amount int64, key *btcec.PrivateKey) *types.Transaction {
+		head := node.HeadBlock()
+		tx, err := types.NewTransaction(head.ID, head.Timestamp.Add(time.Hour))
+		if err != nil {
+			t.Fatalf("NewTransaction failed unexpected error: %v", err)
+		}
+		tx.PushOperation(types.NewTransferOperation(
+			types.MustParseObjectID(alice),
+			types.MustParseObjectID(bob),
+			types.AssetAmount{Amount: uint64(amount), AssetID: core},
+			types.AssetAmount{Amount: DefaultTransferFee, AssetID: core},
+		))
+		if err := tx.Sign(types.ChainIDBTS, key); err != nil {
+			t.Fatalf("Sign failed unexpected error: %v", err)
+		}
+		return tx
+	}
+
+	// the signature must satisfy the active authority of alice
+	if _, err := c.BroadcastTransaction(signed(1000, other)); err == nil || !strings.Contains(err.Error(), "tx_missing_active_auth") {
+		t.Errorf("BroadcastTransaction signed by another key = %v, want a missing authority", err)
+	}
+
+	resp, err := c.BroadcastTransaction(signed(1000, key))
+	if err != nil {
+		t.Fatalf("BroadcastTransaction failed unexpected error: %v", err)
+	}
+	block := node.MintBlock()
+	if len(block.TransactionIDs) != 1 || block.TransactionIDs[0] != resp.ID {
+		t.Errorf("broadcast id = %s, block has %v", resp.ID, block.TransactionIDs)
+	}
+
+	resp, err = c.BroadcastTransactionSynchronous(signed(2000, key))
+	if err != nil {
+		t.Fatalf("BroadcastTransactionSynchronous failed unexpected error: %v", err)
+	}
+	head := node.HeadBlock()
+	if resp.BlockNum != head.Height || resp.TrxNum != 0 || head.TransactionIDs[0] != resp.ID {
+		t.Errorf("synchronous broadcast = %+v, head %d has %v", resp, head.Height, head.TransactionIDs)
+	}
+	if got := node.Balance("bob", CoreAssetID); got != 3000 {
+		t.Errorf("bob balance = %d, want 3000", got)
+	}
+}
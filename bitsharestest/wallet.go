@@ -0,0 +1,100 @@
+/*
+ * Copyright 2018 The OpenWallet Authors
+ * This file is part of the OpenWallet library.
+ *
+ * The OpenWallet library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The OpenWallet library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ */
+
+package bitsharestest
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/blocktree/openwallet/v2/openwallet"
+)
+
+// WalletDAI keeps the assets accounts of a wallet and their addresses in memory
+type WalletDAI struct {
+	openwallet.WalletDAIBase
+
+	mutex     sync.Mutex // protects the following
+	accounts  map[string]*openwallet.AssetsAccount
+	addresses []*openwallet.Address
+}
+
+// NewWalletDAI returns a wallet without accounts
+func NewWalletDAI() *WalletDAI {
+	return &WalletDAI{accounts: make(map[string]*openwallet.AssetsAccount)}
+}
+
+// AddAccount adds the assets account of a chain account, by its name, with an
+// address of every public key. The assets account is returned.
+func (dai *WalletDAI) AddAccount(accountID, name string, publicKeys ...string) *openwallet.AssetsAccount {
+	dai.mutex.Lock()
+	defer dai.mutex.Unlock()
+
+	account := &openwallet.AssetsAccount{
+		AccountID: accountID,
+		Alias:     name,
+		Symbol:    "BTS",
+		Required:  1,
+	}
+	dai.accounts[accountID] = account
+	for i, key := range publicKeys {
+		dai.addresses = append(dai.addresses, &openwallet.Address{
+			AccountID: accountID,
+			Address:   key,
+			PublicKey: key,
+			Index:     uint64(i),
+			Symbol:    "BTS",
+		})
+	}
+	return account
+}
+
+func (dai *WalletDAI) GetAssetsAccountInfo(accountID string) (*openwallet.AssetsAccount, error) {
+	dai.mutex.Lock()
+	defer dai.mutex.Unlock()
+	account, ok := dai.accounts[accountID]
+	if !ok {
+		return nil, fmt.Errorf("assets account %s not found", accountID)
+	}
+	return account, nil
+}
+
+// GetAddressList returns the addresses, filtered by "AccountID" only
+func (dai *WalletDAI) GetAddressList(offset, limit int, cols ...interface{}) ([]*openwallet.Address, error) {
+	dai.mutex.Lock()
+	defer dai.mutex.Unlock()
+
+	var accountID interface{}
+	for i := 0; i+1 < len(cols); i += 2 {
+		if cols[i] == "AccountID" {
+			accountID = cols[i+1]
+		}
+	}
+
+	addresses := make([]*openwallet.Address, 0)
+	for _, addr := range dai.addresses {
+		if accountID == nil || addr.AccountID == accountID {
+			addresses = append(addresses, addr)
+		}
+	}
+	if offset > len(addresses) {
+		offset = len(addresses)
+	}
+	addresses = addresses[offset:]
+	if limit >= 0 && limit < len(addresses) {
+		addresses = addresses[:limit]
+	}
+	return addresses, nil
+}
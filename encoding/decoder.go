@@ -0,0 +1,216 @@
+package encoding
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// maxLength is the max length of a string, a byte array or a collection read,
+// the max transaction size of the chain
+const maxLength = 1 << 20
+
+type Decoder struct {
+	r *bufio.Reader
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{bufio.NewReader(r)}
+}
+
+func (decoder *Decoder) DecodeVarint() (int64, error) {
+	i, err := binary.ReadVarint(decoder.r)
+	if err != nil {
+		return 0, errors.Wrap(err, "decoder: failed to read varint")
+	}
+	return i, nil
+}
+
+func (decoder *Decoder) DecodeUVarint() (uint64, error) {
+	i, err := binary.ReadUvarint(decoder.r)
+	if err != nil {
+		return 0, errors.Wrap(err, "decoder: failed to read uvarint")
+	}
+	return i, nil
+}
+
+func (decoder *Decoder) DecodeLittleEndianUInt64() (uint64, error) {
+	b, err := decoder.DecodeBytes(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+func (decoder *Decoder) DecodeLittleEndianUInt32() (uint32, error) {
+	b, err := decoder.DecodeBytes(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+// DecodeNumber reads a fixed size number into v, a pointer to a number
+func (decoder *Decoder) DecodeNumber(v interface{}) error {
+	if err := binary.Read(decoder.r, binary.LittleEndian, v); err != nil {
+		return errors.Wrapf(err, "decoder: failed to read number: %T", v)
+	}
+	return nil
+}
+
+func (decoder *Decoder) DecodeBool() (bool, error) {
+	var b byte
+	if err := decoder.DecodeNumber(&b); err != nil {
+		return false, err
+	}
+	switch b {
+	case 0:
+		return false, nil
+	case 1:
+		return true, nil
+	default:
+		return false, errors.Errorf("decoder: invalid bool %d", b)
+	}
+}
+
+// DecodeBytes reads n bytes, the size of fixed arrays like keys and signatures
+func (decoder *Decoder) DecodeBytes(n int) ([]byte, error) {
+	if n < 0 || n > maxLength {
+		return nil, errors.Errorf("decoder: invalid length %d", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(decoder.r, b); err != nil {
+		return nil, errors.Wrapf(err, "decoder: failed to read %d bytes", n)
+	}
+	return b, nil
+}
+
+// DecodeString reads a string prefixed by its length
+func (decoder *Decoder) DecodeString() (string, error) {
+	b, err := decoder.decodeLengthBytes()
+	if err != nil {
+		return "", errors.Wrap(err, "decoder: failed to read string")
+	}
+	return string(b), nil
+}
+
+// DecodeOptional reads the flag of an optional value and decodes the value by
+// decodeValue when it is present
+func (decoder *Decoder) DecodeOptional(decodeValue func() error) (bool, error) {
+	present, err := decoder.DecodeBool()
+	if err != nil || !present {
+		return false, err
+	}
+	return true, decodeValue()
+}
+
+// DecodeStaticVariant reads the tag of a static variant and decodes the value
+// by decodeValue of the tag
+func (decoder *Decoder) DecodeStaticVariant(decodeValue func(tag uint64) error) error {
+	tag, err := decoder.DecodeUVarint()
+	if err != nil {
+		return err
+	}
+	return decodeValue(tag)
+}
+
+// DecodeFlatSet reads the length of a flat set or a vector and decodes every
+// element by decodeElement
+func (decoder *Decoder) DecodeFlatSet(decodeElement func(i int) error) error {
+	n, err := decoder.decodeLength()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		if err := decodeElement(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeFlatMap reads the length of a flat map and decodes every key and its
+// value by decodeEntry
+func (decoder *Decoder) DecodeFlatMap(decodeEntry func(i int) error) error {
+	return decoder.DecodeFlatSet(decodeEntry)
+}
+
+// DecodeExtensions reads the present fields of an extension<T> and decodes the
+// value of each by decodeValue of its index. The indexes must be increasing.
+func (decoder *Decoder) DecodeExtensions(decodeValue func(index uint64) error) error {
+	next := uint64(0)
+	return decoder.DecodeFlatSet(func(int) error {
+		return decoder.DecodeStaticVariant(func(index uint64) error {
+			if index < next {
+				return errors.Errorf("decoder: extension %d out of order", index)
+			}
+			next = index + 1
+			return decodeValue(index)
+		})
+	})
+}
+
+// Decode reads v, an Unmarshaller or a pointer to a number, a bool or a string
+func (decoder *Decoder) Decode(v interface{}) error {
+	if unmarshaller, ok := v.(Unmarshaller); ok {
+		return unmarshaller.Unmarshal(decoder)
+	}
+
+	switch v := v.(type) {
+	case *int8, *int16, *int32, *int64, *uint8, *uint16, *uint32, *uint64:
+		return decoder.DecodeNumber(v)
+
+	case *bool:
+		b, err := decoder.DecodeBool()
+		*v = b
+		return err
+
+	case *string:
+		s, err := decoder.DecodeString()
+		*v = s
+		return err
+
+	default:
+		return errors.Errorf("decoder: unsupported type (%T) encountered", v)
+	}
+}
+
+// PeekUVarint returns the next uvarint without reading it, like the tag of a static variant
+func (decoder *Decoder) PeekUVarint() (uint64, error) {
+	b, err := decoder.r.Peek(binary.MaxVarintLen64)
+	if err != nil && err != io.EOF {
+		return 0, errors.Wrap(err, "decoder: failed to peek uvarint")
+	}
+	i, n := binary.Uvarint(b)
+	if n <= 0 {
+		return 0, errors.New("decoder: failed to peek uvarint")
+	}
+	return i, nil
+}
+
+// EOF reports whether everything has been read
+func (decoder *Decoder) EOF() bool {
+	_, err := decoder.r.Peek(1)
+	return err == io.EOF
+}
+
+func (decoder *Decoder) decodeLength() (int, error) {
+	n, err := decoder.DecodeUVarint()
+	if err != nil {
+		return 0, err
+	}
+	if n > maxLength {
+		return 0, errors.Errorf("decoder: invalid length %d", n)
+	}
+	return int(n), nil
+}
+
+func (decoder *Decoder) decodeLengthBytes() ([]byte, error) {
+	n, err := decoder.decodeLength()
+	if err != nil {
+		return nil, err
+	}
+	return decoder.DecodeBytes(n)
+}
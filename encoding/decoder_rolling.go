@@ -0,0 +1,109 @@
+package encoding
+
+// RollingDecoder keeps the first error of the decoder, the reads after it do nothing
+type RollingDecoder struct {
+	next *Decoder
+	err  error
+}
+
+func NewRollingDecoder(next *Decoder) *RollingDecoder {
+	return &RollingDecoder{next, nil}
+}
+
+func (decoder *RollingDecoder) DecodeVarint() int64 {
+	var i int64
+	if decoder.err == nil {
+		i, decoder.err = decoder.next.DecodeVarint()
+	}
+	return i
+}
+
+func (decoder *RollingDecoder) DecodeUVarint() uint64 {
+	var i uint64
+	if decoder.err == nil {
+		i, decoder.err = decoder.next.DecodeUVarint()
+	}
+	return i
+}
+
+func (decoder *RollingDecoder) DecodeLittleEndianUInt64() uint64 {
+	var i uint64
+	if decoder.err == nil {
+		i, decoder.err = decoder.next.DecodeLittleEndianUInt64()
+	}
+	return i
+}
+
+func (decoder *RollingDecoder) DecodeLittleEndianUInt32() uint32 {
+	var i uint32
+	if decoder.err == nil {
+		i, decoder.err = decoder.next.DecodeLittleEndianUInt32()
+	}
+	return i
+}
+
+func (decoder *RollingDecoder) DecodeBool() bool {
+	var b bool
+	if decoder.err == nil {
+		b, decoder.err = decoder.next.DecodeBool()
+	}
+	return b
+}
+
+func (decoder *RollingDecoder) DecodeBytes(n int) []byte {
+	var b []byte
+	if decoder.err == nil {
+		b, decoder.err = decoder.next.DecodeBytes(n)
+	}
+	return b
+}
+
+func (decoder *RollingDecoder) DecodeString() string {
+	var s string
+	if decoder.err == nil {
+		s, decoder.err = decoder.next.DecodeString()
+	}
+	return s
+}
+
+func (decoder *RollingDecoder) DecodeOptional(decodeValue func() error) bool {
+	var present bool
+	if decoder.err == nil {
+		present, decoder.err = decoder.next.DecodeOptional(decodeValue)
+	}
+	return present
+}
+
+func (decoder *RollingDecoder) DecodeStaticVariant(decodeValue func(tag uint64) error) {
+	if decoder.err == nil {
+		decoder.err = decoder.next.DecodeStaticVariant(decodeValue)
+	}
+}
+
+func (decoder *RollingDecoder) DecodeFlatSet(decodeElement func(i int) error) {
+	if decoder.err == nil {
+		decoder.err = decoder.next.DecodeFlatSet(decodeElement)
+	}
+}
+
+func (decoder *RollingDecoder) DecodeFlatMap(decodeEntry func(i int) error) {
+	if decoder.err == nil {
+		decoder.err = decoder.next.DecodeFlatMap(decodeEntry)
+	}
+}
+
+func (decoder *RollingDecoder) DecodeExtensions(decodeValue func(index uint64) error) {
+	if decoder.err == nil {
+		decoder.err = decoder.next.DecodeExtensions(decodeValue)
+	}
+}
+
+func (decoder *RollingDecoder) Decode(v interface{}) {
+	if decoder.err == nil {
+		decoder.err = decoder.next.Decode(v)
+	}
+}
+
+func (decoder *RollingDecoder) Err() error {
+	return decoder.err
+}
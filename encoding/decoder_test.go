@@ -0,0 +1,101 @@
+package encoding
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoder_RoundTrip(t *testing.T) {
+	var b bytes.Buffer
+	enc := NewRollingEncoder(NewEncoder(&b))
+	enc.EncodeVarint(-300)
+	enc.EncodeUVarint(300)
+	enc.EncodeLittleEndianUInt64(1 << 40)
+	enc.EncodeLittleEndianUInt32(1 << 20)
+	enc.Encode(uint16(36752))
+	enc.EncodeBool(true)
+	enc.Encode("bitshares")
+	require.NoError(t, enc.Err())
+
+	dec := NewRollingDecoder(NewDecoder(&b))
+	require.Equal(t, int64(-300), dec.DecodeVarint())
+	require.Equal(t, uint64(300), dec.DecodeUVarint())
+	require.Equal(t, uint64(1<<40), dec.DecodeLittleEndianUInt64())
+	require.Equal(t, uint32(1<<20), dec.DecodeLittleEndianUInt32())
+	var refBlockNum uint16
+	dec.Decode(&refBlockNum)
+	require.Equal(t, uint16(36752), refBlockNum)
+	require.True(t, dec.DecodeBool())
+	require.Equal(t, "bitshares", dec.DecodeString())
+	require.NoError(t, dec.Err())
+}
+
+func TestDecoder_Collections(t *testing.T) {
+	// optional absent, optional uint16, static variant 2 of a string, set of
+	// two uint8 and map of one uint8 to a string
+	data := []byte{0, 1, 0x39, 0x30, 2, 3, 'a', 'b', 'c', 2, 7, 9, 1, 5, 1, 'x'}
+	decoder := NewDecoder(bytes.NewReader(data))
+	dec := NewRollingDecoder(decoder)
+
+	require.False(t, dec.DecodeOptional(func() error { t.Fatal("absent optional decoded"); return nil }))
+	var optional uint16
+	require.True(t, dec.DecodeOptional(func() error { return decoder.Decode(&optional) }))
+	require.Equal(t, uint16(12345), optional)
+
+	tag, err := decoder.PeekUVarint()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), tag)
+	var variant string
+	dec.DecodeStaticVariant(func(tag uint64) error {
+		require.Equal(t, uint64(2), tag)
+		return decoder.Decode(&variant)
+	})
+	require.Equal(t, "abc", variant)
+
+	set := make([]uint8, 0)
+	dec.DecodeFlatSet(func(int) error {
+		var v uint8
+		err := decoder.Decode(&v)
+		set = append(set, v)
+		return err
+	})
+	require.Equal(t, []uint8{7, 9}, set)
+
+	m := make(map[uint8]string)
+	dec.DecodeFlatMap(func(int) error {
+		var k uint8
+		var v string
+		if err := decoder.Decode(&k); err != nil {
+			return err
+		}
+		err := decoder.Decode(&v)
+		m[k] = v
+		return err
+	})
+	require.Equal(t, map[uint8]string{5: "x"}, m)
+	require.NoError(t, dec.Err())
+	require.True(t, decoder.EOF())
+}
+
+func TestDecoder_Errors(t *testing.T) {
+	// truncated
+	_, err := NewDecoder(bytes.NewReader([]byte{1, 2})).DecodeLittleEndianUInt32()
+	require.Error(t, err)
+
+	// invalid bool
+	_, err = NewDecoder(bytes.NewReader([]byte{2})).DecodeBool()
+	require.Error(t, err)
+
+	// string longer than the data
+	_, err = NewDecoder(bytes.NewReader([]byte{5, 'a'})).DecodeString()
+	require.Error(t, err)
+
+	// the first error is kept
+	dec := NewRollingDecoder(NewDecoder(bytes.NewReader([]byte{2, 1})))
+	dec.DecodeBool()
+	require.Error(t, dec.Err())
+	require.False(t, dec.DecodeBool())
+	require.Error(t, dec.Err())
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"io"
 	"math"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
@@ -64,6 +65,70 @@ func (encoder *Encoder) EncodeBool(b bool) error {
 	}
 }
 
+// EncodeOptional writes the flag of an optional value and the value when it is
+// present, v is absent when it is nil or a nil pointer
+func (encoder *Encoder) EncodeOptional(v interface{}) error {
+	if isNil(v) {
+		return encoder.EncodeBool(false)
+	}
+	if err := encoder.EncodeBool(true); err != nil {
+		return err
+	}
+	return encoder.Encode(v)
+}
+
+// EncodeStaticVariant writes the tag of a static variant and its value, a nil
+// value is a void_t
+func (encoder *Encoder) EncodeStaticVariant(tag uint64, v interface{}) error {
+	if err := encoder.EncodeUVarint(tag); err != nil {
+		return err
+	}
+	if isNil(v) {
+		return nil
+	}
+	return encoder.Encode(v)
+}
+
+// EncodeFlatSet writes the length of a flat set or a vector and every element by
+// encodeElement. The elements of a flat set are written in their sorted order.
+func (encoder *Encoder) EncodeFlatSet(n int, encodeElement func(i int) error) error {
+	if err := encoder.EncodeUVarint(uint64(n)); err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		if err := encodeElement(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodeFlatMap writes the length of a flat map and every key and its value by
+// encodeEntry, in the sorted order of the keys
+func (encoder *Encoder) EncodeFlatMap(n int, encodeEntry func(i int) error) error {
+	return encoder.EncodeFlatSet(n, encodeEntry)
+}
+
+// Extension is a field of an extension<T>, index is its position in T
+type Extension struct {
+	Index uint64
+	Value interface{}
+}
+
+// EncodeExtensions writes the present fields of an extension<T>: their count,
+// then the index and the value of each, in the order of the indexes
+func (encoder *Encoder) EncodeExtensions(extensions ...Extension) error {
+	present := make([]Extension, 0, len(extensions))
+	for _, ext := range extensions {
+		if !isNil(ext.Value) {
+			present = append(present, ext)
+		}
+	}
+	return encoder.EncodeFlatSet(len(present), func(i int) error {
+		return encoder.EncodeStaticVariant(present[i].Index, present[i].Value)
+	})
+}
+
 func (encoder *Encoder) Encode(v interface{}) error {
 	if marshaller, ok := v.(Marshaller); ok {
 		return marshaller.Marshal(encoder)
@@ -92,6 +157,9 @@ func (encoder *Encoder) Encode(v interface{}) error {
 	case uint64:
 		return encoder.EncodeNumber(v)
 
+	case bool:
+		return encoder.EncodeBool(v)
+
 	case string:
 		return encoder.encodeString(v)
 
@@ -99,6 +167,10 @@ func (encoder *Encoder) Encode(v interface{}) error {
 		return encoder.writeBytes(v)
 
 	default:
+		// the values of optionals and extensions are pointers
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && !rv.IsNil() {
+			return encoder.Encode(rv.Elem().Interface())
+		}
 		return errors.Errorf("encoder: unsupported type (%+v) encountered", v)
 	}
 }
@@ -166,3 +238,15 @@ func (encoder *Encoder) writeString(s string) error {
 	}
 	return nil
 }
+
+// isNil reports whether v is nil or a nil pointer, slice or map
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
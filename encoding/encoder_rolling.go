@@ -39,6 +39,36 @@ func (encoder *RollingEncoder) Encode(v interface{}) {
 	}
 }
 
+func (encoder *RollingEncoder) EncodeOptional(v interface{}) {
+	if encoder.err == nil {
+		encoder.err = encoder.next.EncodeOptional(v)
+	}
+}
+
+func (encoder *RollingEncoder) EncodeStaticVariant(tag uint64, v interface{}) {
+	if encoder.err == nil {
+		encoder.err = encoder.next.EncodeStaticVariant(tag, v)
+	}
+}
+
+func (encoder *RollingEncoder) EncodeFlatSet(n int, encodeElement func(i int) error) {
+	if encoder.err == nil {
+		encoder.err = encoder.next.EncodeFlatSet(n, encodeElement)
+	}
+}
+
+func (encoder *RollingEncoder) EncodeFlatMap(n int, encodeEntry func(i int) error) {
+	if encoder.err == nil {
+		encoder.err = encoder.next.EncodeFlatMap(n, encodeEntry)
+	}
+}
+
+func (encoder *RollingEncoder) EncodeExtensions(extensions ...Extension) {
+	if encoder.err == nil {
+		encoder.err = encoder.next.EncodeExtensions(extensions...)
+	}
+}
+
 func (encoder *RollingEncoder) EncodeMoney(s string) {
 	if encoder.err == nil {
 		encoder.err = encoder.next.EncodeMoney(s)
@@ -34,3 +34,72 @@ func TestEncoder_EncodeMoney_ValueOverflow(t *testing.T) {
 	err := encoder.EncodeMoney("11111111111111111111111111111111111111 SCR")
 	require.Error(t, err)
 }
+
+func TestEncoder_Optional(t *testing.T) {
+	var b bytes.Buffer
+	enc := NewRollingEncoder(NewEncoder(&b))
+
+	var absent *uint16
+	present := uint16(12345)
+	enc.EncodeOptional(nil)
+	enc.EncodeOptional(absent)
+	enc.EncodeOptional(&present)
+	enc.EncodeOptional("abc")
+	require.NoError(t, enc.Err())
+	require.Equal(t, []byte{0, 0, 1, 0x39, 0x30, 1, 3, 'a', 'b', 'c'}, b.Bytes())
+}
+
+func TestEncoder_Collections(t *testing.T) {
+	var b bytes.Buffer
+	encoder := NewEncoder(&b)
+	enc := NewRollingEncoder(encoder)
+
+	enc.EncodeStaticVariant(2, "abc")
+	enc.EncodeStaticVariant(0, nil)
+	set := []uint8{7, 9}
+	enc.EncodeFlatSet(len(set), func(i int) error { return encoder.Encode(set[i]) })
+	enc.EncodeFlatMap(1, func(int) error {
+		if err := encoder.Encode(uint8(5)); err != nil {
+			return err
+		}
+		return encoder.Encode("x")
+	})
+	require.NoError(t, enc.Err())
+	require.Equal(t, []byte{2, 3, 'a', 'b', 'c', 0, 2, 7, 9, 1, 5, 1, 'x'}, b.Bytes())
+}
+
+func TestEncoder_Extensions(t *testing.T) {
+	var b bytes.Buffer
+	ratio := uint16(1750)
+	var absent *uint32
+
+	// absent fields are left out
+	require.NoError(t, NewEncoder(&b).EncodeExtensions(
+		Extension{Index: 0, Value: &ratio},
+		Extension{Index: 1, Value: absent},
+		Extension{Index: 2, Value: true},
+	))
+	require.Equal(t, []byte{2, 0, 0xd6, 0x06, 2, 1}, b.Bytes())
+
+	decoder := NewDecoder(&b)
+	fields := make(map[uint64]interface{})
+	require.NoError(t, decoder.DecodeExtensions(func(index uint64) error {
+		switch index {
+		case 0:
+			var v uint16
+			err := decoder.Decode(&v)
+			fields[index] = v
+			return err
+		default:
+			var v bool
+			err := decoder.Decode(&v)
+			fields[index] = v
+			return err
+		}
+	}))
+	require.Equal(t, map[uint64]interface{}{0: uint16(1750), 2: true}, fields)
+
+	// indexes out of order
+	err := NewDecoder(bytes.NewReader([]byte{2, 1, 0, 0, 0})).DecodeExtensions(func(uint64) error { return nil })
+	require.Error(t, err)
+}
@@ -3,3 +3,7 @@ package encoding
 type Marshaller interface {
 	Marshal(*Encoder) error
 }
+
+type Unmarshaller interface {
+	Unmarshal(*Decoder) error
+}
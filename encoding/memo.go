@@ -1,22 +1,40 @@
 package encoding
 
 import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
 	"fmt"
+	"strconv"
 
-	"github.com/blocktree/bitshares-adapter/exception"
-	"github.com/denkhaus/bitshares/crypto"
-	"github.com/denkhaus/bitshares/types"
+	"github.com/blocktree/bitshares-adapter/addrdec"
+	"github.com/blocktree/go-owcdrivers/addressEncoder"
+	"github.com/btcsuite/btcd/btcec"
 )
 
-// Encrypt message of a operation.
-func Encrypt(memo *types.Memo, msg string, wif string) error {
-	keyBag := crypto.NewKeyBag()
-	keyBag.Add(wif)
+//Encrypt calculates a shared secret by the senders private key
+//and the receivers public key, then encrypts the message of a memo.
+func Encrypt(msg string, fromPub, toPub string, nonce uint64, wif string) ([]byte, error) {
+	if len(msg) == 0 || len(fromPub) == 0 || len(toPub) == 0 {
+		return nil, fmt.Errorf("args is empty")
+	}
 
-	if err := keyBag.EncryptMemo(memo, msg); err != nil {
-		return fmt.Errorf("EncryptMemo: %v", err)
+	block, iv, err := memoCipher(fromPub, toPub, nonce, wif)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+
+	// checksum + msg
+	digest := sha256.Sum256([]byte(msg))
+	raw := append(digest[:4:4], msg...)
+	raw = pad(raw, aes.BlockSize)
+
+	dst := make([]byte, len(raw))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(dst, raw)
+	return dst, nil
 }
 
 //Decrypt calculates a shared secret by the receivers private key
@@ -27,43 +45,110 @@ func Decrypt(msg []byte, fromPub, toPub string, nonce uint64, wif string) (strin
 		return "", fmt.Errorf("args is empty")
 	}
 
+	if len(msg)%aes.BlockSize != 0 {
+		return "", fmt.Errorf("invalid message length %d", len(msg))
+	}
+
+	block, iv, err := memoCipher(fromPub, toPub, nonce, wif)
+	if err != nil {
+		return "", err
+	}
+
+	dst := make([]byte, len(msg))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(dst, msg)
+
+	//verify checksum
+	plain := unpad(dst)
+	if len(plain) < 4 {
+		return "", fmt.Errorf("invalid checksum")
+	}
+	digest := sha256.Sum256(plain[4:])
+	if !bytes.Equal(plain[:4], digest[:4]) {
+		return "", fmt.Errorf("invalid checksum")
+	}
+	return string(plain[4:]), nil
+}
+
+//memoCipher returns the aes cipher and iv of a memo: the shared secret of the
+//private key with the counterparty key, the one of from and to it is not
+func memoCipher(fromPub, toPub string, nonce uint64, wif string) (cipher.Block, []byte, error) {
 	if len(wif) == 0 {
-		return "", fmt.Errorf("wif cannot be empty")
+		return nil, nil, fmt.Errorf("wif cannot be empty")
 	}
 
-	from, err := types.NewPublicKeyFromString(fromPub)
+	priv, err := DecodeWIF(wif)
+	if err != nil {
+		return nil, nil, err
+	}
+	from, err := parsePublicKey(fromPub)
 	if err != nil {
-		return "", fmt.Errorf("NewPublicKeyFromString: %v", err)
+		return nil, nil, err
 	}
-	to, err := types.NewPublicKeyFromString(toPub)
+	to, err := parsePublicKey(toPub)
 	if err != nil {
-		return "", fmt.Errorf("NewPublicKeyFromString: %v", err)
+		return nil, nil, err
 	}
 
-	memo := types.Memo{
-		From:    *from,
-		To:      *to,
-		Message: types.Buffer(msg),
-		Nonce:   types.UInt64(nonce),
+	var counterparty *btcec.PublicKey
+	switch {
+	case priv.PubKey().IsEqual(to):
+		counterparty = from
+	case priv.PubKey().IsEqual(from):
+		counterparty = to
+	default:
+		return nil, nil, fmt.Errorf("invalid counterparty public key, cannot decrypt")
 	}
 
-	priv, err := types.NewPrivateKeyFromWif(wif)
+	x, _ := btcec.S256().ScalarMult(counterparty.X, counterparty.Y, priv.D.Bytes())
+	secret := make([]byte, 32)
+	xBytes := x.Bytes()
+	copy(secret[len(secret)-len(xBytes):], xBytes)
+	ss := sha512.Sum512(secret)
+
+	seed := strconv.FormatUint(nonce, 10) + hex.EncodeToString(ss[:])
+	sd := sha512.Sum512([]byte(seed))
+	block, err := aes.NewCipher(sd[:32])
 	if err != nil {
-		return "", fmt.Errorf("NewPrivateKeyFromWif: %v", err)
-	}
-
-	m := ""
-	exception.ExceptionError{
-		Try: func() {
-			m, err = memo.Decrypt(priv)
-			if err != nil {
-				exception.Throw(fmt.Sprintf("decrypt error: %v", err))
-			}
-		},
-		Catch: func(e exception.Exception) {
-			err = fmt.Errorf("Unexpected %v", e)
-		},
-	}.Do()
-
-	return m, err
+		return nil, nil, fmt.Errorf("NewCipher: %v", err)
+	}
+	return block, sd[32:48], nil
+}
+
+//DecodeWIF decodes a private key in the wallet import format
+func DecodeWIF(wif string) (*btcec.PrivateKey, error) {
+	raw, err := addressEncoder.Base58Decode(wif, addressEncoder.NewBase58Alphabet(addressEncoder.BTCAlphabet))
+	if err != nil || len(raw) != 37 || raw[0] != 0x80 || !addressEncoder.VerifyChecksum(raw, "doubleSHA256") {
+		return nil, fmt.Errorf("invalid wif private key")
+	}
+	priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), raw[1:33])
+	return priv, nil
+}
+
+//parsePublicKey parses a public key in the BTS... form
+func parsePublicKey(key string) (*btcec.PublicKey, error) {
+	raw, err := addrdec.Default.AddressDecode(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key %s: %v", key, err)
+	}
+	pub, err := btcec.ParsePubKey(raw, btcec.S256())
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key %s: %v", key, err)
+	}
+	return pub, nil
+}
+
+func pad(buf []byte, length int) []byte {
+	cnt := length - len(buf)%length
+	return append(buf, bytes.Repeat([]byte{byte(cnt)}, cnt)...)
+}
+
+func unpad(buf []byte) []byte {
+	if len(buf) == 0 {
+		return buf
+	}
+	cnt := int(buf[len(buf)-1])
+	if cnt == 0 || cnt > len(buf) || !bytes.Equal(buf[len(buf)-cnt:], bytes.Repeat([]byte{buf[len(buf)-1]}, cnt)) {
+		return buf
+	}
+	return buf[:len(buf)-cnt]
 }
@@ -0,0 +1,48 @@
+package encoding
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// keys and message encrypted by github.com/denkhaus/bitshares
+const (
+	memoFromWIF = "5JBWV6pN7wQzggN7gPYzY9yVdvp8ptiYLhPydzQr7jnoMXu527y"
+	memoToWIF   = "5JBWV6pN7wQzggN7gPYzY9yVdvp8ptiYLhPydzQDmYwPYW6Lx5n"
+	memoFrom    = "BTS4vF8KDspj7fKBprtKvc1uamTL4qArHDjJfxyjdTzZWg3C7WSNK"
+	memoTo      = "BTS5FdaHSyyNhZeD3jixgkt7rp1wPRyAUDB9qPEpDoLPNVAZQJysF"
+	memoNonce   = 5862723643998573708
+	memoMessage = "d94312d71487f238512b3f05c22a0796261d70483ba04db3f28eed0f04560479"
+)
+
+func TestEncrypt(t *testing.T) {
+	msg, err := Encrypt("withdraw 1234", memoFrom, memoTo, memoNonce, memoFromWIF)
+	require.NoError(t, err)
+	require.Equal(t, memoMessage, hex.EncodeToString(msg))
+
+	// a checksum and message of whole blocks is padded with one more block
+	msg, err = Encrypt("withdraw 123", memoFrom, memoTo, memoNonce, memoFromWIF)
+	require.NoError(t, err)
+	require.Len(t, msg, 32)
+
+	_, err = Encrypt("withdraw 1234", memoFrom, memoTo, memoNonce, "5JBWV6pN7wQzggN7gPYzY9yVdvp8ptiYLhPydzQr7jnoMXu527x")
+	require.Error(t, err)
+}
+
+func TestDecrypt(t *testing.T) {
+	raw, _ := hex.DecodeString(memoMessage)
+	for _, wif := range []string{memoFromWIF, memoToWIF} {
+		msg, err := Decrypt(raw, memoFrom, memoTo, memoNonce, wif)
+		require.NoError(t, err)
+		require.Equal(t, "withdraw 1234", msg)
+	}
+
+	_, err := Decrypt(raw, memoFrom, memoTo, memoNonce+1, memoToWIF)
+	require.Error(t, err)
+	_, err = Decrypt(raw, memoFrom, memoFrom, memoNonce, memoToWIF)
+	require.Error(t, err)
+	_, err = Decrypt(raw[:20], memoFrom, memoTo, memoNonce, memoToWIF)
+	require.Error(t, err)
+}
@@ -6,13 +6,15 @@ require (
 	github.com/blocktree/go-owcdrivers v1.2.0
 	github.com/blocktree/go-owcrypt v1.1.1
 	github.com/blocktree/openwallet/v2 v2.0.10
-	github.com/denkhaus/bitshares v0.6.1-0.20190502142618-5ae8c00cb394
+	github.com/btcsuite/btcd v0.20.1-beta
+	github.com/btcsuite/btcutil v0.0.0-20191219182022-e17c9730c422 // indirect
 	github.com/golang/protobuf v1.3.2 // indirect
 	github.com/imroc/req v0.2.4
 	github.com/pkg/errors v0.8.1
 	github.com/shopspring/decimal v0.0.0-20200105231215-408a2507e114
 	github.com/stretchr/testify v1.4.0
 	github.com/tidwall/gjson v1.3.5
+	golang.org/x/net v0.0.0-20190628185345-da137c7871d7
 )
 
 go 1.13
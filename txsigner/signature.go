@@ -1,93 +1,129 @@
 package txsigner
 
-//func signRFC6979(privateKey, hash []byte, nonce int) ([]byte, error) {
-//	k := generateRandomFromNonce(privateKey, hash, nonce)
-//
-//	if owcrypt.PreprocessRandomNum(k) != owcrypt.SUCCESS {
-//		return nil, errors.New("Failed to set random!")
-//	}
-//	sig, ret := owcrypt.Signature(privateKey, nil, 0, hash, 32, owcrypt.ECC_CURVE_SECP256K1|owcrypt.NOUNCE_OUTSIDE_FLAG)
-//	if ret != owcrypt.SUCCESS {
-//		return nil, errors.New("Failed to signature!")
-//	}
-//	return sig, nil
-//}
-//
-//func equals(a, b []byte) bool {
-//	if len(a) != len(b) {
-//		return false
-//	}
-//	for index := 0; index < len(a); index++ {
-//		if a[index] != b[index] {
-//			return false
-//		}
-//	}
-//	return true
-//}
-//
-//func makeCompact(sig, publicKey, hash []byte) ([]byte, error) {
-//	for i := 0; i < 2; i++ {
-//		tmp := append(sig, byte(i))
-//		pk, ret := owcrypt.RecoverPubkey(tmp, hash, owcrypt.ECC_CURVE_SECP256K1)
-//		if ret == owcrypt.SUCCESS && equals(pk, publicKey) {
-//			result := make([]byte, 1, 2*32+1)
-//			result[0] = 27 + byte(i)
-//			result[0] += 4
-//			// Not sure this needs rounding but safer to do so.
-//			curvelen := (256 + 7) / 8
-//
-//			// Pad R and S to curvelen if needed.
-//			bytelen := (256 + 7) / 8
-//			if bytelen < curvelen {
-//				result = append(result,
-//					make([]byte, curvelen-bytelen)...)
-//			}
-//			result = append(result, sig[:32]...)
-//
-//			bytelen = (256 + 7) / 8
-//			if bytelen < curvelen {
-//				result = append(result,
-//					make([]byte, curvelen-bytelen)...)
-//			}
-//			result = append(result, sig[32:]...)
-//
-//			return result, nil
-//		}
-//	}
-//
-//	return nil, errors.New("no valid solution for pubkey found")
-//}
-//
-//func isCanonical(compactSig []byte) bool {
-//	d := compactSig
-//	t1 := (d[1] & 0x80) == 0
-//	t2 := !(d[1] == 0 && ((d[2] & 0x80) == 0))
-//	t3 := (d[33] & 0x80) == 0
-//	t4 := !(d[33] == 0 && ((d[34] & 0x80) == 0))
-//	return t1 && t2 && t3 && t4
-//}
-//
-//func SignCanonical(privateKey, hash []byte) ([]byte, error) {
-//
-//	for i := 0; i < 25; i++ {
-//		sig, err := signRFC6979(privateKey, hash, i)
-//		if err != nil {
-//			return nil, err
-//		}
-//
-//		publicKey, ret := owcrypt.GenPubkey(privateKey, owcrypt.ECC_CURVE_SECP256K1)
-//		if ret != owcrypt.SUCCESS {
-//			return nil, errors.New("Invalid private key!")
-//		}
-//
-//		compactSig, err := makeCompact(sig, publicKey, hash)
-//		if err != nil {
-//			continue
-//		}
-//
-//		if isCanonical(compactSig) {
-//			return sig, nil
-//		}
-//	}
-//	return nil, errors.New("couldn't find a canonical signature")
-//}
+import (
+	"errors"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+const (
+	// compactHeader is the first byte of a compact signature of a compressed
+	// key, before the recovery id is added
+	compactHeader = 27 + 4
+
+	// maxCanonicalAttempts bounds the nonces tried, about half of the
+	// signatures are canonical
+	maxCanonicalAttempts = 100
+)
+
+// signRFC6979 signs the hash with the secret derived from the nonce and returns
+// the compact signature: the header with the recovery id, r and the low s.
+func signRFC6979(privateKey, hash []byte, nonce int) ([]byte, error) {
+	curve := btcec.S256()
+	n := curve.N
+
+	d := new(big.Int).SetBytes(privateKey)
+	if len(privateKey) != 32 || d.Sign() == 0 || d.Cmp(n) >= 0 {
+		return nil, errors.New("Invalid private key!")
+	}
+	if len(hash) != 32 {
+		return nil, errors.New("Invalid hash!")
+	}
+
+	k := new(big.Int).SetBytes(generateRandomFromNonce(privateKey, hash, nonce))
+	x, y := curve.ScalarBaseMult(int2octets(k, 32))
+	r := new(big.Int).Mod(x, n)
+	if r.Sign() == 0 {
+		return nil, errors.New("Failed to signature!")
+	}
+
+	recID := byte(y.Bit(0))
+	if x.Cmp(n) >= 0 {
+		recID |= 2
+	}
+
+	// s = k^-1 * (e + r*d) mod n
+	s := new(big.Int).Mul(d, r)
+	s.Add(s, hashToInt(hash))
+	s.Mul(s, new(big.Int).ModInverse(k, n))
+	s.Mod(s, n)
+	if s.Sign() == 0 {
+		return nil, errors.New("Failed to signature!")
+	}
+
+	// the nodes only accept the lower of s and n-s, negating s negates R
+	if s.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		s.Sub(n, s)
+		recID ^= 1
+	}
+
+	sig := make([]byte, 65)
+	sig[0] = compactHeader + recID
+	copy(sig[1:33], int2octets(r, 32))
+	copy(sig[33:], int2octets(s, 32))
+	return sig, nil
+}
+
+// makeCompact finds the recovery id of the r and s of sig for the public key,
+// compressed, uncompressed or its 64 bytes of coordinates, and returns the
+// compact signature
+func makeCompact(sig, publicKey, hash []byte) ([]byte, error) {
+	if len(sig) < 64 {
+		return nil, errors.New("Invalid signature!")
+	}
+	if len(publicKey) == 64 {
+		publicKey = append([]byte{0x04}, publicKey...)
+	}
+	pub, err := btcec.ParsePubKey(publicKey, btcec.S256())
+	if err != nil {
+		return nil, errors.New("Invalid public key!")
+	}
+
+	for i := byte(0); i < 4; i++ {
+		result := make([]byte, 1, 65)
+		result[0] = compactHeader + i
+		result = append(result, sig[:64]...)
+
+		pk, _, err := btcec.RecoverCompact(btcec.S256(), result, hash)
+		if err == nil && pk.IsEqual(pub) {
+			return result, nil
+		}
+	}
+
+	return nil, errors.New("no valid solution for pubkey found")
+}
+
+// isCanonical reports whether r and s of the compact signature are both 32 bytes
+// long as DER positive integers, what graphene requires of a signature
+func isCanonical(compactSig []byte) bool {
+	d := compactSig
+	t1 := (d[1] & 0x80) == 0
+	t2 := !(d[1] == 0 && ((d[2] & 0x80) == 0))
+	t3 := (d[33] & 0x80) == 0
+	t4 := !(d[33] == 0 && ((d[34] & 0x80) == 0))
+	return t1 && t2 && t3 && t4
+}
+
+// IsCanonical reports whether a compact signature is accepted by the nodes
+func IsCanonical(compactSig []byte) bool {
+	return len(compactSig) == 65 && isCanonical(compactSig)
+}
+
+// SignCanonical signs the hash with the secp256k1 private key and returns the
+// compact signature of a transaction. The nonce is increased until the
+// signature is canonical, the way the graphene wallets sign.
+func SignCanonical(privateKey, hash []byte) ([]byte, error) {
+
+	for i := 0; i < maxCanonicalAttempts; i++ {
+		sig, err := signRFC6979(privateKey, hash, i)
+		if err != nil {
+			return nil, err
+		}
+
+		if isCanonical(sig) {
+			return sig, nil
+		}
+	}
+	return nil, errors.New("couldn't find a canonical signature")
+}
@@ -1,28 +1,52 @@
 package txsigner
 
+import (
+	"fmt"
+
+	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/blocktree/go-owcrypt"
+)
+
 var Default = &TransactionSigner{}
 
 type TransactionSigner struct {
 }
 
 // SignTransactionHash 交易哈希签名算法
-// Deprecated
+// The signature is canonical, r and s followed by the recovery id as the openwallet
+// key signatures are.
 func (singer *TransactionSigner) SignTransactionHash(msg []byte, privateKey []byte, eccType uint32) ([]byte, error) {
-	//return SignCanonical(privateKey, msg)
-	return nil, nil
+	if eccType != owcrypt.ECC_CURVE_SECP256K1 {
+		return nil, fmt.Errorf("unsupported curve type: %d", eccType)
+	}
+	compactSig, err := SignCanonical(privateKey, msg)
+	if err != nil {
+		return nil, err
+	}
+	return append(compactSig[1:], compactSig[0]-compactHeader), nil
+}
+
+// SignTransactionHashWithWIF signs the hash as SignTransactionHash with a private key
+// in the wallet import format
+func (singer *TransactionSigner) SignTransactionHashWithWIF(msg []byte, wif string) ([]byte, error) {
+	key, err := encoding.DecodeWIF(wif)
+	if err != nil {
+		return nil, err
+	}
+	return singer.SignTransactionHash(msg, key.Serialize(), owcrypt.ECC_CURVE_SECP256K1)
 }
 
-// Deprecated
+// VerifyAndCombineSignature verifies the signature, r and s with an optional recovery
+// id, of the hash by the public key and returns the compact signature of the transaction
 func (singer *TransactionSigner) VerifyAndCombineSignature(msg, publicKey, signature []byte) (bool, []byte, error) {
-	//compactSig, err := makeCompact(signature, publicKey, msg)
-	//if err != nil {
-	//	return false, nil, err
-	//}
-	//
-	//if !isCanonical(compactSig) {
-	//	return false, nil, fmt.Errorf("it is not canonical signature")
-	//}
-	//
-	//return true, compactSig, nil
-	return false, nil, nil
+	compactSig, err := makeCompact(signature, publicKey, msg)
+	if err != nil {
+		return false, nil, err
+	}
+
+	if !isCanonical(compactSig) {
+		return false, nil, fmt.Errorf("it is not canonical signature")
+	}
+
+	return true, compactSig, nil
 }
@@ -0,0 +1,116 @@
+package txsigner
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+
+	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/blocktree/go-owcrypt"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testWIF      = "5JBWV6pN7wQzggN7gPYzY9yVdvp8ptiYLhPydzQr7jnoMXu527y"
+	testOtherWIF = "5JBWV6pN7wQzggN7gPYzY9yVdvp8ptiYLhPydzQDmYwPYW6Lx5n"
+)
+
+func testDigest(i int) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(i))
+	digest := sha256.Sum256(b[:])
+	return digest[:]
+}
+
+func TestSignCanonical(t *testing.T) {
+	key, err := encoding.DecodeWIF(testWIF)
+	require.NoError(t, err)
+
+	retried := 0
+	for i := 0; i < 64; i++ {
+		digest := testDigest(i)
+
+		sig, err := SignCanonical(key.Serialize(), digest)
+		require.NoError(t, err)
+		require.True(t, IsCanonical(sig))
+
+		pub, compressed, err := btcec.RecoverCompact(btcec.S256(), sig, digest)
+		require.NoError(t, err)
+		require.True(t, compressed)
+		require.True(t, pub.IsEqual(key.PubKey()))
+
+		again, err := SignCanonical(key.Serialize(), digest)
+		require.NoError(t, err)
+		require.Equal(t, sig, again, "signatures are deterministic")
+
+		// the first nonce is plain RFC6979, the later ones extend the hash
+		plain, err := btcec.SignCompact(btcec.S256(), key, digest, true)
+		require.NoError(t, err)
+		if IsCanonical(plain) {
+			require.Equal(t, plain, sig)
+		} else {
+			require.NotEqual(t, plain, sig)
+			retried++
+		}
+	}
+	require.NotZero(t, retried, "no digest needed another nonce")
+
+	_, err = SignCanonical(make([]byte, 32), testDigest(0))
+	require.Error(t, err)
+	_, err = SignCanonical(key.Serialize(), []byte("short"))
+	require.Error(t, err)
+}
+
+func TestTransactionSigner(t *testing.T) {
+	key, err := encoding.DecodeWIF(testWIF)
+	require.NoError(t, err)
+	other, err := encoding.DecodeWIF(testOtherWIF)
+	require.NoError(t, err)
+	digest := testDigest(1)
+
+	// r and s followed by the recovery id
+	signature, err := Default.SignTransactionHashWithWIF(digest, testWIF)
+	require.NoError(t, err)
+	require.Len(t, signature, 65)
+	compactSig, err := SignCanonical(key.Serialize(), digest)
+	require.NoError(t, err)
+	require.Equal(t, compactSig[1:], signature[:64])
+	require.Equal(t, compactSig[0]-compactHeader, signature[64])
+
+	_, ret := owcrypt.RecoverPubkey(signature, digest, owcrypt.ECC_CURVE_SECP256K1)
+	require.Equal(t, owcrypt.SUCCESS, ret)
+
+	// any form of the public key recovers the compact signature
+	for _, publicKey := range [][]byte{
+		key.PubKey().SerializeCompressed(),
+		key.PubKey().SerializeUncompressed(),
+		key.PubKey().SerializeUncompressed()[1:],
+	} {
+		valid, combined, err := Default.VerifyAndCombineSignature(digest, publicKey, signature)
+		require.NoError(t, err)
+		require.True(t, valid)
+		require.Equal(t, compactSig, combined)
+	}
+
+	valid, _, err := Default.VerifyAndCombineSignature(digest, other.PubKey().SerializeCompressed(), signature)
+	require.Error(t, err)
+	require.False(t, valid)
+
+	// a signature the nodes reject is not combined
+	for i := 0; ; i++ {
+		plain, err := btcec.SignCompact(btcec.S256(), key, testDigest(i), true)
+		require.NoError(t, err)
+		if !IsCanonical(plain) {
+			valid, _, err := Default.VerifyAndCombineSignature(testDigest(i), key.PubKey().SerializeCompressed(), plain[1:])
+			require.Error(t, err)
+			require.False(t, valid)
+			break
+		}
+	}
+
+	_, err = Default.SignTransactionHash(digest, key.Serialize(), owcrypt.ECC_CURVE_ED25519)
+	require.Error(t, err)
+	_, err = Default.SignTransactionHashWithWIF(digest, "5JBWV6pN7wQzggN7gPYzY9yVdvp8ptiYLhPydzQr7jnoMXu527")
+	require.Error(t, err)
+}
@@ -1,5 +1,17 @@
 package types
 
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blocktree/bitshares-adapter/addrdec"
+	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/pkg/errors"
+)
+
 type Account struct {
 	ID                            ObjectID   `json:"id"`
 	MembershipExpirationDate      Time       `json:"membership_expiration_date"`
@@ -16,14 +28,357 @@ type Account struct {
 	Active                        Permission `json:"active"`
 }
 
+// Permission is an authority: the accounts, keys and addresses whose weights
+// of approval must reach the threshold
 type Permission struct {
 	WeightThreshold uint32        `json:"weight_threshold"`
-	AccountAuths    []interface{} `json:"account_auths"`
-	KeyAuths        []interface{} `json:"key_auths"`
-	AddressAuths    []interface{} `json:"address_auths"`
+	AccountAuths    []AccountAuth `json:"account_auths"`
+	KeyAuths        []KeyAuth     `json:"key_auths"`
+	AddressAuths    []AddressAuth `json:"address_auths"`
+}
+
+// AccountAuth is an account of an authority and its weight
+type AccountAuth struct {
+	Account ObjectID
+	Weight  uint16
+}
+
+func (a AccountAuth) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{&a.Account, a.Weight})
+}
+
+func (a *AccountAuth) UnmarshalJSON(b []byte) error {
+	return unmarshalWeighted(b, &a.Account, &a.Weight)
+}
+
+// KeyAuth is a public key of an authority and its weight
+type KeyAuth struct {
+	Key    PublicKey
+	Weight uint16
+}
+
+func (a KeyAuth) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{a.Key, a.Weight})
+}
+
+func (a *KeyAuth) UnmarshalJSON(b []byte) error {
+	return unmarshalWeighted(b, &a.Key, &a.Weight)
+}
+
+// AddressAuth is an address of an authority, the ripemd160 of a key, and its weight
+type AddressAuth struct {
+	Address string
+	Weight  uint16
+}
+
+func (a AddressAuth) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{a.Address, a.Weight})
+}
+
+func (a *AddressAuth) UnmarshalJSON(b []byte) error {
+	return unmarshalWeighted(b, &a.Address, &a.Weight)
+}
+
+// unmarshalWeighted parses an entry of the flat maps of an authority, a pair in json
+func unmarshalWeighted(b []byte, key interface{}, weight *uint16) error {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(b, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return errors.New("invalid authority format: should be key, weight")
+	}
+	if err := json.Unmarshal(pair[0], key); err != nil {
+		return err
+	}
+	return json.Unmarshal(pair[1], weight)
+}
+
+// Marshal implements encoding.Marshaller interface. The flat maps are written
+// in the order of their keys, as the chain keeps them.
+func (p Permission) Marshal(encoder *encoding.Encoder) error {
+	accounts := append([]AccountAuth(nil), p.AccountAuths...)
+	sort.SliceStable(accounts, func(i, j int) bool { return accounts[i].Account.ID < accounts[j].Account.ID })
+
+	keys := make([][]byte, len(p.KeyAuths))
+	for i, auth := range p.KeyAuths {
+		raw, err := auth.Key.Bytes()
+		if err != nil {
+			return err
+		}
+		keys[i] = raw
+	}
+	keyAuths := append([]KeyAuth(nil), p.KeyAuths...)
+	sort.Sort(byBytes{keys, func(i, j int) { keyAuths[i], keyAuths[j] = keyAuths[j], keyAuths[i] }})
+
+	addresses := make([][]byte, len(p.AddressAuths))
+	for i, auth := range p.AddressAuths {
+		raw, err := addrdec.Default.AddressDecode(auth.Address)
+		if err != nil || len(raw) != 20 {
+			return errors.Errorf("invalid address %q", auth.Address)
+		}
+		addresses[i] = raw
+	}
+	addressAuths := append([]AddressAuth(nil), p.AddressAuths...)
+	sort.Sort(byBytes{addresses, func(i, j int) { addressAuths[i], addressAuths[j] = addressAuths[j], addressAuths[i] }})
+
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.EncodeLittleEndianUInt32(p.WeightThreshold)
+	enc.EncodeFlatMap(len(accounts), func(i int) error {
+		if err := encoder.Encode(accounts[i].Account); err != nil {
+			return err
+		}
+		return encoder.Encode(accounts[i].Weight)
+	})
+	enc.EncodeFlatMap(len(keys), func(i int) error {
+		if err := encoder.Encode(keys[i]); err != nil {
+			return err
+		}
+		return encoder.Encode(keyAuths[i].Weight)
+	})
+	enc.EncodeFlatMap(len(addresses), func(i int) error {
+		if err := encoder.Encode(addresses[i]); err != nil {
+			return err
+		}
+		return encoder.Encode(addressAuths[i].Weight)
+	})
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (p *Permission) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	p.WeightThreshold = dec.DecodeLittleEndianUInt32()
+
+	p.AccountAuths = []AccountAuth{}
+	dec.DecodeFlatMap(func(int) error {
+		auth := AccountAuth{Account: protocolID(accountObjectType)}
+		if err := decoder.Decode(&auth.Account); err != nil {
+			return err
+		}
+		if err := decoder.Decode(&auth.Weight); err != nil {
+			return err
+		}
+		p.AccountAuths = append(p.AccountAuths, auth)
+		return nil
+	})
+
+	p.KeyAuths = []KeyAuth{}
+	dec.DecodeFlatMap(func(int) error {
+		auth := KeyAuth{}
+		if err := decoder.Decode(&auth.Key); err != nil {
+			return err
+		}
+		if err := decoder.Decode(&auth.Weight); err != nil {
+			return err
+		}
+		p.KeyAuths = append(p.KeyAuths, auth)
+		return nil
+	})
+
+	p.AddressAuths = []AddressAuth{}
+	dec.DecodeFlatMap(func(int) error {
+		raw, err := decoder.DecodeBytes(20)
+		if err != nil {
+			return err
+		}
+		auth := AddressAuth{}
+		if auth.Address, err = addrdec.Default.AddressEncode(raw); err != nil {
+			return err
+		}
+		if err := decoder.Decode(&auth.Weight); err != nil {
+			return err
+		}
+		p.AddressAuths = append(p.AddressAuths, auth)
+		return nil
+	})
+	return dec.Err()
+}
+
+// byBytes sorts serialized keys, swapping the entries they belong to along
+type byBytes struct {
+	keys [][]byte
+	swap func(i, j int)
+}
+
+func (b byBytes) Len() int           { return len(b.keys) }
+func (b byBytes) Less(i, j int) bool { return bytes.Compare(b.keys[i], b.keys[j]) < 0 }
+func (b byBytes) Swap(i, j int) {
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+	b.swap(i, j)
+}
+
+// accountSet is a flat_set of account ids, written in the order of their instances
+type accountSet []ObjectID
+
+// Marshal implements encoding.Marshaller interface.
+func (set accountSet) Marshal(encoder *encoding.Encoder) error {
+	return marshalIDSet(encoder, set)
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (set *accountSet) Unmarshal(decoder *encoding.Decoder) error {
+	ids, err := unmarshalIDSet(decoder, accountObjectType)
+	*set = ids
+	return err
+}
+
+// assetSet is a flat_set of asset ids, written in the order of their instances
+type assetSet []ObjectID
+
+// Marshal implements encoding.Marshaller interface.
+func (set assetSet) Marshal(encoder *encoding.Encoder) error {
+	return marshalIDSet(encoder, set)
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (set *assetSet) Unmarshal(decoder *encoding.Decoder) error {
+	ids, err := unmarshalIDSet(decoder, assetObjectType)
+	*set = ids
+	return err
+}
+
+// marshalIDSet writes a flat_set of ids of one type in the order of their instances
+func marshalIDSet(encoder *encoding.Encoder, set []ObjectID) error {
+	ids := append([]ObjectID(nil), set...)
+	sort.SliceStable(ids, func(i, j int) bool { return ids[i].ID < ids[j].ID })
+	return encoder.EncodeFlatSet(len(ids), func(i int) error {
+		return encoder.Encode(ids[i])
+	})
+}
+
+// unmarshalIDSet reads a flat_set of ids of the protocol object type
+func unmarshalIDSet(decoder *encoding.Decoder, objectType uint64) ([]ObjectID, error) {
+	ids := []ObjectID{}
+	err := decoder.DecodeFlatSet(func(int) error {
+		id := protocolID(objectType)
+		if err := decoder.Decode(&id); err != nil {
+			return err
+		}
+		ids = append(ids, id)
+		return nil
+	})
+	return ids, err
 }
 
+// keySet is a flat_set of public keys, written in the order of their bytes
+type keySet []PublicKey
+
+// Marshal implements encoding.Marshaller interface.
+func (set keySet) Marshal(encoder *encoding.Encoder) error {
+	keys := make([][]byte, len(set))
+	for i, key := range set {
+		raw, err := key.Bytes()
+		if err != nil {
+			return err
+		}
+		keys[i] = raw
+	}
+	sort.Sort(byBytes{keys, func(i, j int) {}})
+	return encoder.EncodeFlatSet(len(keys), func(i int) error {
+		return encoder.Encode(keys[i])
+	})
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (set *keySet) Unmarshal(decoder *encoding.Decoder) error {
+	*set = keySet{}
+	return decoder.DecodeFlatSet(func(int) error {
+		var key PublicKey
+		if err := decoder.Decode(&key); err != nil {
+			return err
+		}
+		*set = append(*set, key)
+		return nil
+	})
+}
+
+// Options are the account options, also those of account_create and account_update
 type Options struct {
-	MemoKey       string   `json:"memo_key"`
-	VotingAccount ObjectID `json:"voting_account"`
+	MemoKey       string          `json:"memo_key"`
+	VotingAccount ObjectID        `json:"voting_account"`
+	NumWitness    uint16          `json:"num_witness"`
+	NumCommittee  uint16          `json:"num_committee"`
+	Votes         []string        `json:"votes"`
+	Extensions    json.RawMessage `json:"extensions,omitempty"`
+}
+
+// Marshal implements encoding.Marshaller interface. The votes are written in
+// the order of their ids, as the chain keeps them.
+func (o Options) Marshal(encoder *encoding.Encoder) error {
+	votes := make([]uint32, len(o.Votes))
+	for i, vote := range o.Votes {
+		id, err := parseVoteID(vote)
+		if err != nil {
+			return err
+		}
+		votes[i] = id
+	}
+	sort.Slice(votes, func(i, j int) bool { return votes[i] < votes[j] })
+
+	exts, err := parseFutureExtensions(o.Extensions)
+	if err != nil {
+		return err
+	}
+
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.Encode(PublicKey(o.MemoKey))
+	enc.Encode(o.VotingAccount)
+	enc.Encode(o.NumWitness)
+	enc.Encode(o.NumCommittee)
+	enc.EncodeFlatSet(len(votes), func(i int) error {
+		return encoder.EncodeLittleEndianUInt32(votes[i])
+	})
+	enc.Encode(exts)
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (o *Options) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	var memoKey PublicKey
+	dec.Decode(&memoKey)
+	o.MemoKey = memoKey.String()
+	o.VotingAccount = protocolID(accountObjectType)
+	dec.Decode(&o.VotingAccount)
+	dec.Decode(&o.NumWitness)
+	dec.Decode(&o.NumCommittee)
+
+	o.Votes = []string{}
+	dec.DecodeFlatSet(func(int) error {
+		id, err := decoder.DecodeLittleEndianUInt32()
+		if err != nil {
+			return err
+		}
+		o.Votes = append(o.Votes, strconv.FormatUint(uint64(id&0xff), 10)+":"+strconv.FormatUint(uint64(id>>8), 10))
+		return nil
+	})
+
+	var exts futureExtensions
+	dec.Decode(&exts)
+	if err := dec.Err(); err != nil {
+		return err
+	}
+	raw, err := json.Marshal([]json.RawMessage(exts))
+	o.Extensions = raw
+	return err
+}
+
+// parseVoteID parses a vote id, type:instance, into the 32 bits of the chain:
+// the type in the lowest 8 bits and the instance in the others
+func parseVoteID(vote string) (uint32, error) {
+	parts := strings.Split(vote, ":")
+	if len(parts) != 2 {
+		return 0, errors.Errorf("invalid vote id %q", vote)
+	}
+	voteType, err := strconv.ParseUint(parts[0], 10, 8)
+	if err != nil {
+		return 0, errors.Errorf("invalid vote id %q", vote)
+	}
+	instance, err := strconv.ParseUint(parts[1], 10, 24)
+	if err != nil {
+		return 0, errors.Errorf("invalid vote id %q", vote)
+	}
+	return uint32(instance<<8 | voteType), nil
 }
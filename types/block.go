@@ -0,0 +1,194 @@
+package types
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/blocktree/bitshares-adapter/txsigner"
+	"github.com/blocktree/go-owcrypt"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/pkg/errors"
+)
+
+// BlockHeader is the signed header of a block, the part of a block its id is calculated from
+type BlockHeader struct {
+	Previous              string            `json:"previous"`
+	Timestamp             Time              `json:"timestamp"`
+	Witness               ObjectID          `json:"witness"`
+	TransactionMerkleRoot string            `json:"transaction_merkle_root"`
+	Extensions            []json.RawMessage `json:"extensions"`
+	WitnessSignature      string            `json:"witness_signature"`
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (header *BlockHeader) Marshal(encoder *encoding.Encoder) error {
+	signature, err := decodeFixedHex(header.WitnessSignature, 65)
+	if err != nil {
+		return errors.Wrap(err, "witness_signature")
+	}
+	if err := header.marshalUnsigned(encoder); err != nil {
+		return err
+	}
+	return encoder.Encode(signature)
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (header *BlockHeader) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	previous := dec.DecodeBytes(20)
+	dec.Decode(&header.Timestamp)
+	header.Witness = protocolID(witnessObjectType)
+	dec.Decode(&header.Witness)
+	root := dec.DecodeBytes(20)
+	dec.Decode((*blockHeaderExtensions)(&header.Extensions))
+	signature := dec.DecodeBytes(65)
+	if err := dec.Err(); err != nil {
+		return err
+	}
+
+	header.Previous = hex.EncodeToString(previous)
+	header.TransactionMerkleRoot = hex.EncodeToString(root)
+	header.WitnessSignature = hex.EncodeToString(signature)
+	return nil
+}
+
+// marshalUnsigned encodes the header without the witness signature
+func (header *BlockHeader) marshalUnsigned(encoder *encoding.Encoder) error {
+	previous, err := decodeFixedHex(header.Previous, 20)
+	if err != nil {
+		return errors.Wrap(err, "previous")
+	}
+	root, err := decodeFixedHex(header.TransactionMerkleRoot, 20)
+	if err != nil {
+		return errors.Wrap(err, "transaction_merkle_root")
+	}
+
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.Encode(previous)
+	enc.Encode(header.Timestamp)
+	enc.Encode(header.Witness)
+	enc.Encode(root)
+	enc.Encode(blockHeaderExtensions(header.Extensions))
+	return enc.Err()
+}
+
+// Digest returns the sha256 of the header without the witness signature, the digest the witness signs
+func (header *BlockHeader) Digest() ([]byte, error) {
+	var b bytes.Buffer
+	if err := header.marshalUnsigned(encoding.NewEncoder(&b)); err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(b.Bytes())
+	return digest[:], nil
+}
+
+// Signee returns the compressed public key recovered from the witness signature
+func (header *BlockHeader) Signee() ([]byte, error) {
+	signature, err := decodeFixedHex(header.WitnessSignature, 65)
+	if err != nil {
+		return nil, errors.Wrap(err, "witness_signature")
+	}
+	digest, err := header.Digest()
+	if err != nil {
+		return nil, err
+	}
+	key, _, err := btcec.RecoverCompact(btcec.S256(), signature, digest)
+	if err != nil {
+		return nil, errors.Wrap(err, "recover witness signing key")
+	}
+	return key.SerializeCompressed(), nil
+}
+
+// Sign sets the witness signature of the header, made with the witness's private
+// signing key, a canonical one the nodes accept
+func (header *BlockHeader) Sign(key *btcec.PrivateKey) error {
+	digest, err := header.Digest()
+	if err != nil {
+		return err
+	}
+	signature, err := txsigner.SignCanonical(key.Serialize(), digest)
+	if err != nil {
+		return err
+	}
+	header.WitnessSignature = hex.EncodeToString(signature)
+	return nil
+}
+
+// BlockNum returns the number of the block, one more than the number encoded in previous
+func (header *BlockHeader) BlockNum() uint32 {
+	return BlockNumFromID(header.Previous) + 1
+}
+
+// ID returns the block id: the sha224 of the serialized header, truncated to
+// 20 bytes, whose first 4 bytes are replaced by the big endian block number.
+func (header *BlockHeader) ID() (string, error) {
+	var b bytes.Buffer
+	if err := encoding.NewEncoder(&b).Encode(header); err != nil {
+		return "", err
+	}
+
+	digest := sha256.Sum224(b.Bytes())
+	binary.BigEndian.PutUint32(digest[:4], header.BlockNum())
+	return hex.EncodeToString(digest[:20]), nil
+}
+
+// MerkleRoot returns the transaction_merkle_root of a block with the transactions:
+// the ripemd160 of the root of a binary tree of sha256 digests, whose leaves are
+// the merkle digests of the transactions. It is zero without transactions.
+func MerkleRoot(transactions []*Transaction) (string, error) {
+	if len(transactions) == 0 {
+		return strings.Repeat("0", 40), nil
+	}
+
+	digests := make([][]byte, len(transactions))
+	for i, tx := range transactions {
+		digest, err := tx.MerkleDigest()
+		if err != nil {
+			return "", errors.Wrapf(err, "transaction %d", i)
+		}
+		digests[i] = digest
+	}
+
+	for len(digests) > 1 {
+		next := make([][]byte, 0, (len(digests)+1)/2)
+		for i := 0; i+1 < len(digests); i += 2 {
+			pair := sha256.Sum256(append(append([]byte{}, digests[i]...), digests[i+1]...))
+			next = append(next, pair[:])
+		}
+		// an odd digest is carried up unchanged
+		if len(digests)%2 == 1 {
+			next = append(next, digests[len(digests)-1])
+		}
+		digests = next
+	}
+
+	return hex.EncodeToString(owcrypt.Hash(digests[0], 0, owcrypt.HASH_ALG_RIPEMD160)), nil
+}
+
+// BlockNumFromID returns the block number encoded in the first 4 bytes of a block id
+func BlockNumFromID(blockID string) uint32 {
+	raw, err := hex.DecodeString(blockID)
+	if err != nil || len(raw) < 4 {
+		return 0
+	}
+	return binary.BigEndian.Uint32(raw[:4])
+}
+
+// decodeFixedHex decodes a hex string of a fixed size binary value
+func decodeFixedHex(s string, size int) ([]byte, error) {
+	raw, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != size {
+		return nil, errors.Errorf("expected %d bytes, got %d", size, len(raw))
+	}
+	return raw, nil
+}
@@ -0,0 +1,35 @@
+package types
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blocktree/bitshares-adapter/txsigner"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockHeader_SignCanonical(t *testing.T) {
+	key, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+
+	for i := 0; i < 64; i++ {
+		header := BlockHeader{
+			Previous:              strings.Repeat("0", 40),
+			Timestamp:             NewTime(time.Unix(1561000000+int64(i)*3, 0)),
+			Witness:               MustParseObjectID("1.6.1"),
+			TransactionMerkleRoot: strings.Repeat("0", 40),
+		}
+		require.NoError(t, header.Sign(key))
+
+		signature, err := hex.DecodeString(header.WitnessSignature)
+		require.NoError(t, err)
+		require.True(t, txsigner.IsCanonical(signature), "signature %d is not canonical", i)
+
+		signee, err := header.Signee()
+		require.NoError(t, err)
+		require.Equal(t, key.PubKey().SerializeCompressed(), signee)
+	}
+}
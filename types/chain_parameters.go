@@ -0,0 +1,395 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/pkg/errors"
+)
+
+// chainParameters are the parameters of the chain the committee changes
+type chainParameters struct {
+	CurrentFees                      feeSchedule     `json:"current_fees"`
+	BlockInterval                    uint8           `json:"block_interval"`
+	MaintenanceInterval              uint32          `json:"maintenance_interval"`
+	MaintenanceSkipSlots             uint8           `json:"maintenance_skip_slots"`
+	CommitteeProposalReviewPeriod    uint32          `json:"committee_proposal_review_period"`
+	MaximumTransactionSize           uint32          `json:"maximum_transaction_size"`
+	MaximumBlockSize                 uint32          `json:"maximum_block_size"`
+	MaximumTimeUntilExpiration       uint32          `json:"maximum_time_until_expiration"`
+	MaximumProposalLifetime          uint32          `json:"maximum_proposal_lifetime"`
+	MaximumAssetWhitelistAuthorities uint8           `json:"maximum_asset_whitelist_authorities"`
+	MaximumAssetFeedPublishers       uint8           `json:"maximum_asset_feed_publishers"`
+	MaximumWitnessCount              uint16          `json:"maximum_witness_count"`
+	MaximumCommitteeCount            uint16          `json:"maximum_committee_count"`
+	MaximumAuthorityMembership       uint16          `json:"maximum_authority_membership"`
+	ReservePercentOfFee              uint16          `json:"reserve_percent_of_fee"`
+	NetworkPercentOfFee              uint16          `json:"network_percent_of_fee"`
+	LifetimeReferrerPercentOfFee     uint16          `json:"lifetime_referrer_percent_of_fee"`
+	CashbackVestingPeriodSeconds     uint32          `json:"cashback_vesting_period_seconds"`
+	CashbackVestingThreshold         Sint64          `json:"cashback_vesting_threshold"`
+	CountNonMemberVotes              bool            `json:"count_non_member_votes"`
+	AllowNonMemberWhitelists         bool            `json:"allow_non_member_whitelists"`
+	WitnessPayPerBlock               Sint64          `json:"witness_pay_per_block"`
+	WorkerBudgetPerDay               Sint64          `json:"worker_budget_per_day"`
+	MaxPredicateOpcode               uint16          `json:"max_predicate_opcode"`
+	FeeLiquidationThreshold          Sint64          `json:"fee_liquidation_threshold"`
+	AccountsPerFeeScale              uint16          `json:"accounts_per_fee_scale"`
+	AccountFeeScaleBitshifts         uint8           `json:"account_fee_scale_bitshifts"`
+	MaxAuthorityDepth                uint8           `json:"max_authority_depth"`
+	Extensions                       json.RawMessage `json:"extensions"`
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (p chainParameters) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.Encode(p.CurrentFees)
+	enc.Encode(p.BlockInterval)
+	enc.Encode(p.MaintenanceInterval)
+	enc.Encode(p.MaintenanceSkipSlots)
+	enc.Encode(p.CommitteeProposalReviewPeriod)
+	enc.Encode(p.MaximumTransactionSize)
+	enc.Encode(p.MaximumBlockSize)
+	enc.Encode(p.MaximumTimeUntilExpiration)
+	enc.Encode(p.MaximumProposalLifetime)
+	enc.Encode(p.MaximumAssetWhitelistAuthorities)
+	enc.Encode(p.MaximumAssetFeedPublishers)
+	enc.Encode(p.MaximumWitnessCount)
+	enc.Encode(p.MaximumCommitteeCount)
+	enc.Encode(p.MaximumAuthorityMembership)
+	enc.Encode(p.ReservePercentOfFee)
+	enc.Encode(p.NetworkPercentOfFee)
+	enc.Encode(p.LifetimeReferrerPercentOfFee)
+	enc.Encode(p.CashbackVestingPeriodSeconds)
+	enc.Encode(p.CashbackVestingThreshold)
+	enc.EncodeBool(p.CountNonMemberVotes)
+	enc.EncodeBool(p.AllowNonMemberWhitelists)
+	enc.Encode(p.WitnessPayPerBlock)
+	enc.Encode(p.WorkerBudgetPerDay)
+	enc.Encode(p.MaxPredicateOpcode)
+	enc.Encode(p.FeeLiquidationThreshold)
+	enc.Encode(p.AccountsPerFeeScale)
+	enc.Encode(p.AccountFeeScaleBitshifts)
+	enc.Encode(p.MaxAuthorityDepth)
+	enc.Encode(typedExtensions{&p.Extensions, chainParametersExtensions})
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (p *chainParameters) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	dec.Decode(&p.CurrentFees)
+	dec.Decode(&p.BlockInterval)
+	dec.Decode(&p.MaintenanceInterval)
+	dec.Decode(&p.MaintenanceSkipSlots)
+	dec.Decode(&p.CommitteeProposalReviewPeriod)
+	dec.Decode(&p.MaximumTransactionSize)
+	dec.Decode(&p.MaximumBlockSize)
+	dec.Decode(&p.MaximumTimeUntilExpiration)
+	dec.Decode(&p.MaximumProposalLifetime)
+	dec.Decode(&p.MaximumAssetWhitelistAuthorities)
+	dec.Decode(&p.MaximumAssetFeedPublishers)
+	dec.Decode(&p.MaximumWitnessCount)
+	dec.Decode(&p.MaximumCommitteeCount)
+	dec.Decode(&p.MaximumAuthorityMembership)
+	dec.Decode(&p.ReservePercentOfFee)
+	dec.Decode(&p.NetworkPercentOfFee)
+	dec.Decode(&p.LifetimeReferrerPercentOfFee)
+	dec.Decode(&p.CashbackVestingPeriodSeconds)
+	dec.Decode(&p.CashbackVestingThreshold)
+	p.CountNonMemberVotes = dec.DecodeBool()
+	p.AllowNonMemberWhitelists = dec.DecodeBool()
+	dec.Decode(&p.WitnessPayPerBlock)
+	dec.Decode(&p.WorkerBudgetPerDay)
+	dec.Decode(&p.MaxPredicateOpcode)
+	dec.Decode(&p.FeeLiquidationThreshold)
+	dec.Decode(&p.AccountsPerFeeScale)
+	dec.Decode(&p.AccountFeeScaleBitshifts)
+	dec.Decode(&p.MaxAuthorityDepth)
+	dec.Decode(typedExtensions{&p.Extensions, chainParametersExtensions})
+	return dec.Err()
+}
+
+// chainParametersExtensions are the fields of the extensions of chain_parameters
+var chainParametersExtensions = []extensionField{
+	{"updatable_htlc_options", func() interface{} { return new(htlcOptions) }},
+	{"custom_authority_options", func() interface{} { return new(customAuthorityOptions) }},
+	{"market_fee_network_percent", func() interface{} { return new(uint16) }},
+	{"maker_fee_discount_percent", func() interface{} { return new(uint16) }},
+}
+
+// htlcOptions are the limits of the htlcs
+type htlcOptions struct {
+	MaxTimeoutSecs  uint32 `json:"max_timeout_secs"`
+	MaxPreimageSize uint32 `json:"max_preimage_size"`
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (o htlcOptions) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.Encode(o.MaxTimeoutSecs)
+	enc.Encode(o.MaxPreimageSize)
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (o *htlcOptions) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	dec.Decode(&o.MaxTimeoutSecs)
+	dec.Decode(&o.MaxPreimageSize)
+	return dec.Err()
+}
+
+// customAuthorityOptions are the limits of the custom authorities
+type customAuthorityOptions struct {
+	MaxCustomAuthorityLifetimeSeconds uint32 `json:"max_custom_authority_lifetime_seconds"`
+	MaxCustomAuthoritiesPerAccount    uint32 `json:"max_custom_authorities_per_account"`
+	MaxCustomAuthoritiesPerAccountOp  uint32 `json:"max_custom_authorities_per_account_op"`
+	MaxCustomAuthorityRestrictions    uint32 `json:"max_custom_authority_restrictions"`
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (o customAuthorityOptions) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.Encode(o.MaxCustomAuthorityLifetimeSeconds)
+	enc.Encode(o.MaxCustomAuthoritiesPerAccount)
+	enc.Encode(o.MaxCustomAuthoritiesPerAccountOp)
+	enc.Encode(o.MaxCustomAuthorityRestrictions)
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (o *customAuthorityOptions) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	dec.Decode(&o.MaxCustomAuthorityLifetimeSeconds)
+	dec.Decode(&o.MaxCustomAuthoritiesPerAccount)
+	dec.Decode(&o.MaxCustomAuthoritiesPerAccountOp)
+	dec.Decode(&o.MaxCustomAuthorityRestrictions)
+	return dec.Err()
+}
+
+// feeSchedule is the fee of every operation and the scale they are multiplied by
+type feeSchedule struct {
+	Parameters []feeParameters `json:"parameters"`
+	Scale      uint32          `json:"scale"`
+}
+
+// Marshal implements encoding.Marshaller interface. The parameters are a flat_set
+// written in the order of their operations.
+func (s feeSchedule) Marshal(encoder *encoding.Encoder) error {
+	params := append([]feeParameters(nil), s.Parameters...)
+	sort.SliceStable(params, func(i, j int) bool { return params[i].Tag < params[j].Tag })
+
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.EncodeFlatSet(len(params), func(i int) error {
+		return encoder.Encode(params[i])
+	})
+	enc.Encode(s.Scale)
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (s *feeSchedule) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	s.Parameters = []feeParameters{}
+	dec.DecodeFlatSet(func(int) error {
+		var params feeParameters
+		if err := decoder.Decode(&params); err != nil {
+			return err
+		}
+		s.Parameters = append(s.Parameters, params)
+		return nil
+	})
+	dec.Decode(&s.Scale)
+	return dec.Err()
+}
+
+// feeParameters are the fee parameters of an operation, tagged by its type
+type feeParameters struct {
+	staticVariant
+}
+
+func (p *feeParameters) UnmarshalJSON(b []byte) error {
+	return p.unmarshalJSON(b, feeParametersAlternatives)
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (p *feeParameters) Unmarshal(decoder *encoding.Decoder) error {
+	return p.unmarshal(decoder, feeParametersAlternatives)
+}
+
+func feeParametersAlternatives(tag uint64) (interface{}, error) {
+	if tag >= uint64(len(feeParameterFields)) {
+		return nil, errors.Errorf("unknown fee parameters of operation %d", tag)
+	}
+	return &feeParameterValues{fields: feeParameterFields[tag]}, nil
+}
+
+// feeParameterField is a field of the fee parameters of an operation, a uint64
+// unless it is narrow
+type feeParameterField struct {
+	name   string
+	narrow bool
+}
+
+// the fields of the fee parameters shared by the operations
+var (
+	noFee              = []feeParameterField{}
+	feeOnly            = []feeParameterField{{name: "fee"}}
+	feeKbyte           = []feeParameterField{{name: "fee"}, {name: "price_per_kbyte", narrow: true}}
+	accountCreateFee   = []feeParameterField{{name: "basic_fee"}, {name: "premium_fee"}, {name: "price_per_kbyte", narrow: true}}
+	accountUpgradeFee  = []feeParameterField{{name: "membership_annual_fee"}, {name: "membership_lifetime_fee"}}
+	assetCreateFee     = []feeParameterField{{name: "symbol3"}, {name: "symbol4"}, {name: "long_symbol"}, {name: "price_per_kbyte", narrow: true}}
+	blindFee           = []feeParameterField{{name: "fee"}, {name: "price_per_output", narrow: true}}
+	htlcDayFee         = []feeParameterField{{name: "fee"}, {name: "fee_per_day"}}
+	htlcRedeemFee      = []feeParameterField{{name: "fee"}, {name: "fee_per_kb"}}
+	customAuthorityFee = []feeParameterField{{name: "basic_fee"}, {name: "price_per_byte", narrow: true}}
+)
+
+// feeParameterFields are the fields of the fee parameters of every operation
+var feeParameterFields = [...][]feeParameterField{
+	TransferOpType:                              feeKbyte,
+	LimitOrderCreateOpType:                      feeOnly,
+	LimitOrderCancelOpType:                      feeOnly,
+	CallOrderUpdateOpType:                       feeOnly,
+	FillOrderOpType:                             noFee,
+	AccountCreateOpType:                         accountCreateFee,
+	AccountUpdateOpType:                         feeKbyte,
+	AccountWhitelistOpType:                      feeOnly,
+	AccountUpgradeOpType:                        accountUpgradeFee,
+	AccountTransferOpType:                       feeOnly,
+	AssetCreateOpType:                           assetCreateFee,
+	AssetUpdateOpType:                           feeKbyte,
+	AssetUpdateBitassetOpType:                   feeOnly,
+	AssetUpdateFeedProducersOpType:              feeOnly,
+	AssetIssueOpType:                            feeKbyte,
+	AssetReserveOpType:                          feeOnly,
+	AssetFundFeePoolOpType:                      feeOnly,
+	AssetSettleOpType:                           feeOnly,
+	AssetGlobalSettleOpType:                     feeOnly,
+	AssetPublishFeedOpType:                      feeOnly,
+	WitnessCreateOpType:                         feeOnly,
+	WitnessUpdateOpType:                         feeOnly,
+	ProposalCreateOpType:                        feeKbyte,
+	ProposalUpdateOpType:                        feeKbyte,
+	ProposalDeleteOpType:                        feeOnly,
+	WithdrawPermissionCreateOpType:              feeOnly,
+	WithdrawPermissionUpdateOpType:              feeOnly,
+	WithdrawPermissionClaimOpType:               feeKbyte,
+	WithdrawPermissionDeleteOpType:              feeOnly,
+	CommitteeMemberCreateOpType:                 feeOnly,
+	CommitteeMemberUpdateOpType:                 feeOnly,
+	CommitteeMemberUpdateGlobalParametersOpType: feeOnly,
+	VestingBalanceCreateOpType:                  feeOnly,
+	VestingBalanceWithdrawOpType:                feeOnly,
+	WorkerCreateOpType:                          feeOnly,
+	CustomOpType:                                feeKbyte,
+	AssertOpType:                                feeOnly,
+	BalanceClaimOpType:                          noFee,
+	OverrideTransferOpType:                      feeKbyte,
+	TransferToBlindOpType:                       blindFee,
+	BlindTransferOpType:                         blindFee,
+	TransferFromBlindOpType:                     feeOnly,
+	AssetSettleCancelOpType:                     noFee,
+	AssetClaimFeesOpType:                        feeOnly,
+	FbaDistributeOpType:                         noFee,
+	BidCollateralOpType:                         feeOnly,
+	ExecuteBidOpType:                            noFee,
+	AssetClaimPoolOpType:                        feeOnly,
+	AssetUpdateIssuerOpType:                     feeOnly,
+	HtlcCreateOpType:                            htlcDayFee,
+	HtlcRedeemOpType:                            htlcRedeemFee,
+	HtlcRedeemedOpType:                          noFee,
+	HtlcExtendOpType:                            htlcDayFee,
+	HtlcRefundOpType:                            noFee,
+	CustomAuthorityCreateOpType:                 customAuthorityFee,
+	CustomAuthorityUpdateOpType:                 customAuthorityFee,
+	CustomAuthorityDeleteOpType:                 feeOnly,
+	TicketCreateOpType:                          feeOnly,
+	TicketUpdateOpType:                          feeOnly,
+	LiquidityPoolCreateOpType:                   feeOnly,
+	LiquidityPoolDeleteOpType:                   feeOnly,
+	LiquidityPoolDepositOpType:                  feeOnly,
+	LiquidityPoolWithdrawOpType:                 feeOnly,
+	LiquidityPoolExchangeOpType:                 feeOnly,
+	SametFundCreateOpType:                       feeOnly,
+	SametFundDeleteOpType:                       feeOnly,
+	SametFundUpdateOpType:                       feeOnly,
+	SametFundBorrowOpType:                       feeOnly,
+	SametFundRepayOpType:                        feeOnly,
+	CreditOfferCreateOpType:                     feeKbyte,
+	CreditOfferDeleteOpType:                     feeOnly,
+	CreditOfferUpdateOpType:                     feeKbyte,
+	CreditOfferAcceptOpType:                     feeOnly,
+	CreditDealRepayOpType:                       feeOnly,
+	CreditDealExpiredOpType:                     noFee,
+	LiquidityPoolUpdateOpType:                   feeOnly,
+	CreditDealUpdateOpType:                      feeOnly,
+	LimitOrderUpdateOpType:                      feeOnly,
+}
+
+// feeParameterValues are the values of the fee parameters of an operation, in
+// the order of its fields
+type feeParameterValues struct {
+	fields []feeParameterField
+	values []uint64
+}
+
+func (v feeParameterValues) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, field := range v.fields {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%q:%d", field.name, v.values[i])
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+func (v *feeParameterValues) UnmarshalJSON(b []byte) error {
+	var values map[string]Suint64
+	if err := json.Unmarshal(b, &values); err != nil {
+		return err
+	}
+	v.values = make([]uint64, len(v.fields))
+	for i, field := range v.fields {
+		value := uint64(values[field.name])
+		if field.narrow && value > 0xffffffff {
+			return errors.Errorf("invalid fee parameter %s %d", field.name, value)
+		}
+		v.values[i] = value
+	}
+	return nil
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (v feeParameterValues) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	for i, field := range v.fields {
+		if field.narrow {
+			enc.EncodeLittleEndianUInt32(uint32(v.values[i]))
+		} else {
+			enc.EncodeLittleEndianUInt64(v.values[i])
+		}
+	}
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (v *feeParameterValues) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	v.values = make([]uint64, len(v.fields))
+	for i, field := range v.fields {
+		if field.narrow {
+			v.values[i] = uint64(dec.DecodeLittleEndianUInt32())
+		} else {
+			v.values[i] = dec.DecodeLittleEndianUInt64()
+		}
+	}
+	return dec.Err()
+}
@@ -0,0 +1,285 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/pkg/errors"
+)
+
+// voidExtension is the json of the only alternative of future_extensions, a void_t
+var voidExtension = json.RawMessage(`[0,{}]`)
+
+// futureExtensions are the extensions_type of the operations and the transactions,
+// a flat_set of static_variant<void_t>
+type futureExtensions []json.RawMessage
+
+// Marshal implements encoding.Marshaller interface.
+func (exts futureExtensions) Marshal(encoder *encoding.Encoder) error {
+	return encoder.EncodeFlatSet(len(exts), func(i int) error {
+		var variant []json.RawMessage
+		var tag uint64
+		if err := json.Unmarshal(exts[i], &variant); err != nil || len(variant) != 2 || json.Unmarshal(variant[0], &tag) != nil {
+			return errors.Errorf("invalid extension %s", exts[i])
+		}
+		if tag != 0 {
+			return errors.Errorf("unknown extension %d", tag)
+		}
+		return encoder.EncodeStaticVariant(tag, nil)
+	})
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (exts *futureExtensions) Unmarshal(decoder *encoding.Decoder) error {
+	*exts = futureExtensions{}
+	return decoder.DecodeFlatSet(func(int) error {
+		return decoder.DecodeStaticVariant(func(tag uint64) error {
+			if tag != 0 {
+				return errors.Errorf("unknown extension %d", tag)
+			}
+			*exts = append(*exts, voidExtension)
+			return nil
+		})
+	})
+}
+
+// parseFutureExtensions parses the json of an extensions_type kept raw, empty
+// when there is none
+func parseFutureExtensions(raw json.RawMessage) (futureExtensions, error) {
+	exts := futureExtensions{}
+	if b := bytes.TrimSpace(raw); len(b) > 0 && string(b) != "null" {
+		if err := json.Unmarshal(b, (*[]json.RawMessage)(&exts)); err != nil {
+			return nil, errors.Errorf("invalid extensions %s", raw)
+		}
+	}
+	return exts, nil
+}
+
+// futureExtensionsJSON are the json of an extensions_type kept raw
+type futureExtensionsJSON json.RawMessage
+
+// Marshal implements encoding.Marshaller interface.
+func (exts futureExtensionsJSON) Marshal(encoder *encoding.Encoder) error {
+	parsed, err := parseFutureExtensions(json.RawMessage(exts))
+	if err != nil {
+		return err
+	}
+	return encoder.Encode(parsed)
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (exts *futureExtensionsJSON) Unmarshal(decoder *encoding.Decoder) error {
+	var parsed futureExtensions
+	if err := decoder.Decode(&parsed); err != nil {
+		return err
+	}
+	raw, err := json.Marshal([]json.RawMessage(parsed))
+	*exts = raw
+	return err
+}
+
+// extensionField is a field of an extension<T>: its json name and a new value
+// it is parsed into, an encoding.Unmarshaller or a pointer to a number
+type extensionField struct {
+	name  string
+	value func() interface{}
+}
+
+// typedExtensions are the json of an extension<T> kept raw and the fields of T
+// in their order. The fields of the json are encoded by their index in T.
+type typedExtensions struct {
+	raw    *json.RawMessage
+	fields []extensionField
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (exts typedExtensions) Marshal(encoder *encoding.Encoder) error {
+	var values map[string]json.RawMessage
+	raw := bytes.TrimSpace(*exts.raw)
+	if len(raw) > 0 && string(raw) != "null" && string(raw) != "[]" {
+		if err := json.Unmarshal(raw, &values); err != nil {
+			return errors.Errorf("invalid extensions %s", raw)
+		}
+	}
+
+	present := make([]encoding.Extension, 0, len(values))
+	for i, field := range exts.fields {
+		value, ok := values[field.name]
+		if !ok {
+			continue
+		}
+		delete(values, field.name)
+		if string(value) == "null" {
+			continue
+		}
+		v := field.value()
+		if err := json.Unmarshal(value, v); err != nil {
+			return errors.Wrapf(err, "extension %s", field.name)
+		}
+		present = append(present, encoding.Extension{Index: uint64(i), Value: v})
+	}
+	for name := range values {
+		return errors.Errorf("extension %s is not supported", name)
+	}
+	return encoder.EncodeExtensions(present...)
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (exts typedExtensions) Unmarshal(decoder *encoding.Decoder) error {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	err := decoder.DecodeExtensions(func(index uint64) error {
+		if index >= uint64(len(exts.fields)) {
+			return errors.Errorf("extension %d is not supported", index)
+		}
+		field := exts.fields[index]
+		v := field.value()
+		if err := decoder.Decode(v); err != nil {
+			return errors.Wrapf(err, "extension %s", field.name)
+		}
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if b.Len() > 1 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%q:%s", field.name, value)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	b.WriteByte('}')
+	*exts.raw = b.Bytes()
+	return nil
+}
+
+// voidT is a void_t, the value of an extension that is only present or not
+type voidT struct{}
+
+// Marshal implements encoding.Marshaller interface.
+func (voidT) Marshal(*encoding.Encoder) error { return nil }
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (*voidT) Unmarshal(*encoding.Decoder) error { return nil }
+
+// Version is a version of the chain, major.hardfork.revision
+type Version uint32
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v>>24, (v>>16)&0xff, v&0xffff)
+}
+
+func (v Version) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.String())
+}
+
+func (v *Version) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	var major, hardfork, revision uint32
+	if n, err := fmt.Sscanf(strings.Replace(s, ".", " ", -1), "%d %d %d", &major, &hardfork, &revision); err != nil || n != 3 || major > 0xff || hardfork > 0xff || revision > 0xffff {
+		return errors.Errorf("invalid version %q", s)
+	}
+	*v = Version(major<<24 | hardfork<<16 | revision)
+	return nil
+}
+
+func (v Version) Marshal(encoder *encoding.Encoder) error {
+	return encoder.EncodeLittleEndianUInt32(uint32(v))
+}
+
+func (v *Version) Unmarshal(decoder *encoding.Decoder) error {
+	raw, err := decoder.DecodeLittleEndianUInt32()
+	*v = Version(raw)
+	return err
+}
+
+// HardforkVersionVote is the hardfork a witness votes for in its blocks
+type HardforkVersionVote struct {
+	HfVersion Version `json:"hf_version"`
+	HfTime    Time    `json:"hf_time"`
+}
+
+func (vote HardforkVersionVote) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.Encode(vote.HfVersion)
+	enc.Encode(vote.HfTime)
+	return enc.Err()
+}
+
+func (vote *HardforkVersionVote) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	dec.Decode(&vote.HfVersion)
+	dec.Decode(&vote.HfTime)
+	return dec.Err()
+}
+
+// blockHeaderExtensions are the extensions of a block header, a flat_set of
+// static_variant<void_t, version, hardfork_version_vote>
+type blockHeaderExtensions []json.RawMessage
+
+// Marshal implements encoding.Marshaller interface.
+func (exts blockHeaderExtensions) Marshal(encoder *encoding.Encoder) error {
+	return encoder.EncodeFlatSet(len(exts), func(i int) error {
+		var variant []json.RawMessage
+		var tag uint64
+		if err := json.Unmarshal(exts[i], &variant); err != nil || len(variant) != 2 || json.Unmarshal(variant[0], &tag) != nil {
+			return errors.Errorf("invalid block header extension %s", exts[i])
+		}
+
+		var value interface{}
+		switch tag {
+		case 0:
+		case 1:
+			value = new(Version)
+		case 2:
+			value = new(HardforkVersionVote)
+		default:
+			return errors.Errorf("unknown block header extension %d", tag)
+		}
+		if value != nil {
+			if err := json.Unmarshal(variant[1], value); err != nil {
+				return errors.Wrapf(err, "block header extension %d", tag)
+			}
+		}
+		return encoder.EncodeStaticVariant(tag, value)
+	})
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (exts *blockHeaderExtensions) Unmarshal(decoder *encoding.Decoder) error {
+	*exts = blockHeaderExtensions{}
+	return decoder.DecodeFlatSet(func(int) error {
+		return decoder.DecodeStaticVariant(func(tag uint64) error {
+			var value interface{}
+			switch tag {
+			case 0:
+				value = struct{}{}
+			case 1:
+				value = new(Version)
+			case 2:
+				value = new(HardforkVersionVote)
+			default:
+				return errors.Errorf("unknown block header extension %d", tag)
+			}
+			if unmarshaller, ok := value.(encoding.Unmarshaller); ok {
+				if err := decoder.Decode(unmarshaller); err != nil {
+					return errors.Wrapf(err, "block header extension %d", tag)
+				}
+			}
+			raw, err := json.Marshal([]interface{}{tag, value})
+			if err != nil {
+				return err
+			}
+			*exts = append(*exts, raw)
+			return nil
+		})
+	})
+}
@@ -0,0 +1,122 @@
+package types
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallOrderUpdateOperation_Marshal(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		hex  string
+	}{
+		{
+			name: "target collateral ratio",
+			json: `{"fee":{"amount":48260,"asset_id":"1.3.0"},"funding_account":"1.2.1601","delta_collateral":{"amount":"-5000000000","asset_id":"1.3.0"},"delta_debt":{"amount":-100000,"asset_id":"1.3.113"},"extensions":{"target_collateral_ratio":1750}}`,
+			hex:  "0384bc00000000000000c10c000efad5feffffff006079feffffffffff710100d606",
+		},
+		{
+			name: "no extension",
+			json: `{"fee":{"amount":48260,"asset_id":"1.3.0"},"funding_account":"1.2.1601","delta_collateral":{"amount":"-5000000000","asset_id":"1.3.0"},"delta_debt":{"amount":-100000,"asset_id":"1.3.113"},"extensions":{}}`,
+			hex:  "0384bc00000000000000c10c000efad5feffffff006079feffffffffff7100",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var op CallOrderUpdateOperation
+			require.NoError(t, json.Unmarshal([]byte(test.json), &op))
+
+			var b bytes.Buffer
+			require.NoError(t, encoding.NewEncoder(&b).Encode(&op))
+			require.Equal(t, test.hex, hex.EncodeToString(b.Bytes()))
+
+			var decoded CallOrderUpdateOperation
+			require.NoError(t, encoding.NewDecoder(&b).Decode(&decoded))
+			require.Equal(t, op, decoded)
+		})
+	}
+}
+
+func TestTransaction_MarshalExtensions(t *testing.T) {
+	data := `{"ref_block_num":36752,"ref_block_prefix":3466271925,"expiration":"2019-07-17T04:10:10","operations":[` + transactionIDTests[1].operation + `],"extensions":[[0,{}]],"signatures":[]}`
+	tx := Transaction{}
+	require.NoError(t, json.Unmarshal([]byte(data), &tx))
+
+	// one void_t extension instead of none
+	var b bytes.Buffer
+	require.NoError(t, encoding.NewEncoder(&b).Encode(&tx))
+	want := transactionIDTests[1].hex[:len(transactionIDTests[1].hex)-2] + "0100"
+	require.Equal(t, want, hex.EncodeToString(b.Bytes()))
+
+	var decoded Transaction
+	require.NoError(t, encoding.NewDecoder(&b).Decode(&decoded))
+	require.Equal(t, []json.RawMessage{voidExtension}, decoded.Extensions)
+
+	tx.Extensions = []json.RawMessage{json.RawMessage(`[1,{}]`)}
+	require.Error(t, encoding.NewEncoder(&b).Encode(&tx))
+}
+
+func TestLimitOrderCreateOperation_MarshalExtensions(t *testing.T) {
+	op := LimitOrderCreateOperation{Expiration: NewTime(time.Unix(1563336610, 0))}
+	for _, exts := range []string{``, `null`, `[]`, `{}`} {
+		op.Extensions = json.RawMessage(exts)
+		var b bytes.Buffer
+		require.NoError(t, encoding.NewEncoder(&b).Encode(&op), "extensions %q", exts)
+		require.Equal(t, byte(0), b.Bytes()[b.Len()-1])
+	}
+
+	// an on_fill of no action is the field 0 of the extensions, an empty vector
+	op.Extensions = json.RawMessage(`{"on_fill":[]}`)
+	var b bytes.Buffer
+	require.NoError(t, encoding.NewEncoder(&b).Encode(&op))
+	require.Equal(t, "010000", hex.EncodeToString(b.Bytes()[b.Len()-3:]))
+
+	op.Extensions = json.RawMessage(`{"on_fill":[[0,{"fee_asset_id":"1.3.0","spread_percent":100,"size_percent":10000,"expiration_seconds":86400,"repeat":true,"extensions":[]}]]}`)
+	b.Reset()
+	require.NoError(t, encoding.NewEncoder(&b).Encode(&op))
+	var decoded LimitOrderCreateOperation
+	require.NoError(t, encoding.NewDecoder(&b).Decode(&decoded))
+	require.JSONEq(t, string(op.Extensions), string(decoded.Extensions))
+
+	op.Extensions = json.RawMessage(`{"on_fill":[],"on_cancel":[]}`)
+	require.Error(t, encoding.NewEncoder(&bytes.Buffer{}).Encode(&op))
+}
+
+func TestBlockHeader_MarshalExtensions(t *testing.T) {
+	var version Version
+	require.NoError(t, json.Unmarshal([]byte(`"2.0.7"`), &version))
+	require.Equal(t, "2.0.7", version.String())
+	require.Error(t, json.Unmarshal([]byte(`"2.0.190219"`), &version))
+
+	exts := blockHeaderExtensions{
+		json.RawMessage(`[1,"2.0.7"]`),
+		json.RawMessage(`[2,{"hf_version":"2.0.0","hf_time":"2019-07-17T04:10:10"}]`),
+	}
+	var b bytes.Buffer
+	require.NoError(t, encoding.NewEncoder(&b).Encode(exts))
+	// 2 extensions, version 0x02000007, vote of version 0x02000000 at 1563336610
+	require.Equal(t, "02"+"0107000002"+"0200000002a29f2e5d", hex.EncodeToString(b.Bytes()))
+
+	require.Error(t, encoding.NewEncoder(&b).Encode(blockHeaderExtensions{json.RawMessage(`[3,{}]`)}))
+}
+
+func TestPublicKey_Marshal(t *testing.T) {
+	key := PublicKey("BTS7oJ5icgrbMRdzGSKau1NKQqxmYsMRa6rnHsZdxArjCVPWnvi3D")
+
+	var b bytes.Buffer
+	require.NoError(t, encoding.NewEncoder(&b).Encode(key))
+	require.Equal(t, 33, b.Len())
+
+	var decoded PublicKey
+	require.NoError(t, encoding.NewDecoder(&b).Decode(&decoded))
+	require.Equal(t, key, decoded)
+
+	require.Error(t, encoding.NewEncoder(&b).Encode(PublicKey("BTS1111")))
+}
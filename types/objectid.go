@@ -46,6 +46,79 @@ func (o ObjectID) Marshal(encoder *encoding.Encoder) error {
 	return nil
 }
 
+// Unmarshal implements encoding.Unmarshaller interface. Only the instance is on
+// the wire, the space and the type of the id are kept.
+func (o *ObjectID) Unmarshal(decoder *encoding.Decoder) error {
+	id, err := decoder.DecodeUVarint()
+	if err != nil {
+		return err
+	}
+	o.ID = id
+	return nil
+}
+
+// object types of the protocol space the operations refer to
+const (
+	accountObjectType            = 2
+	assetObjectType              = 3
+	forceSettlementObjectType    = 4
+	committeeMemberObjectType    = 5
+	witnessObjectType            = 6
+	limitOrderObjectType         = 7
+	callOrderObjectType          = 8
+	customObjectType             = 9
+	proposalObjectType           = 10
+	withdrawPermissionObjectType = 12
+	vestingBalanceObjectType     = 13
+	workerObjectType             = 14
+	balanceObjectType            = 15
+	htlcObjectType               = 16
+	customAuthorityObjectType    = 17
+	ticketObjectType             = 18
+	liquidityPoolObjectType      = 19
+	sametFundObjectType          = 20
+	creditOfferObjectType        = 21
+	creditDealObjectType         = 22
+)
+
+// object types of the implementation space the operations refer to
+const (
+	fbaAccumulatorObjectType = 16
+)
+
+// protocolID returns an id of the protocol space to unmarshal an instance into
+func protocolID(objectType uint64) ObjectID {
+	return ObjectID{Space: 1, Type: objectType}
+}
+
+// implementationID returns an id of the implementation space to unmarshal an instance into
+func implementationID(objectType uint64) ObjectID {
+	return ObjectID{Space: 2, Type: objectType}
+}
+
+// anyObjectID is an object_id_type, an id of any space and type. It is written
+// whole in 64 bits: the space in the highest 8, the type in the next 8 and the
+// instance in the others.
+type anyObjectID ObjectID
+
+// Marshal implements encoding.Marshaller interface.
+func (o anyObjectID) Marshal(encoder *encoding.Encoder) error {
+	if o.Space > 0xff || o.Type > 0xff || o.ID >= 1<<48 {
+		return errors.Errorf("invalid object id %s", ObjectID(o))
+	}
+	return encoder.EncodeLittleEndianUInt64(o.Space<<56 | o.Type<<48 | o.ID)
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (o *anyObjectID) Unmarshal(decoder *encoding.Decoder) error {
+	number, err := decoder.DecodeLittleEndianUInt64()
+	if err != nil {
+		return err
+	}
+	*o = anyObjectID{Space: number >> 56, Type: number >> 48 & 0xff, ID: number & (1<<48 - 1)}
+	return nil
+}
+
 func MustParseObjectID(str string) ObjectID {
 	out, err := ParseObjectID(str)
 	if err != nil {
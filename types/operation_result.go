@@ -0,0 +1,167 @@
+package types
+
+import (
+	"encoding/json"
+
+	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/pkg/errors"
+)
+
+// operation_result tags
+const (
+	VoidResult = iota
+	ObjectIDResult
+	AssetResult
+	GenericOperationResult
+	GenericExchangeOperationResult
+	ExtendableOperationResult
+)
+
+// OperationResult is the result of an applied operation, kept by the block
+// in the [tag, value] form of a static_variant
+type OperationResult struct {
+	Tag   uint64
+	Value json.RawMessage
+}
+
+func (r *OperationResult) UnmarshalJSON(b []byte) error {
+	var kv []json.RawMessage
+	if err := json.Unmarshal(b, &kv); err != nil {
+		return err
+	}
+	if len(kv) != 2 {
+		return errors.New("invalid operation result format: should be tag, value")
+	}
+	if err := json.Unmarshal(kv[0], &r.Tag); err != nil {
+		return err
+	}
+	r.Value = kv[1]
+	return nil
+}
+
+func (r OperationResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{r.Tag, r.Value})
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (r OperationResult) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.EncodeUVarint(r.Tag)
+
+	switch r.Tag {
+	case VoidResult:
+	case ObjectIDResult:
+		var id ObjectID
+		if err := json.Unmarshal(r.Value, &id); err != nil {
+			return err
+		}
+		enc.EncodeLittleEndianUInt64(fullInstance(id))
+	case AssetResult:
+		var amount AssetAmount
+		if err := json.Unmarshal(r.Value, &amount); err != nil {
+			return err
+		}
+		enc.Encode(amount)
+	case GenericOperationResult:
+		var result struct {
+			NewObjects     []ObjectID `json:"new_objects"`
+			UpdatedObjects []ObjectID `json:"updated_objects"`
+			RemovedObjects []ObjectID `json:"removed_objects"`
+		}
+		if err := json.Unmarshal(r.Value, &result); err != nil {
+			return err
+		}
+		encodeObjectIDSet(enc, result.NewObjects)
+		encodeObjectIDSet(enc, result.UpdatedObjects)
+		encodeObjectIDSet(enc, result.RemovedObjects)
+	case GenericExchangeOperationResult:
+		var result struct {
+			Paid     []AssetAmount `json:"paid"`
+			Received []AssetAmount `json:"received"`
+			Fees     []AssetAmount `json:"fees"`
+		}
+		if err := json.Unmarshal(r.Value, &result); err != nil {
+			return err
+		}
+		encodeAssetAmounts(enc, result.Paid)
+		encodeAssetAmounts(enc, result.Received)
+		encodeAssetAmounts(enc, result.Fees)
+	case ExtendableOperationResult:
+		return r.marshalExtendable(enc)
+	default:
+		return errors.Errorf("unknown operation result: %d", r.Tag)
+	}
+	return enc.Err()
+}
+
+// marshalExtendable encodes the fields present in an extendable_operation_result,
+// each preceded by its index
+func (r OperationResult) marshalExtendable(enc *encoding.RollingEncoder) error {
+	var result map[string]json.RawMessage
+	if err := json.Unmarshal(r.Value, &result); err != nil {
+		return err
+	}
+
+	fields := []string{"impacted_accounts", "new_objects", "updated_objects", "removed_objects", "paid", "received", "fees"}
+	for key := range result {
+		known := false
+		for _, field := range fields {
+			known = known || key == field
+		}
+		if !known {
+			return errors.Errorf("unknown operation result field: %s", key)
+		}
+	}
+
+	enc.EncodeUVarint(uint64(len(result)))
+	for i, field := range fields {
+		raw, ok := result[field]
+		if !ok {
+			continue
+		}
+		enc.EncodeUVarint(uint64(i))
+		switch field {
+		case "impacted_accounts":
+			var accounts []ObjectID
+			if err := json.Unmarshal(raw, &accounts); err != nil {
+				return err
+			}
+			enc.EncodeUVarint(uint64(len(accounts)))
+			for _, account := range accounts {
+				enc.Encode(account)
+			}
+		case "new_objects", "updated_objects", "removed_objects":
+			var ids []ObjectID
+			if err := json.Unmarshal(raw, &ids); err != nil {
+				return err
+			}
+			encodeObjectIDSet(enc, ids)
+		default:
+			var amounts []AssetAmount
+			if err := json.Unmarshal(raw, &amounts); err != nil {
+				return err
+			}
+			encodeAssetAmounts(enc, amounts)
+		}
+	}
+	return enc.Err()
+}
+
+// fullInstance returns the id as the single number an object_id_type is packed in
+func fullInstance(id ObjectID) uint64 {
+	return uint64(id.Space)<<56 | uint64(id.Type)<<48 | id.ID
+}
+
+func encodeObjectIDSet(enc *encoding.RollingEncoder, ids []ObjectID) {
+	enc.EncodeUVarint(uint64(len(ids)))
+	for _, id := range ids {
+		enc.EncodeLittleEndianUInt64(fullInstance(id))
+	}
+}
+
+func encodeAssetAmounts(enc *encoding.RollingEncoder, amounts []AssetAmount) {
+	enc.EncodeUVarint(uint64(len(amounts)))
+	for _, amount := range amounts {
+		enc.Encode(amount)
+	}
+}
@@ -0,0 +1,70 @@
+package types
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperationResult_Marshal(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		hex  string
+	}{
+		{"void", `[0,{}]`, "00"},
+		{"object id", `[1,"1.7.29923493"]`, "01a598c80100000701"},
+		{"asset", `[2,{"amount":121,"asset_id":"1.3.0"}]`, "02790000000000000000"},
+		{"generic", `[3,{"new_objects":["1.7.1"],"updated_objects":[],"removed_objects":[]}]`, "030101000000000007010000"},
+		{"generic exchange", `[4,{"paid":[{"amount":1,"asset_id":"1.3.2"}],"received":[],"fees":[]}]`, "0401010000000000000002" + "0000"},
+		{"extendable", `[5,{"fees":[{"amount":1,"asset_id":"1.3.0"}],"impacted_accounts":["1.2.5"]}]`, "05" + "02" + "000105" + "0601010000000000000000"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var result OperationResult
+			require.NoError(t, json.Unmarshal([]byte(test.data), &result))
+
+			var b bytes.Buffer
+			require.NoError(t, encoding.NewEncoder(&b).Encode(result))
+			require.Equal(t, test.hex, hex.EncodeToString(b.Bytes()))
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		var result OperationResult
+		require.NoError(t, json.Unmarshal([]byte(`[9,{}]`), &result))
+		require.Error(t, encoding.NewEncoder(&bytes.Buffer{}).Encode(result))
+	})
+}
+
+func TestMerkleRoot(t *testing.T) {
+	root, err := MerkleRoot(nil)
+	require.NoError(t, err)
+	require.Equal(t, "0000000000000000000000000000000000000000", root)
+
+	txs := make([]*Transaction, 3)
+	for i, test := range transactionIDTests {
+		data := `{"ref_block_num":36752,"ref_block_prefix":3466271925,"expiration":"2019-07-17T04:10:10","operations":[` + test.operation + `],"extensions":[],"signatures":[],"operation_results":[[0,{}]]}`
+		txs[i] = &Transaction{}
+		require.NoError(t, json.Unmarshal([]byte(data), txs[i]))
+	}
+
+	// the roots of two and three transactions differ, the third digest is carried up
+	two, err := MerkleRoot(txs[:2])
+	require.NoError(t, err)
+	three, err := MerkleRoot(txs)
+	require.NoError(t, err)
+	require.Len(t, two, 40)
+	require.NotEqual(t, two, three)
+
+	// a change in the operation results changes the root
+	txs[2].OperationResults[0] = OperationResult{Tag: ObjectIDResult, Value: json.RawMessage(`"1.7.1"`)}
+	changed, err := MerkleRoot(txs)
+	require.NoError(t, err)
+	require.NotEqual(t, three, changed)
+}
@@ -92,9 +92,119 @@ func unmarshalOperation(opType OpType, obj json.RawMessage) (Operation, error) {
 }
 
 var knownOperations = map[OpType]reflect.Type{
-	TransferOpType:         reflect.TypeOf(TransferOperation{}),
-	LimitOrderCreateOpType: reflect.TypeOf(LimitOrderCreateOperation{}),
-	LimitOrderCancelOpType: reflect.TypeOf(LimitOrderCancelOperation{}),
+	TransferOpType:                              reflect.TypeOf(TransferOperation{}),
+	LimitOrderCreateOpType:                      reflect.TypeOf(LimitOrderCreateOperation{}),
+	LimitOrderCancelOpType:                      reflect.TypeOf(LimitOrderCancelOperation{}),
+	CallOrderUpdateOpType:                       reflect.TypeOf(CallOrderUpdateOperation{}),
+	FillOrderOpType:                             reflect.TypeOf(FillOrderOperation{}),
+	AccountCreateOpType:                         reflect.TypeOf(AccountCreateOperation{}),
+	AccountUpdateOpType:                         reflect.TypeOf(AccountUpdateOperation{}),
+	AccountWhitelistOpType:                      reflect.TypeOf(AccountWhitelistOperation{}),
+	AccountUpgradeOpType:                        reflect.TypeOf(AccountUpgradeOperation{}),
+	AccountTransferOpType:                       reflect.TypeOf(AccountTransferOperation{}),
+	AssetCreateOpType:                           reflect.TypeOf(AssetCreateOperation{}),
+	AssetUpdateOpType:                           reflect.TypeOf(AssetUpdateOperation{}),
+	AssetUpdateBitassetOpType:                   reflect.TypeOf(AssetUpdateBitassetOperation{}),
+	AssetUpdateFeedProducersOpType:              reflect.TypeOf(AssetUpdateFeedProducersOperation{}),
+	AssetIssueOpType:                            reflect.TypeOf(AssetIssueOperation{}),
+	AssetReserveOpType:                          reflect.TypeOf(AssetReserveOperation{}),
+	AssetFundFeePoolOpType:                      reflect.TypeOf(AssetFundFeePoolOperation{}),
+	AssetSettleOpType:                           reflect.TypeOf(AssetSettleOperation{}),
+	AssetGlobalSettleOpType:                     reflect.TypeOf(AssetGlobalSettleOperation{}),
+	AssetPublishFeedOpType:                      reflect.TypeOf(AssetPublishFeedOperation{}),
+	WitnessCreateOpType:                         reflect.TypeOf(WitnessCreateOperation{}),
+	WitnessUpdateOpType:                         reflect.TypeOf(WitnessUpdateOperation{}),
+	ProposalCreateOpType:                        reflect.TypeOf(ProposalCreateOperation{}),
+	ProposalUpdateOpType:                        reflect.TypeOf(ProposalUpdateOperation{}),
+	ProposalDeleteOpType:                        reflect.TypeOf(ProposalDeleteOperation{}),
+	WithdrawPermissionCreateOpType:              reflect.TypeOf(WithdrawPermissionCreateOperation{}),
+	WithdrawPermissionUpdateOpType:              reflect.TypeOf(WithdrawPermissionUpdateOperation{}),
+	WithdrawPermissionClaimOpType:               reflect.TypeOf(WithdrawPermissionClaimOperation{}),
+	WithdrawPermissionDeleteOpType:              reflect.TypeOf(WithdrawPermissionDeleteOperation{}),
+	CommitteeMemberCreateOpType:                 reflect.TypeOf(CommitteeMemberCreateOperation{}),
+	CommitteeMemberUpdateOpType:                 reflect.TypeOf(CommitteeMemberUpdateOperation{}),
+	CommitteeMemberUpdateGlobalParametersOpType: reflect.TypeOf(CommitteeMemberUpdateGlobalParametersOperation{}),
+	VestingBalanceCreateOpType:                  reflect.TypeOf(VestingBalanceCreateOperation{}),
+	VestingBalanceWithdrawOpType:                reflect.TypeOf(VestingBalanceWithdrawOperation{}),
+	WorkerCreateOpType:                          reflect.TypeOf(WorkerCreateOperation{}),
+	CustomOpType:                                reflect.TypeOf(CustomOperation{}),
+	AssertOpType:                                reflect.TypeOf(AssertOperation{}),
+	BalanceClaimOpType:                          reflect.TypeOf(BalanceClaimOperation{}),
+	OverrideTransferOpType:                      reflect.TypeOf(OverrideTransferOperation{}),
+	TransferToBlindOpType:                       reflect.TypeOf(TransferToBlindOperation{}),
+	BlindTransferOpType:                         reflect.TypeOf(BlindTransferOperation{}),
+	TransferFromBlindOpType:                     reflect.TypeOf(TransferFromBlindOperation{}),
+	AssetSettleCancelOpType:                     reflect.TypeOf(AssetSettleCancelOperation{}),
+	AssetClaimFeesOpType:                        reflect.TypeOf(AssetClaimFeesOperation{}),
+	FbaDistributeOpType:                         reflect.TypeOf(FbaDistributeOperation{}),
+	BidCollateralOpType:                         reflect.TypeOf(BidCollateralOperation{}),
+	ExecuteBidOpType:                            reflect.TypeOf(ExecuteBidOperation{}),
+	AssetClaimPoolOpType:                        reflect.TypeOf(AssetClaimPoolOperation{}),
+	AssetUpdateIssuerOpType:                     reflect.TypeOf(AssetUpdateIssuerOperation{}),
+	HtlcCreateOpType:                            reflect.TypeOf(HtlcCreateOperation{}),
+	HtlcRedeemOpType:                            reflect.TypeOf(HtlcRedeemOperation{}),
+	HtlcRedeemedOpType:                          reflect.TypeOf(HtlcRedeemedOperation{}),
+	HtlcExtendOpType:                            reflect.TypeOf(HtlcExtendOperation{}),
+	HtlcRefundOpType:                            reflect.TypeOf(HtlcRefundOperation{}),
+	CustomAuthorityCreateOpType:                 reflect.TypeOf(CustomAuthorityCreateOperation{}),
+	CustomAuthorityUpdateOpType:                 reflect.TypeOf(CustomAuthorityUpdateOperation{}),
+	CustomAuthorityDeleteOpType:                 reflect.TypeOf(CustomAuthorityDeleteOperation{}),
+	TicketCreateOpType:                          reflect.TypeOf(TicketCreateOperation{}),
+	TicketUpdateOpType:                          reflect.TypeOf(TicketUpdateOperation{}),
+	LiquidityPoolCreateOpType:                   reflect.TypeOf(LiquidityPoolCreateOperation{}),
+	LiquidityPoolDeleteOpType:                   reflect.TypeOf(LiquidityPoolDeleteOperation{}),
+	LiquidityPoolDepositOpType:                  reflect.TypeOf(LiquidityPoolDepositOperation{}),
+	LiquidityPoolWithdrawOpType:                 reflect.TypeOf(LiquidityPoolWithdrawOperation{}),
+	LiquidityPoolExchangeOpType:                 reflect.TypeOf(LiquidityPoolExchangeOperation{}),
+	SametFundCreateOpType:                       reflect.TypeOf(SametFundCreateOperation{}),
+	SametFundDeleteOpType:                       reflect.TypeOf(SametFundDeleteOperation{}),
+	SametFundUpdateOpType:                       reflect.TypeOf(SametFundUpdateOperation{}),
+	SametFundBorrowOpType:                       reflect.TypeOf(SametFundBorrowOperation{}),
+	SametFundRepayOpType:                        reflect.TypeOf(SametFundRepayOperation{}),
+	CreditOfferCreateOpType:                     reflect.TypeOf(CreditOfferCreateOperation{}),
+	CreditOfferDeleteOpType:                     reflect.TypeOf(CreditOfferDeleteOperation{}),
+	CreditOfferUpdateOpType:                     reflect.TypeOf(CreditOfferUpdateOperation{}),
+	CreditOfferAcceptOpType:                     reflect.TypeOf(CreditOfferAcceptOperation{}),
+	CreditDealRepayOpType:                       reflect.TypeOf(CreditDealRepayOperation{}),
+	CreditDealExpiredOpType:                     reflect.TypeOf(CreditDealExpiredOperation{}),
+	LiquidityPoolUpdateOpType:                   reflect.TypeOf(LiquidityPoolUpdateOperation{}),
+	CreditDealUpdateOpType:                      reflect.TypeOf(CreditDealUpdateOperation{}),
+	LimitOrderUpdateOpType:                      reflect.TypeOf(LimitOrderUpdateOperation{}),
+}
+
+// decodeOperation reads an operation of a known type with a binary form
+func decodeOperation(decoder *encoding.Decoder) (Operation, error) {
+	opType, err := decoder.PeekUVarint()
+	if err != nil {
+		return nil, err
+	}
+	typ, ok := knownOperations[OpType(opType)]
+	if !ok {
+		return nil, errors.Errorf("unknown operation %d", opType)
+	}
+	op := reflect.New(typ).Interface()
+	unmarshaller, ok := op.(encoding.Unmarshaller)
+	if !ok {
+		return nil, errors.Errorf("operation %d can not be decoded", opType)
+	}
+	if err := unmarshaller.Unmarshal(decoder); err != nil {
+		return nil, errors.Wrapf(err, "operation %d", opType)
+	}
+	return op.(Operation), nil
+}
+
+// opTypeTag unmarshals the type an operation marshals first, failing on another type
+type opTypeTag OpType
+
+func (tag opTypeTag) Unmarshal(decoder *encoding.Decoder) error {
+	opType, err := decoder.DecodeUVarint()
+	if err != nil {
+		return err
+	}
+	if OpType(opType) != OpType(tag) {
+		return errors.Errorf("operation %d is not %d", opType, tag)
+	}
+	return nil
 }
 
 // UnknownOperation
@@ -105,6 +215,16 @@ type UnknownOperation struct {
 
 func (op *UnknownOperation) Type() OpType { return op.kind }
 
+// ErrNotSerializable is the cause of the error serializing an operation whose
+// binary form is unknown, so the digests of its transaction cannot be computed.
+var ErrNotSerializable = errors.New("operation not serializable")
+
+// Marshal implements encoding.Marshaller interface. The fields of an unknown
+// operation are unknown, so it fails with ErrNotSerializable.
+func (op *UnknownOperation) Marshal(encoder *encoding.Encoder) error {
+	return errors.Wrapf(ErrNotSerializable, "unknown operation %d", op.kind)
+}
+
 // NewTransferOperation returns a new instance of TransferOperation
 func NewTransferOperation(from, to ObjectID, amount, fee AssetAmount) *TransferOperation {
 	op := &TransferOperation{
@@ -124,7 +244,7 @@ type TransferOperation struct {
 	To         ObjectID          `json:"to"`
 	Amount     AssetAmount       `json:"amount"`
 	Fee        AssetAmount       `json:"fee"`
-	Memo       Memo              `json:"memo"`
+	Memo       *Memo             `json:"memo,omitempty"`
 	Extensions []json.RawMessage `json:"extensions"`
 }
 
@@ -155,6 +275,35 @@ func (p Buffer) Marshal(encoder *encoding.Encoder) error {
 	return enc.Err()
 }
 
+// Unmarshal implements encoding.Unmarshaller interface.
+func (p *Buffer) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	n := dec.DecodeUVarint()
+	*p = dec.DecodeBytes(int(n))
+	return dec.Err()
+}
+
+// fixedBuffer is a Buffer of a fixed size, like a hash, written without its length
+type fixedBuffer struct {
+	buf  *Buffer
+	size int
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (b fixedBuffer) Marshal(encoder *encoding.Encoder) error {
+	if len(*b.buf) != b.size {
+		return errors.Errorf("invalid buffer %s, should be %d bytes", *b.buf, b.size)
+	}
+	return encoder.Encode(b.buf.Bytes())
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (b fixedBuffer) Unmarshal(decoder *encoding.Decoder) error {
+	raw, err := decoder.DecodeBytes(b.size)
+	*b.buf = raw
+	return err
+}
+
 func (p Buffer) MarshalJSON() ([]byte, error) {
 	return json.Marshal(p.String())
 }
@@ -168,22 +317,39 @@ func (p *Buffer) UnmarshalJSON(data []byte) error {
 	return p.FromString(b)
 }
 
+// Memo is the encrypted message of a transfer. The nonce is answered as a
+// string when it does not fit in 32 bits.
 type Memo struct {
-	From    string `json:"from"`
-	To      string `json:"to"`
-	Nonce   string `json:"nonce"`
-	Message Buffer `json:"message"`
+	From    PublicKey `json:"from"`
+	To      PublicKey `json:"to"`
+	Nonce   Suint64   `json:"nonce"`
+	Message Buffer    `json:"message"`
 }
 
 func (m Memo) Marshal(encoder *encoding.Encoder) error {
+	if err := encoder.Encode(m.From); err != nil {
+		return errors.Wrap(err, "memo from")
+	}
+	if err := encoder.Encode(m.To); err != nil {
+		return errors.Wrap(err, "memo to")
+	}
+
 	enc := encoding.NewRollingEncoder(encoder)
-	enc.Encode(m.From)
-	enc.Encode(m.To)
-	enc.Encode(m.Nonce)
+	enc.EncodeLittleEndianUInt64(uint64(m.Nonce))
 	enc.Encode(m.Message)
 	return enc.Err()
 }
 
+// Unmarshal implements encoding.Unmarshaller interface.
+func (m *Memo) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	dec.Decode(&m.From)
+	dec.Decode(&m.To)
+	m.Nonce = Suint64(dec.DecodeLittleEndianUInt64())
+	dec.Decode(&m.Message)
+	return dec.Err()
+}
+
 func (op *TransferOperation) Type() OpType { return TransferOpType }
 
 func (op *TransferOperation) Marshal(encoder *encoding.Encoder) error {
@@ -194,24 +360,42 @@ func (op *TransferOperation) Marshal(encoder *encoding.Encoder) error {
 	enc.Encode(op.From)
 	enc.Encode(op.To)
 	enc.Encode(op.Amount)
-	enc.Encode(op.Memo)
 
-	//Memo?
-	// enc.EncodeUVarint(0)
-	//Extensions
-	enc.EncodeUVarint(0)
+	enc.EncodeOptional(op.Memo)
+	enc.Encode(futureExtensions(op.Extensions))
 	return enc.Err()
 }
 
+func (op *TransferOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.From = protocolID(accountObjectType)
+	dec.Decode(&op.From)
+	op.To = protocolID(accountObjectType)
+	dec.Decode(&op.To)
+	dec.Decode(&op.Amount)
+
+	op.Memo = nil
+	dec.DecodeOptional(func() error {
+		op.Memo = &Memo{}
+		return decoder.Decode(op.Memo)
+	})
+
+	dec.Decode((*futureExtensions)(&op.Extensions))
+	return dec.Err()
+}
+
 // LimitOrderCreateOperation
 type LimitOrderCreateOperation struct {
-	Fee          AssetAmount       `json:"fee"`
-	Seller       ObjectID          `json:"seller"`
-	AmountToSell AssetAmount       `json:"amount_to_sell"`
-	MinToReceive AssetAmount       `json:"min_to_receive"`
-	Expiration   Time              `json:"expiration"`
-	FillOrKill   bool              `json:"fill_or_kill"`
-	Extensions   []json.RawMessage `json:"extensions"`
+	Fee          AssetAmount     `json:"fee"`
+	Seller       ObjectID        `json:"seller"`
+	AmountToSell AssetAmount     `json:"amount_to_sell"`
+	MinToReceive AssetAmount     `json:"min_to_receive"`
+	Expiration   Time            `json:"expiration"`
+	FillOrKill   bool            `json:"fill_or_kill"`
+	Extensions   json.RawMessage `json:"extensions"`
 }
 
 func (op *LimitOrderCreateOperation) Marshal(encoder *encoding.Encoder) error {
@@ -224,14 +408,111 @@ func (op *LimitOrderCreateOperation) Marshal(encoder *encoding.Encoder) error {
 	enc.Encode(op.MinToReceive)
 	enc.Encode(op.Expiration)
 	enc.EncodeBool(op.FillOrKill)
-
-	//extensions
-	enc.EncodeUVarint(0)
+	enc.Encode(typedExtensions{&op.Extensions, limitOrderCreateExtensions})
 	return enc.Err()
 }
 
+func (op *LimitOrderCreateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Seller = protocolID(accountObjectType)
+	dec.Decode(&op.Seller)
+	dec.Decode(&op.AmountToSell)
+	dec.Decode(&op.MinToReceive)
+	dec.Decode(&op.Expiration)
+	op.FillOrKill = dec.DecodeBool()
+	dec.Decode(typedExtensions{&op.Extensions, limitOrderCreateExtensions})
+	return dec.Err()
+}
+
 func (op *LimitOrderCreateOperation) Type() OpType { return LimitOrderCreateOpType }
 
+// limitOrderCreateExtensions are the fields of the extensions of limit_order_create
+var limitOrderCreateExtensions = []extensionField{
+	{"on_fill", func() interface{} { return new(limitOrderAutoActions) }},
+}
+
+// limitOrderAutoActions are the actions taken when a limit order is filled
+type limitOrderAutoActions []limitOrderAutoAction
+
+// Marshal implements encoding.Marshaller interface.
+func (actions limitOrderAutoActions) Marshal(encoder *encoding.Encoder) error {
+	return encoder.EncodeFlatSet(len(actions), func(i int) error {
+		return encoder.Encode(actions[i])
+	})
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (actions *limitOrderAutoActions) Unmarshal(decoder *encoding.Decoder) error {
+	*actions = limitOrderAutoActions{}
+	return decoder.DecodeFlatSet(func(int) error {
+		var action limitOrderAutoAction
+		if err := decoder.Decode(&action); err != nil {
+			return err
+		}
+		*actions = append(*actions, action)
+		return nil
+	})
+}
+
+// limitOrderAutoAction is a limit_order_auto_action, only a take profit order for now
+type limitOrderAutoAction struct {
+	staticVariant
+}
+
+func (a *limitOrderAutoAction) UnmarshalJSON(b []byte) error {
+	return a.unmarshalJSON(b, limitOrderAutoActionAlternatives)
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (a *limitOrderAutoAction) Unmarshal(decoder *encoding.Decoder) error {
+	return a.unmarshal(decoder, limitOrderAutoActionAlternatives)
+}
+
+func limitOrderAutoActionAlternatives(tag uint64) (interface{}, error) {
+	if tag != 0 {
+		return nil, errors.Errorf("unknown limit order auto action %d", tag)
+	}
+	return &takeProfitOrderAction{FeeAssetID: protocolID(assetObjectType)}, nil
+}
+
+// takeProfitOrderAction places an order selling what a filled order received
+// at a price spread from the fill price
+type takeProfitOrderAction struct {
+	FeeAssetID        ObjectID          `json:"fee_asset_id"`
+	SpreadPercent     uint16            `json:"spread_percent"`
+	SizePercent       uint16            `json:"size_percent"`
+	ExpirationSeconds uint32            `json:"expiration_seconds"`
+	Repeat            bool              `json:"repeat"`
+	Extensions        []json.RawMessage `json:"extensions"`
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (a takeProfitOrderAction) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.Encode(a.FeeAssetID)
+	enc.Encode(a.SpreadPercent)
+	enc.Encode(a.SizePercent)
+	enc.Encode(a.ExpirationSeconds)
+	enc.EncodeBool(a.Repeat)
+	enc.Encode(futureExtensions(a.Extensions))
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (a *takeProfitOrderAction) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	dec.Decode(&a.FeeAssetID)
+	dec.Decode(&a.SpreadPercent)
+	dec.Decode(&a.SizePercent)
+	dec.Decode(&a.ExpirationSeconds)
+	a.Repeat = dec.DecodeBool()
+	dec.Decode((*futureExtensions)(&a.Extensions))
+	return dec.Err()
+}
+
 // LimitOrderCancelOpType
 type LimitOrderCancelOperation struct {
 	Fee              AssetAmount       `json:"fee"`
@@ -247,23 +528,114 @@ func (op *LimitOrderCancelOperation) Marshal(encoder *encoding.Encoder) error {
 	enc.Encode(op.Fee)
 	enc.Encode(op.FeePayingAccount)
 	enc.Encode(op.Order)
-
-	// extensions
-	enc.EncodeUVarint(0)
+	enc.Encode(futureExtensions(op.Extensions))
 	return enc.Err()
 }
 
+func (op *LimitOrderCancelOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.FeePayingAccount = protocolID(accountObjectType)
+	dec.Decode(&op.FeePayingAccount)
+	op.Order = protocolID(limitOrderObjectType)
+	dec.Decode(&op.Order)
+	dec.Decode((*futureExtensions)(&op.Extensions))
+	return dec.Err()
+}
+
 func (op *LimitOrderCancelOperation) Type() OpType { return LimitOrderCancelOpType }
 
-// FillOrderOpType
+// FillOrderOperation is the virtual operation of a filled order
 type FillOrderOperation struct {
-	Order   ObjectID
-	Account ObjectID
-	Pays    AssetAmount
-	Recives AssetAmount
-	Fee     AssetAmount
-	Price   Price
-	IsMaker bool
+	Fee      AssetAmount `json:"fee"`
+	Order    ObjectID    `json:"order_id"`
+	Account  ObjectID    `json:"account_id"`
+	Pays     AssetAmount `json:"pays"`
+	Receives AssetAmount `json:"receives"`
+	Price    Price       `json:"fill_price"`
+	IsMaker  bool        `json:"is_maker"`
 }
 
 func (op *FillOrderOperation) Type() OpType { return FillOrderOpType }
+
+func (op *FillOrderOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(anyObjectID(op.Order))
+	enc.Encode(op.Account)
+	enc.Encode(op.Pays)
+	enc.Encode(op.Receives)
+	enc.Encode(op.Price)
+	enc.EncodeBool(op.IsMaker)
+	return enc.Err()
+}
+
+func (op *FillOrderOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	dec.Decode((*anyObjectID)(&op.Order))
+	op.Account = protocolID(accountObjectType)
+	dec.Decode(&op.Account)
+	dec.Decode(&op.Pays)
+	dec.Decode(&op.Receives)
+	dec.Decode(&op.Price)
+	op.IsMaker = dec.DecodeBool()
+	return dec.Err()
+}
+
+// AccountUpdateOperation changes the authorities or the options of an account
+type AccountUpdateOperation struct {
+	Fee        AssetAmount     `json:"fee"`
+	Account    ObjectID        `json:"account"`
+	Owner      *Permission     `json:"owner,omitempty"`
+	Active     *Permission     `json:"active,omitempty"`
+	NewOptions *Options        `json:"new_options,omitempty"`
+	Extensions json.RawMessage `json:"extensions"`
+}
+
+func (op *AccountUpdateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Account)
+	enc.EncodeOptional(op.Owner)
+	enc.EncodeOptional(op.Active)
+	enc.EncodeOptional(op.NewOptions)
+	enc.Encode(typedExtensions{&op.Extensions, accountUpdateExtensions})
+	return enc.Err()
+}
+
+func (op *AccountUpdateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Account = protocolID(accountObjectType)
+	dec.Decode(&op.Account)
+
+	op.Owner, op.Active, op.NewOptions = nil, nil, nil
+	dec.DecodeOptional(func() error {
+		op.Owner = &Permission{}
+		return decoder.Decode(op.Owner)
+	})
+	dec.DecodeOptional(func() error {
+		op.Active = &Permission{}
+		return decoder.Decode(op.Active)
+	})
+	dec.DecodeOptional(func() error {
+		op.NewOptions = &Options{}
+		return decoder.Decode(op.NewOptions)
+	})
+
+	dec.Decode(typedExtensions{&op.Extensions, accountUpdateExtensions})
+	return dec.Err()
+}
+
+func (op *AccountUpdateOperation) Type() OpType { return AccountUpdateOpType }
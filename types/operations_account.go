@@ -0,0 +1,912 @@
+package types
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/pkg/errors"
+)
+
+// AccountCreateOperation registers a new account
+type AccountCreateOperation struct {
+	Fee             AssetAmount     `json:"fee"`
+	Registrar       ObjectID        `json:"registrar"`
+	Referrer        ObjectID        `json:"referrer"`
+	ReferrerPercent uint16          `json:"referrer_percent"`
+	Name            string          `json:"name"`
+	Owner           Permission      `json:"owner"`
+	Active          Permission      `json:"active"`
+	Options         Options         `json:"options"`
+	Extensions      json.RawMessage `json:"extensions"`
+}
+
+func (op *AccountCreateOperation) Type() OpType { return AccountCreateOpType }
+
+func (op *AccountCreateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Registrar)
+	enc.Encode(op.Referrer)
+	enc.Encode(op.ReferrerPercent)
+	enc.Encode(op.Name)
+	enc.Encode(op.Owner)
+	enc.Encode(op.Active)
+	enc.Encode(op.Options)
+	enc.Encode(typedExtensions{&op.Extensions, accountCreateExtensions})
+	return enc.Err()
+}
+
+func (op *AccountCreateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Registrar = protocolID(accountObjectType)
+	dec.Decode(&op.Registrar)
+	op.Referrer = protocolID(accountObjectType)
+	dec.Decode(&op.Referrer)
+	dec.Decode(&op.ReferrerPercent)
+	dec.Decode(&op.Name)
+	dec.Decode(&op.Owner)
+	dec.Decode(&op.Active)
+	dec.Decode(&op.Options)
+	dec.Decode(typedExtensions{&op.Extensions, accountCreateExtensions})
+	return dec.Err()
+}
+
+// accountUpdateExtensions are the fields of the extensions of account_update
+var accountUpdateExtensions = []extensionField{
+	{"null_ext", func() interface{} { return new(voidT) }},
+	{"owner_special_authority", func() interface{} { return new(specialAuthority) }},
+	{"active_special_authority", func() interface{} { return new(specialAuthority) }},
+}
+
+// accountCreateExtensions are the fields of the extensions of account_create
+var accountCreateExtensions = append(accountUpdateExtensions[:len(accountUpdateExtensions):len(accountUpdateExtensions)],
+	extensionField{"buyback_options", func() interface{} { return new(buybackOptions) }},
+)
+
+// specialAuthority is a special_authority of an account, none or the top holders of an asset
+type specialAuthority struct {
+	staticVariant
+}
+
+func (a *specialAuthority) UnmarshalJSON(b []byte) error {
+	return a.unmarshalJSON(b, specialAuthorityAlternatives)
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (a *specialAuthority) Unmarshal(decoder *encoding.Decoder) error {
+	return a.unmarshal(decoder, specialAuthorityAlternatives)
+}
+
+func specialAuthorityAlternatives(tag uint64) (interface{}, error) {
+	switch tag {
+	case 0:
+		return new(voidT), nil
+	case 1:
+		return &topHoldersSpecialAuthority{Asset: protocolID(assetObjectType)}, nil
+	}
+	return nil, errors.Errorf("unknown special authority %d", tag)
+}
+
+// topHoldersSpecialAuthority gives the authority of an account to the top holders of an asset
+type topHoldersSpecialAuthority struct {
+	Asset         ObjectID `json:"asset"`
+	NumTopHolders uint8    `json:"num_top_holders"`
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (a topHoldersSpecialAuthority) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.Encode(a.Asset)
+	enc.Encode(a.NumTopHolders)
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (a *topHoldersSpecialAuthority) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	dec.Decode(&a.Asset)
+	dec.Decode(&a.NumTopHolders)
+	return dec.Err()
+}
+
+// buybackOptions make an account buy an asset in its markets and burn it
+type buybackOptions struct {
+	AssetToBuy       ObjectID   `json:"asset_to_buy"`
+	AssetToBuyIssuer ObjectID   `json:"asset_to_buy_issuer"`
+	Markets          []ObjectID `json:"markets"`
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (o buybackOptions) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.Encode(o.AssetToBuy)
+	enc.Encode(o.AssetToBuyIssuer)
+	enc.Encode(assetSet(o.Markets))
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (o *buybackOptions) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	o.AssetToBuy = protocolID(assetObjectType)
+	dec.Decode(&o.AssetToBuy)
+	o.AssetToBuyIssuer = protocolID(accountObjectType)
+	dec.Decode(&o.AssetToBuyIssuer)
+	dec.Decode((*assetSet)(&o.Markets))
+	return dec.Err()
+}
+
+// account listings of AccountWhitelistOperation
+const (
+	AccountListingNone       = 0
+	AccountListingWhite      = 1
+	AccountListingBlack      = 2
+	AccountListingWhiteBlack = 3
+)
+
+// AccountWhitelistOperation white or black lists an account by an authorizing account
+type AccountWhitelistOperation struct {
+	Fee                AssetAmount     `json:"fee"`
+	AuthorizingAccount ObjectID        `json:"authorizing_account"`
+	AccountToList      ObjectID        `json:"account_to_list"`
+	NewListing         uint8           `json:"new_listing"`
+	Extensions         json.RawMessage `json:"extensions"`
+}
+
+func (op *AccountWhitelistOperation) Type() OpType { return AccountWhitelistOpType }
+
+func (op *AccountWhitelistOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.AuthorizingAccount)
+	enc.Encode(op.AccountToList)
+	enc.Encode(op.NewListing)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *AccountWhitelistOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.AuthorizingAccount = protocolID(accountObjectType)
+	dec.Decode(&op.AuthorizingAccount)
+	op.AccountToList = protocolID(accountObjectType)
+	dec.Decode(&op.AccountToList)
+	dec.Decode(&op.NewListing)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// AccountUpgradeOperation upgrades an account to a lifetime member
+type AccountUpgradeOperation struct {
+	Fee                     AssetAmount     `json:"fee"`
+	AccountToUpgrade        ObjectID        `json:"account_to_upgrade"`
+	UpgradeToLifetimeMember bool            `json:"upgrade_to_lifetime_member"`
+	Extensions              json.RawMessage `json:"extensions"`
+}
+
+func (op *AccountUpgradeOperation) Type() OpType { return AccountUpgradeOpType }
+
+func (op *AccountUpgradeOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.AccountToUpgrade)
+	enc.EncodeBool(op.UpgradeToLifetimeMember)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *AccountUpgradeOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.AccountToUpgrade = protocolID(accountObjectType)
+	dec.Decode(&op.AccountToUpgrade)
+	op.UpgradeToLifetimeMember = dec.DecodeBool()
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// AccountTransferOperation transfers an account to a new owner, it is not enabled on the chain
+type AccountTransferOperation struct {
+	Fee        AssetAmount     `json:"fee"`
+	AccountID  ObjectID        `json:"account_id"`
+	NewOwner   ObjectID        `json:"new_owner"`
+	Extensions json.RawMessage `json:"extensions"`
+}
+
+func (op *AccountTransferOperation) Type() OpType { return AccountTransferOpType }
+
+func (op *AccountTransferOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.AccountID)
+	enc.Encode(op.NewOwner)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *AccountTransferOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.AccountID = protocolID(accountObjectType)
+	dec.Decode(&op.AccountID)
+	op.NewOwner = protocolID(accountObjectType)
+	dec.Decode(&op.NewOwner)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// WithdrawPermissionCreateOperation lets an account withdraw from another one periodically
+type WithdrawPermissionCreateOperation struct {
+	Fee                    AssetAmount `json:"fee"`
+	WithdrawFromAccount    ObjectID    `json:"withdraw_from_account"`
+	AuthorizedAccount      ObjectID    `json:"authorized_account"`
+	WithdrawalLimit        AssetAmount `json:"withdrawal_limit"`
+	WithdrawalPeriodSec    uint32      `json:"withdrawal_period_sec"`
+	PeriodsUntilExpiration uint32      `json:"periods_until_expiration"`
+	PeriodStartTime        Time        `json:"period_start_time"`
+}
+
+func (op *WithdrawPermissionCreateOperation) Type() OpType { return WithdrawPermissionCreateOpType }
+
+func (op *WithdrawPermissionCreateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.WithdrawFromAccount)
+	enc.Encode(op.AuthorizedAccount)
+	enc.Encode(op.WithdrawalLimit)
+	enc.Encode(op.WithdrawalPeriodSec)
+	enc.Encode(op.PeriodsUntilExpiration)
+	enc.Encode(op.PeriodStartTime)
+	return enc.Err()
+}
+
+func (op *WithdrawPermissionCreateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.WithdrawFromAccount = protocolID(accountObjectType)
+	dec.Decode(&op.WithdrawFromAccount)
+	op.AuthorizedAccount = protocolID(accountObjectType)
+	dec.Decode(&op.AuthorizedAccount)
+	dec.Decode(&op.WithdrawalLimit)
+	dec.Decode(&op.WithdrawalPeriodSec)
+	dec.Decode(&op.PeriodsUntilExpiration)
+	dec.Decode(&op.PeriodStartTime)
+	return dec.Err()
+}
+
+// WithdrawPermissionUpdateOperation changes a withdraw permission
+type WithdrawPermissionUpdateOperation struct {
+	Fee                    AssetAmount `json:"fee"`
+	WithdrawFromAccount    ObjectID    `json:"withdraw_from_account"`
+	AuthorizedAccount      ObjectID    `json:"authorized_account"`
+	PermissionToUpdate     ObjectID    `json:"permission_to_update"`
+	WithdrawalLimit        AssetAmount `json:"withdrawal_limit"`
+	WithdrawalPeriodSec    uint32      `json:"withdrawal_period_sec"`
+	PeriodStartTime        Time        `json:"period_start_time"`
+	PeriodsUntilExpiration uint32      `json:"periods_until_expiration"`
+}
+
+func (op *WithdrawPermissionUpdateOperation) Type() OpType { return WithdrawPermissionUpdateOpType }
+
+func (op *WithdrawPermissionUpdateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.WithdrawFromAccount)
+	enc.Encode(op.AuthorizedAccount)
+	enc.Encode(op.PermissionToUpdate)
+	enc.Encode(op.WithdrawalLimit)
+	enc.Encode(op.WithdrawalPeriodSec)
+	enc.Encode(op.PeriodStartTime)
+	enc.Encode(op.PeriodsUntilExpiration)
+	return enc.Err()
+}
+
+func (op *WithdrawPermissionUpdateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.WithdrawFromAccount = protocolID(accountObjectType)
+	dec.Decode(&op.WithdrawFromAccount)
+	op.AuthorizedAccount = protocolID(accountObjectType)
+	dec.Decode(&op.AuthorizedAccount)
+	op.PermissionToUpdate = protocolID(withdrawPermissionObjectType)
+	dec.Decode(&op.PermissionToUpdate)
+	dec.Decode(&op.WithdrawalLimit)
+	dec.Decode(&op.WithdrawalPeriodSec)
+	dec.Decode(&op.PeriodStartTime)
+	dec.Decode(&op.PeriodsUntilExpiration)
+	return dec.Err()
+}
+
+// WithdrawPermissionClaimOperation withdraws by a withdraw permission
+type WithdrawPermissionClaimOperation struct {
+	Fee                 AssetAmount `json:"fee"`
+	WithdrawPermission  ObjectID    `json:"withdraw_permission"`
+	WithdrawFromAccount ObjectID    `json:"withdraw_from_account"`
+	WithdrawToAccount   ObjectID    `json:"withdraw_to_account"`
+	AmountToWithdraw    AssetAmount `json:"amount_to_withdraw"`
+	Memo                *Memo       `json:"memo,omitempty"`
+}
+
+func (op *WithdrawPermissionClaimOperation) Type() OpType { return WithdrawPermissionClaimOpType }
+
+func (op *WithdrawPermissionClaimOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.WithdrawPermission)
+	enc.Encode(op.WithdrawFromAccount)
+	enc.Encode(op.WithdrawToAccount)
+	enc.Encode(op.AmountToWithdraw)
+	enc.EncodeOptional(op.Memo)
+	return enc.Err()
+}
+
+func (op *WithdrawPermissionClaimOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.WithdrawPermission = protocolID(withdrawPermissionObjectType)
+	dec.Decode(&op.WithdrawPermission)
+	op.WithdrawFromAccount = protocolID(accountObjectType)
+	dec.Decode(&op.WithdrawFromAccount)
+	op.WithdrawToAccount = protocolID(accountObjectType)
+	dec.Decode(&op.WithdrawToAccount)
+	dec.Decode(&op.AmountToWithdraw)
+
+	op.Memo = nil
+	dec.DecodeOptional(func() error {
+		op.Memo = &Memo{}
+		return decoder.Decode(op.Memo)
+	})
+	return dec.Err()
+}
+
+// WithdrawPermissionDeleteOperation revokes a withdraw permission
+type WithdrawPermissionDeleteOperation struct {
+	Fee                  AssetAmount `json:"fee"`
+	WithdrawFromAccount  ObjectID    `json:"withdraw_from_account"`
+	AuthorizedAccount    ObjectID    `json:"authorized_account"`
+	WithdrawalPermission ObjectID    `json:"withdrawal_permission"`
+}
+
+func (op *WithdrawPermissionDeleteOperation) Type() OpType { return WithdrawPermissionDeleteOpType }
+
+func (op *WithdrawPermissionDeleteOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.WithdrawFromAccount)
+	enc.Encode(op.AuthorizedAccount)
+	enc.Encode(op.WithdrawalPermission)
+	return enc.Err()
+}
+
+func (op *WithdrawPermissionDeleteOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.WithdrawFromAccount = protocolID(accountObjectType)
+	dec.Decode(&op.WithdrawFromAccount)
+	op.AuthorizedAccount = protocolID(accountObjectType)
+	dec.Decode(&op.AuthorizedAccount)
+	op.WithdrawalPermission = protocolID(withdrawPermissionObjectType)
+	dec.Decode(&op.WithdrawalPermission)
+	return dec.Err()
+}
+
+// CustomOperation carries arbitrary data authorized by the accounts
+type CustomOperation struct {
+	Fee           AssetAmount `json:"fee"`
+	Payer         ObjectID    `json:"payer"`
+	RequiredAuths []ObjectID  `json:"required_auths"`
+	ID            uint16      `json:"id"`
+	Data          Buffer      `json:"data"`
+}
+
+func (op *CustomOperation) Type() OpType { return CustomOpType }
+
+func (op *CustomOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Payer)
+	enc.Encode(accountSet(op.RequiredAuths))
+	enc.Encode(op.ID)
+	enc.Encode(op.Data)
+	return enc.Err()
+}
+
+func (op *CustomOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Payer = protocolID(accountObjectType)
+	dec.Decode(&op.Payer)
+	dec.Decode((*accountSet)(&op.RequiredAuths))
+	dec.Decode(&op.ID)
+	dec.Decode(&op.Data)
+	return dec.Err()
+}
+
+// AssertOperation fails the transaction unless its predicates hold
+type AssertOperation struct {
+	Fee              AssetAmount     `json:"fee"`
+	FeePayingAccount ObjectID        `json:"fee_paying_account"`
+	Predicates       json.RawMessage `json:"predicates"`
+	RequiredAuths    []ObjectID      `json:"required_auths"`
+	Extensions       json.RawMessage `json:"extensions"`
+}
+
+func (op *AssertOperation) Type() OpType { return AssertOpType }
+
+func (op *AssertOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.FeePayingAccount)
+	enc.Encode(rawJSON{&op.Predicates, new(predicates)})
+	enc.Encode(accountSet(op.RequiredAuths))
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *AssertOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.FeePayingAccount = protocolID(accountObjectType)
+	dec.Decode(&op.FeePayingAccount)
+	dec.Decode(rawJSON{&op.Predicates, new(predicates)})
+	dec.Decode((*accountSet)(&op.RequiredAuths))
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// predicates are the predicates of an assert operation
+type predicates []predicate
+
+// Marshal implements encoding.Marshaller interface.
+func (p predicates) Marshal(encoder *encoding.Encoder) error {
+	return encoder.EncodeFlatSet(len(p), func(i int) error {
+		return encoder.Encode(p[i])
+	})
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (p *predicates) Unmarshal(decoder *encoding.Decoder) error {
+	*p = predicates{}
+	return decoder.DecodeFlatSet(func(int) error {
+		var pred predicate
+		if err := decoder.Decode(&pred); err != nil {
+			return err
+		}
+		*p = append(*p, pred)
+		return nil
+	})
+}
+
+// predicate is a predicate of an assert operation: the name of an account, the
+// symbol of an asset or the id of a block
+type predicate struct {
+	staticVariant
+}
+
+func (p *predicate) UnmarshalJSON(b []byte) error {
+	return p.unmarshalJSON(b, predicateAlternatives)
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (p *predicate) Unmarshal(decoder *encoding.Decoder) error {
+	return p.unmarshal(decoder, predicateAlternatives)
+}
+
+func predicateAlternatives(tag uint64) (interface{}, error) {
+	switch tag {
+	case 0:
+		return &accountNamePredicate{AccountID: protocolID(accountObjectType)}, nil
+	case 1:
+		return &assetSymbolPredicate{AssetID: protocolID(assetObjectType)}, nil
+	case 2:
+		return new(blockIDPredicate), nil
+	}
+	return nil, errors.Errorf("unknown predicate %d", tag)
+}
+
+// accountNamePredicate asserts the name of an account
+type accountNamePredicate struct {
+	AccountID ObjectID `json:"account_id"`
+	Name      string   `json:"name"`
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (p accountNamePredicate) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.Encode(p.AccountID)
+	enc.Encode(p.Name)
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (p *accountNamePredicate) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	dec.Decode(&p.AccountID)
+	dec.Decode(&p.Name)
+	return dec.Err()
+}
+
+// assetSymbolPredicate asserts the symbol of an asset
+type assetSymbolPredicate struct {
+	AssetID ObjectID `json:"asset_id"`
+	Symbol  string   `json:"symbol"`
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (p assetSymbolPredicate) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.Encode(p.AssetID)
+	enc.Encode(p.Symbol)
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (p *assetSymbolPredicate) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	dec.Decode(&p.AssetID)
+	dec.Decode(&p.Symbol)
+	return dec.Err()
+}
+
+// blockIDPredicate asserts the id of a block, the transaction is only valid on its fork
+type blockIDPredicate struct {
+	ID Buffer `json:"id"`
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (p blockIDPredicate) Marshal(encoder *encoding.Encoder) error {
+	return encoder.Encode(fixedBuffer{&p.ID, 20})
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (p *blockIDPredicate) Unmarshal(decoder *encoding.Decoder) error {
+	return decoder.Decode(fixedBuffer{&p.ID, 20})
+}
+
+// BalanceClaimOperation claims a genesis balance by its owner key
+type BalanceClaimOperation struct {
+	Fee              AssetAmount `json:"fee"`
+	DepositToAccount ObjectID    `json:"deposit_to_account"`
+	BalanceToClaim   ObjectID    `json:"balance_to_claim"`
+	BalanceOwnerKey  PublicKey   `json:"balance_owner_key"`
+	TotalClaimed     AssetAmount `json:"total_claimed"`
+}
+
+func (op *BalanceClaimOperation) Type() OpType { return BalanceClaimOpType }
+
+func (op *BalanceClaimOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.DepositToAccount)
+	enc.Encode(op.BalanceToClaim)
+	enc.Encode(op.BalanceOwnerKey)
+	enc.Encode(op.TotalClaimed)
+	return enc.Err()
+}
+
+func (op *BalanceClaimOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.DepositToAccount = protocolID(accountObjectType)
+	dec.Decode(&op.DepositToAccount)
+	op.BalanceToClaim = protocolID(balanceObjectType)
+	dec.Decode(&op.BalanceToClaim)
+	dec.Decode(&op.BalanceOwnerKey)
+	dec.Decode(&op.TotalClaimed)
+	return dec.Err()
+}
+
+// CustomAuthorityCreateOperation lets an authority sign one operation type of an account
+type CustomAuthorityCreateOperation struct {
+	Fee           AssetAmount     `json:"fee"`
+	Account       ObjectID        `json:"account"`
+	Enabled       bool            `json:"enabled"`
+	ValidFrom     Time            `json:"valid_from"`
+	ValidTo       Time            `json:"valid_to"`
+	OperationType OpType          `json:"operation_type"`
+	Auth          Permission      `json:"auth"`
+	Restrictions  json.RawMessage `json:"restrictions"`
+	Extensions    json.RawMessage `json:"extensions"`
+}
+
+func (op *CustomAuthorityCreateOperation) Type() OpType { return CustomAuthorityCreateOpType }
+
+func (op *CustomAuthorityCreateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Account)
+	enc.EncodeBool(op.Enabled)
+	enc.Encode(op.ValidFrom)
+	enc.Encode(op.ValidTo)
+	enc.EncodeUVarint(uint64(op.OperationType))
+	enc.Encode(op.Auth)
+	enc.Encode(rawJSON{&op.Restrictions, new(restrictions)})
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *CustomAuthorityCreateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Account = protocolID(accountObjectType)
+	dec.Decode(&op.Account)
+	op.Enabled = dec.DecodeBool()
+	dec.Decode(&op.ValidFrom)
+	dec.Decode(&op.ValidTo)
+	op.OperationType = OpType(dec.DecodeUVarint())
+	dec.Decode(&op.Auth)
+	dec.Decode(rawJSON{&op.Restrictions, new(restrictions)})
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// CustomAuthorityUpdateOperation changes a custom authority
+type CustomAuthorityUpdateOperation struct {
+	Fee                  AssetAmount     `json:"fee"`
+	Account              ObjectID        `json:"account"`
+	AuthorityToUpdate    ObjectID        `json:"authority_to_update"`
+	NewEnabled           *bool           `json:"new_enabled,omitempty"`
+	NewValidFrom         *Time           `json:"new_valid_from,omitempty"`
+	NewValidTo           *Time           `json:"new_valid_to,omitempty"`
+	NewAuth              *Permission     `json:"new_auth,omitempty"`
+	RestrictionsToRemove []uint16        `json:"restrictions_to_remove"`
+	RestrictionsToAdd    json.RawMessage `json:"restrictions_to_add"`
+	Extensions           json.RawMessage `json:"extensions"`
+}
+
+func (op *CustomAuthorityUpdateOperation) Type() OpType { return CustomAuthorityUpdateOpType }
+
+func (op *CustomAuthorityUpdateOperation) Marshal(encoder *encoding.Encoder) error {
+	toRemove := append([]uint16(nil), op.RestrictionsToRemove...)
+	sort.Slice(toRemove, func(i, j int) bool { return toRemove[i] < toRemove[j] })
+
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Account)
+	enc.Encode(op.AuthorityToUpdate)
+	enc.EncodeOptional(op.NewEnabled)
+	enc.EncodeOptional(op.NewValidFrom)
+	enc.EncodeOptional(op.NewValidTo)
+	enc.EncodeOptional(op.NewAuth)
+	enc.EncodeFlatSet(len(toRemove), func(i int) error {
+		return encoder.Encode(toRemove[i])
+	})
+	enc.Encode(rawJSON{&op.RestrictionsToAdd, new(restrictions)})
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *CustomAuthorityUpdateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Account = protocolID(accountObjectType)
+	dec.Decode(&op.Account)
+	op.AuthorityToUpdate = protocolID(customAuthorityObjectType)
+	dec.Decode(&op.AuthorityToUpdate)
+
+	op.NewEnabled, op.NewValidFrom, op.NewValidTo, op.NewAuth = nil, nil, nil, nil
+	dec.DecodeOptional(func() error {
+		op.NewEnabled = new(bool)
+		return decoder.Decode(op.NewEnabled)
+	})
+	dec.DecodeOptional(func() error {
+		op.NewValidFrom = &Time{}
+		return decoder.Decode(op.NewValidFrom)
+	})
+	dec.DecodeOptional(func() error {
+		op.NewValidTo = &Time{}
+		return decoder.Decode(op.NewValidTo)
+	})
+	dec.DecodeOptional(func() error {
+		op.NewAuth = &Permission{}
+		return decoder.Decode(op.NewAuth)
+	})
+
+	op.RestrictionsToRemove = []uint16{}
+	dec.DecodeFlatSet(func(int) error {
+		var index uint16
+		if err := decoder.Decode(&index); err != nil {
+			return err
+		}
+		op.RestrictionsToRemove = append(op.RestrictionsToRemove, index)
+		return nil
+	})
+	dec.Decode(rawJSON{&op.RestrictionsToAdd, new(restrictions)})
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// CustomAuthorityDeleteOperation deletes a custom authority
+type CustomAuthorityDeleteOperation struct {
+	Fee               AssetAmount     `json:"fee"`
+	Account           ObjectID        `json:"account"`
+	AuthorityToDelete ObjectID        `json:"authority_to_delete"`
+	Extensions        json.RawMessage `json:"extensions"`
+}
+
+func (op *CustomAuthorityDeleteOperation) Type() OpType { return CustomAuthorityDeleteOpType }
+
+func (op *CustomAuthorityDeleteOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Account)
+	enc.Encode(op.AuthorityToDelete)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *CustomAuthorityDeleteOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Account = protocolID(accountObjectType)
+	dec.Decode(&op.Account)
+	op.AuthorityToDelete = protocolID(customAuthorityObjectType)
+	dec.Decode(&op.AuthorityToDelete)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// ticket types of the ticket operations
+const (
+	TicketLiquid = iota
+	TicketLock180Days
+	TicketLock360Days
+	TicketLock720Days
+	TicketLockForever
+)
+
+// TicketCreateOperation locks core asset in a voting ticket
+type TicketCreateOperation struct {
+	Fee        AssetAmount     `json:"fee"`
+	Account    ObjectID        `json:"account"`
+	TargetType uint8           `json:"target_type"`
+	Amount     AssetAmount     `json:"amount"`
+	Extensions json.RawMessage `json:"extensions"`
+}
+
+func (op *TicketCreateOperation) Type() OpType { return TicketCreateOpType }
+
+func (op *TicketCreateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Account)
+	enc.EncodeUVarint(uint64(op.TargetType))
+	enc.Encode(op.Amount)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *TicketCreateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Account = protocolID(accountObjectType)
+	dec.Decode(&op.Account)
+	dec.Decode((*ticketType)(&op.TargetType))
+	dec.Decode(&op.Amount)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// TicketUpdateOperation changes the lock of a ticket, or of a part of it
+type TicketUpdateOperation struct {
+	Fee                AssetAmount     `json:"fee"`
+	Ticket             ObjectID        `json:"ticket"`
+	Account            ObjectID        `json:"account"`
+	TargetType         uint8           `json:"target_type"`
+	AmountForNewTarget *AssetAmount    `json:"amount_for_new_target,omitempty"`
+	Extensions         json.RawMessage `json:"extensions"`
+}
+
+func (op *TicketUpdateOperation) Type() OpType { return TicketUpdateOpType }
+
+func (op *TicketUpdateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Ticket)
+	enc.Encode(op.Account)
+	enc.EncodeUVarint(uint64(op.TargetType))
+	enc.EncodeOptional(op.AmountForNewTarget)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *TicketUpdateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Ticket = protocolID(ticketObjectType)
+	dec.Decode(&op.Ticket)
+	op.Account = protocolID(accountObjectType)
+	dec.Decode(&op.Account)
+	dec.Decode((*ticketType)(&op.TargetType))
+
+	op.AmountForNewTarget = nil
+	dec.DecodeOptional(func() error {
+		op.AmountForNewTarget = &AssetAmount{}
+		return decoder.Decode(op.AmountForNewTarget)
+	})
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// ticketType unmarshals the target type of a ticket, an unsigned_int on the wire
+type ticketType uint8
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (t *ticketType) Unmarshal(decoder *encoding.Decoder) error {
+	target, err := decoder.DecodeUVarint()
+	if err != nil {
+		return err
+	}
+	if target > TicketLockForever {
+		return errors.Errorf("unknown ticket type %d", target)
+	}
+	*t = ticketType(target)
+	return nil
+}
@@ -0,0 +1,846 @@
+package types
+
+import (
+	"encoding/json"
+
+	"github.com/blocktree/bitshares-adapter/encoding"
+)
+
+// AssetOptions are the options of every asset
+type AssetOptions struct {
+	MaxSupply            Suint64         `json:"max_supply"`
+	MarketFeePercent     uint16          `json:"market_fee_percent"`
+	MaxMarketFee         Suint64         `json:"max_market_fee"`
+	IssuerPermissions    uint16          `json:"issuer_permissions"`
+	Flags                uint16          `json:"flags"`
+	CoreExchangeRate     Price           `json:"core_exchange_rate"`
+	WhitelistAuthorities []ObjectID      `json:"whitelist_authorities"`
+	BlacklistAuthorities []ObjectID      `json:"blacklist_authorities"`
+	WhitelistMarkets     []ObjectID      `json:"whitelist_markets"`
+	BlacklistMarkets     []ObjectID      `json:"blacklist_markets"`
+	Description          string          `json:"description"`
+	Extensions           json.RawMessage `json:"extensions"`
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (o AssetOptions) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.Encode(o.MaxSupply)
+	enc.Encode(o.MarketFeePercent)
+	enc.Encode(o.MaxMarketFee)
+	enc.Encode(o.IssuerPermissions)
+	enc.Encode(o.Flags)
+	enc.Encode(o.CoreExchangeRate)
+	enc.Encode(accountSet(o.WhitelistAuthorities))
+	enc.Encode(accountSet(o.BlacklistAuthorities))
+	enc.Encode(assetSet(o.WhitelistMarkets))
+	enc.Encode(assetSet(o.BlacklistMarkets))
+	enc.Encode(o.Description)
+	enc.Encode(typedExtensions{&o.Extensions, assetOptionsExtensions})
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (o *AssetOptions) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	dec.Decode(&o.MaxSupply)
+	dec.Decode(&o.MarketFeePercent)
+	dec.Decode(&o.MaxMarketFee)
+	dec.Decode(&o.IssuerPermissions)
+	dec.Decode(&o.Flags)
+	dec.Decode(&o.CoreExchangeRate)
+	dec.Decode((*accountSet)(&o.WhitelistAuthorities))
+	dec.Decode((*accountSet)(&o.BlacklistAuthorities))
+	dec.Decode((*assetSet)(&o.WhitelistMarkets))
+	dec.Decode((*assetSet)(&o.BlacklistMarkets))
+	dec.Decode(&o.Description)
+	dec.Decode(typedExtensions{&o.Extensions, assetOptionsExtensions})
+	return dec.Err()
+}
+
+// assetOptionsExtensions are the fields of the extensions of asset_options
+var assetOptionsExtensions = []extensionField{
+	{"reward_percent", func() interface{} { return new(uint16) }},
+	{"whitelist_market_fee_sharing", func() interface{} { return new(accountSet) }},
+	{"taker_fee_percent", func() interface{} { return new(uint16) }},
+}
+
+// BitassetOptions are the options of a market issued asset
+type BitassetOptions struct {
+	FeedLifetimeSec              uint32          `json:"feed_lifetime_sec"`
+	MinimumFeeds                 uint8           `json:"minimum_feeds"`
+	ForceSettlementDelaySec      uint32          `json:"force_settlement_delay_sec"`
+	ForceSettlementOffsetPercent uint16          `json:"force_settlement_offset_percent"`
+	MaximumForceSettlementVolume uint16          `json:"maximum_force_settlement_volume"`
+	ShortBackingAsset            ObjectID        `json:"short_backing_asset"`
+	Extensions                   json.RawMessage `json:"extensions"`
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (o BitassetOptions) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.Encode(o.FeedLifetimeSec)
+	enc.Encode(o.MinimumFeeds)
+	enc.Encode(o.ForceSettlementDelaySec)
+	enc.Encode(o.ForceSettlementOffsetPercent)
+	enc.Encode(o.MaximumForceSettlementVolume)
+	enc.Encode(o.ShortBackingAsset)
+	enc.Encode(typedExtensions{&o.Extensions, bitassetOptionsExtensions})
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (o *BitassetOptions) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	dec.Decode(&o.FeedLifetimeSec)
+	dec.Decode(&o.MinimumFeeds)
+	dec.Decode(&o.ForceSettlementDelaySec)
+	dec.Decode(&o.ForceSettlementOffsetPercent)
+	dec.Decode(&o.MaximumForceSettlementVolume)
+	o.ShortBackingAsset = protocolID(assetObjectType)
+	dec.Decode(&o.ShortBackingAsset)
+	dec.Decode(typedExtensions{&o.Extensions, bitassetOptionsExtensions})
+	return dec.Err()
+}
+
+// bitassetOptionsExtensions are the fields of the extensions of bitasset_options
+var bitassetOptionsExtensions = []extensionField{
+	{"initial_collateral_ratio", func() interface{} { return new(uint16) }},
+	{"maintenance_collateral_ratio", func() interface{} { return new(uint16) }},
+	{"maximum_short_squeeze_ratio", func() interface{} { return new(uint16) }},
+	{"margin_call_fee_ratio", func() interface{} { return new(uint16) }},
+	{"force_settle_fee_percent", func() interface{} { return new(uint16) }},
+	{"black_swan_response_method", func() interface{} { return new(uint8) }},
+}
+
+// PriceFeed is the price of a market issued asset published by a feed producer
+type PriceFeed struct {
+	SettlementPrice            Price  `json:"settlement_price"`
+	MaintenanceCollateralRatio uint16 `json:"maintenance_collateral_ratio"`
+	MaximumShortSqueezeRatio   uint16 `json:"maximum_short_squeeze_ratio"`
+	CoreExchangeRate           Price  `json:"core_exchange_rate"`
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (f PriceFeed) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.Encode(f.SettlementPrice)
+	enc.Encode(f.MaintenanceCollateralRatio)
+	enc.Encode(f.MaximumShortSqueezeRatio)
+	enc.Encode(f.CoreExchangeRate)
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (f *PriceFeed) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	dec.Decode(&f.SettlementPrice)
+	dec.Decode(&f.MaintenanceCollateralRatio)
+	dec.Decode(&f.MaximumShortSqueezeRatio)
+	dec.Decode(&f.CoreExchangeRate)
+	return dec.Err()
+}
+
+// AssetCreateOperation creates a user issued or a market issued asset
+type AssetCreateOperation struct {
+	Fee                AssetAmount      `json:"fee"`
+	Issuer             ObjectID         `json:"issuer"`
+	Symbol             string           `json:"symbol"`
+	Precision          uint8            `json:"precision"`
+	CommonOptions      AssetOptions     `json:"common_options"`
+	BitassetOpts       *BitassetOptions `json:"bitasset_opts,omitempty"`
+	IsPredictionMarket bool             `json:"is_prediction_market"`
+	Extensions         json.RawMessage  `json:"extensions"`
+}
+
+func (op *AssetCreateOperation) Type() OpType { return AssetCreateOpType }
+
+func (op *AssetCreateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Issuer)
+	enc.Encode(op.Symbol)
+	enc.Encode(op.Precision)
+	enc.Encode(op.CommonOptions)
+	enc.EncodeOptional(op.BitassetOpts)
+	enc.EncodeBool(op.IsPredictionMarket)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *AssetCreateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Issuer = protocolID(accountObjectType)
+	dec.Decode(&op.Issuer)
+	dec.Decode(&op.Symbol)
+	dec.Decode(&op.Precision)
+	dec.Decode(&op.CommonOptions)
+	op.BitassetOpts = nil
+	dec.DecodeOptional(func() error {
+		op.BitassetOpts = &BitassetOptions{}
+		return decoder.Decode(op.BitassetOpts)
+	})
+	op.IsPredictionMarket = dec.DecodeBool()
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// AssetUpdateOperation changes the options of an asset
+type AssetUpdateOperation struct {
+	Fee           AssetAmount     `json:"fee"`
+	Issuer        ObjectID        `json:"issuer"`
+	AssetToUpdate ObjectID        `json:"asset_to_update"`
+	NewIssuer     *ObjectID       `json:"new_issuer,omitempty"`
+	NewOptions    AssetOptions    `json:"new_options"`
+	Extensions    json.RawMessage `json:"extensions"`
+}
+
+func (op *AssetUpdateOperation) Type() OpType { return AssetUpdateOpType }
+
+func (op *AssetUpdateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Issuer)
+	enc.Encode(op.AssetToUpdate)
+	enc.EncodeOptional(op.NewIssuer)
+	enc.Encode(op.NewOptions)
+	enc.Encode(typedExtensions{&op.Extensions, assetUpdateExtensions})
+	return enc.Err()
+}
+
+func (op *AssetUpdateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Issuer = protocolID(accountObjectType)
+	dec.Decode(&op.Issuer)
+	op.AssetToUpdate = protocolID(assetObjectType)
+	dec.Decode(&op.AssetToUpdate)
+	op.NewIssuer = nil
+	dec.DecodeOptional(func() error {
+		id := protocolID(accountObjectType)
+		op.NewIssuer = &id
+		return decoder.Decode(op.NewIssuer)
+	})
+	dec.Decode(&op.NewOptions)
+	dec.Decode(typedExtensions{&op.Extensions, assetUpdateExtensions})
+	return dec.Err()
+}
+
+// assetUpdateExtensions are the fields of the extensions of asset_update
+var assetUpdateExtensions = []extensionField{
+	{"new_precision", func() interface{} { return new(uint8) }},
+	{"skip_core_exchange_rate", func() interface{} { return new(bool) }},
+}
+
+// AssetUpdateBitassetOperation changes the options of a market issued asset
+type AssetUpdateBitassetOperation struct {
+	Fee           AssetAmount     `json:"fee"`
+	Issuer        ObjectID        `json:"issuer"`
+	AssetToUpdate ObjectID        `json:"asset_to_update"`
+	NewOptions    BitassetOptions `json:"new_options"`
+	Extensions    json.RawMessage `json:"extensions"`
+}
+
+func (op *AssetUpdateBitassetOperation) Type() OpType { return AssetUpdateBitassetOpType }
+
+func (op *AssetUpdateBitassetOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Issuer)
+	enc.Encode(op.AssetToUpdate)
+	enc.Encode(op.NewOptions)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *AssetUpdateBitassetOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Issuer = protocolID(accountObjectType)
+	dec.Decode(&op.Issuer)
+	op.AssetToUpdate = protocolID(assetObjectType)
+	dec.Decode(&op.AssetToUpdate)
+	dec.Decode(&op.NewOptions)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// AssetUpdateFeedProducersOperation sets the feed producers of a market issued asset
+type AssetUpdateFeedProducersOperation struct {
+	Fee              AssetAmount     `json:"fee"`
+	Issuer           ObjectID        `json:"issuer"`
+	AssetToUpdate    ObjectID        `json:"asset_to_update"`
+	NewFeedProducers []ObjectID      `json:"new_feed_producers"`
+	Extensions       json.RawMessage `json:"extensions"`
+}
+
+func (op *AssetUpdateFeedProducersOperation) Type() OpType { return AssetUpdateFeedProducersOpType }
+
+func (op *AssetUpdateFeedProducersOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Issuer)
+	enc.Encode(op.AssetToUpdate)
+	enc.Encode(accountSet(op.NewFeedProducers))
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *AssetUpdateFeedProducersOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Issuer = protocolID(accountObjectType)
+	dec.Decode(&op.Issuer)
+	op.AssetToUpdate = protocolID(assetObjectType)
+	dec.Decode(&op.AssetToUpdate)
+	dec.Decode((*accountSet)(&op.NewFeedProducers))
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// AssetIssueOperation issues a user issued asset to an account
+type AssetIssueOperation struct {
+	Fee            AssetAmount     `json:"fee"`
+	Issuer         ObjectID        `json:"issuer"`
+	AssetToIssue   AssetAmount     `json:"asset_to_issue"`
+	IssueToAccount ObjectID        `json:"issue_to_account"`
+	Memo           *Memo           `json:"memo,omitempty"`
+	Extensions     json.RawMessage `json:"extensions"`
+}
+
+func (op *AssetIssueOperation) Type() OpType { return AssetIssueOpType }
+
+func (op *AssetIssueOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Issuer)
+	enc.Encode(op.AssetToIssue)
+	enc.Encode(op.IssueToAccount)
+	enc.EncodeOptional(op.Memo)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *AssetIssueOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Issuer = protocolID(accountObjectType)
+	dec.Decode(&op.Issuer)
+	dec.Decode(&op.AssetToIssue)
+	op.IssueToAccount = protocolID(accountObjectType)
+	dec.Decode(&op.IssueToAccount)
+	op.Memo = nil
+	dec.DecodeOptional(func() error {
+		op.Memo = &Memo{}
+		return decoder.Decode(op.Memo)
+	})
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// AssetReserveOperation burns an amount of an asset, taking it out of the supply
+type AssetReserveOperation struct {
+	Fee             AssetAmount     `json:"fee"`
+	Payer           ObjectID        `json:"payer"`
+	AmountToReserve AssetAmount     `json:"amount_to_reserve"`
+	Extensions      json.RawMessage `json:"extensions"`
+}
+
+func (op *AssetReserveOperation) Type() OpType { return AssetReserveOpType }
+
+func (op *AssetReserveOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Payer)
+	enc.Encode(op.AmountToReserve)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *AssetReserveOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Payer = protocolID(accountObjectType)
+	dec.Decode(&op.Payer)
+	dec.Decode(&op.AmountToReserve)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// AssetFundFeePoolOperation adds core asset to the fee pool of an asset
+type AssetFundFeePoolOperation struct {
+	Fee         AssetAmount     `json:"fee"`
+	FromAccount ObjectID        `json:"from_account"`
+	AssetID     ObjectID        `json:"asset_id"`
+	Amount      Suint64         `json:"amount"`
+	Extensions  json.RawMessage `json:"extensions"`
+}
+
+func (op *AssetFundFeePoolOperation) Type() OpType { return AssetFundFeePoolOpType }
+
+func (op *AssetFundFeePoolOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.FromAccount)
+	enc.Encode(op.AssetID)
+	enc.Encode(op.Amount)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *AssetFundFeePoolOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.FromAccount = protocolID(accountObjectType)
+	dec.Decode(&op.FromAccount)
+	op.AssetID = protocolID(assetObjectType)
+	dec.Decode(&op.AssetID)
+	dec.Decode(&op.Amount)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// AssetSettleOperation asks the settlement of a market issued asset
+type AssetSettleOperation struct {
+	Fee        AssetAmount     `json:"fee"`
+	Account    ObjectID        `json:"account"`
+	Amount     AssetAmount     `json:"amount"`
+	Extensions json.RawMessage `json:"extensions"`
+}
+
+func (op *AssetSettleOperation) Type() OpType { return AssetSettleOpType }
+
+func (op *AssetSettleOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Account)
+	enc.Encode(op.Amount)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *AssetSettleOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Account = protocolID(accountObjectType)
+	dec.Decode(&op.Account)
+	dec.Decode(&op.Amount)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// AssetGlobalSettleOperation settles every position of a market issued asset at a price
+type AssetGlobalSettleOperation struct {
+	Fee           AssetAmount     `json:"fee"`
+	Issuer        ObjectID        `json:"issuer"`
+	AssetToSettle ObjectID        `json:"asset_to_settle"`
+	SettlePrice   Price           `json:"settle_price"`
+	Extensions    json.RawMessage `json:"extensions"`
+}
+
+func (op *AssetGlobalSettleOperation) Type() OpType { return AssetGlobalSettleOpType }
+
+func (op *AssetGlobalSettleOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Issuer)
+	enc.Encode(op.AssetToSettle)
+	enc.Encode(op.SettlePrice)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *AssetGlobalSettleOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Issuer = protocolID(accountObjectType)
+	dec.Decode(&op.Issuer)
+	op.AssetToSettle = protocolID(assetObjectType)
+	dec.Decode(&op.AssetToSettle)
+	dec.Decode(&op.SettlePrice)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// AssetPublishFeedOperation publishes a price feed of a market issued asset
+type AssetPublishFeedOperation struct {
+	Fee        AssetAmount     `json:"fee"`
+	Publisher  ObjectID        `json:"publisher"`
+	AssetID    ObjectID        `json:"asset_id"`
+	Feed       PriceFeed       `json:"feed"`
+	Extensions json.RawMessage `json:"extensions"`
+}
+
+func (op *AssetPublishFeedOperation) Type() OpType { return AssetPublishFeedOpType }
+
+func (op *AssetPublishFeedOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Publisher)
+	enc.Encode(op.AssetID)
+	enc.Encode(op.Feed)
+	enc.Encode(typedExtensions{&op.Extensions, assetPublishFeedExtensions})
+	return enc.Err()
+}
+
+func (op *AssetPublishFeedOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Publisher = protocolID(accountObjectType)
+	dec.Decode(&op.Publisher)
+	op.AssetID = protocolID(assetObjectType)
+	dec.Decode(&op.AssetID)
+	dec.Decode(&op.Feed)
+	dec.Decode(typedExtensions{&op.Extensions, assetPublishFeedExtensions})
+	return dec.Err()
+}
+
+// assetPublishFeedExtensions are the fields of the extensions of asset_publish_feed
+var assetPublishFeedExtensions = []extensionField{
+	{"initial_collateral_ratio", func() interface{} { return new(uint16) }},
+}
+
+// OverrideTransferOperation lets the issuer move its asset between two accounts
+type OverrideTransferOperation struct {
+	Fee        AssetAmount     `json:"fee"`
+	Issuer     ObjectID        `json:"issuer"`
+	From       ObjectID        `json:"from"`
+	To         ObjectID        `json:"to"`
+	Amount     AssetAmount     `json:"amount"`
+	Memo       *Memo           `json:"memo,omitempty"`
+	Extensions json.RawMessage `json:"extensions"`
+}
+
+func (op *OverrideTransferOperation) Type() OpType { return OverrideTransferOpType }
+
+func (op *OverrideTransferOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Issuer)
+	enc.Encode(op.From)
+	enc.Encode(op.To)
+	enc.Encode(op.Amount)
+	enc.EncodeOptional(op.Memo)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *OverrideTransferOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Issuer = protocolID(accountObjectType)
+	dec.Decode(&op.Issuer)
+	op.From = protocolID(accountObjectType)
+	dec.Decode(&op.From)
+	op.To = protocolID(accountObjectType)
+	dec.Decode(&op.To)
+	dec.Decode(&op.Amount)
+	op.Memo = nil
+	dec.DecodeOptional(func() error {
+		op.Memo = &Memo{}
+		return decoder.Decode(op.Memo)
+	})
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// AssetSettleCancelOperation is the virtual operation of a cancelled settlement
+type AssetSettleCancelOperation struct {
+	Fee        AssetAmount     `json:"fee"`
+	Settlement ObjectID        `json:"settlement"`
+	Account    ObjectID        `json:"account"`
+	Amount     AssetAmount     `json:"amount"`
+	Extensions json.RawMessage `json:"extensions"`
+}
+
+func (op *AssetSettleCancelOperation) Type() OpType { return AssetSettleCancelOpType }
+
+func (op *AssetSettleCancelOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Settlement)
+	enc.Encode(op.Account)
+	enc.Encode(op.Amount)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *AssetSettleCancelOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Settlement = protocolID(forceSettlementObjectType)
+	dec.Decode(&op.Settlement)
+	op.Account = protocolID(accountObjectType)
+	dec.Decode(&op.Account)
+	dec.Decode(&op.Amount)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// AssetClaimFeesOperation claims the market fees collected by an asset
+type AssetClaimFeesOperation struct {
+	Fee           AssetAmount     `json:"fee"`
+	Issuer        ObjectID        `json:"issuer"`
+	AmountToClaim AssetAmount     `json:"amount_to_claim"`
+	Extensions    json.RawMessage `json:"extensions"`
+}
+
+func (op *AssetClaimFeesOperation) Type() OpType { return AssetClaimFeesOpType }
+
+func (op *AssetClaimFeesOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Issuer)
+	enc.Encode(op.AmountToClaim)
+	enc.Encode(typedExtensions{&op.Extensions, assetClaimFeesExtensions})
+	return enc.Err()
+}
+
+func (op *AssetClaimFeesOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Issuer = protocolID(accountObjectType)
+	dec.Decode(&op.Issuer)
+	dec.Decode(&op.AmountToClaim)
+	dec.Decode(typedExtensions{&op.Extensions, assetClaimFeesExtensions})
+	return dec.Err()
+}
+
+// assetClaimFeesExtensions are the fields of the extensions of asset_claim_fees
+var assetClaimFeesExtensions = []extensionField{
+	{"claim_from_asset_id", func() interface{} {
+		id := protocolID(assetObjectType)
+		return &id
+	}},
+}
+
+// FbaDistributeOperation is the virtual operation of a fee backed asset distribution
+type FbaDistributeOperation struct {
+	Fee       AssetAmount `json:"fee"`
+	AccountID ObjectID    `json:"account_id"`
+	FbaID     ObjectID    `json:"fba_id"`
+	Amount    Suint64     `json:"amount"`
+}
+
+func (op *FbaDistributeOperation) Type() OpType { return FbaDistributeOpType }
+
+func (op *FbaDistributeOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.AccountID)
+	enc.Encode(op.FbaID)
+	enc.Encode(op.Amount)
+	return enc.Err()
+}
+
+func (op *FbaDistributeOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.AccountID = protocolID(accountObjectType)
+	dec.Decode(&op.AccountID)
+	op.FbaID = implementationID(fbaAccumulatorObjectType)
+	dec.Decode(&op.FbaID)
+	dec.Decode(&op.Amount)
+	return dec.Err()
+}
+
+// BidCollateralOperation bids collateral for the debt of a globally settled asset
+type BidCollateralOperation struct {
+	Fee                  AssetAmount     `json:"fee"`
+	Bidder               ObjectID        `json:"bidder"`
+	AdditionalCollateral AssetAmount     `json:"additional_collateral"`
+	DebtCovered          AssetAmount     `json:"debt_covered"`
+	Extensions           json.RawMessage `json:"extensions"`
+}
+
+func (op *BidCollateralOperation) Type() OpType { return BidCollateralOpType }
+
+func (op *BidCollateralOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Bidder)
+	enc.Encode(op.AdditionalCollateral)
+	enc.Encode(op.DebtCovered)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *BidCollateralOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Bidder = protocolID(accountObjectType)
+	dec.Decode(&op.Bidder)
+	dec.Decode(&op.AdditionalCollateral)
+	dec.Decode(&op.DebtCovered)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// ExecuteBidOperation is the virtual operation of an executed collateral bid
+type ExecuteBidOperation struct {
+	Fee        AssetAmount `json:"fee"`
+	Bidder     ObjectID    `json:"bidder"`
+	Debt       AssetAmount `json:"debt"`
+	Collateral AssetAmount `json:"collateral"`
+}
+
+func (op *ExecuteBidOperation) Type() OpType { return ExecuteBidOpType }
+
+func (op *ExecuteBidOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Bidder)
+	enc.Encode(op.Debt)
+	enc.Encode(op.Collateral)
+	return enc.Err()
+}
+
+func (op *ExecuteBidOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Bidder = protocolID(accountObjectType)
+	dec.Decode(&op.Bidder)
+	dec.Decode(&op.Debt)
+	dec.Decode(&op.Collateral)
+	return dec.Err()
+}
+
+// AssetClaimPoolOperation takes core asset back from the fee pool of an asset
+type AssetClaimPoolOperation struct {
+	Fee           AssetAmount     `json:"fee"`
+	Issuer        ObjectID        `json:"issuer"`
+	AssetID       ObjectID        `json:"asset_id"`
+	AmountToClaim AssetAmount     `json:"amount_to_claim"`
+	Extensions    json.RawMessage `json:"extensions"`
+}
+
+func (op *AssetClaimPoolOperation) Type() OpType { return AssetClaimPoolOpType }
+
+func (op *AssetClaimPoolOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Issuer)
+	enc.Encode(op.AssetID)
+	enc.Encode(op.AmountToClaim)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *AssetClaimPoolOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Issuer = protocolID(accountObjectType)
+	dec.Decode(&op.Issuer)
+	op.AssetID = protocolID(assetObjectType)
+	dec.Decode(&op.AssetID)
+	dec.Decode(&op.AmountToClaim)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// AssetUpdateIssuerOperation transfers an asset to a new issuer
+type AssetUpdateIssuerOperation struct {
+	Fee           AssetAmount     `json:"fee"`
+	Issuer        ObjectID        `json:"issuer"`
+	AssetToUpdate ObjectID        `json:"asset_to_update"`
+	NewIssuer     ObjectID        `json:"new_issuer"`
+	Extensions    json.RawMessage `json:"extensions"`
+}
+
+func (op *AssetUpdateIssuerOperation) Type() OpType { return AssetUpdateIssuerOpType }
+
+func (op *AssetUpdateIssuerOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Issuer)
+	enc.Encode(op.AssetToUpdate)
+	enc.Encode(op.NewIssuer)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *AssetUpdateIssuerOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Issuer = protocolID(accountObjectType)
+	dec.Decode(&op.Issuer)
+	op.AssetToUpdate = protocolID(assetObjectType)
+	dec.Decode(&op.AssetToUpdate)
+	op.NewIssuer = protocolID(accountObjectType)
+	dec.Decode(&op.NewIssuer)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
@@ -0,0 +1,670 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/pkg/errors"
+)
+
+// WitnessCreateOperation makes an account a witness candidate
+type WitnessCreateOperation struct {
+	Fee             AssetAmount `json:"fee"`
+	WitnessAccount  ObjectID    `json:"witness_account"`
+	URL             string      `json:"url"`
+	BlockSigningKey PublicKey   `json:"block_signing_key"`
+}
+
+func (op *WitnessCreateOperation) Type() OpType { return WitnessCreateOpType }
+
+func (op *WitnessCreateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.WitnessAccount)
+	enc.Encode(op.URL)
+	enc.Encode(op.BlockSigningKey)
+	return enc.Err()
+}
+
+func (op *WitnessCreateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.WitnessAccount = protocolID(accountObjectType)
+	dec.Decode(&op.WitnessAccount)
+	dec.Decode(&op.URL)
+	dec.Decode(&op.BlockSigningKey)
+	return dec.Err()
+}
+
+// WitnessUpdateOperation changes the url or the signing key of a witness
+type WitnessUpdateOperation struct {
+	Fee            AssetAmount `json:"fee"`
+	Witness        ObjectID    `json:"witness"`
+	WitnessAccount ObjectID    `json:"witness_account"`
+	NewURL         *string     `json:"new_url,omitempty"`
+	NewSigningKey  *PublicKey  `json:"new_signing_key,omitempty"`
+}
+
+func (op *WitnessUpdateOperation) Type() OpType { return WitnessUpdateOpType }
+
+func (op *WitnessUpdateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Witness)
+	enc.Encode(op.WitnessAccount)
+	enc.EncodeOptional(op.NewURL)
+	enc.EncodeOptional(op.NewSigningKey)
+	return enc.Err()
+}
+
+func (op *WitnessUpdateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Witness = protocolID(witnessObjectType)
+	dec.Decode(&op.Witness)
+	op.WitnessAccount = protocolID(accountObjectType)
+	dec.Decode(&op.WitnessAccount)
+	op.NewURL = nil
+	dec.DecodeOptional(func() error {
+		op.NewURL = new(string)
+		return decoder.Decode(op.NewURL)
+	})
+	op.NewSigningKey = nil
+	dec.DecodeOptional(func() error {
+		op.NewSigningKey = new(PublicKey)
+		return decoder.Decode(op.NewSigningKey)
+	})
+	return dec.Err()
+}
+
+// ProposedOperation is an operation of a proposal
+type ProposedOperation struct {
+	Op Operation
+}
+
+func (p ProposedOperation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"op": &operationTuple{Type: p.Op.Type(), Data: p.Op},
+	})
+}
+
+func (p *ProposedOperation) UnmarshalJSON(b []byte) error {
+	var wrapper struct {
+		Op json.RawMessage `json:"op"`
+	}
+	if err := json.Unmarshal(b, &wrapper); err != nil {
+		return err
+	}
+
+	var ops Operations
+	if err := json.Unmarshal([]byte("["+string(wrapper.Op)+"]"), &ops); err != nil {
+		return err
+	}
+	if len(ops) != 1 {
+		return errors.New("invalid proposed operation format: should be op")
+	}
+	p.Op = ops[0]
+	return nil
+}
+
+// NewProposalCreateOperation returns a proposal of the operations paid by the fee
+// paying account. A review period of 0 leaves the proposal without one.
+func NewProposalCreateOperation(feePayingAccount ObjectID, expiration time.Time, reviewPeriod time.Duration, ops ...Operation) *ProposalCreateOperation {
+	op := &ProposalCreateOperation{
+		FeePayingAccount: feePayingAccount,
+		ExpirationTime:   NewTime(expiration.UTC().Truncate(time.Second)),
+		ProposedOps:      make([]ProposedOperation, 0, len(ops)),
+		Extensions:       json.RawMessage("[]"),
+	}
+	for _, proposed := range ops {
+		op.ProposedOps = append(op.ProposedOps, ProposedOperation{Op: proposed})
+	}
+	if reviewPeriod > 0 {
+		seconds := uint32(reviewPeriod / time.Second)
+		op.ReviewPeriodSeconds = &seconds
+	}
+	return op
+}
+
+// ProposalCreateOperation proposes operations to be approved by their authorities
+type ProposalCreateOperation struct {
+	Fee                 AssetAmount         `json:"fee"`
+	FeePayingAccount    ObjectID            `json:"fee_paying_account"`
+	ExpirationTime      Time                `json:"expiration_time"`
+	ProposedOps         []ProposedOperation `json:"proposed_ops"`
+	ReviewPeriodSeconds *uint32             `json:"review_period_seconds,omitempty"`
+	Extensions          json.RawMessage     `json:"extensions"`
+}
+
+func (op *ProposalCreateOperation) Marshal(encoder *encoding.Encoder) error {
+	exts, err := parseFutureExtensions(op.Extensions)
+	if err != nil {
+		return err
+	}
+
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.FeePayingAccount)
+	enc.Encode(op.ExpirationTime)
+	enc.EncodeFlatSet(len(op.ProposedOps), func(i int) error {
+		return encoder.Encode(op.ProposedOps[i].Op)
+	})
+	enc.EncodeOptional(op.ReviewPeriodSeconds)
+	enc.Encode(exts)
+	return enc.Err()
+}
+
+func (op *ProposalCreateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.FeePayingAccount = protocolID(accountObjectType)
+	dec.Decode(&op.FeePayingAccount)
+	dec.Decode(&op.ExpirationTime)
+
+	op.ProposedOps = []ProposedOperation{}
+	dec.DecodeFlatSet(func(int) error {
+		proposed, err := decodeOperation(decoder)
+		if err != nil {
+			return err
+		}
+		op.ProposedOps = append(op.ProposedOps, ProposedOperation{Op: proposed})
+		return nil
+	})
+
+	op.ReviewPeriodSeconds = nil
+	dec.DecodeOptional(func() error {
+		op.ReviewPeriodSeconds = new(uint32)
+		return decoder.Decode(op.ReviewPeriodSeconds)
+	})
+
+	var exts futureExtensions
+	dec.Decode(&exts)
+	if err := dec.Err(); err != nil {
+		return err
+	}
+	raw, err := json.Marshal([]json.RawMessage(exts))
+	op.Extensions = raw
+	return err
+}
+
+func (op *ProposalCreateOperation) Type() OpType { return ProposalCreateOpType }
+
+// NewProposalUpdateOperation returns an update of the proposal paid by the fee
+// paying account, without any approval to add or remove yet
+func NewProposalUpdateOperation(feePayingAccount, proposal ObjectID) *ProposalUpdateOperation {
+	return &ProposalUpdateOperation{
+		FeePayingAccount:        feePayingAccount,
+		Proposal:                proposal,
+		ActiveApprovalsToAdd:    []ObjectID{},
+		ActiveApprovalsToRemove: []ObjectID{},
+		OwnerApprovalsToAdd:     []ObjectID{},
+		OwnerApprovalsToRemove:  []ObjectID{},
+		KeyApprovalsToAdd:       []PublicKey{},
+		KeyApprovalsToRemove:    []PublicKey{},
+		Extensions:              json.RawMessage("[]"),
+	}
+}
+
+// ProposalUpdateOperation adds or removes approvals of a proposal
+type ProposalUpdateOperation struct {
+	Fee                     AssetAmount     `json:"fee"`
+	FeePayingAccount        ObjectID        `json:"fee_paying_account"`
+	Proposal                ObjectID        `json:"proposal"`
+	ActiveApprovalsToAdd    []ObjectID      `json:"active_approvals_to_add"`
+	ActiveApprovalsToRemove []ObjectID      `json:"active_approvals_to_remove"`
+	OwnerApprovalsToAdd     []ObjectID      `json:"owner_approvals_to_add"`
+	OwnerApprovalsToRemove  []ObjectID      `json:"owner_approvals_to_remove"`
+	KeyApprovalsToAdd       []PublicKey     `json:"key_approvals_to_add"`
+	KeyApprovalsToRemove    []PublicKey     `json:"key_approvals_to_remove"`
+	Extensions              json.RawMessage `json:"extensions"`
+}
+
+func (op *ProposalUpdateOperation) Marshal(encoder *encoding.Encoder) error {
+	exts, err := parseFutureExtensions(op.Extensions)
+	if err != nil {
+		return err
+	}
+
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.FeePayingAccount)
+	enc.Encode(op.Proposal)
+	enc.Encode(accountSet(op.ActiveApprovalsToAdd))
+	enc.Encode(accountSet(op.ActiveApprovalsToRemove))
+	enc.Encode(accountSet(op.OwnerApprovalsToAdd))
+	enc.Encode(accountSet(op.OwnerApprovalsToRemove))
+	enc.Encode(keySet(op.KeyApprovalsToAdd))
+	enc.Encode(keySet(op.KeyApprovalsToRemove))
+	enc.Encode(exts)
+	return enc.Err()
+}
+
+func (op *ProposalUpdateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.FeePayingAccount = protocolID(accountObjectType)
+	dec.Decode(&op.FeePayingAccount)
+	op.Proposal = protocolID(proposalObjectType)
+	dec.Decode(&op.Proposal)
+	dec.Decode((*accountSet)(&op.ActiveApprovalsToAdd))
+	dec.Decode((*accountSet)(&op.ActiveApprovalsToRemove))
+	dec.Decode((*accountSet)(&op.OwnerApprovalsToAdd))
+	dec.Decode((*accountSet)(&op.OwnerApprovalsToRemove))
+	dec.Decode((*keySet)(&op.KeyApprovalsToAdd))
+	dec.Decode((*keySet)(&op.KeyApprovalsToRemove))
+
+	var exts futureExtensions
+	dec.Decode(&exts)
+	if err := dec.Err(); err != nil {
+		return err
+	}
+	raw, err := json.Marshal([]json.RawMessage(exts))
+	op.Extensions = raw
+	return err
+}
+
+func (op *ProposalUpdateOperation) Type() OpType { return ProposalUpdateOpType }
+
+// NewProposalDeleteOperation returns the veto of the proposal by the fee paying
+// account, with its active authority unless usingOwnerAuthority
+func NewProposalDeleteOperation(feePayingAccount, proposal ObjectID, usingOwnerAuthority bool) *ProposalDeleteOperation {
+	return &ProposalDeleteOperation{
+		FeePayingAccount:    feePayingAccount,
+		UsingOwnerAuthority: usingOwnerAuthority,
+		Proposal:            proposal,
+		Extensions:          json.RawMessage("[]"),
+	}
+}
+
+// ProposalDeleteOperation vetoes a proposal
+type ProposalDeleteOperation struct {
+	Fee                 AssetAmount     `json:"fee"`
+	FeePayingAccount    ObjectID        `json:"fee_paying_account"`
+	UsingOwnerAuthority bool            `json:"using_owner_authority"`
+	Proposal            ObjectID        `json:"proposal"`
+	Extensions          json.RawMessage `json:"extensions"`
+}
+
+func (op *ProposalDeleteOperation) Marshal(encoder *encoding.Encoder) error {
+	exts, err := parseFutureExtensions(op.Extensions)
+	if err != nil {
+		return err
+	}
+
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.FeePayingAccount)
+	enc.EncodeBool(op.UsingOwnerAuthority)
+	enc.Encode(op.Proposal)
+	enc.Encode(exts)
+	return enc.Err()
+}
+
+func (op *ProposalDeleteOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.FeePayingAccount = protocolID(accountObjectType)
+	dec.Decode(&op.FeePayingAccount)
+	op.UsingOwnerAuthority = dec.DecodeBool()
+	op.Proposal = protocolID(proposalObjectType)
+	dec.Decode(&op.Proposal)
+
+	var exts futureExtensions
+	dec.Decode(&exts)
+	if err := dec.Err(); err != nil {
+		return err
+	}
+	raw, err := json.Marshal([]json.RawMessage(exts))
+	op.Extensions = raw
+	return err
+}
+
+func (op *ProposalDeleteOperation) Type() OpType { return ProposalDeleteOpType }
+
+// CommitteeMemberCreateOperation makes an account a committee member candidate
+type CommitteeMemberCreateOperation struct {
+	Fee                    AssetAmount `json:"fee"`
+	CommitteeMemberAccount ObjectID    `json:"committee_member_account"`
+	URL                    string      `json:"url"`
+}
+
+func (op *CommitteeMemberCreateOperation) Type() OpType { return CommitteeMemberCreateOpType }
+
+func (op *CommitteeMemberCreateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.CommitteeMemberAccount)
+	enc.Encode(op.URL)
+	return enc.Err()
+}
+
+func (op *CommitteeMemberCreateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.CommitteeMemberAccount = protocolID(accountObjectType)
+	dec.Decode(&op.CommitteeMemberAccount)
+	dec.Decode(&op.URL)
+	return dec.Err()
+}
+
+// CommitteeMemberUpdateOperation changes the url of a committee member
+type CommitteeMemberUpdateOperation struct {
+	Fee                    AssetAmount `json:"fee"`
+	CommitteeMember        ObjectID    `json:"committee_member"`
+	CommitteeMemberAccount ObjectID    `json:"committee_member_account"`
+	NewURL                 *string     `json:"new_url,omitempty"`
+}
+
+func (op *CommitteeMemberUpdateOperation) Type() OpType { return CommitteeMemberUpdateOpType }
+
+func (op *CommitteeMemberUpdateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.CommitteeMember)
+	enc.Encode(op.CommitteeMemberAccount)
+	enc.EncodeOptional(op.NewURL)
+	return enc.Err()
+}
+
+func (op *CommitteeMemberUpdateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.CommitteeMember = protocolID(committeeMemberObjectType)
+	dec.Decode(&op.CommitteeMember)
+	op.CommitteeMemberAccount = protocolID(accountObjectType)
+	dec.Decode(&op.CommitteeMemberAccount)
+	op.NewURL = nil
+	dec.DecodeOptional(func() error {
+		op.NewURL = new(string)
+		return decoder.Decode(op.NewURL)
+	})
+	return dec.Err()
+}
+
+// CommitteeMemberUpdateGlobalParametersOperation changes the chain parameters,
+// applied at the next maintenance
+type CommitteeMemberUpdateGlobalParametersOperation struct {
+	Fee           AssetAmount     `json:"fee"`
+	NewParameters json.RawMessage `json:"new_parameters"`
+}
+
+func (op *CommitteeMemberUpdateGlobalParametersOperation) Type() OpType {
+	return CommitteeMemberUpdateGlobalParametersOpType
+}
+
+func (op *CommitteeMemberUpdateGlobalParametersOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(rawJSON{&op.NewParameters, new(chainParameters)})
+	return enc.Err()
+}
+
+func (op *CommitteeMemberUpdateGlobalParametersOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	dec.Decode(rawJSON{&op.NewParameters, new(chainParameters)})
+	return dec.Err()
+}
+
+// VestingBalanceCreateOperation creates a vesting balance owned by an account
+type VestingBalanceCreateOperation struct {
+	Fee     AssetAmount     `json:"fee"`
+	Creator ObjectID        `json:"creator"`
+	Owner   ObjectID        `json:"owner"`
+	Amount  AssetAmount     `json:"amount"`
+	Policy  json.RawMessage `json:"policy"`
+}
+
+func (op *VestingBalanceCreateOperation) Type() OpType { return VestingBalanceCreateOpType }
+
+func (op *VestingBalanceCreateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Creator)
+	enc.Encode(op.Owner)
+	enc.Encode(op.Amount)
+	enc.Encode(rawJSON{&op.Policy, new(vestingPolicyInitializer)})
+	return enc.Err()
+}
+
+func (op *VestingBalanceCreateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Creator = protocolID(accountObjectType)
+	dec.Decode(&op.Creator)
+	op.Owner = protocolID(accountObjectType)
+	dec.Decode(&op.Owner)
+	dec.Decode(&op.Amount)
+	dec.Decode(rawJSON{&op.Policy, new(vestingPolicyInitializer)})
+	return dec.Err()
+}
+
+// VestingBalanceWithdrawOperation withdraws the vested amount of a vesting balance
+type VestingBalanceWithdrawOperation struct {
+	Fee            AssetAmount `json:"fee"`
+	VestingBalance ObjectID    `json:"vesting_balance"`
+	Owner          ObjectID    `json:"owner"`
+	Amount         AssetAmount `json:"amount"`
+}
+
+func (op *VestingBalanceWithdrawOperation) Type() OpType { return VestingBalanceWithdrawOpType }
+
+func (op *VestingBalanceWithdrawOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.VestingBalance)
+	enc.Encode(op.Owner)
+	enc.Encode(op.Amount)
+	return enc.Err()
+}
+
+func (op *VestingBalanceWithdrawOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.VestingBalance = protocolID(vestingBalanceObjectType)
+	dec.Decode(&op.VestingBalance)
+	op.Owner = protocolID(accountObjectType)
+	dec.Decode(&op.Owner)
+	dec.Decode(&op.Amount)
+	return dec.Err()
+}
+
+// WorkerCreateOperation proposes a worker paid by the chain
+type WorkerCreateOperation struct {
+	Fee           AssetAmount     `json:"fee"`
+	Owner         ObjectID        `json:"owner"`
+	WorkBeginDate Time            `json:"work_begin_date"`
+	WorkEndDate   Time            `json:"work_end_date"`
+	DailyPay      Suint64         `json:"daily_pay"`
+	Name          string          `json:"name"`
+	URL           string          `json:"url"`
+	Initializer   json.RawMessage `json:"initializer"`
+}
+
+func (op *WorkerCreateOperation) Type() OpType { return WorkerCreateOpType }
+
+func (op *WorkerCreateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Owner)
+	enc.Encode(op.WorkBeginDate)
+	enc.Encode(op.WorkEndDate)
+	enc.Encode(op.DailyPay)
+	enc.Encode(op.Name)
+	enc.Encode(op.URL)
+	enc.Encode(rawJSON{&op.Initializer, new(workerInitializer)})
+	return enc.Err()
+}
+
+func (op *WorkerCreateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Owner = protocolID(accountObjectType)
+	dec.Decode(&op.Owner)
+	dec.Decode(&op.WorkBeginDate)
+	dec.Decode(&op.WorkEndDate)
+	dec.Decode(&op.DailyPay)
+	dec.Decode(&op.Name)
+	dec.Decode(&op.URL)
+	dec.Decode(rawJSON{&op.Initializer, new(workerInitializer)})
+	return dec.Err()
+}
+
+// vestingPolicyInitializer is the vesting policy of a new vesting balance: linear,
+// coin days destroyed or instant
+type vestingPolicyInitializer struct {
+	staticVariant
+}
+
+func (p *vestingPolicyInitializer) UnmarshalJSON(b []byte) error {
+	return p.unmarshalJSON(b, vestingPolicyAlternatives)
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (p *vestingPolicyInitializer) Unmarshal(decoder *encoding.Decoder) error {
+	return p.unmarshal(decoder, vestingPolicyAlternatives)
+}
+
+func vestingPolicyAlternatives(tag uint64) (interface{}, error) {
+	switch tag {
+	case 0:
+		return new(linearVestingPolicy), nil
+	case 1:
+		return new(cddVestingPolicy), nil
+	case 2:
+		return new(voidT), nil
+	}
+	return nil, errors.Errorf("unknown vesting policy %d", tag)
+}
+
+// linearVestingPolicy vests the balance linearly after a cliff
+type linearVestingPolicy struct {
+	BeginTimestamp         Time   `json:"begin_timestamp"`
+	VestingCliffSeconds    uint32 `json:"vesting_cliff_seconds"`
+	VestingDurationSeconds uint32 `json:"vesting_duration_seconds"`
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (p linearVestingPolicy) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.Encode(p.BeginTimestamp)
+	enc.Encode(p.VestingCliffSeconds)
+	enc.Encode(p.VestingDurationSeconds)
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (p *linearVestingPolicy) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	dec.Decode(&p.BeginTimestamp)
+	dec.Decode(&p.VestingCliffSeconds)
+	dec.Decode(&p.VestingDurationSeconds)
+	return dec.Err()
+}
+
+// cddVestingPolicy vests the balance by the coin seconds it earned
+type cddVestingPolicy struct {
+	StartClaim     Time   `json:"start_claim"`
+	VestingSeconds uint32 `json:"vesting_seconds"`
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (p cddVestingPolicy) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.Encode(p.StartClaim)
+	enc.Encode(p.VestingSeconds)
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (p *cddVestingPolicy) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	dec.Decode(&p.StartClaim)
+	dec.Decode(&p.VestingSeconds)
+	return dec.Err()
+}
+
+// workerInitializer is the kind of a new worker: refunding its pay, vesting it or
+// burning it
+type workerInitializer struct {
+	staticVariant
+}
+
+func (w *workerInitializer) UnmarshalJSON(b []byte) error {
+	return w.unmarshalJSON(b, workerInitializerAlternatives)
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (w *workerInitializer) Unmarshal(decoder *encoding.Decoder) error {
+	return w.unmarshal(decoder, workerInitializerAlternatives)
+}
+
+func workerInitializerAlternatives(tag uint64) (interface{}, error) {
+	switch tag {
+	case 0, 2:
+		return new(voidT), nil
+	case 1:
+		return new(vestingBalanceWorker), nil
+	}
+	return nil, errors.Errorf("unknown worker initializer %d", tag)
+}
+
+// vestingBalanceWorker pays a worker into a vesting balance
+type vestingBalanceWorker struct {
+	PayVestingPeriodDays uint16 `json:"pay_vesting_period_days"`
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (w vestingBalanceWorker) Marshal(encoder *encoding.Encoder) error {
+	return encoder.Encode(w.PayVestingPeriodDays)
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (w *vestingBalanceWorker) Unmarshal(decoder *encoding.Decoder) error {
+	return decoder.Decode(&w.PayVestingPeriodDays)
+}
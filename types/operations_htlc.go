@@ -0,0 +1,510 @@
+package types
+
+import (
+	"encoding/json"
+
+	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/pkg/errors"
+)
+
+// hash algorithms of HtlcHash
+const (
+	HtlcHashRipemd160 = iota
+	HtlcHashSha1
+	HtlcHashSha256
+	HtlcHashHash160
+)
+
+// HtlcHash is the hash of the preimage of an htlc and its algorithm
+type HtlcHash struct {
+	Algorithm uint8
+	Hash      Buffer
+}
+
+func (h HtlcHash) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{h.Algorithm, h.Hash})
+}
+
+func (h *HtlcHash) UnmarshalJSON(b []byte) error {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(b, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return errors.New("invalid htlc hash format: should be algorithm, hash")
+	}
+	if err := json.Unmarshal(pair[0], &h.Algorithm); err != nil {
+		return err
+	}
+	return json.Unmarshal(pair[1], &h.Hash)
+}
+
+// htlcHashSizes are the sizes of the hashes by their algorithm
+var htlcHashSizes = []int{20, 20, 32, 20}
+
+// Marshal implements encoding.Marshaller interface.
+func (h HtlcHash) Marshal(encoder *encoding.Encoder) error {
+	if int(h.Algorithm) >= len(htlcHashSizes) {
+		return errors.Errorf("unknown htlc hash algorithm %d", h.Algorithm)
+	}
+	return encoder.EncodeStaticVariant(uint64(h.Algorithm), fixedBuffer{&h.Hash, htlcHashSizes[h.Algorithm]})
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (h *HtlcHash) Unmarshal(decoder *encoding.Decoder) error {
+	return decoder.DecodeStaticVariant(func(tag uint64) error {
+		if tag >= uint64(len(htlcHashSizes)) {
+			return errors.Errorf("unknown htlc hash algorithm %d", tag)
+		}
+		h.Algorithm = uint8(tag)
+		return decoder.Decode(fixedBuffer{&h.Hash, htlcHashSizes[tag]})
+	})
+}
+
+// HtlcCreateOperation locks an amount until the preimage of a hash is revealed
+type HtlcCreateOperation struct {
+	Fee                AssetAmount     `json:"fee"`
+	From               ObjectID        `json:"from"`
+	To                 ObjectID        `json:"to"`
+	Amount             AssetAmount     `json:"amount"`
+	PreimageHash       HtlcHash        `json:"preimage_hash"`
+	PreimageSize       uint16          `json:"preimage_size"`
+	ClaimPeriodSeconds uint32          `json:"claim_period_seconds"`
+	Extensions         json.RawMessage `json:"extensions"`
+}
+
+func (op *HtlcCreateOperation) Type() OpType { return HtlcCreateOpType }
+
+func (op *HtlcCreateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.From)
+	enc.Encode(op.To)
+	enc.Encode(op.Amount)
+	enc.Encode(op.PreimageHash)
+	enc.Encode(op.PreimageSize)
+	enc.Encode(op.ClaimPeriodSeconds)
+	enc.Encode(typedExtensions{&op.Extensions, htlcCreateExtensions})
+	return enc.Err()
+}
+
+func (op *HtlcCreateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.From = protocolID(accountObjectType)
+	dec.Decode(&op.From)
+	op.To = protocolID(accountObjectType)
+	dec.Decode(&op.To)
+	dec.Decode(&op.Amount)
+	dec.Decode(&op.PreimageHash)
+	dec.Decode(&op.PreimageSize)
+	dec.Decode(&op.ClaimPeriodSeconds)
+	dec.Decode(typedExtensions{&op.Extensions, htlcCreateExtensions})
+	return dec.Err()
+}
+
+// htlcCreateExtensions are the fields of the extensions of htlc_create
+var htlcCreateExtensions = []extensionField{
+	{"memo", func() interface{} { return new(Memo) }},
+}
+
+// HtlcRedeemOperation reveals the preimage of an htlc, paying its recipient
+type HtlcRedeemOperation struct {
+	Fee        AssetAmount     `json:"fee"`
+	HtlcID     ObjectID        `json:"htlc_id"`
+	Redeemer   ObjectID        `json:"redeemer"`
+	Preimage   Buffer          `json:"preimage"`
+	Extensions json.RawMessage `json:"extensions"`
+}
+
+func (op *HtlcRedeemOperation) Type() OpType { return HtlcRedeemOpType }
+
+func (op *HtlcRedeemOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.HtlcID)
+	enc.Encode(op.Redeemer)
+	enc.Encode(op.Preimage)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *HtlcRedeemOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.HtlcID = protocolID(htlcObjectType)
+	dec.Decode(&op.HtlcID)
+	op.Redeemer = protocolID(accountObjectType)
+	dec.Decode(&op.Redeemer)
+	dec.Decode(&op.Preimage)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// HtlcRedeemedOperation is the virtual operation of a redeemed htlc
+type HtlcRedeemedOperation struct {
+	Fee              AssetAmount `json:"fee"`
+	HtlcID           ObjectID    `json:"htlc_id"`
+	From             ObjectID    `json:"from"`
+	To               ObjectID    `json:"to"`
+	Redeemer         ObjectID    `json:"redeemer"`
+	Amount           AssetAmount `json:"amount"`
+	HtlcPreimageHash HtlcHash    `json:"htlc_preimage_hash"`
+	HtlcPreimageSize uint16      `json:"htlc_preimage_size"`
+}
+
+func (op *HtlcRedeemedOperation) Type() OpType { return HtlcRedeemedOpType }
+
+func (op *HtlcRedeemedOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.HtlcID)
+	enc.Encode(op.From)
+	enc.Encode(op.To)
+	enc.Encode(op.Redeemer)
+	enc.Encode(op.Amount)
+	enc.Encode(op.HtlcPreimageHash)
+	enc.Encode(op.HtlcPreimageSize)
+	return enc.Err()
+}
+
+func (op *HtlcRedeemedOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.HtlcID = protocolID(htlcObjectType)
+	dec.Decode(&op.HtlcID)
+	op.From = protocolID(accountObjectType)
+	dec.Decode(&op.From)
+	op.To = protocolID(accountObjectType)
+	dec.Decode(&op.To)
+	op.Redeemer = protocolID(accountObjectType)
+	dec.Decode(&op.Redeemer)
+	dec.Decode(&op.Amount)
+	dec.Decode(&op.HtlcPreimageHash)
+	dec.Decode(&op.HtlcPreimageSize)
+	return dec.Err()
+}
+
+// HtlcExtendOperation extends the claim period of an htlc
+type HtlcExtendOperation struct {
+	Fee          AssetAmount     `json:"fee"`
+	HtlcID       ObjectID        `json:"htlc_id"`
+	UpdateIssuer ObjectID        `json:"update_issuer"`
+	SecondsToAdd uint32          `json:"seconds_to_add"`
+	Extensions   json.RawMessage `json:"extensions"`
+}
+
+func (op *HtlcExtendOperation) Type() OpType { return HtlcExtendOpType }
+
+func (op *HtlcExtendOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.HtlcID)
+	enc.Encode(op.UpdateIssuer)
+	enc.Encode(op.SecondsToAdd)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *HtlcExtendOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.HtlcID = protocolID(htlcObjectType)
+	dec.Decode(&op.HtlcID)
+	op.UpdateIssuer = protocolID(accountObjectType)
+	dec.Decode(&op.UpdateIssuer)
+	dec.Decode(&op.SecondsToAdd)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// HtlcRefundOperation is the virtual operation of an expired htlc refunded to its sender
+type HtlcRefundOperation struct {
+	Fee                   AssetAmount `json:"fee"`
+	HtlcID                ObjectID    `json:"htlc_id"`
+	To                    ObjectID    `json:"to"`
+	OriginalHtlcRecipient ObjectID    `json:"original_htlc_recipient"`
+	HtlcAmount            AssetAmount `json:"htlc_amount"`
+	HtlcPreimageHash      HtlcHash    `json:"htlc_preimage_hash"`
+	HtlcPreimageSize      uint16      `json:"htlc_preimage_size"`
+}
+
+func (op *HtlcRefundOperation) Type() OpType { return HtlcRefundOpType }
+
+func (op *HtlcRefundOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.HtlcID)
+	enc.Encode(op.To)
+	enc.Encode(op.OriginalHtlcRecipient)
+	enc.Encode(op.HtlcAmount)
+	enc.Encode(op.HtlcPreimageHash)
+	enc.Encode(op.HtlcPreimageSize)
+	return enc.Err()
+}
+
+func (op *HtlcRefundOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.HtlcID = protocolID(htlcObjectType)
+	dec.Decode(&op.HtlcID)
+	op.To = protocolID(accountObjectType)
+	dec.Decode(&op.To)
+	op.OriginalHtlcRecipient = protocolID(accountObjectType)
+	dec.Decode(&op.OriginalHtlcRecipient)
+	dec.Decode(&op.HtlcAmount)
+	dec.Decode(&op.HtlcPreimageHash)
+	dec.Decode(&op.HtlcPreimageSize)
+	return dec.Err()
+}
+
+// TransferToBlindOperation moves a public balance to blinded outputs
+type TransferToBlindOperation struct {
+	Fee            AssetAmount     `json:"fee"`
+	Amount         AssetAmount     `json:"amount"`
+	From           ObjectID        `json:"from"`
+	BlindingFactor Buffer          `json:"blinding_factor"`
+	Outputs        json.RawMessage `json:"outputs"`
+}
+
+func (op *TransferToBlindOperation) Type() OpType { return TransferToBlindOpType }
+
+func (op *TransferToBlindOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Amount)
+	enc.Encode(op.From)
+	enc.Encode(fixedBuffer{&op.BlindingFactor, 32})
+	enc.Encode(rawJSON{&op.Outputs, new(blindOutputs)})
+	return enc.Err()
+}
+
+func (op *TransferToBlindOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	dec.Decode(&op.Amount)
+	op.From = protocolID(accountObjectType)
+	dec.Decode(&op.From)
+	dec.Decode(fixedBuffer{&op.BlindingFactor, 32})
+	dec.Decode(rawJSON{&op.Outputs, new(blindOutputs)})
+	return dec.Err()
+}
+
+// BlindTransferOperation moves blinded inputs to blinded outputs
+type BlindTransferOperation struct {
+	Fee     AssetAmount     `json:"fee"`
+	Inputs  json.RawMessage `json:"inputs"`
+	Outputs json.RawMessage `json:"outputs"`
+}
+
+func (op *BlindTransferOperation) Type() OpType { return BlindTransferOpType }
+
+func (op *BlindTransferOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(rawJSON{&op.Inputs, new(blindInputs)})
+	enc.Encode(rawJSON{&op.Outputs, new(blindOutputs)})
+	return enc.Err()
+}
+
+func (op *BlindTransferOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	dec.Decode(rawJSON{&op.Inputs, new(blindInputs)})
+	dec.Decode(rawJSON{&op.Outputs, new(blindOutputs)})
+	return dec.Err()
+}
+
+// TransferFromBlindOperation moves blinded inputs to a public balance
+type TransferFromBlindOperation struct {
+	Fee            AssetAmount     `json:"fee"`
+	Amount         AssetAmount     `json:"amount"`
+	To             ObjectID        `json:"to"`
+	BlindingFactor Buffer          `json:"blinding_factor"`
+	Inputs         json.RawMessage `json:"inputs"`
+}
+
+func (op *TransferFromBlindOperation) Type() OpType { return TransferFromBlindOpType }
+
+func (op *TransferFromBlindOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Amount)
+	enc.Encode(op.To)
+	enc.Encode(fixedBuffer{&op.BlindingFactor, 32})
+	enc.Encode(rawJSON{&op.Inputs, new(blindInputs)})
+	return enc.Err()
+}
+
+func (op *TransferFromBlindOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	dec.Decode(&op.Amount)
+	op.To = protocolID(accountObjectType)
+	dec.Decode(&op.To)
+	dec.Decode(fixedBuffer{&op.BlindingFactor, 32})
+	dec.Decode(rawJSON{&op.Inputs, new(blindInputs)})
+	return dec.Err()
+}
+
+// blindOutputs are the outputs of a blind transfer
+type blindOutputs []blindOutput
+
+// Marshal implements encoding.Marshaller interface.
+func (outputs blindOutputs) Marshal(encoder *encoding.Encoder) error {
+	return encoder.EncodeFlatSet(len(outputs), func(i int) error {
+		return encoder.Encode(outputs[i])
+	})
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (outputs *blindOutputs) Unmarshal(decoder *encoding.Decoder) error {
+	*outputs = blindOutputs{}
+	return decoder.DecodeFlatSet(func(int) error {
+		var output blindOutput
+		if err := decoder.Decode(&output); err != nil {
+			return err
+		}
+		*outputs = append(*outputs, output)
+		return nil
+	})
+}
+
+// blindOutput is a blinded amount, its commitment and the proof of its range,
+// owned by an authority
+type blindOutput struct {
+	Commitment  Buffer               `json:"commitment"`
+	RangeProof  Buffer               `json:"range_proof"`
+	Owner       Permission           `json:"owner"`
+	StealthMemo *stealthConfirmation `json:"stealth_memo,omitempty"`
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (o blindOutput) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.Encode(fixedBuffer{&o.Commitment, 33})
+	enc.Encode(o.RangeProof)
+	enc.Encode(o.Owner)
+	enc.EncodeOptional(o.StealthMemo)
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (o *blindOutput) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	dec.Decode(fixedBuffer{&o.Commitment, 33})
+	dec.Decode(&o.RangeProof)
+	dec.Decode(&o.Owner)
+	o.StealthMemo = nil
+	dec.DecodeOptional(func() error {
+		o.StealthMemo = &stealthConfirmation{}
+		return decoder.Decode(o.StealthMemo)
+	})
+	return dec.Err()
+}
+
+// stealthConfirmation is the memo of a blinded output encrypted to its recipient
+type stealthConfirmation struct {
+	OneTimeKey    PublicKey  `json:"one_time_key"`
+	To            *PublicKey `json:"to,omitempty"`
+	EncryptedMemo Buffer     `json:"encrypted_memo"`
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (c stealthConfirmation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.Encode(c.OneTimeKey)
+	enc.EncodeOptional(c.To)
+	enc.Encode(c.EncryptedMemo)
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (c *stealthConfirmation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	dec.Decode(&c.OneTimeKey)
+	c.To = nil
+	dec.DecodeOptional(func() error {
+		c.To = new(PublicKey)
+		return decoder.Decode(c.To)
+	})
+	dec.Decode(&c.EncryptedMemo)
+	return dec.Err()
+}
+
+// blindInputs are the inputs of a blind transfer
+type blindInputs []blindInput
+
+// Marshal implements encoding.Marshaller interface.
+func (inputs blindInputs) Marshal(encoder *encoding.Encoder) error {
+	return encoder.EncodeFlatSet(len(inputs), func(i int) error {
+		return encoder.Encode(inputs[i])
+	})
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (inputs *blindInputs) Unmarshal(decoder *encoding.Decoder) error {
+	*inputs = blindInputs{}
+	return decoder.DecodeFlatSet(func(int) error {
+		var input blindInput
+		if err := decoder.Decode(&input); err != nil {
+			return err
+		}
+		*inputs = append(*inputs, input)
+		return nil
+	})
+}
+
+// blindInput is a blinded amount spent by its owner
+type blindInput struct {
+	Commitment Buffer     `json:"commitment"`
+	Owner      Permission `json:"owner"`
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (i blindInput) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.Encode(fixedBuffer{&i.Commitment, 33})
+	enc.Encode(i.Owner)
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (i *blindInput) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	dec.Decode(fixedBuffer{&i.Commitment, 33})
+	dec.Decode(&i.Owner)
+	return dec.Err()
+}
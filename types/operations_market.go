@@ -0,0 +1,1041 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/pkg/errors"
+)
+
+// CallOrderUpdateExtensions are the extensions of a call order update
+type CallOrderUpdateExtensions struct {
+	TargetCollateralRatio *uint16 `json:"target_collateral_ratio,omitempty"`
+}
+
+func (exts CallOrderUpdateExtensions) Marshal(encoder *encoding.Encoder) error {
+	return encoder.EncodeExtensions(encoding.Extension{Index: 0, Value: exts.TargetCollateralRatio})
+}
+
+func (exts *CallOrderUpdateExtensions) Unmarshal(decoder *encoding.Decoder) error {
+	exts.TargetCollateralRatio = nil
+	return decoder.DecodeExtensions(func(index uint64) error {
+		if index != 0 {
+			return errors.Errorf("unknown call order update extension %d", index)
+		}
+		exts.TargetCollateralRatio = new(uint16)
+		return decoder.Decode(exts.TargetCollateralRatio)
+	})
+}
+
+// CallOrderUpdateOperation changes the collateral and the debt of a margin position
+type CallOrderUpdateOperation struct {
+	Fee             AssetAmount               `json:"fee"`
+	FundingAccount  ObjectID                  `json:"funding_account"`
+	DeltaCollateral SignedAssetAmount         `json:"delta_collateral"`
+	DeltaDebt       SignedAssetAmount         `json:"delta_debt"`
+	Extensions      CallOrderUpdateExtensions `json:"extensions"`
+}
+
+func (op *CallOrderUpdateOperation) Type() OpType { return CallOrderUpdateOpType }
+
+func (op *CallOrderUpdateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.FundingAccount)
+	enc.Encode(op.DeltaCollateral)
+	enc.Encode(op.DeltaDebt)
+	enc.Encode(op.Extensions)
+	return enc.Err()
+}
+
+func (op *CallOrderUpdateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.FundingAccount = protocolID(accountObjectType)
+	dec.Decode(&op.FundingAccount)
+	dec.Decode(&op.DeltaCollateral)
+	dec.Decode(&op.DeltaDebt)
+	dec.Decode(&op.Extensions)
+	return dec.Err()
+}
+
+// LimitOrderUpdateOperation changes the price, the amount or the expiration of a limit order
+type LimitOrderUpdateOperation struct {
+	Fee               AssetAmount        `json:"fee"`
+	Seller            ObjectID           `json:"seller"`
+	Order             ObjectID           `json:"order"`
+	NewPrice          *Price             `json:"new_price,omitempty"`
+	DeltaAmountToSell *SignedAssetAmount `json:"delta_amount_to_sell,omitempty"`
+	NewExpiration     *Time              `json:"new_expiration,omitempty"`
+	OnFill            json.RawMessage    `json:"on_fill,omitempty"`
+	Extensions        json.RawMessage    `json:"extensions"`
+}
+
+func (op *LimitOrderUpdateOperation) Type() OpType { return LimitOrderUpdateOpType }
+
+func (op *LimitOrderUpdateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Seller)
+	enc.Encode(op.Order)
+	enc.EncodeOptional(op.NewPrice)
+	enc.EncodeOptional(op.DeltaAmountToSell)
+	enc.EncodeOptional(op.NewExpiration)
+	if onFill := bytes.TrimSpace(op.OnFill); len(onFill) > 0 && string(onFill) != "null" {
+		enc.EncodeBool(true)
+		enc.Encode(rawJSON{&op.OnFill, new(limitOrderAutoActions)})
+	} else {
+		enc.EncodeBool(false)
+	}
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *LimitOrderUpdateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Seller = protocolID(accountObjectType)
+	dec.Decode(&op.Seller)
+	op.Order = protocolID(limitOrderObjectType)
+	dec.Decode(&op.Order)
+	op.NewPrice = nil
+	dec.DecodeOptional(func() error {
+		op.NewPrice = new(Price)
+		return decoder.Decode(op.NewPrice)
+	})
+	op.DeltaAmountToSell = nil
+	dec.DecodeOptional(func() error {
+		op.DeltaAmountToSell = new(SignedAssetAmount)
+		return decoder.Decode(op.DeltaAmountToSell)
+	})
+	op.NewExpiration = nil
+	dec.DecodeOptional(func() error {
+		op.NewExpiration = new(Time)
+		return decoder.Decode(op.NewExpiration)
+	})
+	op.OnFill = nil
+	dec.DecodeOptional(func() error {
+		return decoder.Decode(rawJSON{&op.OnFill, new(limitOrderAutoActions)})
+	})
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// LiquidityPoolCreateOperation creates a liquidity pool of two assets
+type LiquidityPoolCreateOperation struct {
+	Fee                  AssetAmount     `json:"fee"`
+	Account              ObjectID        `json:"account"`
+	AssetA               ObjectID        `json:"asset_a"`
+	AssetB               ObjectID        `json:"asset_b"`
+	ShareAsset           ObjectID        `json:"share_asset"`
+	TakerFeePercent      uint16          `json:"taker_fee_percent"`
+	WithdrawalFeePercent uint16          `json:"withdrawal_fee_percent"`
+	Extensions           json.RawMessage `json:"extensions"`
+}
+
+func (op *LiquidityPoolCreateOperation) Type() OpType { return LiquidityPoolCreateOpType }
+
+func (op *LiquidityPoolCreateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Account)
+	enc.Encode(op.AssetA)
+	enc.Encode(op.AssetB)
+	enc.Encode(op.ShareAsset)
+	enc.Encode(op.TakerFeePercent)
+	enc.Encode(op.WithdrawalFeePercent)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *LiquidityPoolCreateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Account = protocolID(accountObjectType)
+	dec.Decode(&op.Account)
+	op.AssetA = protocolID(assetObjectType)
+	dec.Decode(&op.AssetA)
+	op.AssetB = protocolID(assetObjectType)
+	dec.Decode(&op.AssetB)
+	op.ShareAsset = protocolID(assetObjectType)
+	dec.Decode(&op.ShareAsset)
+	dec.Decode(&op.TakerFeePercent)
+	dec.Decode(&op.WithdrawalFeePercent)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// LiquidityPoolDeleteOperation deletes an empty liquidity pool
+type LiquidityPoolDeleteOperation struct {
+	Fee        AssetAmount     `json:"fee"`
+	Account    ObjectID        `json:"account"`
+	Pool       ObjectID        `json:"pool"`
+	Extensions json.RawMessage `json:"extensions"`
+}
+
+func (op *LiquidityPoolDeleteOperation) Type() OpType { return LiquidityPoolDeleteOpType }
+
+func (op *LiquidityPoolDeleteOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Account)
+	enc.Encode(op.Pool)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *LiquidityPoolDeleteOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Account = protocolID(accountObjectType)
+	dec.Decode(&op.Account)
+	op.Pool = protocolID(liquidityPoolObjectType)
+	dec.Decode(&op.Pool)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// LiquidityPoolDepositOperation adds both assets to a liquidity pool for its shares
+type LiquidityPoolDepositOperation struct {
+	Fee        AssetAmount     `json:"fee"`
+	Account    ObjectID        `json:"account"`
+	Pool       ObjectID        `json:"pool"`
+	AmountA    AssetAmount     `json:"amount_a"`
+	AmountB    AssetAmount     `json:"amount_b"`
+	Extensions json.RawMessage `json:"extensions"`
+}
+
+func (op *LiquidityPoolDepositOperation) Type() OpType { return LiquidityPoolDepositOpType }
+
+func (op *LiquidityPoolDepositOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Account)
+	enc.Encode(op.Pool)
+	enc.Encode(op.AmountA)
+	enc.Encode(op.AmountB)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *LiquidityPoolDepositOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Account = protocolID(accountObjectType)
+	dec.Decode(&op.Account)
+	op.Pool = protocolID(liquidityPoolObjectType)
+	dec.Decode(&op.Pool)
+	dec.Decode(&op.AmountA)
+	dec.Decode(&op.AmountB)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// LiquidityPoolWithdrawOperation redeems shares of a liquidity pool for both assets
+type LiquidityPoolWithdrawOperation struct {
+	Fee         AssetAmount     `json:"fee"`
+	Account     ObjectID        `json:"account"`
+	Pool        ObjectID        `json:"pool"`
+	ShareAmount AssetAmount     `json:"share_amount"`
+	Extensions  json.RawMessage `json:"extensions"`
+}
+
+func (op *LiquidityPoolWithdrawOperation) Type() OpType { return LiquidityPoolWithdrawOpType }
+
+func (op *LiquidityPoolWithdrawOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Account)
+	enc.Encode(op.Pool)
+	enc.Encode(op.ShareAmount)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *LiquidityPoolWithdrawOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Account = protocolID(accountObjectType)
+	dec.Decode(&op.Account)
+	op.Pool = protocolID(liquidityPoolObjectType)
+	dec.Decode(&op.Pool)
+	dec.Decode(&op.ShareAmount)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// LiquidityPoolExchangeOperation sells one asset of a liquidity pool for the other
+type LiquidityPoolExchangeOperation struct {
+	Fee          AssetAmount     `json:"fee"`
+	Account      ObjectID        `json:"account"`
+	Pool         ObjectID        `json:"pool"`
+	AmountToSell AssetAmount     `json:"amount_to_sell"`
+	MinToReceive AssetAmount     `json:"min_to_receive"`
+	Extensions   json.RawMessage `json:"extensions"`
+}
+
+func (op *LiquidityPoolExchangeOperation) Type() OpType { return LiquidityPoolExchangeOpType }
+
+func (op *LiquidityPoolExchangeOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Account)
+	enc.Encode(op.Pool)
+	enc.Encode(op.AmountToSell)
+	enc.Encode(op.MinToReceive)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *LiquidityPoolExchangeOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Account = protocolID(accountObjectType)
+	dec.Decode(&op.Account)
+	op.Pool = protocolID(liquidityPoolObjectType)
+	dec.Decode(&op.Pool)
+	dec.Decode(&op.AmountToSell)
+	dec.Decode(&op.MinToReceive)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// LiquidityPoolUpdateOperation changes the fees of a liquidity pool
+type LiquidityPoolUpdateOperation struct {
+	Fee                  AssetAmount     `json:"fee"`
+	Account              ObjectID        `json:"account"`
+	Pool                 ObjectID        `json:"pool"`
+	TakerFeePercent      *uint16         `json:"taker_fee_percent,omitempty"`
+	WithdrawalFeePercent *uint16         `json:"withdrawal_fee_percent,omitempty"`
+	Extensions           json.RawMessage `json:"extensions"`
+}
+
+func (op *LiquidityPoolUpdateOperation) Type() OpType { return LiquidityPoolUpdateOpType }
+
+func (op *LiquidityPoolUpdateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Account)
+	enc.Encode(op.Pool)
+	enc.EncodeOptional(op.TakerFeePercent)
+	enc.EncodeOptional(op.WithdrawalFeePercent)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *LiquidityPoolUpdateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Account = protocolID(accountObjectType)
+	dec.Decode(&op.Account)
+	op.Pool = protocolID(liquidityPoolObjectType)
+	dec.Decode(&op.Pool)
+	op.TakerFeePercent = nil
+	dec.DecodeOptional(func() error {
+		op.TakerFeePercent = new(uint16)
+		return decoder.Decode(op.TakerFeePercent)
+	})
+	op.WithdrawalFeePercent = nil
+	dec.DecodeOptional(func() error {
+		op.WithdrawalFeePercent = new(uint16)
+		return decoder.Decode(op.WithdrawalFeePercent)
+	})
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// SametFundCreateOperation creates a same-asset flash loan fund
+type SametFundCreateOperation struct {
+	Fee          AssetAmount     `json:"fee"`
+	OwnerAccount ObjectID        `json:"owner_account"`
+	AssetType    ObjectID        `json:"asset_type"`
+	Balance      Suint64         `json:"balance"`
+	FeeRate      uint32          `json:"fee_rate"`
+	Extensions   json.RawMessage `json:"extensions"`
+}
+
+func (op *SametFundCreateOperation) Type() OpType { return SametFundCreateOpType }
+
+func (op *SametFundCreateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.OwnerAccount)
+	enc.Encode(op.AssetType)
+	enc.Encode(op.Balance)
+	enc.Encode(op.FeeRate)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *SametFundCreateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.OwnerAccount = protocolID(accountObjectType)
+	dec.Decode(&op.OwnerAccount)
+	op.AssetType = protocolID(assetObjectType)
+	dec.Decode(&op.AssetType)
+	dec.Decode(&op.Balance)
+	dec.Decode(&op.FeeRate)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// SametFundDeleteOperation deletes a same-asset fund
+type SametFundDeleteOperation struct {
+	Fee          AssetAmount     `json:"fee"`
+	OwnerAccount ObjectID        `json:"owner_account"`
+	FundID       ObjectID        `json:"fund_id"`
+	Extensions   json.RawMessage `json:"extensions"`
+}
+
+func (op *SametFundDeleteOperation) Type() OpType { return SametFundDeleteOpType }
+
+func (op *SametFundDeleteOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.OwnerAccount)
+	enc.Encode(op.FundID)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *SametFundDeleteOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.OwnerAccount = protocolID(accountObjectType)
+	dec.Decode(&op.OwnerAccount)
+	op.FundID = protocolID(sametFundObjectType)
+	dec.Decode(&op.FundID)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// SametFundUpdateOperation changes the balance or the fee rate of a same-asset fund
+type SametFundUpdateOperation struct {
+	Fee          AssetAmount        `json:"fee"`
+	OwnerAccount ObjectID           `json:"owner_account"`
+	FundID       ObjectID           `json:"fund_id"`
+	DeltaAmount  *SignedAssetAmount `json:"delta_amount,omitempty"`
+	NewFeeRate   *uint32            `json:"new_fee_rate,omitempty"`
+	Extensions   json.RawMessage    `json:"extensions"`
+}
+
+func (op *SametFundUpdateOperation) Type() OpType { return SametFundUpdateOpType }
+
+func (op *SametFundUpdateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.OwnerAccount)
+	enc.Encode(op.FundID)
+	enc.EncodeOptional(op.DeltaAmount)
+	enc.EncodeOptional(op.NewFeeRate)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *SametFundUpdateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.OwnerAccount = protocolID(accountObjectType)
+	dec.Decode(&op.OwnerAccount)
+	op.FundID = protocolID(sametFundObjectType)
+	dec.Decode(&op.FundID)
+	op.DeltaAmount = nil
+	dec.DecodeOptional(func() error {
+		op.DeltaAmount = new(SignedAssetAmount)
+		return decoder.Decode(op.DeltaAmount)
+	})
+	op.NewFeeRate = nil
+	dec.DecodeOptional(func() error {
+		op.NewFeeRate = new(uint32)
+		return decoder.Decode(op.NewFeeRate)
+	})
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// SametFundBorrowOperation borrows from a same-asset fund within a transaction
+type SametFundBorrowOperation struct {
+	Fee          AssetAmount     `json:"fee"`
+	Borrower     ObjectID        `json:"borrower"`
+	FundID       ObjectID        `json:"fund_id"`
+	BorrowAmount AssetAmount     `json:"borrow_amount"`
+	Extensions   json.RawMessage `json:"extensions"`
+}
+
+func (op *SametFundBorrowOperation) Type() OpType { return SametFundBorrowOpType }
+
+func (op *SametFundBorrowOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Borrower)
+	enc.Encode(op.FundID)
+	enc.Encode(op.BorrowAmount)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *SametFundBorrowOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Borrower = protocolID(accountObjectType)
+	dec.Decode(&op.Borrower)
+	op.FundID = protocolID(sametFundObjectType)
+	dec.Decode(&op.FundID)
+	dec.Decode(&op.BorrowAmount)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// SametFundRepayOperation repays a same-asset fund with its fee
+type SametFundRepayOperation struct {
+	Fee         AssetAmount     `json:"fee"`
+	Account     ObjectID        `json:"account"`
+	FundID      ObjectID        `json:"fund_id"`
+	RepayAmount AssetAmount     `json:"repay_amount"`
+	FundFee     AssetAmount     `json:"fund_fee"`
+	Extensions  json.RawMessage `json:"extensions"`
+}
+
+func (op *SametFundRepayOperation) Type() OpType { return SametFundRepayOpType }
+
+func (op *SametFundRepayOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Account)
+	enc.Encode(op.FundID)
+	enc.Encode(op.RepayAmount)
+	enc.Encode(op.FundFee)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *SametFundRepayOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Account = protocolID(accountObjectType)
+	dec.Decode(&op.Account)
+	op.FundID = protocolID(sametFundObjectType)
+	dec.Decode(&op.FundID)
+	dec.Decode(&op.RepayAmount)
+	dec.Decode(&op.FundFee)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// CollateralPrice is an asset acceptable as collateral of a credit offer and its price
+type CollateralPrice struct {
+	AssetID ObjectID
+	Price   Price
+}
+
+func (p CollateralPrice) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{&p.AssetID, p.Price})
+}
+
+func (p *CollateralPrice) UnmarshalJSON(b []byte) error {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(b, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return errors.New("invalid collateral format: should be asset, price")
+	}
+	if err := json.Unmarshal(pair[0], &p.AssetID); err != nil {
+		return err
+	}
+	return json.Unmarshal(pair[1], &p.Price)
+}
+
+// BorrowerLimit is an account allowed to borrow from a credit offer and its max amount
+type BorrowerLimit struct {
+	Account ObjectID
+	Amount  Suint64
+}
+
+func (l BorrowerLimit) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{&l.Account, uint64(l.Amount)})
+}
+
+func (l *BorrowerLimit) UnmarshalJSON(b []byte) error {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(b, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return errors.New("invalid borrower format: should be account, amount")
+	}
+	if err := json.Unmarshal(pair[0], &l.Account); err != nil {
+		return err
+	}
+	return json.Unmarshal(pair[1], &l.Amount)
+}
+
+// collateralPrices are a flat_map of the acceptable collateral of a credit offer,
+// written in the order of the assets
+type collateralPrices []CollateralPrice
+
+// Marshal implements encoding.Marshaller interface.
+func (prices collateralPrices) Marshal(encoder *encoding.Encoder) error {
+	sorted := append(collateralPrices(nil), prices...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].AssetID.ID < sorted[j].AssetID.ID })
+	return encoder.EncodeFlatMap(len(sorted), func(i int) error {
+		if err := encoder.Encode(sorted[i].AssetID); err != nil {
+			return err
+		}
+		return encoder.Encode(sorted[i].Price)
+	})
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (prices *collateralPrices) Unmarshal(decoder *encoding.Decoder) error {
+	*prices = collateralPrices{}
+	return decoder.DecodeFlatMap(func(int) error {
+		price := CollateralPrice{AssetID: protocolID(assetObjectType)}
+		dec := encoding.NewRollingDecoder(decoder)
+		dec.Decode(&price.AssetID)
+		dec.Decode(&price.Price)
+		*prices = append(*prices, price)
+		return dec.Err()
+	})
+}
+
+// borrowerLimits are a flat_map of the acceptable borrowers of a credit offer,
+// written in the order of the accounts
+type borrowerLimits []BorrowerLimit
+
+// Marshal implements encoding.Marshaller interface.
+func (limits borrowerLimits) Marshal(encoder *encoding.Encoder) error {
+	sorted := append(borrowerLimits(nil), limits...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Account.ID < sorted[j].Account.ID })
+	return encoder.EncodeFlatMap(len(sorted), func(i int) error {
+		if err := encoder.Encode(sorted[i].Account); err != nil {
+			return err
+		}
+		return encoder.Encode(sorted[i].Amount)
+	})
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (limits *borrowerLimits) Unmarshal(decoder *encoding.Decoder) error {
+	*limits = borrowerLimits{}
+	return decoder.DecodeFlatMap(func(int) error {
+		limit := BorrowerLimit{Account: protocolID(accountObjectType)}
+		dec := encoding.NewRollingDecoder(decoder)
+		dec.Decode(&limit.Account)
+		dec.Decode(&limit.Amount)
+		*limits = append(*limits, limit)
+		return dec.Err()
+	})
+}
+
+// CreditOfferCreateOperation offers an asset to be borrowed against collateral
+type CreditOfferCreateOperation struct {
+	Fee                  AssetAmount       `json:"fee"`
+	OwnerAccount         ObjectID          `json:"owner_account"`
+	AssetType            ObjectID          `json:"asset_type"`
+	Balance              Suint64           `json:"balance"`
+	FeeRate              uint32            `json:"fee_rate"`
+	MaxDurationSeconds   uint32            `json:"max_duration_seconds"`
+	MinDealAmount        Suint64           `json:"min_deal_amount"`
+	Enabled              bool              `json:"enabled"`
+	AutoDisableTime      Time              `json:"auto_disable_time"`
+	AcceptableCollateral []CollateralPrice `json:"acceptable_collateral"`
+	AcceptableBorrowers  []BorrowerLimit   `json:"acceptable_borrowers"`
+	Extensions           json.RawMessage   `json:"extensions"`
+}
+
+func (op *CreditOfferCreateOperation) Type() OpType { return CreditOfferCreateOpType }
+
+func (op *CreditOfferCreateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.OwnerAccount)
+	enc.Encode(op.AssetType)
+	enc.Encode(op.Balance)
+	enc.Encode(op.FeeRate)
+	enc.Encode(op.MaxDurationSeconds)
+	enc.Encode(op.MinDealAmount)
+	enc.EncodeBool(op.Enabled)
+	enc.Encode(op.AutoDisableTime)
+	enc.Encode(collateralPrices(op.AcceptableCollateral))
+	enc.Encode(borrowerLimits(op.AcceptableBorrowers))
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *CreditOfferCreateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.OwnerAccount = protocolID(accountObjectType)
+	dec.Decode(&op.OwnerAccount)
+	op.AssetType = protocolID(assetObjectType)
+	dec.Decode(&op.AssetType)
+	dec.Decode(&op.Balance)
+	dec.Decode(&op.FeeRate)
+	dec.Decode(&op.MaxDurationSeconds)
+	dec.Decode(&op.MinDealAmount)
+	op.Enabled = dec.DecodeBool()
+	dec.Decode(&op.AutoDisableTime)
+	dec.Decode((*collateralPrices)(&op.AcceptableCollateral))
+	dec.Decode((*borrowerLimits)(&op.AcceptableBorrowers))
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// CreditOfferDeleteOperation deletes a credit offer
+type CreditOfferDeleteOperation struct {
+	Fee          AssetAmount     `json:"fee"`
+	OwnerAccount ObjectID        `json:"owner_account"`
+	OfferID      ObjectID        `json:"offer_id"`
+	Extensions   json.RawMessage `json:"extensions"`
+}
+
+func (op *CreditOfferDeleteOperation) Type() OpType { return CreditOfferDeleteOpType }
+
+func (op *CreditOfferDeleteOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.OwnerAccount)
+	enc.Encode(op.OfferID)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *CreditOfferDeleteOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.OwnerAccount = protocolID(accountObjectType)
+	dec.Decode(&op.OwnerAccount)
+	op.OfferID = protocolID(creditOfferObjectType)
+	dec.Decode(&op.OfferID)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// CreditOfferUpdateOperation changes a credit offer, only the given options change
+type CreditOfferUpdateOperation struct {
+	Fee                  AssetAmount        `json:"fee"`
+	OwnerAccount         ObjectID           `json:"owner_account"`
+	OfferID              ObjectID           `json:"offer_id"`
+	DeltaAmount          *SignedAssetAmount `json:"delta_amount,omitempty"`
+	FeeRate              *uint32            `json:"fee_rate,omitempty"`
+	MaxDurationSeconds   *uint32            `json:"max_duration_seconds,omitempty"`
+	MinDealAmount        *Suint64           `json:"min_deal_amount,omitempty"`
+	Enabled              *bool              `json:"enabled,omitempty"`
+	AutoDisableTime      *Time              `json:"auto_disable_time,omitempty"`
+	AcceptableCollateral []CollateralPrice  `json:"acceptable_collateral,omitempty"`
+	AcceptableBorrowers  []BorrowerLimit    `json:"acceptable_borrowers,omitempty"`
+	Extensions           json.RawMessage    `json:"extensions"`
+}
+
+func (op *CreditOfferUpdateOperation) Type() OpType { return CreditOfferUpdateOpType }
+
+func (op *CreditOfferUpdateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.OwnerAccount)
+	enc.Encode(op.OfferID)
+	enc.EncodeOptional(op.DeltaAmount)
+	enc.EncodeOptional(op.FeeRate)
+	enc.EncodeOptional(op.MaxDurationSeconds)
+	enc.EncodeOptional(op.MinDealAmount)
+	enc.EncodeOptional(op.Enabled)
+	enc.EncodeOptional(op.AutoDisableTime)
+	enc.EncodeOptional(collateralPrices(op.AcceptableCollateral))
+	enc.EncodeOptional(borrowerLimits(op.AcceptableBorrowers))
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *CreditOfferUpdateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.OwnerAccount = protocolID(accountObjectType)
+	dec.Decode(&op.OwnerAccount)
+	op.OfferID = protocolID(creditOfferObjectType)
+	dec.Decode(&op.OfferID)
+	op.DeltaAmount = nil
+	dec.DecodeOptional(func() error {
+		op.DeltaAmount = new(SignedAssetAmount)
+		return decoder.Decode(op.DeltaAmount)
+	})
+	op.FeeRate = nil
+	dec.DecodeOptional(func() error {
+		op.FeeRate = new(uint32)
+		return decoder.Decode(op.FeeRate)
+	})
+	op.MaxDurationSeconds = nil
+	dec.DecodeOptional(func() error {
+		op.MaxDurationSeconds = new(uint32)
+		return decoder.Decode(op.MaxDurationSeconds)
+	})
+	op.MinDealAmount = nil
+	dec.DecodeOptional(func() error {
+		op.MinDealAmount = new(Suint64)
+		return decoder.Decode(op.MinDealAmount)
+	})
+	op.Enabled = nil
+	dec.DecodeOptional(func() error {
+		op.Enabled = new(bool)
+		return decoder.Decode(op.Enabled)
+	})
+	op.AutoDisableTime = nil
+	dec.DecodeOptional(func() error {
+		op.AutoDisableTime = new(Time)
+		return decoder.Decode(op.AutoDisableTime)
+	})
+	op.AcceptableCollateral = nil
+	dec.DecodeOptional(func() error {
+		return decoder.Decode((*collateralPrices)(&op.AcceptableCollateral))
+	})
+	op.AcceptableBorrowers = nil
+	dec.DecodeOptional(func() error {
+		return decoder.Decode((*borrowerLimits)(&op.AcceptableBorrowers))
+	})
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// CreditOfferAcceptOperation borrows from a credit offer, opening a credit deal
+type CreditOfferAcceptOperation struct {
+	Fee                AssetAmount     `json:"fee"`
+	Borrower           ObjectID        `json:"borrower"`
+	OfferID            ObjectID        `json:"offer_id"`
+	BorrowAmount       AssetAmount     `json:"borrow_amount"`
+	Collateral         AssetAmount     `json:"collateral"`
+	MaxFeeRate         uint32          `json:"max_fee_rate"`
+	MinDurationSeconds uint32          `json:"min_duration_seconds"`
+	Extensions         json.RawMessage `json:"extensions"`
+}
+
+func (op *CreditOfferAcceptOperation) Type() OpType { return CreditOfferAcceptOpType }
+
+func (op *CreditOfferAcceptOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Borrower)
+	enc.Encode(op.OfferID)
+	enc.Encode(op.BorrowAmount)
+	enc.Encode(op.Collateral)
+	enc.Encode(op.MaxFeeRate)
+	enc.Encode(op.MinDurationSeconds)
+	enc.Encode(typedExtensions{&op.Extensions, creditOfferAcceptExtensions})
+	return enc.Err()
+}
+
+func (op *CreditOfferAcceptOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Borrower = protocolID(accountObjectType)
+	dec.Decode(&op.Borrower)
+	op.OfferID = protocolID(creditOfferObjectType)
+	dec.Decode(&op.OfferID)
+	dec.Decode(&op.BorrowAmount)
+	dec.Decode(&op.Collateral)
+	dec.Decode(&op.MaxFeeRate)
+	dec.Decode(&op.MinDurationSeconds)
+	dec.Decode(typedExtensions{&op.Extensions, creditOfferAcceptExtensions})
+	return dec.Err()
+}
+
+// creditOfferAcceptExtensions are the fields of the extensions of credit_offer_accept
+var creditOfferAcceptExtensions = []extensionField{
+	{"auto_repay", func() interface{} { return new(uint8) }},
+}
+
+// CreditDealRepayOperation repays a credit deal with its fee
+type CreditDealRepayOperation struct {
+	Fee         AssetAmount     `json:"fee"`
+	Account     ObjectID        `json:"account"`
+	DealID      ObjectID        `json:"deal_id"`
+	RepayAmount AssetAmount     `json:"repay_amount"`
+	CreditFee   AssetAmount     `json:"credit_fee"`
+	Extensions  json.RawMessage `json:"extensions"`
+}
+
+func (op *CreditDealRepayOperation) Type() OpType { return CreditDealRepayOpType }
+
+func (op *CreditDealRepayOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Account)
+	enc.Encode(op.DealID)
+	enc.Encode(op.RepayAmount)
+	enc.Encode(op.CreditFee)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *CreditDealRepayOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Account = protocolID(accountObjectType)
+	dec.Decode(&op.Account)
+	op.DealID = protocolID(creditDealObjectType)
+	dec.Decode(&op.DealID)
+	dec.Decode(&op.RepayAmount)
+	dec.Decode(&op.CreditFee)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
+
+// CreditDealExpiredOperation is the virtual operation of an expired credit deal,
+// the collateral goes to the offer owner
+type CreditDealExpiredOperation struct {
+	Fee          AssetAmount `json:"fee"`
+	DealID       ObjectID    `json:"deal_id"`
+	OfferID      ObjectID    `json:"offer_id"`
+	OfferOwner   ObjectID    `json:"offer_owner"`
+	Borrower     ObjectID    `json:"borrower"`
+	UnpaidAmount AssetAmount `json:"unpaid_amount"`
+	Collateral   AssetAmount `json:"collateral"`
+	FeeRate      uint32      `json:"fee_rate"`
+}
+
+func (op *CreditDealExpiredOperation) Type() OpType { return CreditDealExpiredOpType }
+
+func (op *CreditDealExpiredOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.DealID)
+	enc.Encode(op.OfferID)
+	enc.Encode(op.OfferOwner)
+	enc.Encode(op.Borrower)
+	enc.Encode(op.UnpaidAmount)
+	enc.Encode(op.Collateral)
+	enc.Encode(op.FeeRate)
+	return enc.Err()
+}
+
+func (op *CreditDealExpiredOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.DealID = protocolID(creditDealObjectType)
+	dec.Decode(&op.DealID)
+	op.OfferID = protocolID(creditOfferObjectType)
+	dec.Decode(&op.OfferID)
+	op.OfferOwner = protocolID(accountObjectType)
+	dec.Decode(&op.OfferOwner)
+	op.Borrower = protocolID(accountObjectType)
+	dec.Decode(&op.Borrower)
+	dec.Decode(&op.UnpaidAmount)
+	dec.Decode(&op.Collateral)
+	dec.Decode(&op.FeeRate)
+	return dec.Err()
+}
+
+// CreditDealUpdateOperation changes the auto repayment of a credit deal
+type CreditDealUpdateOperation struct {
+	Fee        AssetAmount     `json:"fee"`
+	Account    ObjectID        `json:"account"`
+	DealID     ObjectID        `json:"deal_id"`
+	AutoRepay  uint8           `json:"auto_repay"`
+	Extensions json.RawMessage `json:"extensions"`
+}
+
+func (op *CreditDealUpdateOperation) Type() OpType { return CreditDealUpdateOpType }
+
+func (op *CreditDealUpdateOperation) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+
+	enc.EncodeUVarint(uint64(op.Type()))
+	enc.Encode(op.Fee)
+	enc.Encode(op.Account)
+	enc.Encode(op.DealID)
+	enc.Encode(op.AutoRepay)
+	enc.Encode(futureExtensionsJSON(op.Extensions))
+	return enc.Err()
+}
+
+func (op *CreditDealUpdateOperation) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(opTypeTag(op.Type()))
+	dec.Decode(&op.Fee)
+	op.Account = protocolID(accountObjectType)
+	dec.Decode(&op.Account)
+	op.DealID = protocolID(creditDealObjectType)
+	dec.Decode(&op.DealID)
+	dec.Decode(&op.AutoRepay)
+	dec.Decode((*futureExtensionsJSON)(&op.Extensions))
+	return dec.Err()
+}
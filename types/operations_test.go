@@ -0,0 +1,166 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKnownOperations(t *testing.T) {
+	// every operation type up to the last one of bitshares-core is decoded
+	for opType := TransferOpType; opType <= LimitOrderUpdateOpType; opType++ {
+		typ, ok := knownOperations[opType]
+		require.True(t, ok, "operation %d is not known", opType)
+
+		op := reflect.New(typ).Interface().(Operation)
+		require.Equal(t, opType, op.Type(), "operation %s", typ.Name())
+	}
+}
+
+// testdata/operations.json has a sample of every operation, most of them from the
+// blocks of the mainnet and hand written ones for the operations added since.
+// This only checks the encoder against the decoder: the bytes themselves are
+// checked by testdata/vectors.json and by the blocks recorded in bitshares/testdata.
+func TestOperations_MarshalRoundTrip(t *testing.T) {
+	raw, err := ioutil.ReadFile("testdata/operations.json")
+	require.NoError(t, err)
+	var ops Operations
+	require.NoError(t, json.Unmarshal(raw, &ops))
+
+	sampled := map[OpType]bool{}
+	for i, op := range ops {
+		sampled[op.Type()] = true
+
+		_, ok := op.(encoding.Marshaller)
+		require.True(t, ok, "operation %d can not be encoded", op.Type())
+		var b bytes.Buffer
+		require.NoError(t, encoding.NewEncoder(&b).Encode(op), "sample %d, operation %d", i, op.Type())
+
+		decoder := encoding.NewDecoder(bytes.NewReader(b.Bytes()))
+		decoded, err := decodeOperation(decoder)
+		require.NoError(t, err, "sample %d, operation %d", i, op.Type())
+		require.True(t, decoder.EOF(), "sample %d, operation %d", i, op.Type())
+
+		var again bytes.Buffer
+		require.NoError(t, encoding.NewEncoder(&again).Encode(decoded), "sample %d, operation %d", i, op.Type())
+		require.Equal(t, b.Bytes(), again.Bytes(), "sample %d, operation %d", i, op.Type())
+	}
+
+	for opType := range knownOperations {
+		require.True(t, sampled[opType], "operation %d has no sample", opType)
+	}
+}
+
+func TestOperations_UnmarshalJSON(t *testing.T) {
+	data := `[
+		[3,{"fee":{"amount":48260,"asset_id":"1.3.0"},"funding_account":"1.2.1601","delta_collateral":{"amount":"-5000000000","asset_id":"1.3.0"},"delta_debt":{"amount":-100000,"asset_id":"1.3.113"},"extensions":{"target_collateral_ratio":1750}}],
+		[4,{"fee":{"amount":0,"asset_id":"1.3.0"},"order_id":"1.7.3451","account_id":"1.2.1601","pays":{"amount":100,"asset_id":"1.3.0"},"receives":{"amount":3,"asset_id":"1.3.113"},"fill_price":{"base":{"amount":100,"asset_id":"1.3.0"},"quote":{"amount":3,"asset_id":"1.3.113"}},"is_maker":true}],
+		[5,{"fee":{"amount":500000,"asset_id":"1.3.0"},"registrar":"1.2.17","referrer":"1.2.17","referrer_percent":5000,"name":"alice","owner":{"weight_threshold":1,"account_auths":[],"key_auths":[["BTS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV",1]],"address_auths":[]},"active":{"weight_threshold":1,"account_auths":[],"key_auths":[["BTS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV",1]],"address_auths":[]},"options":{"memo_key":"BTS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV","voting_account":"1.2.5","num_witness":0,"num_committee":0,"votes":[],"extensions":[]},"extensions":{}}],
+		[22,{"fee":{"amount":2000,"asset_id":"1.3.0"},"fee_paying_account":"1.2.1601","expiration_time":"2021-06-01T00:00:00","proposed_ops":[{"op":[0,{"fee":{"amount":0,"asset_id":"1.3.0"},"from":"1.2.1601","to":"1.2.17","amount":{"amount":1000,"asset_id":"1.3.0"},"extensions":[]}]}],"review_period_seconds":3600,"extensions":[]}],
+		[49,{"fee":{"amount":1000,"asset_id":"1.3.0"},"from":"1.2.1601","to":"1.2.17","amount":{"amount":1000,"asset_id":"1.3.0"},"preimage_hash":[2,"6b86b273ff34fce19d6b804eff5a3f5747ada4eaa22f1d49c01e52ddb7875b4b"],"preimage_size":1,"claim_period_seconds":86400,"extensions":[]}],
+		[63,{"fee":{"amount":100,"asset_id":"1.3.0"},"account":"1.2.1601","pool":"1.19.0","amount_to_sell":{"amount":"10000000000","asset_id":"1.3.0"},"min_to_receive":{"amount":1,"asset_id":"1.3.113"},"extensions":[]}],
+		[69,{"fee":{"amount":100,"asset_id":"1.3.0"},"owner_account":"1.2.1601","asset_type":"1.3.0","balance":"100000000","fee_rate":1000,"max_duration_seconds":86400,"min_deal_amount":1,"enabled":true,"auto_disable_time":"2023-01-01T00:00:00","acceptable_collateral":[["1.3.113",{"base":{"amount":1,"asset_id":"1.3.0"},"quote":{"amount":1,"asset_id":"1.3.113"}}]],"acceptable_borrowers":[["1.2.17","5000"]],"extensions":[]}]
+	]`
+
+	var ops Operations
+	require.NoError(t, json.Unmarshal([]byte(data), &ops))
+	require.Len(t, ops, 7)
+
+	call := ops[0].(*CallOrderUpdateOperation)
+	require.Equal(t, Sint64(-5000000000), call.DeltaCollateral.Amount)
+	require.Equal(t, Sint64(-100000), call.DeltaDebt.Amount)
+	require.Equal(t, "1.3.113", call.DeltaDebt.AssetID.String())
+
+	fill := ops[1].(*FillOrderOperation)
+	require.Equal(t, "1.7.3451", fill.Order.String())
+	require.Equal(t, uint64(3), fill.Receives.Amount)
+	require.True(t, fill.IsMaker)
+
+	create := ops[2].(*AccountCreateOperation)
+	require.Equal(t, "alice", create.Name)
+	require.Equal(t, uint16(5000), create.ReferrerPercent)
+	require.Equal(t, "BTS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV", create.Options.MemoKey)
+
+	proposal := ops[3].(*ProposalCreateOperation)
+	require.Len(t, proposal.ProposedOps, 1)
+	transfer := proposal.ProposedOps[0].Op.(*TransferOperation)
+	require.Equal(t, "1.2.17", transfer.To.String())
+	require.Equal(t, uint32(3600), *proposal.ReviewPeriodSeconds)
+
+	htlc := ops[4].(*HtlcCreateOperation)
+	require.Equal(t, uint8(HtlcHashSha256), htlc.PreimageHash.Algorithm)
+	require.Len(t, htlc.PreimageHash.Hash, 32)
+
+	exchange := ops[5].(*LiquidityPoolExchangeOperation)
+	require.Equal(t, "1.19.0", exchange.Pool.String())
+	require.Equal(t, uint64(10000000000), exchange.AmountToSell.Amount)
+
+	offer := ops[6].(*CreditOfferCreateOperation)
+	require.Equal(t, Suint64(100000000), offer.Balance)
+	require.Equal(t, "1.3.113", offer.AcceptableCollateral[0].AssetID.String())
+	require.Equal(t, "1.2.17", offer.AcceptableBorrowers[0].Account.String())
+	require.Equal(t, Suint64(5000), offer.AcceptableBorrowers[0].Amount)
+
+	// the proposed operations are wrapped again
+	b, err := json.Marshal(proposal.ProposedOps)
+	require.NoError(t, err)
+	var again []ProposedOperation
+	require.NoError(t, json.Unmarshal(b, &again))
+	require.Equal(t, TransferOpType, again[0].Op.Type())
+}
+
+func TestProposalOperations(t *testing.T) {
+	treasury, alice, bob := MustParseObjectID("1.2.300"), MustParseObjectID("1.2.100"), MustParseObjectID("1.2.200")
+	core := MustParseObjectID("1.3.0")
+	expiration := time.Date(2019, 7, 17, 4, 10, 10, 500, time.UTC)
+
+	transfer := NewTransferOperation(treasury, bob, AssetAmount{Amount: 1000, AssetID: core}, AssetAmount{Amount: 20, AssetID: core})
+	create := NewProposalCreateOperation(alice, expiration, time.Hour, transfer)
+	require.Equal(t, "2019-07-17T04:10:10Z", create.ExpirationTime.Format(time.RFC3339Nano))
+	require.Equal(t, uint32(3600), *create.ReviewPeriodSeconds)
+	require.Nil(t, NewProposalCreateOperation(alice, expiration, 0, transfer).ReviewPeriodSeconds)
+
+	// approvals are written in the order of the chain, whatever their order here
+	update := NewProposalUpdateOperation(bob, MustParseObjectID("1.10.7"))
+	update.ActiveApprovalsToAdd = []ObjectID{bob, alice}
+	update.KeyApprovalsToRemove = []PublicKey{
+		"BTS7oJ5icgrbMRdzGSKau1NKQqxmYsMRa6rnHsZdxArjCVPWnvi3D",
+		"BTS55JfN7ca5Eeo5Eu8bfRBKFSuAr4BsyzRpDUZUbhC8MzbJehf3L",
+	}
+	remove := NewProposalDeleteOperation(alice, MustParseObjectID("1.10.7"), true)
+
+	tx, err := NewTransaction("00178f912d70e9ed3539f2acfba4752dee5d77bb", expiration)
+	require.NoError(t, err)
+	tx.PushOperation(create)
+	tx.PushOperation(update)
+	tx.PushOperation(remove)
+	require.NoError(t, tx.SetFees([]AssetAmount{{Amount: 30, AssetID: core}, {Amount: 2, AssetID: core}, {Amount: 1, AssetID: core}}))
+
+	var b bytes.Buffer
+	require.NoError(t, encoding.NewEncoder(&b).Encode(tx))
+	decoded := Transaction{}
+	decoder := encoding.NewDecoder(bytes.NewReader(b.Bytes()))
+	require.NoError(t, decoder.Decode(&decoded))
+	require.True(t, decoder.EOF())
+
+	require.Equal(t, create, decoded.Operations[0])
+	decodedUpdate := decoded.Operations[1].(*ProposalUpdateOperation)
+	require.Equal(t, []ObjectID{alice, bob}, decodedUpdate.ActiveApprovalsToAdd)
+	require.Equal(t, []PublicKey{
+		"BTS55JfN7ca5Eeo5Eu8bfRBKFSuAr4BsyzRpDUZUbhC8MzbJehf3L",
+		"BTS7oJ5icgrbMRdzGSKau1NKQqxmYsMRa6rnHsZdxArjCVPWnvi3D",
+	}, decodedUpdate.KeyApprovalsToRemove)
+	require.Equal(t, "1.10.7", decodedUpdate.Proposal.String())
+	require.Equal(t, remove, decoded.Operations[2])
+
+	// the json is the one of the chain
+	raw, err := json.Marshal(Operations{update})
+	require.NoError(t, err)
+	require.JSONEq(t, `[[23,{"fee":{"amount":2,"asset_id":"1.3.0"},"fee_paying_account":"1.2.200","proposal":"1.10.7","active_approvals_to_add":["1.2.200","1.2.100"],"active_approvals_to_remove":[],"owner_approvals_to_add":[],"owner_approvals_to_remove":[],"key_approvals_to_add":[],"key_approvals_to_remove":["BTS7oJ5icgrbMRdzGSKau1NKQqxmYsMRa6rnHsZdxArjCVPWnvi3D","BTS55JfN7ca5Eeo5Eu8bfRBKFSuAr4BsyzRpDUZUbhC8MzbJehf3L"],"extensions":[]}]]`, string(raw))
+}
@@ -2,49 +2,85 @@ package types
 
 type OpType uint16
 
+// operation types in the order of the operation static_variant of bitshares-core,
+// the virtual ones are only produced by the chain
 const (
 	TransferOpType OpType = iota
 	LimitOrderCreateOpType
 	LimitOrderCancelOpType
 	CallOrderUpdateOpType
-	FillOrderOpType
-	/*   account_create,
-	account_update,
-	account_whitelist,
-	account_upgrade,
-	account_transfer,
-	asset_create,
-	asset_update,
-	asset_update_bitasset,
-	asset_update_feed_producers,
-	asset_issue,
-	asset_reserve,
-	asset_fund_fee_pool,
-	asset_settle,
-	asset_global_settle,
-	asset_publish_feed,
-	witness_create,
-	witness_update,
-	proposal_create,
-	proposal_update,
-	proposal_delete,
-	withdraw_permission_create,
-	withdraw_permission_update,
-	withdraw_permission_claim,
-	withdraw_permission_delete,
-	committee_member_create,
-	committee_member_update,
-	committee_member_update_global_parameters,
-	vesting_balance_create,
-	vesting_balance_withdraw,
-	worker_create,
-	custom,
-	assert,
-	balance_claim,
-	override_transfer,
-	transfer_to_blind,
-	blind_transfer,
-	transfer_from_blind,
-	asset_settle_cancel,
-	asset_claim_fees */
+	FillOrderOpType // virtual
+	AccountCreateOpType
+	AccountUpdateOpType
+	AccountWhitelistOpType
+	AccountUpgradeOpType
+	AccountTransferOpType
+	AssetCreateOpType
+	AssetUpdateOpType
+	AssetUpdateBitassetOpType
+	AssetUpdateFeedProducersOpType
+	AssetIssueOpType
+	AssetReserveOpType
+	AssetFundFeePoolOpType
+	AssetSettleOpType
+	AssetGlobalSettleOpType
+	AssetPublishFeedOpType
+	WitnessCreateOpType
+	WitnessUpdateOpType
+	ProposalCreateOpType
+	ProposalUpdateOpType
+	ProposalDeleteOpType
+	WithdrawPermissionCreateOpType
+	WithdrawPermissionUpdateOpType
+	WithdrawPermissionClaimOpType
+	WithdrawPermissionDeleteOpType
+	CommitteeMemberCreateOpType
+	CommitteeMemberUpdateOpType
+	CommitteeMemberUpdateGlobalParametersOpType
+	VestingBalanceCreateOpType
+	VestingBalanceWithdrawOpType
+	WorkerCreateOpType
+	CustomOpType
+	AssertOpType
+	BalanceClaimOpType
+	OverrideTransferOpType
+	TransferToBlindOpType
+	BlindTransferOpType
+	TransferFromBlindOpType
+	AssetSettleCancelOpType // virtual
+	AssetClaimFeesOpType
+	FbaDistributeOpType // virtual
+	BidCollateralOpType
+	ExecuteBidOpType // virtual
+	AssetClaimPoolOpType
+	AssetUpdateIssuerOpType
+	HtlcCreateOpType
+	HtlcRedeemOpType
+	HtlcRedeemedOpType // virtual
+	HtlcExtendOpType
+	HtlcRefundOpType // virtual
+	CustomAuthorityCreateOpType
+	CustomAuthorityUpdateOpType
+	CustomAuthorityDeleteOpType
+	TicketCreateOpType
+	TicketUpdateOpType
+	LiquidityPoolCreateOpType
+	LiquidityPoolDeleteOpType
+	LiquidityPoolDepositOpType
+	LiquidityPoolWithdrawOpType
+	LiquidityPoolExchangeOpType
+	SametFundCreateOpType
+	SametFundDeleteOpType
+	SametFundUpdateOpType
+	SametFundBorrowOpType
+	SametFundRepayOpType
+	CreditOfferCreateOpType
+	CreditOfferDeleteOpType
+	CreditOfferUpdateOpType
+	CreditOfferAcceptOpType
+	CreditDealRepayOpType
+	CreditDealExpiredOpType // virtual
+	LiquidityPoolUpdateOpType
+	CreditDealUpdateOpType
+	LimitOrderUpdateOpType
 )
@@ -12,6 +12,21 @@ type Price struct {
 	Quote AssetAmount `json:"quote"`
 }
 
+func (p Price) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.Encode(p.Base)
+	enc.Encode(p.Quote)
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (p *Price) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	dec.Decode(&p.Base)
+	dec.Decode(&p.Quote)
+	return dec.Err()
+}
+
 type AssetAmount struct {
 	Amount  uint64   `json:"amount"`
 	AssetID ObjectID `json:"asset_id"`
@@ -24,6 +39,15 @@ func (aa AssetAmount) Marshal(encoder *encoding.Encoder) error {
 	return enc.Err()
 }
 
+// Unmarshal implements encoding.Unmarshaller interface.
+func (aa *AssetAmount) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	aa.Amount = dec.DecodeLittleEndianUInt64()
+	aa.AssetID = protocolID(assetObjectType)
+	dec.Decode(&aa.AssetID)
+	return dec.Err()
+}
+
 // RPC client might return asset amount as uint64 or string,
 // therefore a custom unmarshaller is used
 func (aa *AssetAmount) UnmarshalJSON(b []byte) (err error) {
@@ -52,3 +76,26 @@ func (aa *AssetAmount) UnmarshalJSON(b []byte) (err error) {
 
 	return err
 }
+
+// SignedAssetAmount is an asset amount that may be negative, like the
+// collateral and debt deltas of a call order update
+type SignedAssetAmount struct {
+	Amount  Sint64   `json:"amount"`
+	AssetID ObjectID `json:"asset_id"`
+}
+
+func (aa SignedAssetAmount) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.EncodeLittleEndianUInt64(uint64(aa.Amount))
+	enc.Encode(aa.AssetID)
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (aa *SignedAssetAmount) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	aa.Amount = Sint64(dec.DecodeLittleEndianUInt64())
+	aa.AssetID = protocolID(assetObjectType)
+	dec.Decode(&aa.AssetID)
+	return dec.Err()
+}
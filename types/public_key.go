@@ -0,0 +1,49 @@
+package types
+
+import (
+	"github.com/blocktree/bitshares-adapter/addrdec"
+	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/pkg/errors"
+)
+
+// PublicKey is a public key in the BTS... form, a compressed key of 33 bytes on the wire
+type PublicKey string
+
+func (key PublicKey) String() string {
+	return string(key)
+}
+
+// Bytes returns the 33 bytes of the compressed key
+func (key PublicKey) Bytes() ([]byte, error) {
+	raw, err := addrdec.Default.AddressDecode(string(key))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid public key %q", key)
+	}
+	if len(raw) != 33 {
+		return nil, errors.Errorf("invalid public key %q", key)
+	}
+	return raw, nil
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (key PublicKey) Marshal(encoder *encoding.Encoder) error {
+	raw, err := key.Bytes()
+	if err != nil {
+		return err
+	}
+	return encoder.Encode(raw)
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (key *PublicKey) Unmarshal(decoder *encoding.Decoder) error {
+	raw, err := decoder.DecodeBytes(33)
+	if err != nil {
+		return err
+	}
+	encoded, err := addrdec.Default.AddressEncode(raw)
+	if err != nil {
+		return err
+	}
+	*key = PublicKey(encoded)
+	return nil
+}
@@ -0,0 +1,271 @@
+package types
+
+import (
+	"encoding/json"
+
+	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/pkg/errors"
+)
+
+// restrictions are the restrictions of a custom authority on the operation it signs
+type restrictions []restriction
+
+// Marshal implements encoding.Marshaller interface.
+func (r restrictions) Marshal(encoder *encoding.Encoder) error {
+	return encoder.EncodeFlatSet(len(r), func(i int) error {
+		return encoder.Encode(r[i])
+	})
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (r *restrictions) Unmarshal(decoder *encoding.Decoder) error {
+	*r = restrictions{}
+	return decoder.DecodeFlatSet(func(int) error {
+		var item restriction
+		if err := decoder.Decode(&item); err != nil {
+			return err
+		}
+		*r = append(*r, item)
+		return nil
+	})
+}
+
+// restriction restricts a member of an operation by the type of the restriction
+// and its argument
+type restriction struct {
+	MemberIndex     uint64              `json:"member_index"`
+	RestrictionType uint64              `json:"restriction_type"`
+	Argument        restrictionArgument `json:"argument"`
+	Extensions      []json.RawMessage   `json:"extensions"`
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (r restriction) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.EncodeUVarint(r.MemberIndex)
+	enc.EncodeUVarint(r.RestrictionType)
+	enc.Encode(r.Argument)
+	enc.Encode(futureExtensions(r.Extensions))
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (r *restriction) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	r.MemberIndex = dec.DecodeUVarint()
+	r.RestrictionType = dec.DecodeUVarint()
+	dec.Decode(&r.Argument)
+	dec.Decode((*futureExtensions)(&r.Extensions))
+	return dec.Err()
+}
+
+// restrictionArgument is the argument of a restriction: a value, a flat_set of
+// values or nested restrictions
+type restrictionArgument struct {
+	staticVariant
+}
+
+func (a *restrictionArgument) UnmarshalJSON(b []byte) error {
+	return a.unmarshalJSON(b, restrictionArgumentAlternatives)
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (a *restrictionArgument) Unmarshal(decoder *encoding.Decoder) error {
+	return a.unmarshal(decoder, restrictionArgumentAlternatives)
+}
+
+// restrictionIDTypes are the object types of the ids a restriction argument may be,
+// in the order of their alternatives
+var restrictionIDTypes = []uint64{
+	accountObjectType,
+	assetObjectType,
+	forceSettlementObjectType,
+	committeeMemberObjectType,
+	witnessObjectType,
+	limitOrderObjectType,
+	callOrderObjectType,
+	customObjectType,
+	proposalObjectType,
+	withdrawPermissionObjectType,
+	vestingBalanceObjectType,
+	workerObjectType,
+	balanceObjectType,
+}
+
+// the alternatives of a restriction argument: void_t, the values, a flat_set of
+// every value but void_t, then the nested restrictions
+const (
+	restrictionValues        = 7 + 13
+	restrictionSets          = restrictionValues + restrictionValues - 1
+	restrictionList          = restrictionSets
+	restrictionLists         = restrictionSets + 1
+	restrictionVariantAssert = restrictionSets + 2
+)
+
+func restrictionArgumentAlternatives(tag uint64) (interface{}, error) {
+	switch {
+	case tag < restrictionValues:
+		return restrictionValue(tag), nil
+	case tag < restrictionSets:
+		kind := tag - restrictionValues + 1
+		return &argumentSet{element: func() interface{} { return restrictionValue(kind) }}, nil
+	case tag == restrictionList:
+		return new(restrictions), nil
+	case tag == restrictionLists:
+		return new(restrictionGroups), nil
+	case tag == restrictionVariantAssert:
+		return new(variantAssertArgument), nil
+	}
+	return nil, errors.Errorf("unknown restriction argument %d", tag)
+}
+
+// restrictionValue returns a new value of a restriction argument of one value
+func restrictionValue(tag uint64) interface{} {
+	switch tag {
+	case 0:
+		return new(voidT)
+	case 1:
+		return new(bool)
+	case 2:
+		return new(Sint64)
+	case 3:
+		return new(string)
+	case 4:
+		return new(Time)
+	case 5:
+		return new(PublicKey)
+	case 6:
+		return new(sha256Hash)
+	}
+	id := protocolID(restrictionIDTypes[tag-7])
+	return &id
+}
+
+// argumentSet is a flat_set argument of a restriction. The elements are written
+// in their order in the json, the sorted one of the nodes.
+type argumentSet struct {
+	element func() interface{}
+	values  []interface{}
+}
+
+func (set argumentSet) MarshalJSON() ([]byte, error) {
+	if set.values == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(set.values)
+}
+
+func (set *argumentSet) UnmarshalJSON(b []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	set.values = make([]interface{}, 0, len(raw))
+	for _, item := range raw {
+		value := set.element()
+		if err := json.Unmarshal(item, value); err != nil {
+			return err
+		}
+		set.values = append(set.values, value)
+	}
+	return nil
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (set argumentSet) Marshal(encoder *encoding.Encoder) error {
+	return encoder.EncodeFlatSet(len(set.values), func(i int) error {
+		return encoder.Encode(set.values[i])
+	})
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (set *argumentSet) Unmarshal(decoder *encoding.Decoder) error {
+	set.values = []interface{}{}
+	return decoder.DecodeFlatSet(func(int) error {
+		value := set.element()
+		if err := decoder.Decode(value); err != nil {
+			return err
+		}
+		set.values = append(set.values, value)
+		return nil
+	})
+}
+
+// restrictionGroups are alternatives of restrictions, one of them must hold
+type restrictionGroups []restrictions
+
+// Marshal implements encoding.Marshaller interface.
+func (g restrictionGroups) Marshal(encoder *encoding.Encoder) error {
+	return encoder.EncodeFlatSet(len(g), func(i int) error {
+		return encoder.Encode(g[i])
+	})
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (g *restrictionGroups) Unmarshal(decoder *encoding.Decoder) error {
+	*g = restrictionGroups{}
+	return decoder.DecodeFlatSet(func(int) error {
+		var group restrictions
+		if err := decoder.Decode(&group); err != nil {
+			return err
+		}
+		*g = append(*g, group)
+		return nil
+	})
+}
+
+// variantAssertArgument asserts the alternative of a static_variant member and
+// restricts its value
+type variantAssertArgument struct {
+	Which        Sint64
+	Restrictions restrictions
+}
+
+func (a variantAssertArgument) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{a.Which, a.Restrictions})
+}
+
+func (a *variantAssertArgument) UnmarshalJSON(b []byte) error {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(b, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return errors.New("invalid variant assert format: should be which, restrictions")
+	}
+	if err := json.Unmarshal(pair[0], &a.Which); err != nil {
+		return err
+	}
+	return json.Unmarshal(pair[1], &a.Restrictions)
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (a variantAssertArgument) Marshal(encoder *encoding.Encoder) error {
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.Encode(a.Which)
+	enc.Encode(a.Restrictions)
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (a *variantAssertArgument) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+	dec.Decode(&a.Which)
+	dec.Decode(&a.Restrictions)
+	return dec.Err()
+}
+
+// sha256Hash is a sha256, its 32 bytes are written without their length
+type sha256Hash struct {
+	Buffer
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (h sha256Hash) Marshal(encoder *encoding.Encoder) error {
+	return encoder.Encode(fixedBuffer{&h.Buffer, 32})
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (h *sha256Hash) Unmarshal(decoder *encoding.Decoder) error {
+	return decoder.Decode(fixedBuffer{&h.Buffer, 32})
+}
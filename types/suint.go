@@ -3,6 +3,8 @@ package types
 import (
 	"encoding/json"
 	"strconv"
+
+	"github.com/blocktree/bitshares-adapter/encoding"
 )
 
 // Suint64 uint64 with redeclared JSON unmarshal;
@@ -16,20 +18,18 @@ type Suint32 uint32
 func (su *Suint64) UnmarshalJSON(b []byte) (err error) {
 	var u uint64
 	if err = json.Unmarshal(b, &u); err == nil {
-		temp := Suint64(u)
-		su = &temp
+		*su = Suint64(u)
 		return nil
 	}
 
 	// failed on uint64, try string
 	var s string
 	if err = json.Unmarshal(b, &s); err == nil {
-		u, err := strconv.Atoi(s)
+		u, err := strconv.ParseUint(s, 10, 64)
 		if err != nil {
 			return err
 		}
-		temp := Suint64(u)
-		su = &temp
+		*su = Suint64(u)
 		return nil
 	}
 
@@ -39,22 +39,69 @@ func (su *Suint64) UnmarshalJSON(b []byte) (err error) {
 func (su *Suint32) UnmarshalJSON(b []byte) (err error) {
 	var u uint32
 	if err = json.Unmarshal(b, &u); err == nil {
-		temp := Suint32(u)
-		su = &temp
+		*su = Suint32(u)
 		return nil
 	}
 
 	// failed on uint32, try string
 	var s string
 	if err = json.Unmarshal(b, &s); err == nil {
-		u, err := strconv.Atoi(s)
+		u, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return err
+		}
+		*su = Suint32(u)
+		return nil
+	}
+
+	return err
+}
+
+// Sint64 int64 with redeclared JSON unmarshal;
+// Can be parsed from int64 either string
+type Sint64 int64
+
+func (si *Sint64) UnmarshalJSON(b []byte) (err error) {
+	var i int64
+	if err = json.Unmarshal(b, &i); err == nil {
+		*si = Sint64(i)
+		return nil
+	}
+
+	// failed on int64, try string
+	var s string
+	if err = json.Unmarshal(b, &s); err == nil {
+		i, err := strconv.ParseInt(s, 10, 64)
 		if err != nil {
 			return err
 		}
-		temp := Suint32(u)
-		su = &temp
+		*si = Sint64(i)
 		return nil
 	}
 
 	return err
 }
+
+// Marshal implements encoding.Marshaller interface, a share_type of 64 bits.
+func (su Suint64) Marshal(encoder *encoding.Encoder) error {
+	return encoder.EncodeLittleEndianUInt64(uint64(su))
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (su *Suint64) Unmarshal(decoder *encoding.Decoder) error {
+	u, err := decoder.DecodeLittleEndianUInt64()
+	*su = Suint64(u)
+	return err
+}
+
+// Marshal implements encoding.Marshaller interface, a share_type of 64 bits.
+func (si Sint64) Marshal(encoder *encoding.Encoder) error {
+	return encoder.EncodeLittleEndianUInt64(uint64(si))
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (si *Sint64) Unmarshal(decoder *encoding.Decoder) error {
+	u, err := decoder.DecodeLittleEndianUInt64()
+	*si = Sint64(u)
+	return err
+}
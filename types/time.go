@@ -34,3 +34,14 @@ func (t *Time) UnmarshalJSON(data []byte) error {
 func (t Time) Marshal(encoder *encoding.Encoder) error {
 	return encoder.EncodeLittleEndianUInt32(uint32(t.Time.UTC().Unix()))
 }
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (t *Time) Unmarshal(decoder *encoding.Decoder) error {
+	seconds, err := decoder.DecodeLittleEndianUInt32()
+	if err != nil {
+		return err
+	}
+	parsed := time.Unix(int64(seconds), 0).UTC()
+	t.Time = &parsed
+	return nil
+}
@@ -1,17 +1,152 @@
 package types
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"time"
+
 	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/blocktree/bitshares-adapter/txsigner"
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/pkg/errors"
 )
 
+const (
+	// ChainIDBTS is the chain id of the bitshares mainnet
+	ChainIDBTS = "4018d7844c78f6a6c41c6a552b898022310fc5dec06da467ee7905a8dad512c8"
+	// DefaultTxExpiration is how long after the head block time a new transaction expires
+	DefaultTxExpiration = 30 * time.Second
+)
+
 type Transaction struct {
-	RefBlockNum    uint16     `json:"ref_block_num"`
-	RefBlockPrefix uint32     `json:"ref_block_prefix"`
-	Expiration     Time       `json:"expiration"`
-	Operations     Operations `json:"operations"`
-	Signatures     []string   `json:"signatures"`
-	TransactionID  string
+	RefBlockNum    uint16            `json:"ref_block_num"`
+	RefBlockPrefix uint32            `json:"ref_block_prefix"`
+	Expiration     Time              `json:"expiration"`
+	Operations     Operations        `json:"operations"`
+	Extensions     []json.RawMessage `json:"extensions"`
+	Signatures     []string          `json:"signatures"`
+	TransactionID  string            `json:"-"`
+
+	// OperationResults are only set on the transactions of a block
+	OperationResults []OperationResult `json:"operation_results,omitempty"`
+}
+
+// NewTransaction returns an empty transaction referencing the block, expiring at expiration
+func NewTransaction(refBlockID string, expiration time.Time) (*Transaction, error) {
+	tx := &Transaction{
+		Expiration: NewTime(expiration.UTC().Truncate(time.Second)),
+		Operations: Operations{},
+		Extensions: []json.RawMessage{},
+		Signatures: []string{},
+	}
+	if err := tx.SetReferenceBlock(refBlockID); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// SetReferenceBlock sets the TaPoS fields of the transaction: the lower 16 bits
+// of the block number and the 4 bytes following it in the block id.
+func (tx *Transaction) SetReferenceBlock(blockID string) error {
+	raw, err := decodeFixedHex(blockID, 20)
+	if err != nil {
+		return errors.Wrapf(err, "invalid block id %q", blockID)
+	}
+	tx.RefBlockNum = uint16(binary.BigEndian.Uint32(raw[:4]))
+	tx.RefBlockPrefix = binary.LittleEndian.Uint32(raw[4:8])
+	return nil
+}
+
+// SetFees sets the fee of every operation, in the order of the operations
+func (tx *Transaction) SetFees(fees []AssetAmount) error {
+	return tx.Operations.SetFees(fees)
+}
+
+// SetFees sets the fee of every operation, in the order of the operations
+func (ops Operations) SetFees(fees []AssetAmount) error {
+	if len(fees) != len(ops) {
+		return errors.Errorf("%d fees for %d operations", len(fees), len(ops))
+	}
+	for i, op := range ops {
+		v := reflect.ValueOf(op)
+		if v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		var fee reflect.Value
+		if v.Kind() == reflect.Struct {
+			fee = v.FieldByName("Fee")
+		}
+		if !fee.IsValid() || !fee.CanSet() || fee.Type() != reflect.TypeOf(AssetAmount{}) {
+			return errors.Errorf("operation %d has no fee to set", op.Type())
+		}
+		fee.Set(reflect.ValueOf(fees[i]))
+	}
+	return nil
+}
+
+// Digest returns the digest the signatures of the transaction sign: the sha256
+// of the chain id followed by the serialization without signatures.
+func (tx *Transaction) Digest(chainID string) ([]byte, error) {
+	chain, err := decodeFixedHex(chainID, 32)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid chain id %q", chainID)
+	}
+
+	var b bytes.Buffer
+	b.Write(chain)
+	if err := encoding.NewEncoder(&b).Encode(tx); err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(b.Bytes())
+	return digest[:], nil
+}
+
+// AddSignature appends a compact signature of 65 bytes to the transaction
+func (tx *Transaction) AddSignature(signature []byte) error {
+	if len(signature) != 65 {
+		return errors.Errorf("invalid signature length %d", len(signature))
+	}
+	tx.Signatures = append(tx.Signatures, hex.EncodeToString(signature))
+	return nil
+}
+
+// Sign appends the signature of the transaction made with the private key, a
+// canonical one the nodes accept
+func (tx *Transaction) Sign(chainID string, key *btcec.PrivateKey) error {
+	digest, err := tx.Digest(chainID)
+	if err != nil {
+		return err
+	}
+	signature, err := txsigner.SignCanonical(key.Serialize(), digest)
+	if err != nil {
+		return err
+	}
+	return tx.AddSignature(signature)
+}
+
+// Signees returns the compressed public keys recovered from the signatures
+func (tx *Transaction) Signees(chainID string) ([][]byte, error) {
+	digest, err := tx.Digest(chainID)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([][]byte, len(tx.Signatures))
+	for i, sig := range tx.Signatures {
+		signature, err := decodeFixedHex(sig, 65)
+		if err != nil {
+			return nil, errors.Wrap(err, "signature")
+		}
+		key, _, err := btcec.RecoverCompact(btcec.S256(), signature, digest)
+		if err != nil {
+			return nil, errors.Wrapf(err, "recover the key of signature %d", i)
+		}
+		keys[i] = key.SerializeCompressed()
+	}
+	return keys, nil
 }
 
 // Marshal implements encoding.Marshaller interface.
@@ -31,11 +166,112 @@ func (tx *Transaction) Marshal(encoder *encoding.Encoder) error {
 		enc.Encode(op)
 	}
 
-	// Extensions are not supported yet.
-	enc.EncodeUVarint(0)
+	enc.Encode(futureExtensions(tx.Extensions))
+	return enc.Err()
+}
+
+// Unmarshal implements encoding.Unmarshaller interface, reading the transaction
+// without signatures Marshal writes.
+func (tx *Transaction) Unmarshal(decoder *encoding.Decoder) error {
+	dec := encoding.NewRollingDecoder(decoder)
+
+	dec.Decode(&tx.RefBlockNum)
+	dec.Decode(&tx.RefBlockPrefix)
+	dec.Decode(&tx.Expiration)
+
+	tx.Operations = Operations{}
+	dec.DecodeFlatSet(func(int) error {
+		op, err := decodeOperation(decoder)
+		if err != nil {
+			return err
+		}
+		tx.Operations = append(tx.Operations, op)
+		return nil
+	})
+
+	dec.Decode((*futureExtensions)(&tx.Extensions))
+	if err := dec.Err(); err != nil {
+		return err
+	}
+	if len(tx.Operations) == 0 {
+		return errors.New("no operation specified")
+	}
+	return nil
+}
+
+// MarshalSigned writes a signed transaction in the wire format: the transaction
+// followed by its signatures.
+func (tx *Transaction) MarshalSigned(encoder *encoding.Encoder) error {
+	if err := tx.Marshal(encoder); err != nil {
+		return err
+	}
+
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.EncodeUVarint(uint64(len(tx.Signatures)))
+	for _, sig := range tx.Signatures {
+		raw, err := decodeFixedHex(sig, 65)
+		if err != nil {
+			return errors.Wrap(err, "signature")
+		}
+		enc.Encode(raw)
+	}
 	return enc.Err()
 }
 
+// UnmarshalSigned reads a signed transaction in the wire format: the transaction
+// followed by its signatures.
+func (tx *Transaction) UnmarshalSigned(decoder *encoding.Decoder) error {
+	if err := tx.Unmarshal(decoder); err != nil {
+		return err
+	}
+
+	dec := encoding.NewRollingDecoder(decoder)
+	tx.Signatures = []string{}
+	dec.DecodeFlatSet(func(int) error {
+		sig, err := decoder.DecodeBytes(65)
+		if err != nil {
+			return err
+		}
+		tx.Signatures = append(tx.Signatures, hex.EncodeToString(sig))
+		return nil
+	})
+	return dec.Err()
+}
+
+// ID returns the transaction id: the first 20 bytes of the sha256 of the
+// serialization without signatures, as bitshares-core derives it.
+func (tx *Transaction) ID() (string, error) {
+	var b bytes.Buffer
+	if err := encoding.NewEncoder(&b).Encode(tx); err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256(b.Bytes())
+	return hex.EncodeToString(digest[:20]), nil
+}
+
+// MerkleDigest returns the digest of the transaction the transaction_merkle_root
+// of its block is built from: the sha256 of its serialization with signatures
+// and operation results.
+func (tx *Transaction) MerkleDigest() ([]byte, error) {
+	var b bytes.Buffer
+	encoder := encoding.NewEncoder(&b)
+	if err := tx.MarshalSigned(encoder); err != nil {
+		return nil, err
+	}
+
+	enc := encoding.NewRollingEncoder(encoder)
+	enc.EncodeUVarint(uint64(len(tx.OperationResults)))
+	for _, result := range tx.OperationResults {
+		enc.Encode(result)
+	}
+	if err := enc.Err(); err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256(b.Bytes())
+	return digest[:], nil
+}
+
 // PushOperation can be used to add an operation into the encoding.
 func (tx *Transaction) PushOperation(op Operation) {
 	tx.Operations = append(tx.Operations, op)
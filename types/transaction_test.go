@@ -0,0 +1,223 @@
+package types
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/blocktree/bitshares-adapter/txsigner"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+)
+
+// operations of mainnet transactions, wrapped with the same block reference
+var transactionIDTests = []struct {
+	name      string
+	operation string
+	hex       string
+	id        string
+}{
+	{
+		name:      "transfer with memo",
+		operation: `[0,{"amount":{"amount":126044500,"asset_id":"1.3.0"},"extensions":[],"fee":{"amount":22941,"asset_id":"1.3.0"},"from":"1.2.9173","memo":{"from":"BTS7oJ5icgrbMRdzGSKau1NKQqxmYsMRa6rnHsZdxArjCVPWnvi3D","message":"8e2d57042fcec6b823683ae28ca70b34","nonce":"7855784342084694923","to":"BTS55JfN7ca5Eeo5Eu8bfRBKFSuAr4BsyzRpDUZUbhC8MzbJehf3L"},"to":"1.2.152119"}]`,
+		hex:       "908fb51c9bcea29f2e5d01009d5900000000000000d547b7a40954498307000000000001037f4b3dbaa0c3b487fd5ed6161807b85a4b0ff2b24553b1bff4869d58ad915fcd02188eec44b9d26844a8dafdabdd384065c45f3ec6f080d3959368b64bd16215698bb308c63c5a056d108e2d57042fcec6b823683ae28ca70b340000",
+		id:        "cf9b93e4e31093c4a3d461263bd9232a37777a0d",
+	},
+	{
+		name:      "transfer without memo",
+		operation: `[0,{"amount":{"amount":1000000,"asset_id":"1.3.0"},"extensions":[],"fee":{"amount":2000000,"asset_id":"1.3.0"},"from":"1.2.90507","to":"1.2.90735"}]`,
+		hex:       "908fb51c9bcea29f2e5d010080841e0000000000008bc305efc40540420f000000000000000000",
+		id:        "d996eb1d841998c2450d1000a160d0ead7b5c487",
+	},
+	{
+		name:      "limit order cancel",
+		operation: `[2,{"extensions":[],"fee":{"amount":121,"asset_id":"1.3.0"},"fee_paying_account":"1.2.36449","order":"1.7.29923493"}]`,
+		hex:       "908fb51c9bcea29f2e5d0102790000000000000000e19c02a5b1a20e0000",
+		id:        "e9e694e29fddc6f176616be98acd926772efea66",
+	},
+}
+
+func TestTransaction_ID(t *testing.T) {
+	for _, test := range transactionIDTests {
+		t.Run(test.name, func(t *testing.T) {
+			data := `{"ref_block_num":36752,"ref_block_prefix":3466271925,"expiration":"2019-07-17T04:10:10","operations":[` + test.operation + `],"extensions":[],"signatures":[]}`
+			tx := Transaction{}
+			require.NoError(t, json.Unmarshal([]byte(data), &tx))
+
+			var b bytes.Buffer
+			require.NoError(t, encoding.NewEncoder(&b).Encode(&tx))
+			require.Equal(t, test.hex, hex.EncodeToString(b.Bytes()))
+
+			id, err := tx.ID()
+			require.NoError(t, err)
+			require.Equal(t, test.id, id)
+		})
+	}
+
+	t.Run("unknown operation", func(t *testing.T) {
+		data := `{"ref_block_num":1,"ref_block_prefix":1,"expiration":"2019-07-17T04:10:10","operations":[[1000,{}]],"extensions":[],"signatures":[]}`
+		tx := Transaction{}
+		require.NoError(t, json.Unmarshal([]byte(data), &tx))
+
+		_, err := tx.ID()
+		require.Error(t, err)
+	})
+}
+
+func TestTransaction_Unmarshal(t *testing.T) {
+	for _, test := range transactionIDTests {
+		t.Run(test.name, func(t *testing.T) {
+			raw, err := hex.DecodeString(test.hex)
+			require.NoError(t, err)
+
+			tx := Transaction{}
+			require.NoError(t, encoding.NewDecoder(bytes.NewReader(raw)).Decode(&tx))
+
+			var b bytes.Buffer
+			require.NoError(t, encoding.NewEncoder(&b).Encode(&tx))
+			require.Equal(t, test.hex, hex.EncodeToString(b.Bytes()))
+
+			id, err := tx.ID()
+			require.NoError(t, err)
+			require.Equal(t, test.id, id)
+
+			// the operation is the one of the json, ids and keys included
+			want := Operations{}
+			require.NoError(t, json.Unmarshal([]byte("["+test.operation+"]"), &want))
+			require.Equal(t, want, tx.Operations)
+		})
+	}
+
+	t.Run("signed", func(t *testing.T) {
+		signature := "1f" + strings.Repeat("ab", 64)
+		raw, err := hex.DecodeString(transactionIDTests[1].hex + "01" + signature)
+		require.NoError(t, err)
+
+		tx := Transaction{}
+		require.NoError(t, tx.UnmarshalSigned(encoding.NewDecoder(bytes.NewReader(raw))))
+		require.Equal(t, []string{signature}, tx.Signatures)
+		require.Equal(t, uint16(36752), tx.RefBlockNum)
+		require.Equal(t, "2019-07-17T04:10:10Z", tx.Expiration.Format(time.RFC3339))
+	})
+
+	t.Run("unknown operation", func(t *testing.T) {
+		// an operation 99, whose fields the adapter does not know
+		raw, err := hex.DecodeString("908fb51c9bcea29f2e5d016300000000000000000000")
+		require.NoError(t, err)
+		err = encoding.NewDecoder(bytes.NewReader(raw)).Decode(&Transaction{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unknown operation 99")
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		raw, err := hex.DecodeString(transactionIDTests[0].hex)
+		require.NoError(t, err)
+		require.Error(t, encoding.NewDecoder(bytes.NewReader(raw[:len(raw)-10])).Decode(&Transaction{}))
+	})
+}
+
+func TestNewTransaction(t *testing.T) {
+	expiration := time.Date(2019, 7, 17, 4, 10, 10, 500, time.UTC)
+	tx, err := NewTransaction("00178f912d70e9ed3539f2acfba4752dee5d77bb", expiration)
+	require.NoError(t, err)
+	require.Equal(t, uint16(0x8f91), tx.RefBlockNum)
+	require.Equal(t, uint32(0xede9702d), tx.RefBlockPrefix)
+	require.Equal(t, "2019-07-17T04:10:10Z", tx.Expiration.Format(time.RFC3339Nano))
+
+	_, err = NewTransaction("00178f91", expiration)
+	require.Error(t, err)
+
+	core := MustParseObjectID("1.3.0")
+	tx.PushOperation(NewTransferOperation(MustParseObjectID("1.2.100"), MustParseObjectID("1.2.200"), AssetAmount{Amount: 1000, AssetID: core}, AssetAmount{}))
+	tx.PushOperation(&LimitOrderCancelOperation{})
+	require.Error(t, tx.SetFees([]AssetAmount{{Amount: 1}}))
+	require.NoError(t, tx.SetFees([]AssetAmount{{Amount: 1, AssetID: core}, {Amount: 2, AssetID: core}}))
+	require.Equal(t, uint64(1), tx.Operations[0].(*TransferOperation).Fee.Amount)
+	require.Equal(t, uint64(2), tx.Operations[1].(*LimitOrderCancelOperation).Fee.Amount)
+}
+
+func TestTransaction_Digest(t *testing.T) {
+	// digest calculated by github.com/denkhaus/bitshares for the mainnet
+	data := `{"signatures":[],"ref_block_num":10795,"ref_block_prefix":67305985,"expiration":"2026-10-18T08:44:56","operations":[[0,{"from":"1.2.100","to":"1.2.200","amount":{"amount":100000,"asset_id":"1.3.0"},"extensions":[],"fee":{"amount":86869,"asset_id":"1.3.0"}}]],"extensions":[]}`
+	tx := Transaction{}
+	require.NoError(t, json.Unmarshal([]byte(data), &tx))
+
+	digest, err := tx.Digest(ChainIDBTS)
+	require.NoError(t, err)
+	require.Equal(t, "f3fdd715514d24c53e118d166498508a9ec80d768912ce94440d788234240c18", hex.EncodeToString(digest))
+
+	_, err = tx.Digest("4018d784")
+	require.Error(t, err)
+}
+
+func TestTransaction_Sign(t *testing.T) {
+	data := `{"ref_block_num":36752,"ref_block_prefix":3466271925,"expiration":"2019-07-17T04:10:10","operations":[` + transactionIDTests[1].operation + `],"extensions":[],"signatures":[]}`
+	tx := Transaction{}
+	require.NoError(t, json.Unmarshal([]byte(data), &tx))
+
+	key, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	require.NoError(t, tx.Sign(ChainIDBTS, key))
+	require.Error(t, tx.AddSignature(make([]byte, 64)))
+
+	signees, err := tx.Signees(ChainIDBTS)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{key.PubKey().SerializeCompressed()}, signees)
+
+	// signatures do not change the id
+	id, err := tx.ID()
+	require.NoError(t, err)
+	require.Equal(t, transactionIDTests[1].id, id)
+
+	var b bytes.Buffer
+	require.NoError(t, tx.MarshalSigned(encoding.NewEncoder(&b)))
+	require.Equal(t, transactionIDTests[1].hex+"01"+tx.Signatures[0], hex.EncodeToString(b.Bytes()))
+
+	signed := Transaction{}
+	require.NoError(t, signed.UnmarshalSigned(encoding.NewDecoder(&b)))
+	require.Equal(t, tx.Signatures, signed.Signatures)
+
+	// the signed json is parsed back as is
+	raw, err := json.Marshal(&tx)
+	require.NoError(t, err)
+	parsed := Transaction{}
+	require.NoError(t, json.Unmarshal(raw, &parsed))
+	require.Equal(t, tx.Signatures, parsed.Signatures)
+	require.NotContains(t, string(raw), "TransactionID")
+}
+
+func TestTransaction_SignCanonical(t *testing.T) {
+	data := `{"ref_block_num":36752,"ref_block_prefix":3466271925,"expiration":"2019-07-17T04:10:10","operations":[` + transactionIDTests[1].operation + `],"extensions":[],"signatures":[]}`
+	key, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+
+	// about half of the plain signatures are not canonical
+	retried := 0
+	for i := 0; i < 64; i++ {
+		tx := Transaction{}
+		require.NoError(t, json.Unmarshal([]byte(data), &tx))
+		tx.Expiration = NewTime(tx.Expiration.Add(time.Duration(i) * time.Second))
+		require.NoError(t, tx.Sign(ChainIDBTS, key))
+
+		signature, err := hex.DecodeString(tx.Signatures[0])
+		require.NoError(t, err)
+		require.True(t, txsigner.IsCanonical(signature), "signature %d is not canonical", i)
+
+		signees, err := tx.Signees(ChainIDBTS)
+		require.NoError(t, err)
+		require.Equal(t, [][]byte{key.PubKey().SerializeCompressed()}, signees)
+
+		digest, err := tx.Digest(ChainIDBTS)
+		require.NoError(t, err)
+		plain, err := btcec.SignCompact(btcec.S256(), key, digest, true)
+		require.NoError(t, err)
+		if !txsigner.IsCanonical(plain) {
+			retried++
+		}
+	}
+	require.NotZero(t, retried, "no digest needed another nonce")
+}
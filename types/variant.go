@@ -0,0 +1,93 @@
+package types
+
+import (
+	"encoding/json"
+
+	"github.com/blocktree/bitshares-adapter/encoding"
+	"github.com/pkg/errors"
+)
+
+// variantAlternatives returns a new value of the alternative of a static_variant
+// by its tag, a pointer the json or the binary form is parsed into
+type variantAlternatives func(tag uint64) (interface{}, error)
+
+// staticVariant is a static_variant, the tag of its alternative and the value.
+// Its json is [tag, value] and a void_t alternative is a voidT.
+type staticVariant struct {
+	Tag   uint64
+	Value interface{}
+}
+
+func (v staticVariant) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{v.Tag, v.Value})
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (v staticVariant) Marshal(encoder *encoding.Encoder) error {
+	return encoder.EncodeStaticVariant(v.Tag, v.Value)
+}
+
+func (v *staticVariant) unmarshalJSON(b []byte, alternatives variantAlternatives) error {
+	tag, raw, err := unmarshalStaticVariant(b)
+	if err != nil {
+		return err
+	}
+	value, err := alternatives(tag)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, value); err != nil {
+		return errors.Wrapf(err, "static variant %d", tag)
+	}
+	v.Tag, v.Value = tag, value
+	return nil
+}
+
+func (v *staticVariant) unmarshal(decoder *encoding.Decoder, alternatives variantAlternatives) error {
+	return decoder.DecodeStaticVariant(func(tag uint64) error {
+		value, err := alternatives(tag)
+		if err != nil {
+			return err
+		}
+		if err := decoder.Decode(value); err != nil {
+			return errors.Wrapf(err, "static variant %d", tag)
+		}
+		v.Tag, v.Value = tag, value
+		return nil
+	})
+}
+
+// unmarshalStaticVariant parses the json of a static_variant, [tag, value]
+func unmarshalStaticVariant(b []byte) (uint64, json.RawMessage, error) {
+	var pair []json.RawMessage
+	var tag uint64
+	if err := json.Unmarshal(b, &pair); err != nil || len(pair) != 2 || json.Unmarshal(pair[0], &tag) != nil {
+		return 0, nil, errors.Errorf("invalid static variant %s", b)
+	}
+	return tag, pair[1], nil
+}
+
+// rawJSON is a field kept as raw json and the typed value its json is parsed
+// into to be marshalled, an encoding.Marshaller and Unmarshaller
+type rawJSON struct {
+	raw   *json.RawMessage
+	value interface{}
+}
+
+// Marshal implements encoding.Marshaller interface.
+func (r rawJSON) Marshal(encoder *encoding.Encoder) error {
+	if err := json.Unmarshal(*r.raw, r.value); err != nil {
+		return errors.Wrapf(err, "invalid %T %s", r.value, *r.raw)
+	}
+	return encoder.Encode(r.value)
+}
+
+// Unmarshal implements encoding.Unmarshaller interface.
+func (r rawJSON) Unmarshal(decoder *encoding.Decoder) error {
+	if err := decoder.Decode(r.value); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(r.value)
+	*r.raw = raw
+	return err
+}